@@ -2,4 +2,14 @@ module github.com/humanbelnik/kinoswap/client/tui
 
 go 1.25.0
 
-require github.com/gorilla/websocket v1.5.3 // indirect
+require (
+	github.com/google/uuid v1.6.0
+	github.com/humanbelnik/kinoswap/core v0.0.0
+)
+
+require (
+	github.com/gorilla/websocket v1.5.3 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/humanbelnik/kinoswap/core => ../../services/core
@@ -3,18 +3,22 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsclient"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
 )
 
 type Preference struct {
@@ -37,17 +41,17 @@ type Result struct {
 }
 
 type Client struct {
-	baseURL        string
-	adminToken     string
-	userToken      string
-	currentRoom    string
-	httpClient     *http.Client
-	wsConn         *websocket.Conn
-	wsDone         chan struct{}
-	scanner        *bufio.Scanner
-	votingActive   bool
-	waitingForVote chan bool
-	inputChan      chan string
+	baseURL      string
+	adminToken   string
+	userToken    string
+	currentRoom  string
+	httpClient   *http.Client
+	ws           *wsclient.Client
+	transport    wsclient.Transport
+	wsDone       chan struct{}
+	scanner      *bufio.Scanner
+	votingActive bool
+	inputChan    chan string
 }
 
 func (c *Client) SetScanner(scanner *bufio.Scanner) {
@@ -71,36 +75,25 @@ type ParticipateResponse struct {
 type MoviesResponse struct {
 	Movies []*MovieMeta `json:"movies"`
 	Total  int          `json:"total"`
-}
-
-type VotingMoviesResponse struct {
-	Movies []*MovieMeta `json:"movies"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
 }
 
 type ResultsResponse struct {
 	Results []*Result `json:"results"`
 }
 
-type VoteRequest struct {
-	Reactions map[string]int `json:"reactions"`
-}
-
 type AuthRequest struct {
 	Code string `json:"code"`
 }
 
-type WSEvent struct {
-	Type    string      `json:"type"`
-	Payload interface{} `json:"payload"`
-}
-
-func NewClient(baseURL string) *Client {
+func NewClient(baseURL string, transport wsclient.Transport) *Client {
 	return &Client{
-		baseURL:        baseURL,
-		httpClient:     &http.Client{Timeout: 30 * time.Second},
-		wsDone:         make(chan struct{}),
-		waitingForVote: make(chan bool),
-		inputChan:      make(chan string),
+		baseURL:    baseURL,
+		transport:  transport,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		wsDone:     make(chan struct{}),
+		inputChan:  make(chan string),
 	}
 }
 
@@ -183,7 +176,7 @@ func (c *Client) CreateRoom() error {
 		return fmt.Errorf("failed to join room as owner: %s - %s", resp.Status, string(body))
 	}
 
-	if err := c.connectWebSocket(response.RoomCode); err != nil {
+	if err := c.connectRoom(response.RoomCode); err != nil {
 		return err
 	}
 
@@ -231,7 +224,7 @@ func (c *Client) JoinRoom() error {
 	c.currentRoom = roomCode
 	fmt.Printf("Ваш токен: %s\n", c.userToken)
 
-	if err := c.connectWebSocket(roomCode); err != nil {
+	if err := c.connectRoom(roomCode); err != nil {
 		return err
 	}
 
@@ -239,28 +232,19 @@ func (c *Client) JoinRoom() error {
 	return nil
 }
 
-func (c *Client) connectWebSocket(roomCode string) error {
-	baseURL, err := url.Parse(c.baseURL)
-	if err != nil {
-		return fmt.Errorf("invalid base URL: %v", err)
-	}
-
-	u := url.URL{
-		Scheme:   "ws",
-		Host:     baseURL.Host,
-		Path:     fmt.Sprintf("/api/v1/ws/rooms/%s", roomCode),
-		RawQuery: fmt.Sprintf("token=%s", c.userToken),
-	}
+func (c *Client) connectRoom(roomCode string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	ws, err := wsclient.Dial(ctx, c.baseURL, roomCode, c.userToken, wsclient.WithTransport(c.transport))
 	if err != nil {
-		return fmt.Errorf("websocket connection failed: %v", err)
+		return err
 	}
 
-	c.wsConn = conn
-	fmt.Printf("WebSocket подключен к комнате %s\n", roomCode)
+	c.ws = ws
+	fmt.Printf("Подключен к комнате %s (транспорт %s, протокол v%d)\n", roomCode, c.transport, ws.Hello().Version)
 
-	go c.listenWebSocket()
+	go c.listenRoom()
 
 	return nil
 }
@@ -270,16 +254,11 @@ func (c *Client) StartVoting() error {
 		return fmt.Errorf("сначала присоединитесь к комнате")
 	}
 
-	if c.wsConn == nil {
-		return fmt.Errorf("WebSocket соединение не установлено")
-	}
-
-	event := WSEvent{
-		Type: "START_VOTING",
+	if c.ws == nil {
+		return fmt.Errorf("Соединение с комнатой не установлено")
 	}
 
-	err := c.wsConn.WriteJSON(event)
-	if err != nil {
+	if err := c.ws.StartVoting(); err != nil {
 		return fmt.Errorf("failed to start voting: %v", err)
 	}
 
@@ -287,72 +266,35 @@ func (c *Client) StartVoting() error {
 	return nil
 }
 
-func (c *Client) startVotingProcess() {
-	c.votingActive = true
-	defer func() { c.votingActive = false }()
-
-	movies, err := c.getVotingMovies(5)
-	if err != nil {
-		fmt.Printf("Ошибка получения фильмов: %v\n", err)
-		return
-	}
-
-	fmt.Println("\nФильмы для голосования:")
-	for i, movie := range movies {
-		fmt.Printf("%d. %s (%d) - Рейтинг: %.1f\n", i+1, movie.Title, movie.Year, movie.Rating)
-		fmt.Printf("   Жанры: %s\n", strings.Join(movie.Genres, ", "))
-		fmt.Printf("   Описание: %s\n\n", movie.Overview)
-	}
-
-	fmt.Printf("Введите ваши реакции для всех %d фильмов через пробел (0 - не нравится, 1 - нравится):\n", len(movies))
-	fmt.Print("Например: 1 0 1 1 0\n")
-	fmt.Print("Ваши реакции: ")
-
-	select {
-	case reactionsInput := <-c.inputChan:
-		reactionsInput = strings.TrimSpace(reactionsInput)
-
-		reactionStrs := strings.Fields(reactionsInput)
-
-		if len(reactionStrs) != len(movies) {
-			fmt.Printf("Ошибка: ожидается %d реакций, получено %d. Ввод: '%s'\n", len(movies), len(reactionStrs), reactionsInput)
-			return
-		}
-
-		reactions := make(map[string]int)
+func (c *Client) promptReaction(movie wsproto.Movie) {
+	fmt.Printf("\n%s (%d) - Рейтинг: %.1f\n", movie.Title, movie.Year, movie.Rating)
+	fmt.Printf("   Жанры: %s\n", strings.Join(movie.Genres, ", "))
+	fmt.Printf("   Описание: %s\n", movie.Overview)
+	fmt.Print("Ваша реакция (0 - не нравится, 1 - нравится): ")
 
-		for i, reactionStr := range reactionStrs {
-			reaction, err := strconv.Atoi(reactionStr)
+	for {
+		select {
+		case input := <-c.inputChan:
+			reaction, err := strconv.Atoi(strings.TrimSpace(input))
 			if err != nil || (reaction != 0 && reaction != 1) {
-				fmt.Printf("Неверная реакция для фильма '%s': '%s' (должно быть 0 или 1)\n", movies[i].Title, reactionStr)
-				return
+				fmt.Print("Реакция должна быть 0 или 1: ")
+				continue
 			}
-			reactions[movies[i].ID] = reaction
-		}
 
-		fmt.Println("\nВаш выбор:")
-		for i, movie := range movies {
-			likeText := "не нравится"
-			if reactions[movie.ID] == 1 {
-				likeText = "нравится"
+			if err := c.sendReaction(movie.ID, reaction); err != nil {
+				fmt.Printf("Ошибка отправки реакции: %v\n", err)
 			}
-			fmt.Printf("   %d. %s - %s\n", i+1, movie.Title, likeText)
-		}
+			return
 
-		err = c.sendVote(reactions)
-		if err != nil {
-			fmt.Printf("Ошибка отправки голоса: %v\n", err)
+		case <-time.After(30 * time.Second):
+			fmt.Println("\nТаймаут: вы не ввели реакцию вовремя")
 			return
 		}
-
-		fmt.Println("\nВаш голос принят! Ожидаем других участников...")
-
-	case <-time.After(30 * time.Second):
-		fmt.Println("\nТаймаут: вы не ввели реакции вовремя")
-		return
 	}
+}
 
-	<-c.waitingForVote
+func (c *Client) sendReaction(movieID uuid.UUID, reaction int) error {
+	return c.ws.React(movieID, reaction)
 }
 
 func (c *Client) makeRequestWithoutTokens(method, path string, body io.Reader) (*http.Response, error) {
@@ -368,92 +310,98 @@ func (c *Client) makeRequestWithoutTokens(method, path string, body io.Reader) (
 	return c.httpClient.Do(req)
 }
 
-func (c *Client) listenWebSocket() {
+func (c *Client) listenRoom() {
 	defer close(c.wsDone)
 
-	for {
-		var event WSEvent
-		err := c.wsConn.ReadJSON(&event)
-		if err != nil {
-			fmt.Printf("WebSocket error: %v\n", err)
-			return
-		}
+	for event := range c.ws.Events() {
+		switch e := event.(type) {
+		case *wsproto.LobbyUpdate:
+			fmt.Printf("Обновление лобби: %d участников\n", e.ParticipantsCount)
 
-		switch event.Type {
-		case "LOBBY_UPDATE":
-			if payload, ok := event.Payload.(map[string]interface{}); ok {
-				if count, exists := payload["participants_count"]; exists {
-					fmt.Printf("Обновление лобби: %v участников\n", count)
-				}
-			}
+		case *wsproto.RedirectToVoting:
+			fmt.Println("Голосование начато! Ожидаем первый фильм...")
+			c.votingActive = true
 
-		case "REDIRECT_TO_VOTING":
-			fmt.Println("Голосование начато! Получаем фильмы для голосования...")
-			go c.startVotingProcess()
+		case *wsproto.NextMovie:
+			go c.promptReaction(e.Movie)
 
-		case "VOTING_FINISHED":
+		case *wsproto.DeckFinished:
+			fmt.Println("\nФильмы закончились! Ожидаем других участников...")
+
+		case *wsproto.VotingFinished:
 			fmt.Println("Все участники проголосовали! Загружаем результаты...")
-			select {
-			case c.waitingForVote <- true:
-			default:
-			}
+			c.votingActive = false
 			c.showResults()
 
-		case "ERROR":
-			if payload, ok := event.Payload.(map[string]interface{}); ok {
-				if message, exists := payload["message"]; exists {
-					fmt.Printf("Ошибка: %v\n", message)
-				}
+		case *wsproto.ChatHistory:
+			fmt.Println("\nИстория чата:")
+			for _, message := range e.Messages {
+				printChatMessage(message)
 			}
+
+		case *wsproto.ChatMessage:
+			printChatMessage(*e)
+
+		case *wsproto.Emoji:
+			fmt.Printf("[%s] %s\n", e.UserID, e.Emoji)
+
+		case *wsproto.Mute:
+			if e.Muted {
+				fmt.Printf("Участник %s заглушен\n", e.UserID)
+			} else {
+				fmt.Printf("Участник %s снова может писать\n", e.UserID)
+			}
+
+		case *wsproto.Error:
+			fmt.Printf("Ошибка: %s\n", e.Message)
 		}
 	}
-}
 
-func (c *Client) getVotingMovies(count int) ([]*MovieMeta, error) {
-	resp, err := c.makeRequest("GET",
-		fmt.Sprintf("/rooms/%s/movies?count=%d", c.currentRoom, count), nil, nil)
-	if err != nil {
-		return nil, err
+	if err := c.ws.Err(); err != nil {
+		fmt.Printf("WebSocket error: %v\n", err)
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get movies: %s - %s", resp.Status, string(body))
-	}
+func printChatMessage(message wsproto.ChatMessage) {
+	fmt.Printf("[%s %s] %s\n",
+		time.Unix(message.SentAt, 0).Format("15:04"), message.UserID, message.Text)
+}
 
-	var response VotingMoviesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, err
+// Chat sends lobby message, single supported emoji is sent as reaction
+func (c *Client) Chat() error {
+	if c.ws == nil {
+		return fmt.Errorf("Соединение с комнатой не установлено")
 	}
 
-	return response.Movies, nil
-}
+	fmt.Printf("Сообщение (или одно из %s): ", strings.Join(wsproto.Emojis, " "))
+	if !c.scanner.Scan() {
+		return fmt.Errorf("failed to read message")
+	}
+	text := strings.TrimSpace(c.scanner.Text())
 
-func (c *Client) sendVote(reactions map[string]int) error {
-	voteReq := VoteRequest{
-		Reactions: reactions,
+	if slices.Contains(wsproto.Emojis, text) {
+		return c.ws.SendEmoji(text)
 	}
+	return c.ws.SendMessage(text)
+}
 
-	body, err := json.Marshal(voteReq)
-	if err != nil {
-		return err
+func (c *Client) MuteParticipant() error {
+	if c.ws == nil {
+		return fmt.Errorf("Соединение с комнатой не установлено")
 	}
 
-	resp, err := c.makeRequest("PATCH",
-		fmt.Sprintf("/rooms/%s/results", c.currentRoom),
-		strings.NewReader(string(body)), nil)
-	if err != nil {
-		return err
+	fmt.Print("ID участника: ")
+	if !c.scanner.Scan() {
+		return fmt.Errorf("failed to read user ID")
 	}
-	defer resp.Body.Close()
+	userID := strings.TrimSpace(c.scanner.Text())
 
-	if resp.StatusCode != http.StatusAccepted {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("vote failed: %s - %s", resp.Status, string(body))
+	fmt.Print("Заглушить? (1 - да, 0 - снять): ")
+	if !c.scanner.Scan() {
+		return fmt.Errorf("failed to read choice")
 	}
 
-	return nil
+	return c.ws.Mute(userID, strings.TrimSpace(c.scanner.Text()) != "0")
 }
 
 func (c *Client) showResults() {
@@ -624,45 +572,75 @@ func (c *Client) ViewMovies() error {
 		return fmt.Errorf("сначала выполните аутентификацию администратора")
 	}
 
-	resp, err := c.makeRequest("GET", "/movies", nil, nil)
+	offset := 0
+	for {
+		response, err := c.loadMoviesPage(offset)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nВсего фильмов: %d\n", response.Total)
+		for i, movie := range response.Movies {
+			fmt.Printf("\n%d. %s (%d)\n", response.Offset+i+1, movie.Title, movie.Year)
+			fmt.Printf("   Рейтинг: %.1f\n", movie.Rating)
+			fmt.Printf("   Жанры: %s\n", strings.Join(movie.Genres, ", "))
+			fmt.Printf("   Описание: %s\n", movie.Overview)
+			if movie.PosterLink != "" {
+				fmt.Printf("   Постер: %s\n", movie.PosterLink)
+			}
+		}
+
+		offset = response.Offset + len(response.Movies)
+		if len(response.Movies) == 0 || offset >= response.Total {
+			return nil
+		}
+
+		fmt.Printf("\nПоказано %d из %d. Enter - следующая страница, q - выход: ", offset, response.Total)
+		if !c.scanner.Scan() || strings.TrimSpace(c.scanner.Text()) == "q" {
+			return nil
+		}
+	}
+}
+
+func (c *Client) loadMoviesPage(offset int) (*MoviesResponse, error) {
+	resp, err := c.makeRequest("GET", fmt.Sprintf("/movies?offset=%d", offset), nil, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to get movies: %s - %s", resp.Status, string(body))
+		return nil, fmt.Errorf("failed to get movies: %s - %s", resp.Status, string(body))
 	}
 
 	var response MoviesResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return err
-	}
-
-	fmt.Printf("\nВсего фильмов: %d\n", response.Total)
-	for i, movie := range response.Movies {
-		fmt.Printf("\n%d. %s (%d)\n", i+1, movie.Title, movie.Year)
-		fmt.Printf("   Рейтинг: %.1f\n", movie.Rating)
-		fmt.Printf("   Жанры: %s\n", strings.Join(movie.Genres, ", "))
-		fmt.Printf("   Описание: %s\n", movie.Overview)
-		if movie.PosterLink != "" {
-			fmt.Printf("   Постер: %s\n", movie.PosterLink)
-		}
+		return nil, err
 	}
-
-	return nil
+	return &response, nil
 }
 
 func (c *Client) Close() {
-	if c.wsConn != nil {
-		c.wsConn.Close()
+	if c.ws != nil {
+		c.ws.Close()
 		<-c.wsDone
 	}
 }
 
 func main() {
-	client := NewClient("http://localhost/api/v1")
+	baseURL := flag.String("url", "http://localhost/api/v1", "API base URL")
+	transportFlag := flag.String("transport", string(wsclient.TransportWebSocket),
+		"room events transport: ws, sse or poll (for proxies stripping WebSocket)")
+	flag.Parse()
+
+	transport, err := wsclient.ParseTransport(*transportFlag)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		os.Exit(2)
+	}
+
+	client := NewClient(*baseURL, transport)
 	defer client.Close()
 
 	scanner := bufio.NewScanner(os.Stdin)
@@ -677,6 +655,8 @@ func main() {
 			fmt.Println("4. Войти как администратор")
 			fmt.Println("5. Добавить фильм")
 			fmt.Println("6. Просмотреть фильмы")
+			fmt.Println("7. Написать в чат лобби")
+			fmt.Println("8. Заглушить участника")
 			fmt.Println("0. Выход")
 			fmt.Print("Выберите действие: ")
 		}
@@ -721,6 +701,14 @@ func main() {
 			if err := client.ViewMovies(); err != nil {
 				fmt.Printf("Ошибка: %v\n", err)
 			}
+		case "7":
+			if err := client.Chat(); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
+		case "8":
+			if err := client.MuteParticipant(); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+			}
 		case "0":
 			fmt.Println("До свидания!")
 			return
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+)
+
+func main() {
+	out := flag.String("out", "api/ws.yaml", "path to write AsyncAPI document to")
+	flag.Parse()
+
+	doc, err := wsproto.AsyncAPI()
+	if err != nil {
+		log.Fatalf("failed to render AsyncAPI document: %v", err)
+	}
+
+	if err := os.WriteFile(*out, doc, 0o644); err != nil {
+		log.Fatalf("failed to write %s: %v", *out, err)
+	}
+}
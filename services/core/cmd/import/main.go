@@ -0,0 +1,135 @@
+// Command import loads movie catalog from CSV or JSONL file.
+//
+//	go run ./cmd/import -file ../../data/imdb_top_1000.csv -embedder offline -fake-s3
+//
+// Postgres and embedder are configured the same way as for the app.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/config"
+	infra_embedder "github.com/humanbelnik/kinoswap/core/internal/infra/embedder"
+	infra_embedder_offline "github.com/humanbelnik/kinoswap/core/internal/infra/embedder/offline"
+	infra_pg_init "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/init"
+	infra_postgres_movie "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/movie"
+	infra_s3 "github.com/humanbelnik/kinoswap/core/internal/infra/s3"
+	"github.com/humanbelnik/kinoswap/core/internal/infra/s3mock"
+	"github.com/humanbelnik/kinoswap/core/internal/service/embedding_reducer"
+	movie_import "github.com/humanbelnik/kinoswap/core/internal/service/movie_import"
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
+)
+
+func main() {
+	var (
+		file        = flag.String("file", "", "catalog file path")
+		format      = flag.String("format", "", "csv or jsonl, guessed by file extension if empty")
+		concurrency = flag.Int("concurrency", 4, "movies embedded in parallel")
+		embedder    = flag.String("embedder", "grpc", "grpc or offline")
+		posters     = flag.Bool("posters", false, "download posters by Poster_Link")
+		fakeS3      = flag.Bool("fake-s3", false, "don't upload posters to S3")
+	)
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	fileFormat, err := movie_import.FormatOf(*file)
+	if *format != "" {
+		fileFormat, err = movie_import.ParseFormat(*format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	records, err := movie_import.Read(f, fileFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Load()
+	pgConn := infra_pg_init.MustEstablishConn(cfg.Postgres)
+
+	var movieEmbedder usecase_movie.Embedder
+	switch *embedder {
+	case "grpc":
+		grpcEmbedder := infra_embedder.MustEstablishConnection(cfg.Embedder)
+		healthCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := grpcEmbedder.Health(healthCtx); err != nil {
+			log.Fatalf("embedder isn't ready: %v", err)
+		}
+		cancel()
+		movieEmbedder = grpcEmbedder
+	case "offline":
+		// The same vocabulary file as the app loads, see EMBEDDER_VOCABULARY
+		vocabulary, err := infra_embedder_offline.LoadVocabularyFile(cfg.Embedder.Vocabulary)
+		if err != nil {
+			log.Fatalf("failed to load vocabulary: %v", err)
+		}
+		movieEmbedder = infra_embedder_offline.New(infra_embedder_offline.WithVocabulary(vocabulary))
+	default:
+		log.Fatalf("unknown embedder %q", *embedder)
+	}
+
+	var posterRepository usecase_movie.PosterRepository = s3mock.New()
+	if !*fakeS3 {
+		posterRepository, err = infra_s3.New("hbk-test-bucket", infra_s3.MustEstablishConn(), "poster/")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	movieRepository := infra_postgres_movie.New(pgConn)
+	uc := usecase_movie.New(
+		movieRepository, movieRepository, movieRepository, movieRepository,
+		posterRepository,
+		movieEmbedder,
+		embedding_reducer.New(),
+	)
+
+	opts := usecase_movie.ImportOptions{Concurrency: *concurrency}
+	if *posters {
+		opts.FetchPoster = movie_import.PosterFetcher(&http.Client{Timeout: 30 * time.Second})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Imported vectors are recorded with embedder model, vectors of other models are re-embedded by the app
+	em, stale, err := uc.CheckEmbeddingModel(ctx)
+	if err != nil {
+		log.Fatalf("failed to check embedding model: %v", err)
+	}
+	fmt.Printf("embedding model: %s\n", em.ID())
+	if stale > 0 {
+		fmt.Printf("catalog has %d vectors of other models, start re-embedding with POST /movies/reembed\n", stale)
+	}
+
+	start := time.Now()
+	report, err := uc.Import(ctx, records, opts)
+
+	fmt.Printf("total: %d, imported: %d, skipped: %d, failed: %d (%s)\n",
+		report.Total, report.Imported, report.Skipped, report.Failed, time.Since(start).Round(time.Millisecond))
+	for _, failure := range report.Failures {
+		fmt.Printf("  line %d %q: %s\n", failure.Line, failure.Title, failure.Error)
+	}
+
+	if err != nil {
+		log.Fatalf("import interrupted: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Command vocabulary fits offline embedder vocabulary to catalog and writes it to file.
+//
+//	go run ./cmd/vocabulary -out vocabulary.json
+//
+// Every instance loads the file by EMBEDDER_VOCABULARY. Catalog is re-embedded once the app starts with a new one.
+// Postgres is configured the same way as for the app.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/humanbelnik/kinoswap/core/internal/config"
+	infra_embedder_offline "github.com/humanbelnik/kinoswap/core/internal/infra/embedder/offline"
+	infra_pg_init "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/init"
+	infra_postgres_movie "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/movie"
+)
+
+func main() {
+	out := flag.String("out", "", "vocabulary file path")
+	flag.Parse()
+
+	if *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	movies, err := infra_postgres_movie.New(infra_pg_init.MustEstablishConn(cfg.Postgres)).LoadAll(context.Background())
+	if err != nil {
+		log.Fatalf("failed to load catalog: %v", err)
+	}
+	vocabulary := infra_embedder_offline.Fit(movies)
+
+	// Renamed into place, instance starting meanwhile never reads half written file
+	f, err := os.CreateTemp(filepath.Dir(*out), filepath.Base(*out)+".*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := vocabulary.Save(f); err != nil {
+		log.Fatalf("failed to write vocabulary: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Rename(f.Name(), *out); err != nil {
+		log.Fatal(err)
+	}
+
+	model, _ := infra_embedder_offline.New(infra_embedder_offline.WithVocabulary(vocabulary)).ModelInfo(context.Background())
+	fmt.Printf("vocabulary of %d movies, embedding model: %s\n", len(movies), model.ID())
+}
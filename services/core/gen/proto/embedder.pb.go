@@ -22,12 +22,18 @@ const (
 )
 
 type MovieEmbeddingRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
-	Genres        []string               `protobuf:"bytes,2,rep,name=genres,proto3" json:"genres,omitempty"`
-	Overview      string                 `protobuf:"bytes,3,opt,name=overview,proto3" json:"overview,omitempty"`
-	Year          int32                  `protobuf:"varint,4,opt,name=year,proto3" json:"year,omitempty"`
-	Rating        float32                `protobuf:"fixed32,5,opt,name=rating,proto3" json:"rating,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Title    string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Genres   []string               `protobuf:"bytes,2,rep,name=genres,proto3" json:"genres,omitempty"`
+	Overview string                 `protobuf:"bytes,3,opt,name=overview,proto3" json:"overview,omitempty"`
+	Year     int32                  `protobuf:"varint,4,opt,name=year,proto3" json:"year,omitempty"`
+	Rating   float32                `protobuf:"fixed32,5,opt,name=rating,proto3" json:"rating,omitempty"`
+	Director string                 `protobuf:"bytes,6,opt,name=director,proto3" json:"director,omitempty"`
+	// Stars in billing order
+	Cast []string `protobuf:"bytes,7,rep,name=cast,proto3" json:"cast,omitempty"`
+	// Minutes, zero if unknown
+	Runtime       int32  `protobuf:"varint,8,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	Certificate   string `protobuf:"bytes,9,opt,name=certificate,proto3" json:"certificate,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -97,6 +103,34 @@ func (x *MovieEmbeddingRequest) GetRating() float32 {
 	return 0
 }
 
+func (x *MovieEmbeddingRequest) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *MovieEmbeddingRequest) GetCast() []string {
+	if x != nil {
+		return x.Cast
+	}
+	return nil
+}
+
+func (x *MovieEmbeddingRequest) GetRuntime() int32 {
+	if x != nil {
+		return x.Runtime
+	}
+	return 0
+}
+
+func (x *MovieEmbeddingRequest) GetCertificate() string {
+	if x != nil {
+		return x.Certificate
+	}
+	return ""
+}
+
 type PreferenceEmbeddingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
@@ -185,24 +219,358 @@ func (x *EmbeddingResponse) GetEmbedding() []float32 {
 	return nil
 }
 
+type BatchMovieEmbeddingRequest struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Movies        []*MovieEmbeddingRequest `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchMovieEmbeddingRequest) Reset() {
+	*x = BatchMovieEmbeddingRequest{}
+	mi := &file_api_proto_embedder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchMovieEmbeddingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchMovieEmbeddingRequest) ProtoMessage() {}
+
+func (x *BatchMovieEmbeddingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchMovieEmbeddingRequest.ProtoReflect.Descriptor instead.
+func (*BatchMovieEmbeddingRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchMovieEmbeddingRequest) GetMovies() []*MovieEmbeddingRequest {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+type BatchEmbeddingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Embeddings    []*EmbeddingResponse   `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchEmbeddingResponse) Reset() {
+	*x = BatchEmbeddingResponse{}
+	mi := &file_api_proto_embedder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchEmbeddingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchEmbeddingResponse) ProtoMessage() {}
+
+func (x *BatchEmbeddingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchEmbeddingResponse.ProtoReflect.Descriptor instead.
+func (*BatchEmbeddingResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchEmbeddingResponse) GetEmbeddings() []*EmbeddingResponse {
+	if x != nil {
+		return x.Embeddings
+	}
+	return nil
+}
+
+type StreamMovieEmbeddingRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chosen by client to match response
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Movie         *MovieEmbeddingRequest `protobuf:"bytes,2,opt,name=movie,proto3" json:"movie,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamMovieEmbeddingRequest) Reset() {
+	*x = StreamMovieEmbeddingRequest{}
+	mi := &file_api_proto_embedder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMovieEmbeddingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMovieEmbeddingRequest) ProtoMessage() {}
+
+func (x *StreamMovieEmbeddingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamMovieEmbeddingRequest.ProtoReflect.Descriptor instead.
+func (*StreamMovieEmbeddingRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamMovieEmbeddingRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StreamMovieEmbeddingRequest) GetMovie() *MovieEmbeddingRequest {
+	if x != nil {
+		return x.Movie
+	}
+	return nil
+}
+
+type StreamEmbeddingResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Embedding []float32              `protobuf:"fixed32,2,rep,packed,name=embedding,proto3" json:"embedding,omitempty"`
+	// Failure of a single movie doesn't break the stream
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEmbeddingResponse) Reset() {
+	*x = StreamEmbeddingResponse{}
+	mi := &file_api_proto_embedder_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEmbeddingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEmbeddingResponse) ProtoMessage() {}
+
+func (x *StreamEmbeddingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEmbeddingResponse.ProtoReflect.Descriptor instead.
+func (*StreamEmbeddingResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamEmbeddingResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StreamEmbeddingResponse) GetEmbedding() []float32 {
+	if x != nil {
+		return x.Embedding
+	}
+	return nil
+}
+
+func (x *StreamEmbeddingResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ModelInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelInfoRequest) Reset() {
+	*x = ModelInfoRequest{}
+	mi := &file_api_proto_embedder_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfoRequest) ProtoMessage() {}
+
+func (x *ModelInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfoRequest.ProtoReflect.Descriptor instead.
+func (*ModelInfoRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{7}
+}
+
+// Vectors are comparable only if name and version match
+type ModelInfo struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Name      string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version   string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Dimension int32                  `protobuf:"varint,3,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	// "l2" if vectors are unit length, "none" otherwise
+	Normalization string `protobuf:"bytes,4,opt,name=normalization,proto3" json:"normalization,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelInfo) Reset() {
+	*x = ModelInfo{}
+	mi := &file_api_proto_embedder_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfo) ProtoMessage() {}
+
+func (x *ModelInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_embedder_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
+func (*ModelInfo) Descriptor() ([]byte, []int) {
+	return file_api_proto_embedder_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ModelInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetDimension() int32 {
+	if x != nil {
+		return x.Dimension
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetNormalization() string {
+	if x != nil {
+		return x.Normalization
+	}
+	return ""
+}
+
 var File_api_proto_embedder_proto protoreflect.FileDescriptor
 
 const file_api_proto_embedder_proto_rawDesc = "" +
 	"\n" +
-	"\x18api/proto/embedder.proto\x12\tembedding\"\x8d\x01\n" +
+	"\x18api/proto/embedder.proto\x12\tembedding\"\xf9\x01\n" +
 	"\x15MovieEmbeddingRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
 	"\x06genres\x18\x02 \x03(\tR\x06genres\x12\x1a\n" +
 	"\boverview\x18\x03 \x01(\tR\boverview\x12\x12\n" +
 	"\x04year\x18\x04 \x01(\x05R\x04year\x12\x16\n" +
-	"\x06rating\x18\x05 \x01(\x02R\x06rating\"0\n" +
+	"\x06rating\x18\x05 \x01(\x02R\x06rating\x12\x1a\n" +
+	"\bdirector\x18\x06 \x01(\tR\bdirector\x12\x12\n" +
+	"\x04cast\x18\a \x03(\tR\x04cast\x12\x18\n" +
+	"\aruntime\x18\b \x01(\x05R\aruntime\x12 \n" +
+	"\vcertificate\x18\t \x01(\tR\vcertificate\"0\n" +
 	"\x1aPreferenceEmbeddingRequest\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\"1\n" +
 	"\x11EmbeddingResponse\x12\x1c\n" +
-	"\tembedding\x18\x01 \x03(\x02R\tembedding2\xd0\x01\n" +
+	"\tembedding\x18\x01 \x03(\x02R\tembedding\"V\n" +
+	"\x1aBatchMovieEmbeddingRequest\x128\n" +
+	"\x06movies\x18\x01 \x03(\v2 .embedding.MovieEmbeddingRequestR\x06movies\"V\n" +
+	"\x16BatchEmbeddingResponse\x12<\n" +
+	"\n" +
+	"embeddings\x18\x01 \x03(\v2\x1c.embedding.EmbeddingResponseR\n" +
+	"embeddings\"e\n" +
+	"\x1bStreamMovieEmbeddingRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\x05movie\x18\x02 \x01(\v2 .embedding.MovieEmbeddingRequestR\x05movie\"]\n" +
+	"\x17StreamEmbeddingResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tembedding\x18\x02 \x03(\x02R\tembedding\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x12\n" +
+	"\x10ModelInfoRequest\"}\n" +
+	"\tModelInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1c\n" +
+	"\tdimension\x18\x03 \x01(\x05R\tdimension\x12$\n" +
+	"\rnormalization\x18\x04 \x01(\tR\rnormalization2\xea\x03\n" +
 	"\x10EmbeddingService\x12X\n" +
 	"\x14CreateMovieEmbedding\x12 .embedding.MovieEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x12b\n" +
-	"\x19CreatePreferenceEmbedding\x12%.embedding.PreferenceEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00B\vZ\tgen/protob\x06proto3"
+	"\x19CreatePreferenceEmbedding\x12%.embedding.PreferenceEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x12C\n" +
+	"\fGetModelInfo\x12\x1b.embedding.ModelInfoRequest\x1a\x14.embedding.ModelInfo\"\x00\x12h\n" +
+	"\x1aBatchCreateMovieEmbeddings\x12%.embedding.BatchMovieEmbeddingRequest\x1a!.embedding.BatchEmbeddingResponse\"\x00\x12i\n" +
+	"\x15StreamMovieEmbeddings\x12&.embedding.StreamMovieEmbeddingRequest\x1a\".embedding.StreamEmbeddingResponse\"\x00(\x010\x01B\vZ\tgen/protob\x06proto3"
 
 var (
 	file_api_proto_embedder_proto_rawDescOnce sync.Once
@@ -216,22 +584,37 @@ func file_api_proto_embedder_proto_rawDescGZIP() []byte {
 	return file_api_proto_embedder_proto_rawDescData
 }
 
-var file_api_proto_embedder_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_api_proto_embedder_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_api_proto_embedder_proto_goTypes = []any{
-	(*MovieEmbeddingRequest)(nil),      // 0: embedding.MovieEmbeddingRequest
-	(*PreferenceEmbeddingRequest)(nil), // 1: embedding.PreferenceEmbeddingRequest
-	(*EmbeddingResponse)(nil),          // 2: embedding.EmbeddingResponse
+	(*MovieEmbeddingRequest)(nil),       // 0: embedding.MovieEmbeddingRequest
+	(*PreferenceEmbeddingRequest)(nil),  // 1: embedding.PreferenceEmbeddingRequest
+	(*EmbeddingResponse)(nil),           // 2: embedding.EmbeddingResponse
+	(*BatchMovieEmbeddingRequest)(nil),  // 3: embedding.BatchMovieEmbeddingRequest
+	(*BatchEmbeddingResponse)(nil),      // 4: embedding.BatchEmbeddingResponse
+	(*StreamMovieEmbeddingRequest)(nil), // 5: embedding.StreamMovieEmbeddingRequest
+	(*StreamEmbeddingResponse)(nil),     // 6: embedding.StreamEmbeddingResponse
+	(*ModelInfoRequest)(nil),            // 7: embedding.ModelInfoRequest
+	(*ModelInfo)(nil),                   // 8: embedding.ModelInfo
 }
 var file_api_proto_embedder_proto_depIdxs = []int32{
-	0, // 0: embedding.EmbeddingService.CreateMovieEmbedding:input_type -> embedding.MovieEmbeddingRequest
-	1, // 1: embedding.EmbeddingService.CreatePreferenceEmbedding:input_type -> embedding.PreferenceEmbeddingRequest
-	2, // 2: embedding.EmbeddingService.CreateMovieEmbedding:output_type -> embedding.EmbeddingResponse
-	2, // 3: embedding.EmbeddingService.CreatePreferenceEmbedding:output_type -> embedding.EmbeddingResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: embedding.BatchMovieEmbeddingRequest.movies:type_name -> embedding.MovieEmbeddingRequest
+	2, // 1: embedding.BatchEmbeddingResponse.embeddings:type_name -> embedding.EmbeddingResponse
+	0, // 2: embedding.StreamMovieEmbeddingRequest.movie:type_name -> embedding.MovieEmbeddingRequest
+	0, // 3: embedding.EmbeddingService.CreateMovieEmbedding:input_type -> embedding.MovieEmbeddingRequest
+	1, // 4: embedding.EmbeddingService.CreatePreferenceEmbedding:input_type -> embedding.PreferenceEmbeddingRequest
+	7, // 5: embedding.EmbeddingService.GetModelInfo:input_type -> embedding.ModelInfoRequest
+	3, // 6: embedding.EmbeddingService.BatchCreateMovieEmbeddings:input_type -> embedding.BatchMovieEmbeddingRequest
+	5, // 7: embedding.EmbeddingService.StreamMovieEmbeddings:input_type -> embedding.StreamMovieEmbeddingRequest
+	2, // 8: embedding.EmbeddingService.CreateMovieEmbedding:output_type -> embedding.EmbeddingResponse
+	2, // 9: embedding.EmbeddingService.CreatePreferenceEmbedding:output_type -> embedding.EmbeddingResponse
+	8, // 10: embedding.EmbeddingService.GetModelInfo:output_type -> embedding.ModelInfo
+	4, // 11: embedding.EmbeddingService.BatchCreateMovieEmbeddings:output_type -> embedding.BatchEmbeddingResponse
+	6, // 12: embedding.EmbeddingService.StreamMovieEmbeddings:output_type -> embedding.StreamEmbeddingResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_embedder_proto_init() }
@@ -245,7 +628,7 @@ func file_api_proto_embedder_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_embedder_proto_rawDesc), len(file_api_proto_embedder_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EmbeddingService_CreateMovieEmbedding_FullMethodName      = "/embedding.EmbeddingService/CreateMovieEmbedding"
-	EmbeddingService_CreatePreferenceEmbedding_FullMethodName = "/embedding.EmbeddingService/CreatePreferenceEmbedding"
+	EmbeddingService_CreateMovieEmbedding_FullMethodName       = "/embedding.EmbeddingService/CreateMovieEmbedding"
+	EmbeddingService_CreatePreferenceEmbedding_FullMethodName  = "/embedding.EmbeddingService/CreatePreferenceEmbedding"
+	EmbeddingService_GetModelInfo_FullMethodName               = "/embedding.EmbeddingService/GetModelInfo"
+	EmbeddingService_BatchCreateMovieEmbeddings_FullMethodName = "/embedding.EmbeddingService/BatchCreateMovieEmbeddings"
+	EmbeddingService_StreamMovieEmbeddings_FullMethodName      = "/embedding.EmbeddingService/StreamMovieEmbeddings"
 )
 
 // EmbeddingServiceClient is the client API for EmbeddingService service.
@@ -29,6 +32,11 @@ const (
 type EmbeddingServiceClient interface {
 	CreateMovieEmbedding(ctx context.Context, in *MovieEmbeddingRequest, opts ...grpc.CallOption) (*EmbeddingResponse, error)
 	CreatePreferenceEmbedding(ctx context.Context, in *PreferenceEmbeddingRequest, opts ...grpc.CallOption) (*EmbeddingResponse, error)
+	GetModelInfo(ctx context.Context, in *ModelInfoRequest, opts ...grpc.CallOption) (*ModelInfo, error)
+	// Embeddings are in order of movies
+	BatchCreateMovieEmbeddings(ctx context.Context, in *BatchMovieEmbeddingRequest, opts ...grpc.CallOption) (*BatchEmbeddingResponse, error)
+	// Every request is answered with response of the same id, not necessarily in order
+	StreamMovieEmbeddings(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMovieEmbeddingRequest, StreamEmbeddingResponse], error)
 }
 
 type embeddingServiceClient struct {
@@ -59,12 +67,50 @@ func (c *embeddingServiceClient) CreatePreferenceEmbedding(ctx context.Context,
 	return out, nil
 }
 
+func (c *embeddingServiceClient) GetModelInfo(ctx context.Context, in *ModelInfoRequest, opts ...grpc.CallOption) (*ModelInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModelInfo)
+	err := c.cc.Invoke(ctx, EmbeddingService_GetModelInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *embeddingServiceClient) BatchCreateMovieEmbeddings(ctx context.Context, in *BatchMovieEmbeddingRequest, opts ...grpc.CallOption) (*BatchEmbeddingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchEmbeddingResponse)
+	err := c.cc.Invoke(ctx, EmbeddingService_BatchCreateMovieEmbeddings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *embeddingServiceClient) StreamMovieEmbeddings(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMovieEmbeddingRequest, StreamEmbeddingResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EmbeddingService_ServiceDesc.Streams[0], EmbeddingService_StreamMovieEmbeddings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EmbeddingService_StreamMovieEmbeddingsClient = grpc.BidiStreamingClient[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]
+
 // EmbeddingServiceServer is the server API for EmbeddingService service.
 // All implementations must embed UnimplementedEmbeddingServiceServer
 // for forward compatibility.
 type EmbeddingServiceServer interface {
 	CreateMovieEmbedding(context.Context, *MovieEmbeddingRequest) (*EmbeddingResponse, error)
 	CreatePreferenceEmbedding(context.Context, *PreferenceEmbeddingRequest) (*EmbeddingResponse, error)
+	GetModelInfo(context.Context, *ModelInfoRequest) (*ModelInfo, error)
+	// Embeddings are in order of movies
+	BatchCreateMovieEmbeddings(context.Context, *BatchMovieEmbeddingRequest) (*BatchEmbeddingResponse, error)
+	// Every request is answered with response of the same id, not necessarily in order
+	StreamMovieEmbeddings(grpc.BidiStreamingServer[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]) error
 	mustEmbedUnimplementedEmbeddingServiceServer()
 }
 
@@ -81,6 +127,15 @@ func (UnimplementedEmbeddingServiceServer) CreateMovieEmbedding(context.Context,
 func (UnimplementedEmbeddingServiceServer) CreatePreferenceEmbedding(context.Context, *PreferenceEmbeddingRequest) (*EmbeddingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreatePreferenceEmbedding not implemented")
 }
+func (UnimplementedEmbeddingServiceServer) GetModelInfo(context.Context, *ModelInfoRequest) (*ModelInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModelInfo not implemented")
+}
+func (UnimplementedEmbeddingServiceServer) BatchCreateMovieEmbeddings(context.Context, *BatchMovieEmbeddingRequest) (*BatchEmbeddingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateMovieEmbeddings not implemented")
+}
+func (UnimplementedEmbeddingServiceServer) StreamMovieEmbeddings(grpc.BidiStreamingServer[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMovieEmbeddings not implemented")
+}
 func (UnimplementedEmbeddingServiceServer) mustEmbedUnimplementedEmbeddingServiceServer() {}
 func (UnimplementedEmbeddingServiceServer) testEmbeddedByValue()                          {}
 
@@ -138,6 +193,49 @@ func _EmbeddingService_CreatePreferenceEmbedding_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EmbeddingService_GetModelInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModelInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmbeddingServiceServer).GetModelInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmbeddingService_GetModelInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmbeddingServiceServer).GetModelInfo(ctx, req.(*ModelInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmbeddingService_BatchCreateMovieEmbeddings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchMovieEmbeddingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmbeddingServiceServer).BatchCreateMovieEmbeddings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmbeddingService_BatchCreateMovieEmbeddings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmbeddingServiceServer).BatchCreateMovieEmbeddings(ctx, req.(*BatchMovieEmbeddingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EmbeddingService_StreamMovieEmbeddings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EmbeddingServiceServer).StreamMovieEmbeddings(&grpc.GenericServerStream[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EmbeddingService_StreamMovieEmbeddingsServer = grpc.BidiStreamingServer[StreamMovieEmbeddingRequest, StreamEmbeddingResponse]
+
 // EmbeddingService_ServiceDesc is the grpc.ServiceDesc for EmbeddingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +251,22 @@ var EmbeddingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreatePreferenceEmbedding",
 			Handler:    _EmbeddingService_CreatePreferenceEmbedding_Handler,
 		},
+		{
+			MethodName: "GetModelInfo",
+			Handler:    _EmbeddingService_GetModelInfo_Handler,
+		},
+		{
+			MethodName: "BatchCreateMovieEmbeddings",
+			Handler:    _EmbeddingService_BatchCreateMovieEmbeddings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamMovieEmbeddings",
+			Handler:       _EmbeddingService_StreamMovieEmbeddings_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/embedder.proto",
 }
@@ -1,31 +1,56 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/humanbelnik/kinoswap/core/internal/config"
 	http_auth "github.com/humanbelnik/kinoswap/core/internal/delivery/http/auth"
+	http_collection "github.com/humanbelnik/kinoswap/core/internal/delivery/http/collection"
+	http_embedding_cache "github.com/humanbelnik/kinoswap/core/internal/delivery/http/embedding_cache"
 	http_init "github.com/humanbelnik/kinoswap/core/internal/delivery/http/init"
 	http_auth_middleware "github.com/humanbelnik/kinoswap/core/internal/delivery/http/middleware/auth"
 	http_movie "github.com/humanbelnik/kinoswap/core/internal/delivery/http/movie"
 	http_room "github.com/humanbelnik/kinoswap/core/internal/delivery/http/room"
 	http_swagger "github.com/humanbelnik/kinoswap/core/internal/delivery/http/swagger"
 	http_vote "github.com/humanbelnik/kinoswap/core/internal/delivery/http/voting"
+	http_watchlist "github.com/humanbelnik/kinoswap/core/internal/delivery/http/watchlist"
 	ws_room "github.com/humanbelnik/kinoswap/core/internal/delivery/ws/room"
 	auth_client "github.com/humanbelnik/kinoswap/core/internal/infra/auth"
 	infra_embedder "github.com/humanbelnik/kinoswap/core/internal/infra/embedder"
+	infra_embedder_offline "github.com/humanbelnik/kinoswap/core/internal/infra/embedder/offline"
+	infra_memory_vote "github.com/humanbelnik/kinoswap/core/internal/infra/memory/vote"
+	infra_postgres_collection "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/collection"
 	infra_pg_init "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/init"
 	infra_postgres_movie "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/movie"
 	infra_postgres_room "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/room"
+	infra_postgres_vector "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/vector"
 	infra_postgres_vote "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/vote"
+	infra_postgres_watchlist "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/watchlist"
+	infra_embedding_cache "github.com/humanbelnik/kinoswap/core/internal/infra/redis/embedding"
 	infra_redis_init "github.com/humanbelnik/kinoswap/core/internal/infra/redis/init"
 	infra_session_cache "github.com/humanbelnik/kinoswap/core/internal/infra/redis/session"
 	infra_s3 "github.com/humanbelnik/kinoswap/core/internal/infra/s3"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
 	servie_simple_auth "github.com/humanbelnik/kinoswap/core/internal/service/auth/simple"
+	"github.com/humanbelnik/kinoswap/core/internal/service/embedding_cache"
 	"github.com/humanbelnik/kinoswap/core/internal/service/embedding_reducer"
+	"github.com/humanbelnik/kinoswap/core/internal/service/hnsw"
+	"github.com/humanbelnik/kinoswap/core/internal/service/ingestion"
+	"github.com/humanbelnik/kinoswap/core/internal/service/neighbours"
+	"github.com/humanbelnik/kinoswap/core/internal/service/reembed"
+	"github.com/humanbelnik/kinoswap/core/internal/service/saga"
+	usecase_collection "github.com/humanbelnik/kinoswap/core/internal/usecase/collection"
 	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
 	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
 	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	usecase_watchlist "github.com/humanbelnik/kinoswap/core/internal/usecase/watchlist"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func resloveS3() usecase_movie.PosterRepository {
@@ -44,23 +69,251 @@ func resloveS3() usecase_movie.PosterRepository {
 	panic("unable to cast s3 object")
 }
 
+// checkEmbeddingModel refuses embedder of foreign dimension. Unreachable embedder isn't fatal,
+// its model is asked again on the first upload.
+func checkEmbeddingModel(movieUC *usecase_movie.Usecase, startReembed bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	em, stale, err := movieUC.CheckEmbeddingModel(ctx)
+	switch {
+	case errors.Is(err, usecase_movie.ErrInvalidEmbeddingDimension):
+		panic(err)
+	case err != nil:
+		slog.Warn("failed to check embedding model", slog.String("error", err.Error()))
+		return
+	case stale == 0:
+		slog.Info("catalog vectors match embedder model", slog.String("model", em.ID()))
+		return
+	}
+
+	slog.Warn("catalog has vectors of other embedding models",
+		slog.String("model", em.ID()), slog.Int("stale", stale))
+	if !startReembed {
+		return
+	}
+
+	job, err := movieUC.StartReembed(ctx)
+	switch {
+	case errors.Is(err, usecase_movie.ErrReembedRunning):
+		slog.Info("re-embedding is already running", slog.String("job", job.ID.String()), slog.String("model", job.Model))
+	case err != nil:
+		slog.Error("failed to start re-embedding", slog.String("error", err.Error()))
+	default:
+		slog.Info("re-embedding started", slog.String("job", job.ID.String()), slog.String("model", job.Model))
+	}
+}
+
+// mustEmbedder loads offline vocabulary from file, so every instance embeds with the same weights
+func mustEmbedder(cfg config.Embedder) usecase_movie.Embedder {
+	switch cfg.Mode {
+	case "grpc":
+		return infra_embedder.MustEstablishConnection(cfg)
+	case "offline":
+		vocabulary, err := infra_embedder_offline.LoadVocabularyFile(cfg.Vocabulary)
+		if err != nil {
+			panic(err)
+		}
+		embedder := infra_embedder_offline.New(infra_embedder_offline.WithVocabulary(vocabulary))
+		info, _ := embedder.ModelInfo(context.Background())
+		slog.Info("offline embedder is loaded", slog.String("model", info.ID()))
+		return embedder
+	}
+	panic("unknown embedder mode " + cfg.Mode)
+}
+
+// mustVectorSearch warns if migrated index doesn't serve configured metric, vector queries scan the whole table then
+func mustVectorSearch(cfg config.Vector, db *sqlx.DB) infra_postgres_vector.Search {
+	metric, err := infra_postgres_vector.ParseMetric(cfg.Metric)
+	if err != nil {
+		panic(err)
+	}
+	index, err := infra_postgres_vector.ParseIndex(cfg.Index)
+	if err != nil {
+		panic(err)
+	}
+	efSearch, err := strconv.Atoi(cfg.EfSearch)
+	if err != nil {
+		panic(err)
+	}
+	probes, err := strconv.Atoi(cfg.Probes)
+	if err != nil {
+		panic(err)
+	}
+	quantization, err := infra_postgres_vector.ParseQuantization(cfg.Quantization)
+	if err != nil {
+		panic(err)
+	}
+	overfetch, err := strconv.Atoi(cfg.Overfetch)
+	if err != nil {
+		panic(err)
+	}
+	search := infra_postgres_vector.Search{
+		Metric:       metric,
+		Index:        index,
+		EfSearch:     efSearch,
+		Probes:       probes,
+		Quantization: quantization,
+		Overfetch:    overfetch,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := search.CheckIndex(ctx, db, "movies", "movie_vector"); err != nil {
+		slog.Warn("vector index isn't usable", slog.String("error", err.Error()))
+	}
+	return search
+}
+
+// mustVoteRepository keeps vector index in memory if configured so. Listener applies changes of movies
+// as soon as they happen, standby can't listen and waits for reconcile.
+func mustVoteRepository(cfg config.Vector, pg config.Postgres, driver *infra_postgres_vote.Driver, listen bool) usecase_vote.VoteRepository {
+	switch cfg.Backend {
+	case "pgvector":
+		return driver
+	case "memory":
+	default:
+		panic("unknown vector backend " + cfg.Backend)
+	}
+
+	efSearch, err := strconv.Atoi(cfg.EfSearch)
+	if err != nil {
+		panic(err)
+	}
+	syncInterval, err := time.ParseDuration(cfg.MemorySyncInterval)
+	if err != nil {
+		panic(err)
+	}
+	opts := []infra_memory_vote.Option{
+		infra_memory_vote.WithIndexOptions(hnsw.WithEfSearch(efSearch)),
+		infra_memory_vote.WithSnapshot(cfg.MemorySnapshot),
+		infra_memory_vote.WithSyncInterval(syncInterval),
+	}
+	if listen {
+		listener := pq.NewListener(infra_pg_init.DSN(pg), time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				slog.Warn("movie changes listener", slog.String("error", err.Error()))
+			}
+		})
+		opts = append(opts, infra_memory_vote.WithListener(listener))
+	}
+	repo := infra_memory_vote.New(driver, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := repo.Load(ctx); err != nil {
+		panic(err)
+	}
+	go repo.Run(context.Background())
+	return repo
+}
+
+func mustIngestionWorker(cfg config.Ingestion, repo ingestion.Repository, ingester ingestion.Ingester) *ingestion.Worker {
+	interval, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		panic(err)
+	}
+	maxAttempts, err := strconv.Atoi(cfg.MaxAttempts)
+	if err != nil {
+		panic(err)
+	}
+	backoff, err := time.ParseDuration(cfg.Backoff)
+	if err != nil {
+		panic(err)
+	}
+	maxBackoff, err := time.ParseDuration(cfg.MaxBackoff)
+	if err != nil {
+		panic(err)
+	}
+
+	return ingestion.New(repo, ingester,
+		ingestion.WithInterval(interval),
+		ingestion.WithMaxAttempts(maxAttempts),
+		ingestion.WithBackoff(backoff, maxBackoff),
+	)
+}
+
+func mustPreferenceCache(cfg config.PreferenceCache, embedder embedding_cache.Embedder, store embedding_cache.Store) *embedding_cache.Cache {
+	size, err := strconv.Atoi(cfg.Size)
+	if err != nil {
+		panic(err)
+	}
+	ttl, err := time.ParseDuration(cfg.TTL)
+	if err != nil {
+		panic(err)
+	}
+
+	return embedding_cache.New(embedder, store,
+		embedding_cache.WithSize(size),
+		embedding_cache.WithTTL(ttl),
+	)
+}
+
 func Go(cfg *config.Config) {
 	redisConn := infra_redis_init.MustEstablishConn(cfg.Redis)
 	pgConn := infra_pg_init.MustEstablishConn(cfg.Postgres)
-	embedder := infra_embedder.MustEstablishConnection(cfg.Embedder)
 	posterRepository := resloveS3()
 
 	embeddingReducer := embedding_reducer.New()
 	roomRepository := infra_postgres_room.New(pgConn)
-	voteRepo := infra_postgres_vote.New(pgConn)
-	movieRepository := infra_postgres_movie.New(pgConn)
+	vectorSearch := mustVectorSearch(cfg.Vector, pgConn)
+	readOnly := os.Getenv("MODE") == "RO"
+	voteRepo := mustVoteRepository(cfg.Vector, cfg.Postgres,
+		infra_postgres_vote.New(pgConn, infra_postgres_vote.WithVectorSearch(vectorSearch)), !readOnly)
+	movieRepository := infra_postgres_movie.New(pgConn, infra_postgres_movie.WithVectorSearch(vectorSearch))
+	embedder := mustEmbedder(cfg.Embedder)
+	collectionRepository := infra_postgres_collection.New(pgConn)
+	watchlistRepository := infra_postgres_watchlist.New(pgConn)
 
-	roomUC := usecase_room.New(roomRepository, embedder, 20 /* orphant room cleanups on every _ booking */)
+	preferenceCache := mustPreferenceCache(cfg.PreferenceCache, embedder,
+		infra_embedding_cache.New(redisConn, "preference_embedding"))
 
-	hub := ws_room.NewHub(roomUC)
+	roomUC := usecase_room.New(roomRepository, preferenceCache, 20 /* orphant room cleanups on every _ booking */)
+
+	candidateMode, err := model.ParseSearchMode(cfg.Voting.Candidates)
+	if err != nil {
+		panic(err)
+	}
+	voteUC := usecase_vote.New(voteRepo, roomUC,
+		usecase_vote.WithCandidateMode(candidateMode),
+		usecase_vote.WithPosterPresigner(posterRepository),
+	)
+	hub := ws_room.NewHub(roomUC, voteUC)
 	go hub.Run()
-	voteUC := usecase_vote.New(voteRepo, roomUC)
-	movieUC := usecase_movie.New(movieRepository, posterRepository, embedder, embeddingReducer)
+	movieUC := usecase_movie.New(movieRepository, movieRepository, movieRepository, movieRepository,
+		posterRepository, embedder, embeddingReducer)
+
+	checkEmbeddingModel(movieUC, !readOnly)
+
+	// Read-only instances serve neighbours and vectors computed by the main one
+	if !readOnly {
+		refreshInterval, err := time.ParseDuration(cfg.Neighbours.RefreshInterval)
+		if err != nil {
+			panic(err)
+		}
+		go neighbours.New(movieRepository, neighbours.WithInterval(refreshInterval)).Run(context.Background())
+
+		reembedInterval, err := time.ParseDuration(cfg.Reembed.Interval)
+		if err != nil {
+			panic(err)
+		}
+		reembedBatchSize, err := strconv.Atoi(cfg.Reembed.BatchSize)
+		if err != nil {
+			panic(err)
+		}
+		go reembed.New(movieRepository, embedder,
+			reembed.WithInterval(reembedInterval),
+			reembed.WithBatchSize(reembedBatchSize),
+		).Run(context.Background())
+
+		go mustIngestionWorker(cfg.Ingestion, movieRepository, movieUC).Run(context.Background())
+
+		sagaInterval, err := time.ParseDuration(cfg.Saga.RecoveryInterval)
+		if err != nil {
+			panic(err)
+		}
+		go saga.New(movieRepository, movieUC, saga.WithInterval(sagaInterval)).Run(context.Background())
+	}
 
 	authClient := auth_client.New(os.Getenv("SERVER_LIST"))
 	sessionCache := infra_session_cache.New(redisConn, "session_cache")
@@ -69,9 +322,12 @@ func Go(cfg *config.Config) {
 
 	controllerPool := http_init.NewControllerPool()
 	controllerPool.Add(http_swagger.New())
-	controllerPool.Add(http_room.New(roomUC))
-	controllerPool.Add(http_movie.New(movieUC, authMiddleware))
+	controllerPool.Add(http_room.New(roomUC, http_room.WithFreeNotifier(hub)))
+	controllerPool.Add(http_movie.New(movieUC, authMiddleware, http_movie.WithParticipantValidator(roomUC)))
+	controllerPool.Add(http_collection.New(usecase_collection.New(collectionRepository), authMiddleware))
+	controllerPool.Add(http_embedding_cache.New(preferenceCache, authMiddleware))
 	controllerPool.Add(http_vote.New(voteUC, roomUC, hub))
+	controllerPool.Add(http_watchlist.New(usecase_watchlist.New(watchlistRepository)))
 	controllerPool.Add(http_auth.New(authService))
 	controllerPool.Add(ws_room.NewController(hub))
 
@@ -32,17 +32,108 @@ type TelegramBot struct {
 }
 
 type Embedder struct {
-	Host string
-	Port string
+	// grpc or offline, offline embeds in process with no embedder service
+	Mode string
+	// Vocabulary file of offline mode written by cmd/vocabulary, words weigh equally if empty
+	Vocabulary string
+	Host       string
+	Port       string
+	// Comma separated host:port replicas, every address a host resolves to is a replica.
+	// Host and Port are used if empty
+	Hosts string
+	// Go duration, how often hosts are resolved again
+	ResolveInterval string
+	// Replica failing that many times in a row is out of balancing for ejection time
+	EjectionThreshold string
+	EjectionTime      string
+	// Go duration, concurrent movie embedding calls within it share a round trip. Zero disables batching
+	BatchWindow string
+	// Movies sent at once at most
+	BatchSize string
+	// Go durations, every attempt of a call is limited by them
+	Timeout      string
+	BatchTimeout string
+	// Transient failures are retried that many times after jittered doubling backoff
+	Retries      string
+	RetryBackoff string
+	// Calls fail fast for cooldown after that many transient failures in a row
+	BreakerThreshold string
+	BreakerCooldown  string
+}
+
+type PreferenceCache struct {
+	// Embeddings kept in memory of an instance, 0 leaves only Redis
+	Size string
+	// Go duration, how long embeddings stay in Redis
+	TTL string
+}
+
+type Vector struct {
+	// cosine, l2 or ip, has to match metric of vector index, see 000014_vector_index migration
+	Metric string
+	// hnsw or ivfflat
+	Index string
+	// Query-time tuning, trades speed for recall. 0 keeps server default
+	EfSearch string
+	Probes   string
+	// none, halfvec or binary, has to match 000015_vector_quantization migration.
+	// Quantized index gives overfetch times more candidates, exact distances re-rank them
+	Quantization string
+	Overfetch    string
+	// pgvector or memory. Memory keeps cosine HNSW index in every instance, EfSearch tunes it too
+	Backend string
+	// File index is saved to, so restart doesn't build it again. Empty saves nothing
+	MemorySnapshot string
+	// Go duration, how often in-memory index is reconciled with catalog
+	MemorySyncInterval string
+}
+
+type Voting struct {
+	// vector, lexical or hybrid
+	Candidates string
+}
+
+type Neighbours struct {
+	// Go duration, ex. 10m
+	RefreshInterval string
+}
+
+type Reembed struct {
+	// Go duration, how often running job is looked for
+	Interval string
+	// Movies embedded between progress saves
+	BatchSize string
+}
+
+type Ingestion struct {
+	// Go duration, how often due jobs are looked for
+	Interval string
+	// Failed jobs are dead-lettered after that many attempts
+	MaxAttempts string
+	// Go durations, retry delay doubles from base up to max
+	Backoff    string
+	MaxBackoff string
+}
+
+type Saga struct {
+	// Go duration, how often unfinished movie writes of crashed instances are looked for
+	RecoveryInterval string
 }
 
 type Config struct {
-	HTTP        HTTPServer
-	Redis       RedisCache
-	Postgres    Postgres
-	TelegramBot TelegramBot
-	Embedder    Embedder
-	TestWord    string
+	HTTP            HTTPServer
+	Redis           RedisCache
+	Postgres        Postgres
+	TelegramBot     TelegramBot
+	Embedder        Embedder
+	PreferenceCache PreferenceCache
+	Vector          Vector
+	Voting          Voting
+	Neighbours      Neighbours
+	Reembed         Reembed
+	Ingestion       Ingestion
+	Saga            Saga
+	TestWord        string
 }
 
 const logtag = "[config]"
@@ -62,12 +153,19 @@ func Load() *Config {
 	// }
 
 	cfg := &Config{
-		HTTP:        *newHTTP(),
-		Redis:       *newRedis(),
-		Postgres:    *newPostgres(),
-		TelegramBot: *newTelegramBot(),
-		Embedder:    *newEmbedder(),
-		TestWord:    os.Getenv("TEST_WORD"),
+		HTTP:            *newHTTP(),
+		Redis:           *newRedis(),
+		Postgres:        *newPostgres(),
+		TelegramBot:     *newTelegramBot(),
+		Embedder:        *newEmbedder(),
+		PreferenceCache: *newPreferenceCache(),
+		Vector:          *newVector(),
+		Voting:          *newVoting(),
+		Neighbours:      *newNeighbours(),
+		Reembed:         *newReembed(),
+		Ingestion:       *newIngestion(),
+		Saga:            *newSaga(),
+		TestWord:        os.Getenv("TEST_WORD"),
 	}
 
 	log.Printf("%s backend config : %+v\n", logtag, cfg)
@@ -83,8 +181,83 @@ func newHTTP() *HTTPServer {
 
 func newEmbedder() *Embedder {
 	return &Embedder{
-		Port: getenv("EMBEDDER_PORT", "50051"),
-		Host: getenv("EMBEDDER_HOST", "embedder-app"),
+		Mode:       getenv("EMBEDDER_MODE", "grpc"),
+		Vocabulary: getenv("EMBEDDER_VOCABULARY", ""),
+		Port:       getenv("EMBEDDER_PORT", "50051"),
+		Host:       getenv("EMBEDDER_HOST", "embedder-app"),
+
+		BatchWindow: getenv("EMBEDDER_BATCH_WINDOW", "5ms"),
+		BatchSize:   getenv("EMBEDDER_BATCH_SIZE", "32"),
+
+		Timeout:      getenv("EMBEDDER_TIMEOUT", "5s"),
+		BatchTimeout: getenv("EMBEDDER_BATCH_TIMEOUT", "1m"),
+		RetryBackoff: getenv("EMBEDDER_RETRY_BACKOFF", "100ms"),
+		Retries:      getenv("EMBEDDER_RETRIES", "2"),
+
+		BreakerThreshold: getenv("EMBEDDER_BREAKER_THRESHOLD", "5"),
+		BreakerCooldown:  getenv("EMBEDDER_BREAKER_COOLDOWN", "30s"),
+
+		Hosts:             getenv("EMBEDDER_HOSTS", ""),
+		ResolveInterval:   getenv("EMBEDDER_RESOLVE_INTERVAL", "30s"),
+		EjectionThreshold: getenv("EMBEDDER_EJECTION_THRESHOLD", "3"),
+		EjectionTime:      getenv("EMBEDDER_EJECTION_TIME", "30s"),
+	}
+}
+
+func newVector() *Vector {
+	return &Vector{
+		Metric:   getenv("VECTOR_METRIC", "cosine"),
+		Index:    getenv("VECTOR_INDEX", "hnsw"),
+		EfSearch: getenv("VECTOR_EF_SEARCH", "0"),
+		Probes:   getenv("VECTOR_PROBES", "0"),
+
+		Quantization: getenv("VECTOR_QUANTIZATION", "none"),
+		Overfetch:    getenv("VECTOR_OVERFETCH", "4"),
+
+		Backend:            getenv("VECTOR_BACKEND", "pgvector"),
+		MemorySnapshot:     getenv("VECTOR_MEMORY_SNAPSHOT", ""),
+		MemorySyncInterval: getenv("VECTOR_MEMORY_SYNC_INTERVAL", "5m"),
+	}
+}
+
+func newVoting() *Voting {
+	return &Voting{
+		Candidates: getenv("VOTING_CANDIDATES", "vector"),
+	}
+}
+
+func newNeighbours() *Neighbours {
+	return &Neighbours{
+		RefreshInterval: getenv("NEIGHBOURS_REFRESH_INTERVAL", "10m"),
+	}
+}
+
+func newPreferenceCache() *PreferenceCache {
+	return &PreferenceCache{
+		Size: getenv("PREFERENCE_CACHE_SIZE", "1024"),
+		TTL:  getenv("PREFERENCE_CACHE_TTL", "168h"),
+	}
+}
+
+func newReembed() *Reembed {
+	return &Reembed{
+		Interval:  getenv("REEMBED_INTERVAL", "5s"),
+		BatchSize: getenv("REEMBED_BATCH_SIZE", "64"),
+	}
+}
+
+func newIngestion() *Ingestion {
+	return &Ingestion{
+		Interval:    getenv("INGESTION_INTERVAL", "2s"),
+		MaxAttempts: getenv("INGESTION_MAX_ATTEMPTS", "5"),
+		Backoff:     getenv("INGESTION_BACKOFF", "5s"),
+		MaxBackoff:  getenv("INGESTION_MAX_BACKOFF", "10m"),
+	}
+}
+
+func newSaga() *Saga {
+	return &Saga{
+		RecoveryInterval: getenv("SAGA_RECOVERY_INTERVAL", "30s"),
 	}
 }
 
@@ -0,0 +1,358 @@
+package http_collection
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	http_common "github.com/humanbelnik/kinoswap/core/internal/delivery/http/common"
+	http_auth_middleware "github.com/humanbelnik/kinoswap/core/internal/delivery/http/middleware/auth"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_collection "github.com/humanbelnik/kinoswap/core/internal/usecase/collection"
+)
+
+// CreateCollectionRequestDTO запрос на создание подборки
+type CreateCollectionRequestDTO struct {
+	Name        string `json:"name" binding:"required" example:"Рождественская классика"`
+	Description string `json:"description" example:"Фильмы для новогодних праздников"`
+}
+
+// UpdateCollectionRequestDTO запрос на изменение подборки. Отсутствующие поля не меняются
+type UpdateCollectionRequestDTO struct {
+	Name        *string `json:"name,omitempty" example:"Оскар 2020"`
+	Description *string `json:"description,omitempty" example:"Номинанты и победители"`
+}
+
+// CollectionMoviesRequestDTO фильмы, добавляемые в подборку или удаляемые из нее
+type CollectionMoviesRequestDTO struct {
+	MovieIDs []uuid.UUID `json:"movie_ids" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// CollectionResponseDTO подборка фильмов
+type CollectionResponseDTO struct {
+	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name        string    `json:"name" example:"Рождественская классика"`
+	Description string    `json:"description" example:"Фильмы для новогодних праздников"`
+	// Количество фильмов в подборке
+	Size      int       `json:"size" example:"24"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ConvertFromCollection(c model.Collection) CollectionResponseDTO {
+	return CollectionResponseDTO{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		Size:        c.Size,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+func ConvertFromCollections(cs []model.Collection) []CollectionResponseDTO {
+	dtos := make([]CollectionResponseDTO, len(cs))
+	for i, c := range cs {
+		dtos[i] = ConvertFromCollection(c)
+	}
+	return dtos
+}
+
+type Controller struct {
+	uc *usecase_collection.Usecase
+
+	authMiddleware *http_auth_middleware.Middleware
+
+	logger *slog.Logger
+}
+
+type ControllerOption func(*Controller)
+
+func WithLogger(logger *slog.Logger) ControllerOption {
+	return func(c *Controller) {
+		c.logger = logger
+	}
+}
+
+func New(uc *usecase_collection.Usecase,
+	authMiddleware *http_auth_middleware.Middleware,
+	opts ...ControllerOption) *Controller {
+	c := &Controller{
+		uc:             uc,
+		authMiddleware: authMiddleware,
+		logger:         slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	collections := router.Group("/collections")
+	collections.Use(c.authMiddleware.AuthRequired())
+
+	collections.POST("", c.createCollection)
+	collections.GET("", c.listCollections)
+	collections.GET("/:collection_id", c.getCollection)
+	collections.PATCH("/:collection_id", c.updateCollection)
+	collections.DELETE("/:collection_id", c.deleteCollection)
+	collections.POST("/:collection_id/movies", c.addMovies)
+	collections.DELETE("/:collection_id/movies", c.removeMovies)
+}
+
+// @Summary Создание подборки
+// @Description Создает пустую именованную подборку фильмов. Имена подборок уникальны
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Param request body CreateCollectionRequestDTO true "Данные подборки"
+// @Success 201 {object} CollectionResponseDTO "Подборка создана"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 409 {object} http_common.ErrorResponse "Подборка с таким именем уже есть"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections [post]
+func (c *Controller) createCollection(ctx *gin.Context) {
+	var req CreateCollectionRequestDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid request format",
+		})
+		return
+	}
+
+	collection, err := c.uc.Create(ctx.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		c.respondError(ctx, "failed to create collection", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ConvertFromCollection(collection))
+}
+
+// @Summary Список подборок
+// @Description Возвращает все подборки, упорядоченные по имени. Фильмы подборки доступны через GET /movies?collection={id}
+// @Tags Collections
+// @Produce json
+// @Success 200 {array} CollectionResponseDTO "Подборки"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections [get]
+func (c *Controller) listCollections(ctx *gin.Context) {
+	collections, err := c.uc.List(ctx.Request.Context())
+	if err != nil {
+		c.respondError(ctx, "failed to list collections", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ConvertFromCollections(collections))
+}
+
+// @Summary Подборка
+// @Description Возвращает подборку по идентификатору
+// @Tags Collections
+// @Produce json
+// @Param collection_id path string true "UUID подборки" example("550e8400-e29b-41d4-a716-446655440000")
+// @Success 200 {object} CollectionResponseDTO "Подборка"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректный UUID подборки"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Подборка не найдена"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections/{collection_id} [get]
+func (c *Controller) getCollection(ctx *gin.Context) {
+	id, ok := c.collectionID(ctx)
+	if !ok {
+		return
+	}
+
+	collection, err := c.uc.Get(ctx.Request.Context(), id)
+	if err != nil {
+		c.respondError(ctx, "failed to load collection", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ConvertFromCollection(collection))
+}
+
+// @Summary Изменение подборки
+// @Description Переименовывает подборку и/или меняет ее описание
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Param collection_id path string true "UUID подборки" example("550e8400-e29b-41d4-a716-446655440000")
+// @Param request body UpdateCollectionRequestDTO true "Изменяемые поля"
+// @Success 200 {object} CollectionResponseDTO "Измененная подборка"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса, нечего менять"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Подборка не найдена"
+// @Failure 409 {object} http_common.ErrorResponse "Подборка с таким именем уже есть"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections/{collection_id} [patch]
+func (c *Controller) updateCollection(ctx *gin.Context) {
+	id, ok := c.collectionID(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateCollectionRequestDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid request format",
+		})
+		return
+	}
+
+	patch := usecase_collection.CollectionPatch{Name: req.Name, Description: req.Description}
+	if patch.Empty() {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "nothing to update",
+		})
+		return
+	}
+
+	collection, err := c.uc.Update(ctx.Request.Context(), id, patch)
+	if err != nil {
+		c.respondError(ctx, "failed to update collection", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ConvertFromCollection(collection))
+}
+
+// @Summary Удаление подборки
+// @Description Удаляет подборку, сами фильмы остаются в каталоге. Подборку, из которой выбирает комната, удалить нельзя
+// @Tags Collections
+// @Param collection_id path string true "UUID подборки" example("550e8400-e29b-41d4-a716-446655440000")
+// @Success 204 "Подборка удалена"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректный UUID подборки"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Подборка не найдена"
+// @Failure 409 {object} http_common.ErrorResponse "Подборка используется комнатой"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections/{collection_id} [delete]
+func (c *Controller) deleteCollection(ctx *gin.Context) {
+	id, ok := c.collectionID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.uc.Delete(ctx.Request.Context(), id); err != nil {
+		c.respondError(ctx, "failed to delete collection", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Добавление фильмов в подборку
+// @Description Добавляет фильмы в подборку, уже добавленные пропускаются. Если хотя бы одного фильма нет, не добавляется ни один
+// @Tags Collections
+// @Accept json
+// @Param collection_id path string true "UUID подборки" example("550e8400-e29b-41d4-a716-446655440000")
+// @Param request body CollectionMoviesRequestDTO true "UUID фильмов"
+// @Success 204 "Фильмы добавлены"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Подборка или фильм не найдены"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections/{collection_id}/movies [post]
+func (c *Controller) addMovies(ctx *gin.Context) {
+	id, req, ok := c.moviesRequest(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.uc.AddMovies(ctx.Request.Context(), id, req.MovieIDs); err != nil {
+		c.respondError(ctx, "failed to add movies to collection", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Удаление фильмов из подборки
+// @Description Убирает фильмы из подборки, фильмы не из подборки пропускаются
+// @Tags Collections
+// @Accept json
+// @Param collection_id path string true "UUID подборки" example("550e8400-e29b-41d4-a716-446655440000")
+// @Param request body CollectionMoviesRequestDTO true "UUID фильмов"
+// @Success 204 "Фильмы убраны"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Подборка не найдена"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /collections/{collection_id}/movies [delete]
+func (c *Controller) removeMovies(ctx *gin.Context) {
+	id, req, ok := c.moviesRequest(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.uc.RemoveMovies(ctx.Request.Context(), id, req.MovieIDs); err != nil {
+		c.respondError(ctx, "failed to remove movies from collection", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *Controller) collectionID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("collection_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid resource id format",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (c *Controller) moviesRequest(ctx *gin.Context) (uuid.UUID, CollectionMoviesRequestDTO, bool) {
+	var req CollectionMoviesRequestDTO
+
+	id, ok := c.collectionID(ctx)
+	if !ok {
+		return id, req, false
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid request format",
+		})
+		return id, req, false
+	}
+	return id, req, true
+}
+
+func (c *Controller) respondError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, usecase_collection.ErrInvalidCollection):
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+	case errors.Is(err, usecase_collection.ErrResourceNotFound):
+		ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+			Message: "not found",
+		})
+	case errors.Is(err, usecase_collection.ErrNameConflict), errors.Is(err, usecase_collection.ErrCollectionInUse):
+		ctx.JSON(http.StatusConflict, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+	default:
+		c.logger.Error(msg, slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+	}
+}
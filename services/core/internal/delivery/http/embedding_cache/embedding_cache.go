@@ -0,0 +1,115 @@
+package http_embedding_cache
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	http_common "github.com/humanbelnik/kinoswap/core/internal/delivery/http/common"
+	http_auth_middleware "github.com/humanbelnik/kinoswap/core/internal/delivery/http/middleware/auth"
+	"github.com/humanbelnik/kinoswap/core/internal/service/embedding_cache"
+)
+
+type Cache interface {
+	Stats() embedding_cache.Stats
+	Invalidate(ctx context.Context) (int, error)
+}
+
+// CacheStatsResponseDTO статистика кеша эмбеддингов предпочтений этого экземпляра с его запуска
+type CacheStatsResponseDTO struct {
+	Model     string  `json:"model" example:"all-MiniLM-L6-v2@1"`
+	LocalHits int64   `json:"local_hits" example:"120"`
+	StoreHits int64   `json:"store_hits" example:"40"`
+	Misses    int64   `json:"misses" example:"60"`
+	Errors    int64   `json:"errors" example:"0"`
+	HitRate   float64 `json:"hit_rate" example:"0.73"`
+	LocalSize int     `json:"local_size" example:"85"`
+}
+
+func ConvertFromStats(s embedding_cache.Stats) CacheStatsResponseDTO {
+	return CacheStatsResponseDTO{
+		Model:     s.Model,
+		LocalHits: s.LocalHits,
+		StoreHits: s.StoreHits,
+		Misses:    s.Misses,
+		Errors:    s.Errors,
+		HitRate:   s.HitRate(),
+		LocalSize: s.LocalSize,
+	}
+}
+
+// CacheInvalidateResponseDTO результат сброса кеша
+type CacheInvalidateResponseDTO struct {
+	Purged int `json:"purged" example:"512"`
+}
+
+type Controller struct {
+	cache          Cache
+	authMiddleware *http_auth_middleware.Middleware
+
+	logger *slog.Logger
+}
+
+type ControllerOption func(*Controller)
+
+func WithLogger(logger *slog.Logger) ControllerOption {
+	return func(c *Controller) {
+		c.logger = logger
+	}
+}
+
+func New(cache Cache, authMiddleware *http_auth_middleware.Middleware, opts ...ControllerOption) *Controller {
+	c := &Controller{
+		cache:          cache,
+		authMiddleware: authMiddleware,
+		logger:         slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	cache := router.Group("/embedding-cache")
+	cache.Use(c.authMiddleware.AuthRequired())
+
+	cache.GET("", c.stats)
+	cache.DELETE("", c.invalidate)
+}
+
+// @Summary Статистика кеша эмбеддингов предпочтений
+// @Description Попадания в память экземпляра и в Redis, промахи и ошибки с запуска экземпляра
+// @Tags Embeddings
+// @Produce json
+// @Success 200 {object} CacheStatsResponseDTO "Статистика кеша"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Security AdminToken
+// @Router /embedding-cache [get]
+func (c *Controller) stats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, ConvertFromStats(c.cache.Stats()))
+}
+
+// @Summary Сброс кеша эмбеддингов предпочтений
+// @Description Удаляет закешированные векторы всех экземпляров. Нужен после смены модели эмбеддера, иначе остальные экземпляры заметят ее в течение минуты
+// @Tags Embeddings
+// @Produce json
+// @Success 200 {object} CacheInvalidateResponseDTO "Кеш сброшен"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /embedding-cache [delete]
+func (c *Controller) invalidate(ctx *gin.Context) {
+	purged, err := c.cache.Invalidate(ctx.Request.Context())
+	if err != nil {
+		c.logger.Error("failed to invalidate embedding cache", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	c.logger.Info("embedding cache invalidated", slog.Int("purged", purged))
+	ctx.JSON(http.StatusOK, CacheInvalidateResponseDTO{Purged: purged})
+}
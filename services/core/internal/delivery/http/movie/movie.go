@@ -1,11 +1,17 @@
 package http_movie
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -13,6 +19,7 @@ import (
 	http_common "github.com/humanbelnik/kinoswap/core/internal/delivery/http/common"
 	http_auth_middleware "github.com/humanbelnik/kinoswap/core/internal/delivery/http/middleware/auth"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
+	movie_import "github.com/humanbelnik/kinoswap/core/internal/service/movie_import"
 	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
 )
 
@@ -24,12 +31,51 @@ type CreateMovieRequestDTO struct {
 
 	Year   int     `json:"year" example:"2014"`
 	Rating float64 `json:"rating" example:"8.6"`
+
+	Director    string   `json:"director,omitempty" example:"Кристофер Нолан"`
+	Cast        []string `json:"cast,omitempty" example:"Мэттью Макконахи,Энн Хэтэуэй"`
+	Runtime     int      `json:"runtime,omitempty" validate:"gte=0" example:"169"`
+	Certificate string   `json:"certificate,omitempty" example:"PG-13"`
+	MetaScore   int      `json:"meta_score,omitempty" example:"74"`
+	Votes       int      `json:"votes,omitempty" example:"1854740"`
+	Gross       int64    `json:"gross,omitempty" example:"188020017"`
+	ImdbID      string   `json:"imdb_id,omitempty" example:"tt0816692"`
 }
 
 func (r *CreateMovieRequestDTO) Validate() error {
 	return validator.New().Struct(r)
 }
 
+// UpdateMovieRequestDTO представляет запрос на изменение фильма. Отсутствующие поля не меняются
+type UpdateMovieRequestDTO struct {
+	Title    *string  `json:"title,omitempty" example:"Интерстеллар"`
+	Genres   []string `json:"genres,omitempty" example:"фантастика,драма"`
+	Overview *string  `json:"overview,omitempty" example:"Исправленное описание"`
+
+	Year   *int     `json:"year,omitempty" example:"2014"`
+	Rating *float64 `json:"rating,omitempty" example:"8.7"`
+
+	Director    *string  `json:"director,omitempty" example:"Кристофер Нолан"`
+	Cast        []string `json:"cast,omitempty" example:"Мэттью Макконахи,Энн Хэтэуэй"`
+	Runtime     *int     `json:"runtime,omitempty" example:"169"`
+	Certificate *string  `json:"certificate,omitempty" example:"PG-13"`
+}
+
+func (r *UpdateMovieRequestDTO) ConvertToMoviePatch() usecase_movie.MoviePatch {
+	return usecase_movie.MoviePatch{
+		Title:    r.Title,
+		Genres:   r.Genres,
+		Year:     r.Year,
+		Rating:   r.Rating,
+		Overview: r.Overview,
+
+		Director:    r.Director,
+		Cast:        r.Cast,
+		Runtime:     r.Runtime,
+		Certificate: r.Certificate,
+	}
+}
+
 // MovieResponseDTO представляет ответ с данными фильма
 type MovieResponseDTO struct {
 	ID         uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
@@ -39,12 +85,27 @@ type MovieResponseDTO struct {
 	Genres     []string  `json:"genres" example:"фантастика,драма,приключения"`
 	Overview   string    `json:"overview" example:"Захватывающая история о путешествии через червоточину..."`
 	PosterLink string    `json:"poster_link" example:"https://example.com/poster.jpg"`
+
+	Director string   `json:"director,omitempty" example:"Кристофер Нолан"`
+	Cast     []string `json:"cast,omitempty" example:"Мэттью Макконахи,Энн Хэтэуэй"`
+	// Минуты, 0 если неизвестна
+	Runtime     int    `json:"runtime,omitempty" example:"169"`
+	Certificate string `json:"certificate,omitempty" example:"PG-13"`
+	MetaScore   int    `json:"meta_score,omitempty" example:"74"`
+	Votes       int    `json:"votes,omitempty" example:"1854740"`
+	Gross       int64  `json:"gross,omitempty" example:"188020017"`
+	ImdbID      string `json:"imdb_id,omitempty" example:"tt0816692"`
+
+	// PENDING пока сохраняются постер и эмбеддинг, FAILED если это не удалось, READY когда фильм участвует в голосовании
+	Status string `json:"status,omitempty" example:"READY"`
 }
 
 // MoviesListResponseDTO DTO для списка фильмов
 type MoviesListResponseDTO struct {
 	Movies []MovieResponseDTO `json:"movies"`
-	Total  int                `json:"total"`
+	Total  int                `json:"total" example:"1000"`
+	Limit  int                `json:"limit" example:"20"`
+	Offset int                `json:"offset" example:"40"`
 }
 
 func (r *CreateMovieRequestDTO) ConvertToMovieMeta() model.MovieMeta {
@@ -55,6 +116,15 @@ func (r *CreateMovieRequestDTO) ConvertToMovieMeta() model.MovieMeta {
 		Rating:   r.Rating,
 		Genres:   r.Genres,
 		Overview: r.Overview,
+
+		Director:    r.Director,
+		Cast:        r.Cast,
+		Runtime:     r.Runtime,
+		Certificate: r.Certificate,
+		MetaScore:   r.MetaScore,
+		Votes:       r.Votes,
+		Gross:       r.Gross,
+		ImdbID:      r.ImdbID,
 	}
 }
 
@@ -67,6 +137,16 @@ func ConvertFromMovieMeta(meta model.MovieMeta) MovieResponseDTO {
 		Genres:     meta.Genres,
 		Overview:   meta.Overview,
 		PosterLink: meta.PosterLink,
+		Status:     string(meta.Status),
+
+		Director:    meta.Director,
+		Cast:        meta.Cast,
+		Runtime:     meta.Runtime,
+		Certificate: meta.Certificate,
+		MetaScore:   meta.MetaScore,
+		Votes:       meta.Votes,
+		Gross:       meta.Gross,
+		ImdbID:      meta.ImdbID,
 	}
 }
 
@@ -78,8 +158,127 @@ func ConvertFromMovieMetaList(metas []*model.MovieMeta) []MovieResponseDTO {
 	return movies
 }
 
+// SearchResultDTO найденный фильм и его близость к запросу
+type SearchResultDTO struct {
+	Movie MovieResponseDTO `json:"movie"`
+	Score float64          `json:"score" example:"0.63"`
+}
+
+// SearchResponseDTO результаты поиска, самые близкие первыми
+type SearchResponseDTO struct {
+	Query   string            `json:"query" example:"космос и черные дыры"`
+	Mode    string            `json:"mode" example:"hybrid"`
+	Results []SearchResultDTO `json:"results"`
+}
+
+func ConvertFromScoredMovies(hits []model.ScoredMovie) []SearchResultDTO {
+	results := make([]SearchResultDTO, len(hits))
+	for i, hit := range hits {
+		results[i] = SearchResultDTO{
+			Movie: ConvertFromMovieMeta(*hit.Movie),
+			Score: hit.Score,
+		}
+	}
+	return results
+}
+
+// ImportFailureDTO строка каталога, которую не удалось импортировать
+type ImportFailureDTO struct {
+	Line  int    `json:"line" example:"967"`
+	Title string `json:"title" example:"Apollo 13"`
+	Error string `json:"error" example:"invalid record: invalid year \"PG\""`
+}
+
+// ImportReportResponseDTO итог импорта каталога
+type ImportReportResponseDTO struct {
+	Total    int                `json:"total" example:"1000"`
+	Imported int                `json:"imported" example:"997"`
+	Skipped  int                `json:"skipped" example:"2"`
+	Failed   int                `json:"failed" example:"1"`
+	Failures []ImportFailureDTO `json:"failures"`
+}
+
+func ConvertFromImportReport(report usecase_movie.ImportReport) ImportReportResponseDTO {
+	failures := make([]ImportFailureDTO, len(report.Failures))
+	for i, f := range report.Failures {
+		failures[i] = ImportFailureDTO{
+			Line:  f.Line,
+			Title: f.Title,
+			Error: f.Error,
+		}
+	}
+	return ImportReportResponseDTO{
+		Total:    report.Total,
+		Imported: report.Imported,
+		Skipped:  report.Skipped,
+		Failed:   report.Failed,
+		Failures: failures,
+	}
+}
+
+// ReembedJobResponseDTO прогресс пересчета эмбеддингов каталога
+type ReembedJobResponseDTO struct {
+	ID         uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Model      string     `json:"model" example:"sentence-transformers/all-MiniLM-L6-v2@2"`
+	Status     string     `json:"status" example:"running"`
+	Done       int        `json:"done" example:"420"`
+	Total      int        `json:"total" example:"1000"`
+	Progress   float64    `json:"progress" example:"42"`
+	Error      string     `json:"error,omitempty" example:"failed to embed movie: connection refused"`
+	StartedAt  time.Time  `json:"started_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func ConvertFromReembedJob(job model.ReembedJob) ReembedJobResponseDTO {
+	return ReembedJobResponseDTO{
+		ID:         job.ID,
+		Model:      job.Model,
+		Status:     string(job.Status),
+		Done:       job.Done,
+		Total:      job.Total,
+		Progress:   job.Progress(),
+		Error:      job.Error,
+		StartedAt:  job.StartedAt,
+		UpdatedAt:  job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// IngestionJobResponseDTO загрузка фильма, исчерпавшая попытки
+type IngestionJobResponseDTO struct {
+	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	MovieID   uuid.UUID `json:"movie_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Attempts  int       `json:"attempts" example:"5"`
+	LastError string    `json:"last_error" example:"internal error\nrpc error: code = Unavailable"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ConvertFromIngestionJobs(jobs []model.IngestionJob) []IngestionJobResponseDTO {
+	dtos := make([]IngestionJobResponseDTO, len(jobs))
+	for i, job := range jobs {
+		dtos[i] = IngestionJobResponseDTO{
+			ID:        job.ID,
+			MovieID:   job.MovieID,
+			Attempts:  job.Attempts,
+			LastError: job.LastError,
+			CreatedAt: job.CreatedAt,
+		}
+	}
+	return dtos
+}
+
+// Protects embedder from admins asking for too much
+const maxImportConcurrency = 16
+
+// ParticipantValidator lets room participants search catalog without admin token
+type ParticipantValidator interface {
+	IsParticipant(ctx context.Context, code string, userID string) (bool, error)
+}
+
 type Controller struct {
 	uc *usecase_movie.Usecase
+	pv ParticipantValidator
 
 	authMiddleware *http_auth_middleware.Middleware
 
@@ -94,6 +293,12 @@ func WithLogger(logger *slog.Logger) ControllerOption {
 	}
 }
 
+func WithParticipantValidator(pv ParticipantValidator) ControllerOption {
+	return func(c *Controller) {
+		c.pv = pv
+	}
+}
+
 func New(uc *usecase_movie.Usecase,
 	authMiddleware *http_auth_middleware.Middleware,
 	opts ...ControllerOption) *Controller {
@@ -113,19 +318,63 @@ func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
 	movies.Use(c.authMiddleware.AuthRequired())
 
 	movies.POST("", c.createMovie)
+	movies.POST("/import", c.importMovies)
+	movies.POST("/reembed", c.startReembed)
+	movies.GET("/reembed", c.reembedStatus)
+	movies.DELETE("/reembed", c.cancelReembed)
+	movies.GET("/ingestion/dead", c.deadIngestions)
+	movies.POST("/:movie_id/ingestion/retry", c.retryIngestion)
 	movies.GET("", c.getMovies)
+	movies.GET("/search", c.searchMovies)
+	movies.GET("/:movie_id/similar", c.similarMovies)
+	movies.PATCH("/:movie_id", c.updateMovie)
 	movies.DELETE("/:movie_id", c.deleteMovie)
+
+	if c.pv != nil {
+		router.GET("/rooms/:room_id/movies/search", c.participantRequired(), c.searchMovies)
+		router.GET("/rooms/:room_id/movies/:movie_id/similar", c.participantRequired(), c.similarMovies)
+	}
+}
+
+func (c *Controller) participantRequired() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userToken := ctx.GetHeader("X-user-token")
+		if userToken == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, http_common.ErrorResponse{
+				Message: "X-user-token header required",
+			})
+			return
+		}
+
+		isParticipant, err := c.pv.IsParticipant(ctx.Request.Context(), ctx.Param("room_id"), userToken)
+		if err != nil {
+			c.logger.Error("failed to validate participant", slog.String("error", err.Error()))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, http_common.ErrorResponse{
+				Message: "internal error",
+			})
+			return
+		}
+		if !isParticipant {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, http_common.ErrorResponse{
+				Message: "user is not a participant of this room",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
 }
 
 // @Summary Создание фильма
-// @Description Создает новый фильм в системе. Принимает multipart/form-data с JSON данными о фильме и опциональным файлом постера
+// @Description Создает новый фильм в статусе PENDING. Постер и эмбеддинг сохраняются в фоне с повторными попытками, после чего фильм становится READY и участвует в голосовании. Принимает multipart/form-data с JSON данными о фильме и опциональным файлом постера
 // @Tags Movies operations
 // @Accept multipart/form-data
 // @Produce json
 // @Param body formData string true "Данные фильма в JSON формате" example({"title":"Inception","year":2010,"rating":8.8,"genres":["sci-fi","action"],"overview":"A thief who steals corporate secrets..."})
 // @Param file formData file false "Файл постера "
-// @Success 201 "Фильм успешно создан"
+// @Success 202 {object} MovieResponseDTO "Фильм принят в обработку"
 // @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса: невалидный JSON, отсутствует поле body"
+// @Failure 409 {object} http_common.ErrorResponse "Фильм с таким названием и годом уже есть"
 // @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
 // @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
 // @Security AdminToken
@@ -192,7 +441,14 @@ func (c *Controller) createMovie(ctx *gin.Context) {
 		MM:     &movieMeta,
 		Poster: poster,
 	}
-	if err := c.uc.Upload(ctx.Request.Context(), movie); err != nil {
+	mm, err := c.uc.Enqueue(ctx.Request.Context(), movie)
+	if errors.Is(err, usecase_movie.ErrDuplicate) {
+		ctx.JSON(http.StatusConflict, http_common.ErrorResponse{
+			Message: "movie already exists",
+		})
+		return
+	}
+	if err != nil {
 		c.logger.Error("failed to create movies", slog.String("error", err.Error()))
 		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
 			Message: "Failed to create movie",
@@ -200,43 +456,673 @@ func (c *Controller) createMovie(ctx *gin.Context) {
 		return
 	}
 
-	ctx.Status(http.StatusCreated)
+	ctx.JSON(http.StatusAccepted, ConvertFromMovieMeta(mm))
 }
 
-// GetMovies возвращает список фильмов
+// @Summary Загрузки фильмов, исчерпавшие попытки
+// @Description Возвращает фоновые загрузки фильмов, которые не удалось выполнить. Такие фильмы в статусе FAILED, их можно перезапустить
+// @Tags Movies operations
+// @Produce json
+// @Success 200 {array} IngestionJobResponseDTO "Неудавшиеся загрузки, последние первыми"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /movies/ingestion/dead [get]
+func (c *Controller) deadIngestions(ctx *gin.Context) {
+	jobs, err := c.uc.DeadIngestions(ctx.Request.Context())
+	if err != nil {
+		c.logger.Error("failed to load dead ingestions", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ConvertFromIngestionJobs(jobs))
+}
+
+// @Summary Повтор загрузки фильма
+// @Description Перезапускает неудавшуюся фоновую загрузку фильма с новым запасом попыток, фильм возвращается в статус PENDING
+// @Tags Movies operations
+// @Param movie_id path string true "UUID фильма" example("550e8400-e29b-41d4-a716-446655440000")
+// @Success 202 "Загрузка перезапущена"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректный UUID фильма"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Неудавшейся загрузки фильма нет"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /movies/{movie_id}/ingestion/retry [post]
+func (c *Controller) retryIngestion(ctx *gin.Context) {
+	movieID, err := uuid.Parse(ctx.Param("movie_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid resource id format",
+		})
+		return
+	}
+
+	if err := c.uc.RetryIngestion(ctx.Request.Context(), movieID); err != nil {
+		if errors.Is(err, usecase_movie.ErrResourceNotFound) {
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+			return
+		}
+		c.logger.Error("failed to retry ingestion", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// @Summary Импорт каталога
+// @Description Импортирует фильмы из CSV (колонки как в data/imdb_top_1000.csv) или JSONL (поля как при создании фильма). Файл передается полем file в multipart/form-data или телом запроса. Дубликаты (то же название и год) пропускаются, ошибки по строкам возвращаются в отчете
+// @Tags Movies operations
+// @Accept multipart/form-data,text/csv,application/x-ndjson
+// @Produce json
+// @Param file formData file false "Файл каталога"
+// @Param format query string false "Формат файла: csv или jsonl. По умолчанию определяется по имени файла или Content-Type"
+// @Param concurrency query int false "Сколько фильмов эмбеддится параллельно" default(4)
+// @Param posters query bool false "Скачивать постеры по Poster_Link" default(false)
+// @Success 200 {object} ImportReportResponseDTO "Отчет об импорте"
+// @Failure 400 {object} http_common.ErrorResponse "Неизвестный формат или нет обязательных колонок"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Security AdminToken
+// @Router /movies/import [post]
+func (c *Controller) importMovies(ctx *gin.Context) {
+	var (
+		body     io.Reader = ctx.Request.Body
+		filename string
+	)
+
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "file not found",
+			})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{Message: "error on read file"})
+			return
+		}
+		defer file.Close()
+
+		body = file
+		filename = fileHeader.Filename
+	}
+
+	format, err := importFormat(ctx.Query("format"), filename, ctx.ContentType())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "unknown format, use csv or jsonl",
+		})
+		return
+	}
+
+	opts := usecase_movie.ImportOptions{}
+	if raw := ctx.Query("concurrency"); raw != "" {
+		concurrency, err := strconv.Atoi(raw)
+		if err != nil || concurrency <= 0 {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "concurrency must be positive integer",
+			})
+			return
+		}
+		opts.Concurrency = min(concurrency, maxImportConcurrency)
+	}
+	if posters, _ := strconv.ParseBool(ctx.Query("posters")); posters {
+		opts.FetchPoster = movie_import.PosterFetcher(&http.Client{Timeout: 30 * time.Second})
+	}
+
+	records, err := movie_import.Read(body, format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	report, err := c.uc.Import(ctx.Request.Context(), records, opts)
+	if err != nil {
+		c.logger.Error("failed to import movies", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	c.logger.Info("movies imported",
+		slog.Int("total", report.Total),
+		slog.Int("imported", report.Imported),
+		slog.Int("skipped", report.Skipped),
+		slog.Int("failed", report.Failed))
+
+	ctx.JSON(http.StatusOK, ConvertFromImportReport(report))
+}
+
+// @Summary Пересчет эмбеддингов каталога
+// @Description Запускает фоновый пересчет векторов всех фильмов моделью, которую сейчас отдает эмбеддер. Новые векторы строятся рядом со старыми, поиск работает на старых, пока не будут готовы все. Прерванный перезапуском пересчет продолжается с места остановки
+// @Tags Movies operations
+// @Produce json
+// @Success 202 {object} ReembedJobResponseDTO "Пересчет запущен"
+// @Failure 409 {object} ReembedJobResponseDTO "Пересчет уже идет"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /movies/reembed [post]
+func (c *Controller) startReembed(ctx *gin.Context) {
+	job, err := c.uc.StartReembed(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, usecase_movie.ErrReembedRunning) {
+			ctx.JSON(http.StatusConflict, ConvertFromReembedJob(job))
+			return
+		}
+		c.logger.Error("failed to start re-embedding", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	c.logger.Info("re-embedding started", slog.String("job", job.ID.String()), slog.String("model", job.Model))
+	ctx.JSON(http.StatusAccepted, ConvertFromReembedJob(job))
+}
+
+// @Summary Прогресс пересчета эмбеддингов
+// @Description Возвращает идущий пересчет или последний завершенный
+// @Tags Movies operations
+// @Produce json
+// @Success 200 {object} ReembedJobResponseDTO "Прогресс пересчета"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Пересчетов не было"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /movies/reembed [get]
+func (c *Controller) reembedStatus(ctx *gin.Context) {
+	job, err := c.uc.ReembedStatus(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, usecase_movie.ErrResourceNotFound) {
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+			return
+		}
+		c.logger.Error("failed to load re-embedding status", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ConvertFromReembedJob(job))
+}
+
+// @Summary Отмена пересчета эмбеддингов
+// @Description Останавливает идущий пересчет, построенные им векторы удаляются, фильмы остаются на текущих
+// @Tags Movies operations
+// @Success 204 "Пересчет отменен"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Пересчет не идет"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Router /movies/reembed [delete]
+func (c *Controller) cancelReembed(ctx *gin.Context) {
+	err := c.uc.CancelReembed(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, usecase_movie.ErrResourceNotFound) {
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+			return
+		}
+		c.logger.Error("failed to cancel re-embedding", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func importFormat(explicit, filename, contentType string) (movie_import.Format, error) {
+	switch {
+	case explicit != "":
+		return movie_import.ParseFormat(explicit)
+	case filename != "":
+		return movie_import.FormatOf(filename)
+	case contentType == "text/csv":
+		return movie_import.FormatCSV, nil
+	case contentType == "application/x-ndjson", contentType == "application/jsonl":
+		return movie_import.FormatJSONL, nil
+	}
+	return movie_import.ParseFormat(contentType)
+}
+
+// GetMovies возвращает страницу каталога
 // @Summary Получение списка фильмов
-// @Description Возвращает список всех фильмов в системе
+// @Description Возвращает страницу каталога с учетом фильтров и сортировки. Фильмы в статусе PENDING не показываются. total - число фильмов, подходящих под фильтры
 // @Tags Movies operations
 // @Produce json
+// @Param limit query int false "Размер страницы, не больше 100" default(20)
+// @Param offset query int false "Сколько фильмов пропустить" default(0)
+// @Param sort query string false "Поле сортировки" Enums(title, year, rating) default(title)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(asc)
+// @Param genre query []string false "Жанры, фильм должен содержать все" collectionFormat(multi)
+// @Param year_from query int false "Год выхода от"
+// @Param year_to query int false "Год выхода до"
+// @Param rating_min query number false "Рейтинг от"
+// @Param rating_max query number false "Рейтинг до"
+// @Param director query string false "Режиссер, достаточно части имени" example(Nolan)
+// @Param actor query string false "Актер, достаточно части имени"
+// @Param runtime_min query int false "Длительность от, в минутах"
+// @Param runtime_max query int false "Длительность до, в минутах" example(120)
+// @Param collection query []string false "UUID подборок, фильм должен входить хотя бы в одну" collectionFormat(multi)
 // @Success 200 {object} MoviesListResponseDTO "Список фильмов успешно получен"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные параметры запроса"
 // @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
 // @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
 // @Security AdminToken
 // @Router /movies [get]
 func (c *Controller) getMovies(ctx *gin.Context) {
 	ctx.Header("X-port", os.Getenv("HTTP_PORT")) // или ctx.Writer.Header().Set("X-port", c.port)
-	movies, err := c.uc.LoadAll(ctx.Request.Context())
+
+	query, err := parseMovieQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	page, err := c.uc.LoadPage(ctx.Request.Context(), query)
 	if err != nil {
 		c.logger.Error("failed to load movies", slog.String("error", err.Error()))
-		switch err {
-		case usecase_movie.ErrInternal:
-			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
-				Message: "internal error",
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	response := MoviesListResponseDTO{
+		Movies: ConvertFromMovieMetaList(page.Movies),
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Поиск фильмов по описанию
+// @Description Ищет фильмы по произвольному тексту. vector - по смыслу, score - косинусная близость от -1 до 1. lexical - полнотекстовый поиск по названию и описанию, score - ts_rank. hybrid - оба рейтинга, объединенные reciprocal rank fusion, score - сумма 1/(60+позиция). Доступен администраторам и участникам комнаты
+// @Tags Movies operations
+// @Produce json
+// @Param room_id path string false "Код комнаты, для участников"
+// @Param q query string true "Текст запроса" example(фильм про ограбление банка)
+// @Param mode query string false "Режим поиска" Enums(vector, lexical, hybrid) default(vector)
+// @Param limit query int false "Сколько фильмов вернуть, не больше 50" default(10)
+// @Param genre query []string false "Жанры, фильм должен содержать все" collectionFormat(multi)
+// @Param year_from query int false "Год выхода от"
+// @Param year_to query int false "Год выхода до"
+// @Param rating_min query number false "Рейтинг от"
+// @Param rating_max query number false "Рейтинг до"
+// @Param director query string false "Режиссер, достаточно части имени" example(Nolan)
+// @Param actor query string false "Актер, достаточно части имени"
+// @Param runtime_min query int false "Длительность от, в минутах"
+// @Param runtime_max query int false "Длительность до, в минутах" example(120)
+// @Param collection query []string false "UUID подборок, фильм должен входить хотя бы в одну" collectionFormat(multi)
+// @Success 200 {object} SearchResponseDTO "Результаты поиска"
+// @Failure 400 {object} http_common.ErrorResponse "Пустой запрос или некорректные параметры"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 403 {object} http_common.ErrorResponse "Пользователь не является участником комнаты"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Security UserToken
+// @Router /movies/search [get]
+// @Router /rooms/{room_id}/movies/search [get]
+func (c *Controller) searchMovies(ctx *gin.Context) {
+	limit, err := queryInt(ctx, "limit")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filter, err := parseMovieFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	mode, err := model.ParseSearchMode(ctx.DefaultQuery("mode", string(model.SearchModeVector)))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "mode must be one of vector, lexical, hybrid",
+		})
+		return
+	}
+
+	query := ctx.Query("q")
+	hits, err := c.uc.Search(ctx.Request.Context(), model.SearchQuery{
+		Text:   query,
+		Mode:   mode,
+		Limit:  limit,
+		Filter: filter,
+	})
+	if err != nil {
+		if errors.Is(err, usecase_movie.ErrEmptyQuery) {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "q is required",
 			})
-		case usecase_movie.ErrResourceNotFound:
+			return
+		}
+		c.logger.Error("failed to search movies", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SearchResponseDTO{
+		Query:   query,
+		Mode:    string(mode),
+		Results: ConvertFromScoredMovies(hits),
+	})
+}
+
+// SimilarResponseDTO похожие фильмы, самые близкие первыми
+type SimilarResponseDTO struct {
+	MovieID uuid.UUID         `json:"movie_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Results []SearchResultDTO `json:"results"`
+}
+
+// @Summary Похожие фильмы
+// @Description Возвращает фильмы, ближайшие к данному по эмбеддингу, сам фильм исключается. Списки соседей пересчитываются в фоне, фильтры применяются к сохраненным 50 ближайшим. score - косинусная близость. Доступен администраторам и участникам комнаты
+// @Tags Movies operations
+// @Produce json
+// @Param room_id path string false "Код комнаты, для участников"
+// @Param movie_id path string true "UUID фильма" example("550e8400-e29b-41d4-a716-446655440000")
+// @Param limit query int false "Сколько фильмов вернуть, не больше 50" default(10)
+// @Param genre query []string false "Жанры, фильм должен содержать все" collectionFormat(multi)
+// @Param year_from query int false "Год выхода от"
+// @Param year_to query int false "Год выхода до"
+// @Param rating_min query number false "Рейтинг от"
+// @Param rating_max query number false "Рейтинг до"
+// @Param director query string false "Режиссер, достаточно части имени" example(Nolan)
+// @Param actor query string false "Актер, достаточно части имени"
+// @Param runtime_min query int false "Длительность от, в минутах"
+// @Param runtime_max query int false "Длительность до, в минутах" example(120)
+// @Param collection query []string false "UUID подборок, фильм должен входить хотя бы в одну" collectionFormat(multi)
+// @Success 200 {object} SimilarResponseDTO "Похожие фильмы"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректный UUID фильма или параметры"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Failure 403 {object} http_common.ErrorResponse "Пользователь не является участником комнаты"
+// @Failure 404 {object} http_common.ErrorResponse "Фильм не найден"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security AdminToken
+// @Security UserToken
+// @Router /movies/{movie_id}/similar [get]
+// @Router /rooms/{room_id}/movies/{movie_id}/similar [get]
+func (c *Controller) similarMovies(ctx *gin.Context) {
+	movieID, err := uuid.Parse(ctx.Param("movie_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid resource id format",
+		})
+		return
+	}
+
+	limit, err := queryInt(ctx, "limit")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filter, err := parseMovieFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	hits, err := c.uc.Similar(ctx.Request.Context(), movieID, limit, filter)
+	if err != nil {
+		if errors.Is(err, usecase_movie.ErrResourceNotFound) {
 			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
 				Message: "not found",
 			})
+			return
+		}
+		c.logger.Error("failed to load similar movies", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SimilarResponseDTO{
+		MovieID: movieID,
+		Results: ConvertFromScoredMovies(hits),
+	})
+}
+
+func parseMovieQuery(ctx *gin.Context) (model.MovieQuery, error) {
+	var (
+		q   model.MovieQuery
+		err error
+	)
+
+	if q.Limit, err = queryInt(ctx, "limit"); err != nil {
+		return q, err
+	}
+	if q.Offset, err = queryInt(ctx, "offset"); err != nil {
+		return q, err
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return q, errors.New("limit and offset must not be negative")
+	}
+
+	switch sort := model.MovieSortField(ctx.DefaultQuery("sort", string(model.MovieSortTitle))); sort {
+	case model.MovieSortTitle, model.MovieSortYear, model.MovieSortRating:
+		q.SortBy = sort
+	default:
+		return q, errors.New("sort must be one of title, year, rating")
+	}
+
+	switch ctx.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+
+	q.Filter, err = parseMovieFilter(ctx)
+	return q, err
+}
+
+// Shared by every endpoint listing movies
+func parseMovieFilter(ctx *gin.Context) (model.MovieFilter, error) {
+	var f model.MovieFilter
+
+	for _, raw := range ctx.QueryArray("genre") {
+		for _, genre := range strings.Split(raw, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				f.Genres = append(f.Genres, genre)
+			}
+		}
+	}
+
+	for _, raw := range ctx.QueryArray("collection") {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			id, err := uuid.Parse(part)
+			if err != nil {
+				return f, errors.New("collection must be UUID")
+			}
+			f.Collections = append(f.Collections, id)
+		}
+	}
+
+	for name, dst := range map[string]**string{"director": &f.Director, "actor": &f.Actor} {
+		if raw := strings.TrimSpace(ctx.Query(name)); raw != "" {
+			*dst = &raw
+		}
+	}
+
+	ints := map[string]**int{
+		"year_from":   &f.YearFrom,
+		"year_to":     &f.YearTo,
+		"runtime_min": &f.RuntimeMin,
+		"runtime_max": &f.RuntimeMax,
+	}
+	for name, dst := range ints {
+		if raw, ok := ctx.GetQuery(name); ok {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				return f, fmt.Errorf("%s must be integer", name)
+			}
+			*dst = &v
+		}
+	}
+
+	for name, dst := range map[string]**float64{"rating_min": &f.RatingMin, "rating_max": &f.RatingMax} {
+		if raw, ok := ctx.GetQuery(name); ok {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return f, fmt.Errorf("%s must be number", name)
+			}
+			*dst = &v
+		}
+	}
+
+	return f, nil
+}
+
+func queryInt(ctx *gin.Context, name string) (int, error) {
+	raw, ok := ctx.GetQuery(name)
+	if !ok {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be integer", name)
+	}
+	return v, nil
+}
+
+// @Summary Изменение фильма
+// @Description Изменяет данные фильма и/или заменяет постер, реакции на фильм сохраняются. Эмбеддинг пересчитывается только при изменении названия, описания, жанров, режиссера или актеров
+// @Tags Movies operations
+// @Accept multipart/form-data
+// @Produce json
+// @Param movie_id path string true "UUID фильма" example("550e8400-e29b-41d4-a716-446655440000")
+// @Param body formData string false "Изменяемые поля в JSON формате" example({"overview":"A thief who steals corporate secrets..."})
+// @Param file formData file false "Новый файл постера"
+// @Success 204 "Фильм успешно изменен"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса: невалидный JSON, пустые обязательные поля, нечего менять"
+// @Failure 404 {object} http_common.ErrorResponse "Фильм не найден"
+// @Failure 409 {object} http_common.ErrorResponse "Фильм с таким названием и годом уже есть"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 401 {object} http_common.ErrorResponse "Пользователь не авторизован"
+// @Security AdminToken
+// @Router /movies/{id} [patch]
+func (c *Controller) updateMovie(ctx *gin.Context) {
+	movieID, err := uuid.Parse(ctx.Param("movie_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid resource id format",
+		})
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "bad request",
+		})
+		return
+	}
+
+	var req UpdateMovieRequestDTO
+	if body := form.Value["body"]; len(body) > 0 {
+		if err := json.Unmarshal([]byte(body[0]), &req); err != nil {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "invalid body",
+			})
+			return
+		}
+	}
+	patch := req.ConvertToMoviePatch()
+
+	if files := form.File["file"]; len(files) > 0 {
+		fileHeader := files[0]
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{Message: "error on read file"})
+			return
 		}
+		defer file.Close()
+
+		posterData, err := io.ReadAll(file)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{Message: "error on read file"})
+			return
+		}
+		patch.Poster = &model.Poster{
+			Filename: fileHeader.Filename,
+			Content:  posterData,
+		}
+	}
+
+	if patch.Empty() {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "nothing to update",
+		})
 		return
 	}
 
-	response := MoviesListResponseDTO{
-		Movies: ConvertFromMovieMetaList(movies),
-		Total:  len(movies),
+	if err := c.uc.Update(ctx.Request.Context(), movieID, patch); err != nil {
+		c.logger.Error("failed to update movie", slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, usecase_movie.ErrInvalidMovie):
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "empty fields",
+			})
+		case errors.Is(err, usecase_movie.ErrResourceNotFound):
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+		case errors.Is(err, usecase_movie.ErrDuplicate):
+			ctx.JSON(http.StatusConflict, http_common.ErrorResponse{
+				Message: "movie already exists",
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+				Message: "internal error",
+			})
+		}
+		return
 	}
 
-	ctx.JSON(http.StatusOK, response)
+	ctx.Status(http.StatusNoContent)
 }
 
 // DeleteMovie удаляет фильм
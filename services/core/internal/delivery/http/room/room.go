@@ -4,23 +4,43 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	http_common "github.com/humanbelnik/kinoswap/core/internal/delivery/http/common"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
 	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
 )
 
+// FreeNotifier is told about freed rooms to drop their in-memory state (ex. lobby chat)
+type FreeNotifier interface {
+	NotifyRoomFreed(roomCode string)
+}
+
 type Controller struct {
-	usecase *usecase_room.Usecase
-	logger  *slog.Logger
+	usecase  *usecase_room.Usecase
+	notifier FreeNotifier
+	logger   *slog.Logger
+}
+
+type ControllerOption func(*Controller)
+
+func WithFreeNotifier(notifier FreeNotifier) ControllerOption {
+	return func(c *Controller) {
+		c.notifier = notifier
+	}
 }
 
-func New(usecase *usecase_room.Usecase) *Controller {
-	return &Controller{
+func New(usecase *usecase_room.Usecase, opts ...ControllerOption) *Controller {
+	c := &Controller{
 		usecase: usecase,
 		logger:  slog.Default(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
@@ -30,38 +50,92 @@ func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
 		rooms.GET("/:room_id/status", c.status)
 		rooms.POST("/:room_id/participations", c.participate)
 		rooms.DELETE("/:room_id", c.free)
+		rooms.GET("/:room_id/collections", c.collections)
+		rooms.PUT("/:room_id/collections", c.setCollections)
 	}
 }
 
+// ShortlistDTO фильмы, за которые голосует комната вместо рекомендаций
+type ShortlistDTO struct {
+	MovieIDs []uuid.UUID `json:"movie_ids" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// Название сопоставляется нечетко, год в скобках уточняет совпадение
+	Titles []string `json:"titles" example:"Heat (1995)"`
+}
+
+// BookRequestDTO DTO для создания комнаты
+type BookRequestDTO struct {
+	// Без шортлиста комната рекомендует фильмы по предпочтениям
+	Shortlist *ShortlistDTO `json:"shortlist,omitempty"`
+}
+
 // BookResponseDTO DTO для ответа создания комнаты
 type BookResponseDTO struct {
 	RoomCode string `json:"room_code"`
 }
 
+// ShortlistErrorResponse DTO ошибки несопоставленного шортлиста
+type ShortlistErrorResponse struct {
+	Message   string   `json:"message"`
+	Unmatched []string `json:"unmatched"`
+}
+
 // Book создает новую комнату
 // @Summary Создание комнаты
-// @Description Создает новую комнату для выбора фильмов
+// @Description Создает новую комнату для выбора фильмов. С шортлистом участники голосуют только за выбранные владельцем фильмы
 // @Tags Rooms
 // @Accept json
 // @Produce json
+// @Param request body BookRequestDTO false "Шортлист комнаты"
 // @Success 201 "Комната успешно создана"
 // @Header 201 {string} X-user-token "Токен владельца комнаты"
+// @Failure 400 {object} ShortlistErrorResponse "Некорректный шортлист"
 // @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
 // @Failure 503 {object} http_common.ErrorResponse "Ресур недоступен"
 // @Router /rooms [post]
 func (c *Controller) book(ctx *gin.Context) {
-	roomCode, ownerToken, err := c.usecase.Book(ctx)
+	var req BookRequestDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "invalid request format",
+			})
+			return
+		}
+	}
+
+	var (
+		roomCode, ownerToken string
+		err                  error
+	)
+	if req.Shortlist != nil {
+		roomCode, ownerToken, err = c.usecase.BookShortlist(ctx, usecase_room.ShortlistRequest{
+			MovieIDs: req.Shortlist.MovieIDs,
+			Titles:   req.Shortlist.Titles,
+		})
+	} else {
+		roomCode, ownerToken, err = c.usecase.Book(ctx)
+	}
 	if err != nil {
 		c.logger.Error("failed to book room", slog.String("error", err.Error()))
-		switch err {
-		case usecase_room.ErrInternal:
-			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
-				Message: "internal error",
+		var mismatch *usecase_room.ShortlistMismatchError
+		switch {
+		case errors.As(err, &mismatch):
+			ctx.JSON(http.StatusBadRequest, ShortlistErrorResponse{
+				Message:   "shortlist movies not found",
+				Unmatched: mismatch.Unmatched,
+			})
+		case errors.Is(err, usecase_room.ErrInvalidShortlist):
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: err.Error(),
 			})
-		case usecase_room.ErrRoomsUnavailable:
+		case errors.Is(err, usecase_room.ErrRoomsUnavailable):
 			ctx.JSON(http.StatusServiceUnavailable, http_common.ErrorResponse{
 				Message: "unavailable",
 			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+				Message: "internal error",
+			})
 		}
 		return
 	}
@@ -168,12 +242,17 @@ func (c *Controller) free(ctx *gin.Context) {
 		return
 	}
 
+	if c.notifier != nil {
+		c.notifier.NotifyRoomFreed(code)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
 // ParticipateRequestDTO DTO для участия в комнате
 type ParticipateRequestDTO struct {
-	Preference model.Preference `json:"preference" binding:"required"`
+	// В комнате с шортлистом предпочтения можно не указывать
+	Preference model.Preference `json:"preference"`
 }
 
 // ParticipateResponseDTO DTO для ответа участия
@@ -191,8 +270,10 @@ type ParticipateResponseDTO struct {
 // @Param request body ParticipateRequestDTO true "Данные участника"
 // @Success 201 {object} ParticipateResponseDTO "Участник успешно добавлен"
 // @Header 201 {string} X-user-token "Токен пользователя"
+// @Failure 400 {object} http_common.ErrorResponse "Предпочтения обязательны"
 // @Failure 404 {object} http_common.ErrorResponse "Комната не найдена"
 // @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 503 {object} http_common.ErrorResponse "Рекомендации временно недоступны"
 // @Security UserToken
 // @Router /rooms/{room_id}/participations [post]
 func (c *Controller) participate(ctx *gin.Context) {
@@ -223,6 +304,19 @@ func (c *Controller) participate(ctx *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecase_room.ErrPreferenceRequired) {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "preference is required",
+			})
+			return
+		}
+		if errors.Is(err, usecase_room.ErrRecommendationsUnavailable) {
+			c.logger.Warn("failed to participate in room", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusServiceUnavailable, http_common.ErrorResponse{
+				Message: usecase_room.ErrRecommendationsUnavailable.Error(),
+			})
+			return
+		}
 		c.logger.Error("failed to participate in room", slog.String("error", err.Error()))
 		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
 			Message: "internal error",
@@ -234,3 +328,133 @@ func (c *Controller) participate(ctx *gin.Context) {
 	ctx.Header("X-user-token", returnedUserID)
 	ctx.Status(http.StatusCreated)
 }
+
+// SetCollectionsRequestDTO подборки, из которых комната выбирает фильмы
+type SetCollectionsRequestDTO struct {
+	// Пустой список снимает ограничение
+	CollectionIDs []uuid.UUID `json:"collection_ids" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// RoomCollectionDTO подборка, из которой комната выбирает фильмы
+type RoomCollectionDTO struct {
+	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name        string    `json:"name" example:"Рождественская классика"`
+	Description string    `json:"description" example:"Фильмы для новогодних праздников"`
+	Size        int       `json:"size" example:"24"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Collections возвращает подборки комнаты
+// @Summary Подборки комнаты
+// @Description Возвращает подборки, из которых комната выбирает фильмы. Пустой список значит весь каталог
+// @Tags Rooms
+// @Produce json
+// @Param room_id path string true "Код комнаты"
+// @Success 200 {array} RoomCollectionDTO "Подборки комнаты"
+// @Failure 404 {object} http_common.ErrorResponse "Комната не найдена"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /rooms/{room_id}/collections [get]
+func (c *Controller) collections(ctx *gin.Context) {
+	collections, err := c.usecase.Collections(ctx, ctx.Param("room_id"))
+	if err != nil {
+		if errors.Is(err, usecase_room.ErrResourceNotFound) {
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+			return
+		}
+		c.logger.Error("failed to load room collections", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+
+	dtos := make([]RoomCollectionDTO, len(collections))
+	for i, collection := range collections {
+		dtos[i] = RoomCollectionDTO{
+			ID:          collection.ID,
+			Name:        collection.Name,
+			Description: collection.Description,
+			Size:        collection.Size,
+			UpdatedAt:   collection.UpdatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, dtos)
+}
+
+// SetCollections ограничивает фильмы комнаты подборками
+// @Summary Выбор подборок комнаты
+// @Description Ограничивает кандидатов для голосования фильмами из выбранных подборок. Доступно владельцу комнаты до начала голосования
+// @Tags Rooms
+// @Accept json
+// @Param room_id path string true "Код комнаты"
+// @Param request body SetCollectionsRequestDTO true "UUID подборок"
+// @Success 204 "Подборки выбраны"
+// @Failure 400 {object} http_common.ErrorResponse "Некорректные данные запроса"
+// @Failure 401 {object} http_common.ErrorResponse "Не авторизован"
+// @Failure 404 {object} http_common.ErrorResponse "Комната или подборка не найдены"
+// @Failure 409 {object} http_common.ErrorResponse "Голосование уже началось"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security UserToken
+// @Router /rooms/{room_id}/collections [put]
+func (c *Controller) setCollections(ctx *gin.Context) {
+	code := ctx.Param("room_id")
+
+	var req SetCollectionsRequestDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "invalid request format",
+		})
+		return
+	}
+
+	userToken := ctx.GetHeader("X-user-token")
+	if userToken == "" {
+		ctx.JSON(http.StatusUnauthorized, http_common.ErrorResponse{
+			Message: "X-user-token not found",
+		})
+		return
+	}
+	isOwner, err := c.usecase.IsOwner(ctx, code, userToken)
+	if err != nil {
+		if errors.Is(err, usecase_room.ErrResourceNotFound) {
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+			return
+		}
+		c.logger.Error("failed to check room owner", slog.String("error", err.Error()))
+		ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+			Message: "internal error",
+		})
+		return
+	}
+	if !isOwner {
+		ctx.JSON(http.StatusUnauthorized, http_common.ErrorResponse{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	if err := c.usecase.SetCollections(ctx, code, req.CollectionIDs); err != nil {
+		switch {
+		case errors.Is(err, usecase_room.ErrResourceNotFound):
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+		case errors.Is(err, usecase_room.ErrVotingStarted):
+			ctx.JSON(http.StatusConflict, http_common.ErrorResponse{
+				Message: "voting has started",
+			})
+		default:
+			c.logger.Error("failed to set room collections", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+				Message: "internal error",
+			})
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -117,6 +117,7 @@ type GetMoviesResponseDTO struct {
 // @Failure 400 {object} http_common.ErrorResponse "Неверный формат запроса"
 // @Failure 403 {object} http_common.ErrorResponse "Пользователь не является участником комнаты"
 // @Failure 404 {object} http_common.ErrorResponse "Комната не найдена"
+// @Failure 409 {object} http_common.ErrorResponse "В шортлисте комнаты нет готовых фильмов"
 // @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
 // @Security UserToken
 // @Router /rooms/{room_id}/movies [get]
@@ -137,6 +138,13 @@ func (c *Controller) getMovies(ctx *gin.Context) {
 
 	movies, err := c.uc.VotingBatch(ctx, req.Count, roomID)
 	if err != nil {
+		if errors.Is(err, usecase_vote.ErrDeckExhausted) {
+			c.logger.Warn("shortlist has no ready movies", slog.String("room_id", roomID))
+			ctx.JSON(http.StatusConflict, http_common.ErrorResponse{
+				Message: "shortlist has no ready movies",
+			})
+			return
+		}
 		if errors.Is(err, usecase_vote.ErrResourceNotFound) {
 			c.logger.Error("no participants found for voting", slog.String("room_id", roomID), slog.String("error", err.Error()))
 			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
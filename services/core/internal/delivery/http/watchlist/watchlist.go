@@ -0,0 +1,173 @@
+package http_watchlist
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	http_common "github.com/humanbelnik/kinoswap/core/internal/delivery/http/common"
+	"github.com/humanbelnik/kinoswap/core/internal/service/watchlist_import"
+	usecase_watchlist "github.com/humanbelnik/kinoswap/core/internal/usecase/watchlist"
+)
+
+// Exports of MaxEntries rows are far smaller
+const maxUploadSize = 8 << 20
+
+// UnmatchedDTO строка выгрузки, не найденная в каталоге
+type UnmatchedDTO struct {
+	Line   int    `json:"line" example:"7"`
+	Title  string `json:"title" example:"Twin Peaks"`
+	Year   int    `json:"year,omitempty" example:"1990"`
+	Reason string `json:"reason" example:"not found in catalog"`
+}
+
+// WatchlistReportResponseDTO отчет о загрузке списка
+type WatchlistReportResponseDTO struct {
+	// letterboxd или imdb
+	Source    string         `json:"source" example:"letterboxd"`
+	Total     int            `json:"total" example:"120"`
+	Matched   int            `json:"matched" example:"97"`
+	Unmatched []UnmatchedDTO `json:"unmatched"`
+}
+
+func ConvertFromReport(source watchlist_import.Source, report usecase_watchlist.Report) WatchlistReportResponseDTO {
+	dto := WatchlistReportResponseDTO{
+		Source:    string(source),
+		Total:     report.Total,
+		Matched:   report.Matched,
+		Unmatched: make([]UnmatchedDTO, len(report.Unmatched)),
+	}
+	for i, u := range report.Unmatched {
+		dto.Unmatched[i] = UnmatchedDTO{
+			Line:   u.Line,
+			Title:  u.Title,
+			Year:   u.Year,
+			Reason: u.Reason,
+		}
+	}
+	return dto
+}
+
+type Controller struct {
+	uc *usecase_watchlist.Usecase
+
+	logger *slog.Logger
+}
+
+type ControllerOption func(*Controller)
+
+func WithLogger(logger *slog.Logger) ControllerOption {
+	return func(c *Controller) {
+		c.logger = logger
+	}
+}
+
+func New(uc *usecase_watchlist.Usecase, opts ...ControllerOption) *Controller {
+	c := &Controller{
+		uc:     uc,
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	router.PUT("/rooms/:room_id/watchlists/:list", c.upload)
+}
+
+// @Summary Загрузка списка фильмов участника
+// @Description Сопоставляет выгрузку Letterboxd (watchlist.csv, watched.csv, ratings.csv, diary.csv) или IMDb (watchlist, ratings) с каталогом по названию и году. Фильмы из списка watchlist чаще попадают в подборку комнаты, фильмы из списка watched исключаются из нее. Повторная загрузка заменяет список. Несопоставленные строки возвращаются в отчете
+// @Tags Rooms
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Param room_id path string true "Код комнаты"
+// @Param list path string true "Список: watchlist или watched"
+// @Param file formData file false "CSV выгрузка"
+// @Success 200 {object} WatchlistReportResponseDTO "Отчет о загрузке"
+// @Failure 400 {object} http_common.ErrorResponse "Неизвестный список или формат выгрузки"
+// @Failure 401 {object} http_common.ErrorResponse "Нет токена участника"
+// @Failure 404 {object} http_common.ErrorResponse "Участник не найден в комнате"
+// @Failure 500 {object} http_common.ErrorResponse "Внутренняя ошибка сервера"
+// @Security UserToken
+// @Router /rooms/{room_id}/watchlists/{list} [put]
+func (c *Controller) upload(ctx *gin.Context) {
+	code := ctx.Param("room_id")
+
+	userID, err := uuid.Parse(ctx.GetHeader("X-user-token"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, http_common.ErrorResponse{
+			Message: "X-user-token not found",
+		})
+		return
+	}
+
+	kind, err := usecase_watchlist.ParseKind(strings.ToUpper(ctx.Param("list")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: "unknown list, use watchlist or watched",
+		})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxUploadSize)
+	var body io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: "file not found",
+			})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{Message: "error on read file"})
+			return
+		}
+		defer file.Close()
+
+		body = file
+	}
+
+	export, err := watchlist_import.Read(body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	report, err := c.uc.Import(ctx.Request.Context(), code, userID, kind, export)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase_watchlist.ErrInvalidWatchlist):
+			ctx.JSON(http.StatusBadRequest, http_common.ErrorResponse{
+				Message: err.Error(),
+			})
+		case errors.Is(err, usecase_watchlist.ErrResourceNotFound):
+			ctx.JSON(http.StatusNotFound, http_common.ErrorResponse{
+				Message: "not found",
+			})
+		default:
+			c.logger.Error("failed to import watchlist", slog.String("error", err.Error()))
+			ctx.JSON(http.StatusInternalServerError, http_common.ErrorResponse{
+				Message: "internal error",
+			})
+		}
+		return
+	}
+
+	c.logger.Info("watchlist imported",
+		slog.String("room", code),
+		slog.String("list", string(kind)),
+		slog.Int("total", report.Total),
+		slog.Int("matched", report.Matched))
+	ctx.JSON(http.StatusOK, ConvertFromReport(export.Source, report))
+}
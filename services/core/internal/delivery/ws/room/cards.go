@@ -0,0 +1,134 @@
+package ws_room
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cards of rooms dropped by orphan cleanup or just abandoned are swept as idle chats are
+const defaultCardIdleTTL = time.Hour
+
+type cardState int
+
+const (
+	cardUnknown cardState = iota
+	// Served and waiting for reaction
+	cardPending
+	// Reacted already, client resends unacknowledged swipe
+	cardAcked
+)
+
+type servedCard struct {
+	pending uuid.UUID
+	acked   uuid.UUID
+	touched time.Time
+}
+
+// servedCards remember the card pushed to every participant, so nobody votes on movies never served to him.
+// Tabs of the same participant share the card.
+type servedCards struct {
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cards map[string]servedCard
+}
+
+func newServedCards() *servedCards {
+	return &servedCards{
+		idleTTL: defaultCardIdleTTL,
+		now:     time.Now,
+		cards:   make(map[string]servedCard),
+	}
+}
+
+func cardKey(roomCode, userID string) string {
+	return roomCode + "/" + userID
+}
+
+func (s *servedCards) Serve(roomCode, userID string, movieID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := cardKey(roomCode, userID)
+	card := s.touch(key)
+	card.pending = movieID
+	s.cards[key] = card
+}
+
+func (s *servedCards) State(roomCode, userID string, movieID uuid.UUID) cardState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card := s.cards[cardKey(roomCode, userID)]
+	switch movieID {
+	case uuid.Nil:
+		return cardUnknown
+	case card.pending:
+		return cardPending
+	case card.acked:
+		return cardAcked
+	}
+	return cardUnknown
+}
+
+func (s *servedCards) Ack(roomCode, userID string, movieID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := cardKey(roomCode, userID)
+	card := s.touch(key)
+	if card.pending == movieID {
+		card.pending = uuid.Nil
+	}
+	card.acked = movieID
+	s.cards[key] = card
+}
+
+// Finish leaves nothing to vote on once deck is over, the last swipe may still be resent
+func (s *servedCards) Finish(roomCode, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := cardKey(roomCode, userID)
+	card := s.touch(key)
+	card.pending = uuid.Nil
+	s.cards[key] = card
+}
+
+// Drop forgets cards of the whole room, so its code can be reused from scratch
+func (s *servedCards) Drop(roomCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.cards {
+		if strings.HasPrefix(key, roomCode+"/") {
+			delete(s.cards, key)
+		}
+	}
+}
+
+// Must be called under the lock
+func (s *servedCards) touch(key string) servedCard {
+	now := s.now()
+
+	card, ok := s.cards[key]
+	if !ok {
+		s.sweep(now)
+	}
+	card.touched = now
+
+	return card
+}
+
+// Must be called under the lock
+func (s *servedCards) sweep(now time.Time) {
+	for key, card := range s.cards {
+		if now.Sub(card.touched) > s.idleTTL {
+			delete(s.cards, key)
+		}
+	}
+}
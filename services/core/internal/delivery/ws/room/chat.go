@@ -0,0 +1,220 @@
+package ws_room
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+)
+
+var (
+	ErrMuted            = errors.New("participant is muted")
+	ErrRateLimited      = errors.New("too many messages")
+	ErrEmptyMessage     = errors.New("empty message")
+	ErrMessageTooLong   = errors.New("message is too long")
+	ErrUnsupportedEmoji = errors.New("unsupported emoji")
+)
+
+const (
+	defaultHistorySize      = 50
+	defaultMaxMessageLength = 500 /* runes */
+
+	// Token bucket per participant: burst of messages, then one per refill period
+	defaultRateBurst  = 5
+	defaultRateRefill = time.Second
+
+	// Rooms may be dropped by orphan cleanup without anyone telling the hub.
+	// Lobbies and votings don't live that long, so such chats are swept away.
+	defaultChatIdleTTL = time.Hour
+)
+
+type bucket struct {
+	tokens   float64
+	refilled time.Time
+}
+
+type roomChat struct {
+	roomCode   string
+	history    []wsproto.ChatMessage
+	muted      map[string]bool
+	buckets    map[string]*bucket
+	lastActive time.Time
+}
+
+// lobbyChat keeps chat state of the rooms.
+// Rooms are keyed by ID, not by code, as codes get reused once room is freed.
+type lobbyChat struct {
+	historySize      int
+	maxMessageLength int
+	rateBurst        int
+	rateRefill       time.Duration
+	idleTTL          time.Duration
+	now              func() time.Time
+
+	mu    sync.Mutex
+	rooms map[string]*roomChat
+}
+
+func newLobbyChat() *lobbyChat {
+	return &lobbyChat{
+		historySize:      defaultHistorySize,
+		maxMessageLength: defaultMaxMessageLength,
+		rateBurst:        defaultRateBurst,
+		rateRefill:       defaultRateRefill,
+		idleTTL:          defaultChatIdleTTL,
+		now:              time.Now,
+		rooms:            make(map[string]*roomChat),
+	}
+}
+
+// Post validates message and appends it to room history
+func (c *lobbyChat) Post(roomID, roomCode, userID, text string) (wsproto.ChatMessage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return wsproto.ChatMessage{}, ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > c.maxMessageLength {
+		return wsproto.ChatMessage{}, ErrMessageTooLong
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	room := c.room(roomID, roomCode)
+	if err := c.allow(room, userID); err != nil {
+		return wsproto.ChatMessage{}, err
+	}
+
+	message := wsproto.ChatMessage{
+		UserID: userID,
+		Text:   text,
+		SentAt: c.now().Unix(),
+	}
+
+	room.history = append(room.history, message)
+	if len(room.history) > c.historySize {
+		room.history = room.history[len(room.history)-c.historySize:]
+	}
+
+	return message, nil
+}
+
+// React validates emoji, emojis share rate limit with messages but aren't kept in history
+func (c *lobbyChat) React(roomID, roomCode, userID, emoji string) (wsproto.Emoji, error) {
+	if !slices.Contains(wsproto.Emojis, emoji) {
+		return wsproto.Emoji{}, ErrUnsupportedEmoji
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.allow(c.room(roomID, roomCode), userID); err != nil {
+		return wsproto.Emoji{}, err
+	}
+
+	return wsproto.Emoji{
+		UserID: userID,
+		Emoji:  emoji,
+	}, nil
+}
+
+func (c *lobbyChat) SetMuted(roomID, roomCode, userID string, muted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	room := c.room(roomID, roomCode)
+	if muted {
+		room.muted[userID] = true
+	} else {
+		delete(room.muted, userID)
+	}
+}
+
+// History returns copy of room history, oldest message first
+func (c *lobbyChat) History(roomID string) []wsproto.ChatMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	room, ok := c.rooms[roomID]
+	if !ok {
+		return nil
+	}
+
+	history := make([]wsproto.ChatMessage, len(room.history))
+	copy(history, room.history)
+	return history
+}
+
+// Drop forgets everything said in the room with given code
+func (c *lobbyChat) Drop(roomCode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, room := range c.rooms {
+		if room.roomCode == roomCode {
+			delete(c.rooms, id)
+		}
+	}
+}
+
+// Must be called under the lock
+func (c *lobbyChat) room(roomID, roomCode string) *roomChat {
+	now := c.now()
+
+	room, ok := c.rooms[roomID]
+	if !ok {
+		c.sweep(now)
+		room = &roomChat{
+			roomCode: roomCode,
+			muted:    make(map[string]bool),
+			buckets:  make(map[string]*bucket),
+		}
+		c.rooms[roomID] = room
+	}
+	room.lastActive = now
+
+	return room
+}
+
+// Must be called under the lock
+func (c *lobbyChat) allow(room *roomChat, userID string) error {
+	if room.muted[userID] {
+		return ErrMuted
+	}
+
+	now := c.now()
+	b, ok := room.buckets[userID]
+	if !ok {
+		b = &bucket{
+			tokens:   float64(c.rateBurst),
+			refilled: now,
+		}
+		room.buckets[userID] = b
+	}
+
+	b.tokens += float64(now.Sub(b.refilled)) / float64(c.rateRefill)
+	if b.tokens > float64(c.rateBurst) {
+		b.tokens = float64(c.rateBurst)
+	}
+	b.refilled = now
+
+	if b.tokens < 1 {
+		return ErrRateLimited
+	}
+	b.tokens--
+
+	return nil
+}
+
+// Must be called under the lock
+func (c *lobbyChat) sweep(now time.Time) {
+	for id, room := range c.rooms {
+		if now.Sub(room.lastActive) > c.idleTTL {
+			delete(c.rooms, id)
+		}
+	}
+}
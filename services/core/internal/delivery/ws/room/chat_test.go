@@ -0,0 +1,188 @@
+//go:build !integration
+// +build !integration
+
+package ws_room
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRoomID   = "0f4a7bb0-5d43-4a63-9a1e-2f1d1c1c7a10"
+	testRoomCode = "123456"
+	testUserID   = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+)
+
+type ChatUnitSuite struct {
+	suite.Suite
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func initChat() (*lobbyChat, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	chat := newLobbyChat()
+	chat.now = clock.Now
+	return chat, clock
+}
+
+func (suite *ChatUnitSuite) TestPost(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		text          string
+		expectedError error
+		expectedText  string
+	}{
+		{
+			name:         "Should trim and post message",
+			text:         "  let's watch something scary  ",
+			expectedText: "let's watch something scary",
+		},
+		{
+			name:          "Should reject blank message",
+			text:          "   ",
+			expectedError: ErrEmptyMessage,
+		},
+		{
+			name:          "Should reject message longer than cap",
+			text:          strings.Repeat("я", defaultMaxMessageLength+1),
+			expectedError: ErrMessageTooLong,
+		},
+		{
+			name:         "Should count length in characters, not bytes",
+			text:         strings.Repeat("я", defaultMaxMessageLength),
+			expectedText: strings.Repeat("я", defaultMaxMessageLength),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			chat, clock := initChat()
+
+			message, err := chat.Post(testRoomID, testRoomCode, testUserID, tc.text)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Empty(t, chat.History(testRoomID))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedText, message.Text)
+				assert.Equal(t, testUserID, message.UserID)
+				assert.Equal(t, clock.now.Unix(), message.SentAt)
+				assert.Equal(t, message, chat.History(testRoomID)[0])
+			}
+		})
+	}
+}
+
+func (suite *ChatUnitSuite) TestRateLimit(t provider.T) {
+	t.Parallel()
+	chat, clock := initChat()
+
+	for range defaultRateBurst {
+		_, err := chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+		assert.NoError(t, err)
+	}
+
+	_, err := chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = chat.React(testRoomID, testRoomCode, testUserID, "🍿")
+	assert.ErrorIs(t, err, ErrRateLimited, "emojis share limit with messages")
+
+	_, err = chat.Post(testRoomID, testRoomCode, "another-user", "hi")
+	assert.NoError(t, err, "limit is per participant")
+
+	clock.now = clock.now.Add(defaultRateRefill)
+	_, err = chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+	assert.NoError(t, err)
+}
+
+func (suite *ChatUnitSuite) TestHistory(t provider.T) {
+	t.Parallel()
+	chat, clock := initChat()
+
+	for i := range defaultHistorySize + 3 {
+		clock.now = clock.now.Add(defaultRateRefill)
+		_, err := chat.Post(testRoomID, testRoomCode, testUserID, strings.Repeat("x", i+1))
+		assert.NoError(t, err)
+	}
+
+	history := chat.History(testRoomID)
+	assert.Len(t, history, defaultHistorySize)
+	assert.Equal(t, strings.Repeat("x", 4), history[0].Text, "oldest messages are evicted")
+	assert.Equal(t, strings.Repeat("x", defaultHistorySize+3), history[len(history)-1].Text)
+
+	_, err := chat.React(testRoomID, testRoomCode, testUserID, "🔥")
+	assert.NoError(t, err)
+	assert.Len(t, chat.History(testRoomID), defaultHistorySize, "emojis aren't kept")
+}
+
+func (suite *ChatUnitSuite) TestEmoji(t provider.T) {
+	t.Parallel()
+	chat, _ := initChat()
+
+	emoji, err := chat.React(testRoomID, testRoomCode, testUserID, "👍")
+	assert.NoError(t, err)
+	assert.Equal(t, testUserID, emoji.UserID)
+	assert.Equal(t, "👍", emoji.Emoji)
+
+	_, err = chat.React(testRoomID, testRoomCode, testUserID, "🦄")
+	assert.ErrorIs(t, err, ErrUnsupportedEmoji)
+}
+
+func (suite *ChatUnitSuite) TestMute(t provider.T) {
+	t.Parallel()
+	chat, _ := initChat()
+
+	chat.SetMuted(testRoomID, testRoomCode, testUserID, true)
+
+	_, err := chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+	assert.ErrorIs(t, err, ErrMuted)
+	_, err = chat.React(testRoomID, testRoomCode, testUserID, "👍")
+	assert.ErrorIs(t, err, ErrMuted)
+
+	chat.SetMuted(testRoomID, testRoomCode, testUserID, false)
+
+	_, err = chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+	assert.NoError(t, err)
+}
+
+func (suite *ChatUnitSuite) TestDrop(t provider.T) {
+	t.Parallel()
+	chat, clock := initChat()
+
+	_, err := chat.Post(testRoomID, testRoomCode, testUserID, "hi")
+	assert.NoError(t, err)
+
+	chat.Drop(testRoomCode)
+	assert.Empty(t, chat.History(testRoomID))
+
+	const reusedCodeRoomID = "3b241101-e2bb-4255-8caf-4136c566a962"
+	_, err = chat.Post(testRoomID, testRoomCode, testUserID, "old room")
+	assert.NoError(t, err)
+	assert.Empty(t, chat.History(reusedCodeRoomID), "rooms reusing the code start from scratch")
+
+	clock.now = clock.now.Add(defaultChatIdleTTL + time.Minute)
+	_, err = chat.Post(reusedCodeRoomID, testRoomCode, testUserID, "new room")
+	assert.NoError(t, err)
+	assert.Empty(t, chat.History(testRoomID), "idle chats are swept")
+}
+
+func TestChatUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(ChatUnitSuite))
+}
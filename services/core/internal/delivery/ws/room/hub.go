@@ -2,63 +2,66 @@ package ws_room
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
 	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
+	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
 )
 
-const (
-	EventUserJoined       = "USER_JOINED"
-	EventLobbyUpdate      = "LOBBY_UPDATE"
-	EventStartVoting      = "START_VOTING"
-	EventRedirectToVoting = "REDIRECT_TO_VOTING"
-	EventVotingFinished   = "VOTING_FINISHED"
-	EventError            = "ERROR"
-)
-
-type Event struct {
-	Type    string      `json:"type"`
-	Payload interface{} `json:"payload"`
-}
-
 type Client struct {
 	hub      *Hub
 	conn     *websocket.Conn
-	send     chan Event
+	send     chan wsproto.Payload
 	userID   string
+	roomID   string
 	roomCode string
 	role     string
+	version  int
+
+	// Set for SSE and long-poll subscribers, which send their events over plain HTTP
+	session string
 }
 
 type roomEvent struct {
 	roomCode string
-	event    Event
+	event    wsproto.Payload
 }
 
 type Hub struct {
-	usecase    *usecase_room.Usecase
-	logger     *slog.Logger
-	clients    map[*Client]bool
-	rooms      map[string]map[*Client]bool
-	register   chan *Client
-	unregister chan *Client
-	broadcast  chan roomEvent
-	mu         sync.RWMutex
+	usecase     *usecase_room.Usecase
+	voteUsecase *usecase_vote.Usecase
+	logger      *slog.Logger
+	clients     map[*Client]bool
+	rooms       map[string]map[*Client]bool
+	sessions    map[string]*Client
+	register    chan *Client
+	unregister  chan *Client
+	broadcast   chan roomEvent
+	chat        *lobbyChat
+	cards       *servedCards
+	mu          sync.RWMutex
 }
 
-func NewHub(usecase *usecase_room.Usecase) *Hub {
+func NewHub(usecase *usecase_room.Usecase, voteUsecase *usecase_vote.Usecase) *Hub {
 	return &Hub{
-		usecase:    usecase,
-		logger:     slog.Default(),
-		clients:    make(map[*Client]bool),
-		rooms:      make(map[string]map[*Client]bool),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		broadcast:  make(chan roomEvent),
+		usecase:     usecase,
+		voteUsecase: voteUsecase,
+		logger:      slog.Default(),
+		clients:     make(map[*Client]bool),
+		rooms:       make(map[string]map[*Client]bool),
+		sessions:    make(map[string]*Client),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		broadcast:   make(chan roomEvent),
+		chat:        newLobbyChat(),
+		cards:       newServedCards(),
 	}
 }
 
@@ -86,13 +89,25 @@ func (h *Hub) handleRegister(client *Client) {
 		h.rooms[client.roomCode] = make(map[*Client]bool)
 	}
 	h.rooms[client.roomCode][client] = true
+	if client.session != "" {
+		h.sessions[client.session] = client
+	}
 
 	h.logger.Info("client registered",
 		"user_id", client.userID,
 		"room", client.roomCode,
 		"role", client.role)
 
+	// Late joiners catch up with what was said in the lobby
+	if history := h.chat.History(client.roomID); len(history) > 0 {
+		select {
+		case client.send <- wsproto.ChatHistory{Messages: history}:
+		default:
+		}
+	}
+
 	go h.broadcastParticipantsCount(client.roomCode)
+	go h.resumeState(client)
 }
 
 func (h *Hub) handleUnregister(client *Client) {
@@ -101,6 +116,7 @@ func (h *Hub) handleUnregister(client *Client) {
 
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
+		delete(h.sessions, client.session)
 		close(client.send)
 
 		if roomClients, exists := h.rooms[client.roomCode]; exists {
@@ -127,15 +143,12 @@ func (h *Hub) broadcastParticipantsCount(roomCode string) {
 		return
 	}
 
-	h.broadcastToRoom(roomCode, Event{
-		Type: EventLobbyUpdate,
-		Payload: map[string]interface{}{
-			"participants_count": count,
-		},
+	h.broadcastToRoom(roomCode, wsproto.LobbyUpdate{
+		ParticipantsCount: count,
 	})
 }
 
-func (h *Hub) broadcastToRoom(roomCode string, event Event) {
+func (h *Hub) broadcastToRoom(roomCode string, event wsproto.Payload) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
@@ -144,8 +157,11 @@ func (h *Hub) broadcastToRoom(roomCode string, event Event) {
 			select {
 			case client.send <- event:
 			default:
-				close(client.send)
-				delete(h.rooms[roomCode], client)
+				// Slow client is disconnected, registry is only modified by Run loop
+				h.logger.Error("client send buffer is full, disconnecting",
+					"user_id", client.userID,
+					"room", roomCode)
+				go func() { h.unregister <- client }()
 			}
 		}
 	}
@@ -164,32 +180,22 @@ func (h *Hub) StartVoting(roomCode string, userID string) error {
 
 	h.broadcast <- roomEvent{
 		roomCode: roomCode,
-		event: Event{
-			Type: EventRedirectToVoting,
-			Payload: map[string]interface{}{
-				"initiated_by": userID,
-				"room_code":    roomCode,
-				"redirect_url": "/rooms/" + roomCode + "/voting/",
-			},
+		event: wsproto.RedirectToVoting{
+			InitiatedBy: userID,
+			RoomCode:    roomCode,
+			RedirectURL: "/rooms/" + roomCode + "/voting/",
 		},
 	}
 
+	go h.pushNextMovies(roomCode)
+
 	return nil
 }
 
 func (h *Hub) NotifyVotingComplete(roomCode string) error {
 	h.broadcast <- roomEvent{
 		roomCode: roomCode,
-		event: Event{
-			Type: EventVotingFinished,
-			Payload: map[string]interface{}{
-				"room_code":    roomCode,
-				"message":      "All participants have voted",
-				"code":         roomCode,
-				"redirect_url": "/rooms/" + roomCode + "/results/",
-				"timestamp":    time.Now().Unix(),
-			},
-		},
+		event:    votingFinished(roomCode),
 	}
 
 	h.logger.Info("voting complete notification sent",
@@ -197,3 +203,192 @@ func (h *Hub) NotifyVotingComplete(roomCode string) error {
 
 	return nil
 }
+
+func votingFinished(roomCode string) wsproto.VotingFinished {
+	return wsproto.VotingFinished{
+		RoomCode:    roomCode,
+		Message:     "All participants have voted",
+		Code:        roomCode,
+		RedirectURL: "/rooms/" + roomCode + "/results/",
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
+// NotifyRoomFreed drops chat and cards of the room, so its code can be reused from scratch
+func (h *Hub) NotifyRoomFreed(roomCode string) {
+	h.chat.Drop(roomCode)
+	h.cards.Drop(roomCode)
+
+	h.logger.Info("room chat dropped",
+		"room", roomCode)
+}
+
+func (h *Hub) PostMessage(client *Client, text string) error {
+	message, err := h.chat.Post(client.roomID, client.roomCode, client.userID, text)
+	if err != nil {
+		return err
+	}
+
+	h.broadcast <- roomEvent{
+		roomCode: client.roomCode,
+		event:    message,
+	}
+	return nil
+}
+
+func (h *Hub) PostEmoji(client *Client, emoji string) error {
+	event, err := h.chat.React(client.roomID, client.roomCode, client.userID, emoji)
+	if err != nil {
+		return err
+	}
+
+	h.broadcast <- roomEvent{
+		roomCode: client.roomCode,
+		event:    event,
+	}
+	return nil
+}
+
+func (h *Hub) SetMuted(client *Client, userID string, muted bool) error {
+	isParticipant, err := h.usecase.IsParticipant(context.Background(), client.roomCode, userID)
+	if err != nil {
+		return err
+	}
+	if !isParticipant {
+		return usecase_room.ErrResourceNotFound
+	}
+
+	h.chat.SetMuted(client.roomID, client.roomCode, userID, muted)
+
+	h.broadcast <- roomEvent{
+		roomCode: client.roomCode,
+		event: wsproto.Mute{
+			UserID: userID,
+			Muted:  muted,
+		},
+	}
+
+	h.logger.Info("participant mute changed",
+		"room", client.roomCode,
+		"user_id", userID,
+		"muted", muted)
+
+	return nil
+}
+
+// Reconnected clients continue swiping from where they stopped or get to results they missed
+func (h *Hub) resumeState(client *Client) {
+	status, err := h.usecase.Status(context.Background(), client.roomCode)
+	if err != nil {
+		h.logger.Error("failed to get room status", "error", err, "room", client.roomCode)
+		return
+	}
+
+	switch status {
+	case model.StatusVoting:
+		h.pushNextMovie(client)
+	case model.StatusFinished:
+		h.sendToClient(client, votingFinished(client.roomCode))
+	}
+}
+
+func (h *Hub) pushNextMovies(roomCode string) {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.rooms[roomCode]))
+	for client := range h.rooms[roomCode] {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.pushNextMovie(client)
+	}
+}
+
+func (h *Hub) pushNextMovie(client *Client) {
+	userID, err := uuid.Parse(client.userID)
+	if err != nil {
+		h.sendToClient(client, errorEvent("Invalid user token"))
+		return
+	}
+
+	movie, err := h.voteUsecase.NextMovie(context.Background(), client.roomCode, userID)
+	if err != nil {
+		if errors.Is(err, usecase_vote.ErrDeckExhausted) {
+			h.cards.Finish(client.roomCode, client.userID)
+			h.sendToClient(client, wsproto.DeckFinished{
+				RoomCode: client.roomCode,
+			})
+			h.checkVotingComplete(client.roomCode)
+			return
+		}
+
+		h.logger.Error("failed to get next movie",
+			"error", err,
+			"user_id", client.userID,
+			"room", client.roomCode)
+		h.sendToClient(client, errorEvent("Failed to get next movie"))
+		return
+	}
+
+	h.cards.Serve(client.roomCode, client.userID, movie.ID)
+	h.sendToClient(client, wsproto.NextMovie{
+		Movie: toProtoMovie(movie),
+	})
+}
+
+func (h *Hub) checkVotingComplete(roomCode string) {
+	ready, err := h.voteUsecase.IsAllReady(context.Background(), roomCode)
+	if err != nil {
+		h.logger.Error("failed to check readiness", "error", err, "room", roomCode)
+		return
+	}
+
+	if ready {
+		_ = h.NotifyVotingComplete(roomCode)
+	}
+}
+
+func (h *Hub) clientBySession(session string) (*Client, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	client, ok := h.sessions[session]
+	return client, ok
+}
+
+// Client might be unregistered concurrently, so delivery is done under the lock
+func (h *Hub) sendToClient(client *Client, event wsproto.Payload) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+
+	select {
+	case client.send <- event:
+	default:
+		h.logger.Error("client send buffer is full",
+			"user_id", client.userID,
+			"room", client.roomCode)
+	}
+}
+
+func errorEvent(message string) wsproto.Error {
+	return wsproto.Error{
+		Message: message,
+	}
+}
+
+func toProtoMovie(mm *model.MovieMeta) wsproto.Movie {
+	return wsproto.Movie{
+		ID:         mm.ID,
+		Title:      mm.Title,
+		Year:       mm.Year,
+		Rating:     mm.Rating,
+		Genres:     mm.Genres,
+		Overview:   mm.Overview,
+		PosterLink: mm.PosterLink,
+	}
+}
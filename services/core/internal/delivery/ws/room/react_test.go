@@ -0,0 +1,139 @@
+//go:build !integration
+// +build !integration
+
+package ws_room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	mocks_repo "github.com/humanbelnik/kinoswap/core/internal/usecase/vote/mocks/vote/repository"
+	mocks_room "github.com/humanbelnik/kinoswap/core/internal/usecase/vote/mocks/vote/roomuc"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReactUnitSuite struct {
+	suite.Suite
+}
+
+type reactResources struct {
+	repo   *mocks_repo.VoteRepository
+	client *Client
+	userID uuid.UUID
+	roomID uuid.UUID
+}
+
+// Registered client of a voting room, the hub loop isn't running
+func initReact(t provider.T) *reactResources {
+	repo := mocks_repo.NewVoteRepository(t)
+	roomUC := mocks_room.NewRoomUUIDer(t)
+	roomID := uuid.MustParse(testRoomID)
+	roomUC.On("UUIDByCode", mock.Anything, testRoomCode).Return(roomID, nil).Maybe()
+
+	hub := NewHub(nil, usecase_vote.New(repo, roomUC))
+	client := &Client{
+		hub:      hub,
+		send:     make(chan wsproto.Payload, 16),
+		userID:   testUserID,
+		roomCode: testRoomCode,
+	}
+	hub.clients[client] = true
+
+	return &reactResources{repo: repo, client: client, userID: uuid.MustParse(testUserID), roomID: roomID}
+}
+
+// expectNext makes movie the next card, reactions count keeps it off watchlist turn
+func (r *reactResources) expectNext(movie *model.MovieMeta) {
+	r.repo.On("RoomMode", mock.Anything, r.roomID).Return(model.ModeRecommend, nil).Once()
+	r.repo.On("ReactionsCount", mock.Anything, r.roomID, r.userID).Return(1, nil).Once()
+	r.repo.On("ParticipantsEmbeddings", mock.Anything, r.roomID).Return([]model.Embedding{{1, 0}}, nil).Once()
+	r.repo.On("NextMovie", mock.Anything, r.roomID, r.userID, mock.Anything).Return(movie, nil).Once()
+}
+
+func (r *reactResources) events() []wsproto.Payload {
+	var events []wsproto.Payload
+	for {
+		select {
+		case e := <-r.client.send:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func (suite *ReactUnitSuite) TestServedCard(t provider.T) {
+	t.Parallel()
+
+	first := &model.MovieMeta{ID: uuid.New(), Title: "Heat"}
+	second := &model.MovieMeta{ID: uuid.New(), Title: "Alien"}
+
+	r := initReact(t)
+
+	r.client.handleReact(&wsproto.React{MovieID: first.ID, Reaction: model.SmashReaction})
+	assert.Equal(t, []wsproto.Payload{errorEvent("Movie isn't the card served to you")}, r.events(),
+		"nothing is served before voting")
+
+	r.expectNext(first)
+	r.client.hub.pushNextMovie(r.client)
+	assert.Equal(t, []wsproto.Payload{wsproto.NextMovie{Movie: toProtoMovie(first)}}, r.events())
+
+	r.client.handleReact(&wsproto.React{MovieID: second.ID, Reaction: model.SmashReaction})
+	assert.Equal(t, []wsproto.Payload{errorEvent("Movie isn't the card served to you")}, r.events(),
+		"cards can't be skipped")
+
+	r.repo.On("AddReaction", mock.Anything, r.roomID, r.userID, first.ID, model.SmashReaction).Return(nil).Once()
+	r.expectNext(second)
+	r.client.handleReact(&wsproto.React{MovieID: first.ID, Reaction: model.SmashReaction})
+	assert.Equal(t, []wsproto.Payload{
+		wsproto.ReactAck{MovieID: first.ID, Reaction: model.SmashReaction},
+		wsproto.NextMovie{Movie: toProtoMovie(second)},
+	}, r.events())
+
+	r.expectNext(second)
+	r.client.handleReact(&wsproto.React{MovieID: first.ID, Reaction: model.SmashReaction})
+	assert.Equal(t, []wsproto.Payload{
+		wsproto.ReactAck{MovieID: first.ID, Reaction: model.SmashReaction},
+		wsproto.NextMovie{Movie: toProtoMovie(second)},
+	}, r.events(), "resent swipe is acknowledged again")
+
+	r.repo.On("AddReaction", mock.Anything, r.roomID, r.userID, second.ID, model.PassReaction).Return(assert.AnError).Once()
+	r.client.handleReact(&wsproto.React{MovieID: second.ID, Reaction: model.PassReaction})
+	assert.Equal(t, []wsproto.Payload{errorEvent("Failed to save reaction")}, r.events(),
+		"no next card after failed reaction")
+}
+
+func (suite *ReactUnitSuite) TestIdleCardsSwept(t provider.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cards := newServedCards()
+	cards.now = clock.Now
+
+	abandoned, active := uuid.New(), uuid.New()
+	cards.Serve(testRoomCode, testUserID, abandoned)
+	assert.Equal(t, cardPending, cards.State(testRoomCode, testUserID, abandoned))
+
+	clock.now = clock.now.Add(defaultCardIdleTTL / 2)
+	const otherRoomCode = "654321"
+	cards.Serve(otherRoomCode, testUserID, active)
+
+	clock.now = clock.now.Add(defaultCardIdleTTL/2 + time.Minute)
+	cards.Ack(otherRoomCode, testUserID, active)
+	cards.Serve(otherRoomCode, "another user", uuid.New())
+
+	assert.Equal(t, cardUnknown, cards.State(testRoomCode, testUserID, abandoned), "idle cards are swept")
+	assert.Equal(t, cardAcked, cards.State(otherRoomCode, testUserID, active), "touched cards are kept")
+	assert.Len(t, cards.cards, 2)
+}
+
+func TestReactUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(ReactUnitSuite))
+}
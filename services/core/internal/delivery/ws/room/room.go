@@ -1,10 +1,17 @@
 package ws_room
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
+	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
 )
 
 var upgrader = websocket.Upgrader{
@@ -14,12 +21,14 @@ var upgrader = websocket.Upgrader{
 }
 
 type Controller struct {
-	hub *Hub
+	hub   *Hub
+	polls *pollSessions
 }
 
 func NewController(hub *Hub) *Controller {
 	return &Controller{
-		hub: hub,
+		hub:   hub,
+		polls: newPollSessions(),
 	}
 }
 
@@ -28,6 +37,14 @@ func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		ws.GET("/rooms/:room_id", c.connect)
 	}
+
+	// Same events for clients behind proxies stripping WebSocket upgrades
+	events := router.Group("/rooms/:room_id/events")
+	{
+		events.GET("", c.stream)
+		events.GET("/poll", c.poll)
+		events.POST("", c.publish)
+	}
 }
 
 type ConnectRequest struct {
@@ -43,33 +60,34 @@ func (c *Controller) connect(ctx *gin.Context) {
 		return
 	}
 
-	status, err := c.hub.usecase.Status(ctx, roomCode)
+	client, err := c.newClient(ctx, roomCode, userToken)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
 		return
 	}
 
-	isOwner, _ := c.hub.usecase.IsOwner(ctx, roomCode, userToken)
-	role := "participant"
-	if isOwner {
-		role = "owner"
+	version, subprotocol, err := wsproto.Negotiate(websocket.Subprotocols(ctx.Request))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":     "unsupported protocol version",
+			"supported": wsproto.Subprotocols(),
+		})
+		return
 	}
 
-	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	var responseHeader http.Header
+	if subprotocol != "" {
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": {subprotocol}}
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, responseHeader)
 	if err != nil {
 		c.hub.logger.Error("failed to upgrade connection", "error", err)
 		return
 	}
 
-	client := &Client{
-		hub:      c.hub,
-		conn:     conn,
-		send:     make(chan Event, 256),
-		userID:   userToken,
-		roomCode: roomCode,
-		role:     role,
-	}
-
+	client.conn = conn
+	client.greet(version)
 	c.hub.register <- client
 
 	go client.writePump()
@@ -78,8 +96,52 @@ func (c *Controller) connect(ctx *gin.Context) {
 	c.hub.logger.Info("WebSocket connection established",
 		"user_id", userToken,
 		"room", roomCode,
-		"role", role,
-		"status", status)
+		"role", client.role,
+		"version", version)
+}
+
+// newClient resolves room and role of the user, transport is attached by the caller
+func (c *Controller) newClient(ctx context.Context, roomCode, userToken string) (*Client, error) {
+	if _, err := c.hub.usecase.Status(ctx, roomCode); err != nil {
+		return nil, err
+	}
+
+	roomID, err := c.hub.usecase.UUIDByCode(ctx, roomCode)
+	if err != nil {
+		return nil, err
+	}
+
+	isOwner, _ := c.hub.usecase.IsOwner(ctx, roomCode, userToken)
+	role := "participant"
+	if isOwner {
+		role = "owner"
+	}
+
+	return &Client{
+		hub:      c.hub,
+		send:     make(chan wsproto.Payload, 256),
+		userID:   userToken,
+		roomID:   roomID.String(),
+		roomCode: roomCode,
+		role:     role,
+	}, nil
+}
+
+// Greeting goes first so client knows negotiated version before any other event
+func (c *Client) greet(version int) {
+	c.version = version
+	c.send <- wsproto.Hello{
+		Version:  version,
+		RoomCode: c.roomCode,
+		UserID:   c.userID,
+		Role:     c.role,
+		Session:  c.session,
+	}
+}
+
+// reply is safe to call from any goroutine, client may be unregistered concurrently
+func (c *Client) reply(event wsproto.Payload) {
+	c.hub.sendToClient(c, event)
 }
 
 func (c *Client) readPump() {
@@ -89,8 +151,8 @@ func (c *Client) readPump() {
 	}()
 
 	for {
-		var event Event
-		err := c.conn.ReadJSON(&event)
+		var envelope wsproto.Envelope
+		err := c.conn.ReadJSON(&envelope)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				c.hub.logger.Error("WebSocket read error", "error", err)
@@ -98,6 +160,16 @@ func (c *Client) readPump() {
 			break
 		}
 
+		event, err := envelope.Decode()
+		if err != nil {
+			if errors.Is(err, wsproto.ErrUnknownEvent) {
+				c.reply(errorEvent("Unknown event type: " + string(envelope.Type)))
+			} else {
+				c.reply(errorEvent("Invalid " + string(envelope.Type) + " payload"))
+			}
+			continue
+		}
+
 		c.handleEvent(event)
 	}
 }
@@ -106,7 +178,13 @@ func (c *Client) writePump() {
 	defer c.conn.Close()
 
 	for event := range c.send {
-		if err := c.conn.WriteJSON(event); err != nil {
+		envelope, err := wsproto.Encode(event)
+		if err != nil {
+			c.hub.logger.Error("failed to encode event", "error", err, "type", event.EventType())
+			continue
+		}
+
+		if err := c.conn.WriteJSON(envelope); err != nil {
 			c.hub.logger.Error("WebSocket write error", "error", err)
 			return
 		}
@@ -115,27 +193,17 @@ func (c *Client) writePump() {
 	_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 }
 
-func (c *Client) handleEvent(event Event) {
-	switch event.Type {
-	case "START_VOTING":
+func (c *Client) handleEvent(event wsproto.Payload) {
+	switch e := event.(type) {
+	case *wsproto.StartVoting:
 		if c.role != "owner" {
-			c.send <- Event{
-				Type: EventError,
-				Payload: map[string]interface{}{
-					"message": "Only room owner can start voting",
-				},
-			}
+			c.reply(errorEvent("Only room owner can start voting"))
 			return
 		}
 
 		err := c.hub.StartVoting(c.roomCode, c.userID)
 		if err != nil {
-			c.send <- Event{
-				Type: EventError,
-				Payload: map[string]interface{}{
-					"message": "Failed to start voting: " + err.Error(),
-				},
-			}
+			c.reply(errorEvent("Failed to start voting: " + err.Error()))
 			return
 		}
 
@@ -143,12 +211,118 @@ func (c *Client) handleEvent(event Event) {
 			"room", c.roomCode,
 			"initiated_by", c.userID)
 
+	case *wsproto.React:
+		c.handleReact(e)
+
+	case *wsproto.ChatMessage:
+		if err := c.hub.PostMessage(c, e.Text); err != nil {
+			c.reply(chatErrorEvent(err))
+		}
+
+	case *wsproto.Emoji:
+		if err := c.hub.PostEmoji(c, e.Emoji); err != nil {
+			c.reply(chatErrorEvent(err))
+		}
+
+	case *wsproto.Mute:
+		c.handleMute(e)
+
 	default:
-		c.send <- Event{
-			Type: EventError,
-			Payload: map[string]interface{}{
-				"message": "Unknown event type: " + event.Type,
-			},
+		c.reply(errorEvent("Unexpected event type: " + string(event.EventType())))
+	}
+}
+
+// handleReact accepts reaction only on the card served to participant, the next card is pushed once it's saved
+func (c *Client) handleReact(react *wsproto.React) {
+	if react.MovieID == uuid.Nil {
+		c.reply(errorEvent("Invalid REACT payload"))
+		return
+	}
+
+	userID, err := uuid.Parse(c.userID)
+	if err != nil {
+		c.reply(errorEvent("Invalid user token"))
+		return
+	}
+
+	ack := wsproto.ReactAck{
+		MovieID:  react.MovieID,
+		Reaction: react.Reaction,
+	}
+
+	switch c.hub.cards.State(c.roomCode, c.userID, react.MovieID) {
+	case cardPending:
+	case cardAcked:
+		// Resent swipe, its next card might be lost as well
+		c.reply(ack)
+		c.hub.pushNextMovie(c)
+		return
+	default:
+		c.reply(errorEvent("Movie isn't the card served to you"))
+		return
+	}
+
+	err = c.hub.voteUsecase.React(context.Background(), c.roomCode, userID, react.MovieID, react.Reaction)
+	if err != nil {
+		c.hub.logger.Error("failed to react",
+			"error", err,
+			"user_id", c.userID,
+			"room", c.roomCode,
+			"movie_id", react.MovieID)
+
+		switch {
+		case errors.Is(err, usecase_vote.ErrInvalidReaction):
+			c.reply(errorEvent("Reaction must be 0 or 1"))
+		case errors.Is(err, usecase_vote.ErrResourceNotFound):
+			c.reply(errorEvent("Room, participant or movie not found"))
+		default:
+			c.reply(errorEvent("Failed to save reaction"))
+		}
+		return
+	}
+
+	c.hub.cards.Ack(c.roomCode, c.userID, react.MovieID)
+	c.reply(ack)
+	c.hub.pushNextMovie(c)
+}
+
+func (c *Client) handleMute(mute *wsproto.Mute) {
+	if c.role != "owner" {
+		c.reply(errorEvent("Only room owner can mute participants"))
+		return
+	}
+	if mute.UserID == c.userID {
+		c.reply(errorEvent("Room owner can't mute themselves"))
+		return
+	}
+
+	err := c.hub.SetMuted(c, mute.UserID, mute.Muted)
+	if err != nil {
+		if errors.Is(err, usecase_room.ErrResourceNotFound) {
+			c.reply(errorEvent("Participant not found"))
+			return
 		}
+
+		c.hub.logger.Error("failed to mute participant",
+			"error", err,
+			"user_id", mute.UserID,
+			"room", c.roomCode)
+		c.reply(errorEvent("Failed to mute participant"))
+	}
+}
+
+func chatErrorEvent(err error) wsproto.Error {
+	switch {
+	case errors.Is(err, ErrMuted):
+		return errorEvent("You are muted by room owner")
+	case errors.Is(err, ErrRateLimited):
+		return errorEvent("Too many messages, slow down")
+	case errors.Is(err, ErrEmptyMessage):
+		return errorEvent("Message is empty")
+	case errors.Is(err, ErrMessageTooLong):
+		return errorEvent(fmt.Sprintf("Message is longer than %d characters", defaultMaxMessageLength))
+	case errors.Is(err, ErrUnsupportedEmoji):
+		return errorEvent("Unsupported emoji")
 	}
+	return errorEvent("Failed to send message")
 }
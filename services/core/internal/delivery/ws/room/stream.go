@@ -0,0 +1,394 @@
+package ws_room
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+)
+
+const (
+	sseHeartbeat = 15 * time.Second
+
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 30 * time.Second
+
+	// Session is dropped once client stops polling
+	pollSessionTTL = time.Minute
+
+	// Events client hasn't acknowledged yet, oldest are dropped beyond that
+	pollBufferSize = 512
+)
+
+// Fallback transports share hub with WebSocket: subscriber is a Client without connection
+func (c *Controller) subscriber(ctx *gin.Context) (*Client, bool) {
+	roomCode := ctx.Param("room_id")
+
+	userToken := requestToken(ctx)
+	if userToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "token query parameter required"})
+		return nil, false
+	}
+
+	version, _, err := wsproto.Negotiate(ctx.QueryArray("protocol"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":     "unsupported protocol version",
+			"supported": wsproto.Subprotocols(),
+		})
+		return nil, false
+	}
+
+	client, err := c.newClient(ctx, roomCode, userToken)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return nil, false
+	}
+
+	client.session = uuid.NewString()
+	client.greet(version)
+
+	return client, true
+}
+
+// EventSource can't set headers, so token is accepted in query as for WebSocket
+func requestToken(ctx *gin.Context) string {
+	if token := ctx.Query("token"); token != "" {
+		return token
+	}
+	return ctx.GetHeader("X-user-token")
+}
+
+// lastEventID is sent by EventSource on reconnect, query is for clients opening a new EventSource
+func lastEventID(ctx *gin.Context) (int64, error) {
+	raw := ctx.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = ctx.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid last event id")
+	}
+	return id, nil
+}
+
+// Reconnected stream continues numbering after Last-Event-ID, so ids only grow for the client.
+// Missed events aren't kept: hub replays current room state to every new subscription.
+func (c *Controller) stream(ctx *gin.Context) {
+	seq, err := lastEventID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be non-negative integer"})
+		return
+	}
+
+	client, ok := c.subscriber(ctx)
+	if !ok {
+		return
+	}
+
+	c.hub.register <- client
+	defer func() {
+		c.hub.unregister <- client
+	}()
+
+	c.hub.logger.Info("SSE subscription established",
+		"user_id", client.userID,
+		"room", client.roomCode,
+		"role", client.role,
+		"version", client.version)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// Disables response buffering in nginx
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-client.send:
+			if !ok {
+				return
+			}
+
+			envelope, err := wsproto.Encode(event)
+			if err != nil {
+				c.hub.logger.Error("failed to encode event", "error", err, "type", event.EventType())
+				continue
+			}
+			data, err := json.Marshal(envelope)
+			if err != nil {
+				c.hub.logger.Error("failed to encode event", "error", err, "type", event.EventType())
+				continue
+			}
+
+			seq++
+			if _, err := fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", seq, envelope.Type, data); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+
+		case <-ctx.Request.Context().Done():
+			return
+		}
+	}
+}
+
+type pollSession struct {
+	client *Client
+
+	mu       sync.Mutex
+	events   []wsproto.SequencedEnvelope
+	seq      int64
+	lastSeen time.Time
+	// Closed and replaced on every new event to wake up waiting polls
+	notify chan struct{}
+	closed bool
+}
+
+type pollSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*pollSession
+}
+
+func newPollSessions() *pollSessions {
+	return &pollSessions{
+		sessions: make(map[string]*pollSession),
+	}
+}
+
+func (p *pollSessions) get(id string) (*pollSession, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[id]
+	return s, ok
+}
+
+// poll returns events client hasn't acknowledged, waiting for new ones up to timeout
+func (c *Controller) poll(ctx *gin.Context) {
+	session, ok := c.pollSession(ctx)
+	if !ok {
+		return
+	}
+
+	var after int64
+	if raw := ctx.Query("after"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "after must be non-negative integer"})
+			return
+		}
+		after = parsed
+	}
+
+	timeout := defaultPollTimeout
+	if raw := ctx.Query("timeout"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "timeout must be non-negative integer"})
+			return
+		}
+		timeout = min(time.Duration(seconds)*time.Second, maxPollTimeout)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+
+	events, err := session.wait(waitCtx, after)
+	if err != nil {
+		ctx.JSON(http.StatusGone, gin.H{"error": "subscription is closed"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wsproto.PollResponse{
+		Session: session.client.session,
+		Events:  events,
+	})
+}
+
+// pollSession resumes subscription by session or starts a new one
+func (c *Controller) pollSession(ctx *gin.Context) (*pollSession, bool) {
+	if id := ctx.Query("session"); id != "" {
+		session, ok := c.polls.get(id)
+		if !ok || session.client.userID != requestToken(ctx) || session.client.roomCode != ctx.Param("room_id") {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+			return nil, false
+		}
+		return session, true
+	}
+
+	client, ok := c.subscriber(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	session := &pollSession{
+		client:   client,
+		lastSeen: time.Now(),
+		notify:   make(chan struct{}),
+	}
+
+	c.polls.mu.Lock()
+	c.polls.sessions[client.session] = session
+	c.polls.mu.Unlock()
+
+	c.hub.register <- client
+	go c.pumpPollSession(session)
+
+	c.hub.logger.Info("long-poll subscription established",
+		"user_id", client.userID,
+		"room", client.roomCode,
+		"role", client.role,
+		"version", client.version)
+
+	return session, true
+}
+
+// Buffers hub events of the session until client polls them or goes away
+func (c *Controller) pumpPollSession(s *pollSession) {
+	expiry := time.NewTicker(pollSessionTTL / 2)
+	defer func() {
+		expiry.Stop()
+
+		c.polls.mu.Lock()
+		delete(c.polls.sessions, s.client.session)
+		c.polls.mu.Unlock()
+
+		s.mu.Lock()
+		s.closed = true
+		close(s.notify)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case event, ok := <-s.client.send:
+			if !ok {
+				return
+			}
+
+			envelope, err := wsproto.Encode(event)
+			if err != nil {
+				c.hub.logger.Error("failed to encode event", "error", err, "type", event.EventType())
+				continue
+			}
+			s.push(envelope)
+
+		case <-expiry.C:
+			s.mu.Lock()
+			expired := time.Since(s.lastSeen) > pollSessionTTL
+			s.mu.Unlock()
+
+			if expired {
+				c.hub.logger.Info("long-poll subscription expired",
+					"user_id", s.client.userID,
+					"room", s.client.roomCode)
+				c.hub.unregister <- s.client
+				// Hub closes send channel, remaining events are drained above
+			}
+		}
+	}
+}
+
+func (s *pollSession) push(envelope wsproto.Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	s.events = append(s.events, wsproto.SequencedEnvelope{
+		ID:       s.seq,
+		Envelope: envelope,
+	})
+	if len(s.events) > pollBufferSize {
+		s.events = s.events[len(s.events)-pollBufferSize:]
+	}
+
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+var errSessionClosed = errors.New("session is closed")
+
+func (s *pollSession) wait(ctx context.Context, after int64) ([]wsproto.SequencedEnvelope, error) {
+	for {
+		s.mu.Lock()
+		s.lastSeen = time.Now()
+
+		// Everything up to after is acknowledged by client
+		for len(s.events) > 0 && s.events[0].ID <= after {
+			s.events = s.events[1:]
+		}
+
+		if len(s.events) > 0 {
+			events := make([]wsproto.SequencedEnvelope, len(s.events))
+			copy(events, s.events)
+			s.mu.Unlock()
+			return events, nil
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return nil, errSessionClosed
+		}
+
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return []wsproto.SequencedEnvelope{}, nil
+		}
+	}
+}
+
+// publish accepts client events from SSE and long-poll subscribers.
+// Replies are delivered through the subscription, not in response.
+func (c *Controller) publish(ctx *gin.Context) {
+	client, ok := c.hub.clientBySession(ctx.Query("session"))
+	if !ok || client.userID != requestToken(ctx) || client.roomCode != ctx.Param("room_id") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	var envelope wsproto.Envelope
+	if err := ctx.ShouldBindJSON(&envelope); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request format"})
+		return
+	}
+
+	event, err := envelope.Decode()
+	if err != nil {
+		if errors.Is(err, wsproto.ErrUnknownEvent) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + string(envelope.Type)})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + string(envelope.Type) + " payload"})
+		}
+		return
+	}
+
+	client.handleEvent(event)
+	ctx.Status(http.StatusAccepted)
+}
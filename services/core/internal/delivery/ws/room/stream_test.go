@@ -0,0 +1,279 @@
+//go:build !integration
+// +build !integration
+
+package ws_room
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
+	embedder_mocks "github.com/humanbelnik/kinoswap/core/internal/usecase/room/mocks/room/Embedder"
+	repo_mocks "github.com/humanbelnik/kinoswap/core/internal/usecase/room/mocks/room/repository"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsclient"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type StreamUnitSuite struct {
+	suite.Suite
+}
+
+// Serves room events of a single room in given status with the owner in it
+func initServer(t provider.T, status model.RoomStatus) (*httptest.Server, string) {
+	ownerID := uuid.New()
+
+	roomRepo := repo_mocks.NewRoomRepository(t)
+	roomRepo.On("StatusByCode", mock.Anything, testRoomCode).Return(status, nil).Maybe()
+	roomRepo.On("UUIDByCode", mock.Anything, testRoomCode).Return(uuid.MustParse(testRoomID), nil).Maybe()
+	roomRepo.On("IsOwner", mock.Anything, testRoomCode, ownerID).Return(true, nil).Maybe()
+	roomRepo.On("ParticipantsCount", mock.Anything, testRoomCode).Return(1, nil).Maybe()
+
+	hub := NewHub(usecase_room.New(roomRepo, embedder_mocks.NewEmbedder(t), 1), nil)
+	go hub.Run()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewController(hub).RegisterRoutes(router.Group("/api/v1"))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server, ownerID.String()
+}
+
+func awaitEvent[T any](t provider.T, client *wsclient.Client) *T {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-client.Events():
+			if !ok {
+				t.Fatalf("stream closed: %v", client.Err())
+			}
+			if e, ok := any(event).(*T); ok {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("no %T received", new(T))
+		}
+	}
+}
+
+func (suite *StreamUnitSuite) TestFallbackTransports(t provider.T) {
+	t.Parallel()
+
+	transports := []wsclient.Transport{
+		wsclient.TransportWebSocket,
+		wsclient.TransportSSE,
+		wsclient.TransportLongPoll,
+	}
+
+	for _, transport := range transports {
+		t.Run("Should deliver room events over "+string(transport), func(t provider.T) {
+			t.Parallel()
+			server, ownerToken := initServer(t, model.StatusLobby)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			client, err := wsclient.Dial(ctx, server.URL+"/api/v1", testRoomCode, ownerToken,
+				wsclient.WithTransport(transport))
+			t.Require().NoError(err)
+			defer client.Close()
+
+			hello := client.Hello()
+			assert.Equal(t, wsproto.Version, hello.Version)
+			assert.Equal(t, "owner", hello.Role)
+			if transport == wsclient.TransportWebSocket {
+				assert.Empty(t, hello.Session)
+			} else {
+				assert.NotEmpty(t, hello.Session)
+			}
+
+			lobby := awaitEvent[wsproto.LobbyUpdate](t, client)
+			assert.Equal(t, 1, lobby.ParticipantsCount)
+
+			t.Require().NoError(client.SendMessage("popcorn is ready"))
+			message := awaitEvent[wsproto.ChatMessage](t, client)
+			assert.Equal(t, "popcorn is ready", message.Text)
+			assert.Equal(t, ownerToken, message.UserID)
+
+			t.Require().NoError(client.SendEmoji("🦄"))
+			failure := awaitEvent[wsproto.Error](t, client)
+			assert.Equal(t, "Unsupported emoji", failure.Message)
+		})
+	}
+}
+
+type sseEvent struct {
+	id        string
+	eventType string
+}
+
+// Reads events until one of wanted type, returns all read so far
+func readSSEUntil(t provider.T, reader *bufio.Reader, want wsproto.EventType) []sseEvent {
+	var (
+		events  []sseEvent
+		current sseEvent
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		t.Require().NoError(err)
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case line == "" && current.eventType != "":
+			events = append(events, current)
+			if current.eventType == string(want) {
+				return events
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.eventType = strings.TrimPrefix(line, "event: ")
+		}
+	}
+}
+
+func (suite *StreamUnitSuite) TestSSEReconnect(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		status     model.RoomStatus
+		header     string
+		query      string
+		wantCode   int
+		wantEvents []sseEvent
+	}{
+		{
+			name:     "Should number events from one on first connect",
+			status:   model.StatusLobby,
+			wantCode: http.StatusOK,
+			wantEvents: []sseEvent{
+				{id: "1", eventType: string(wsproto.EventHello)},
+				{id: "2", eventType: string(wsproto.EventLobbyUpdate)},
+			},
+		},
+		{
+			name:     "Should continue numbering after Last-Event-ID",
+			status:   model.StatusLobby,
+			header:   "41",
+			wantCode: http.StatusOK,
+			wantEvents: []sseEvent{
+				{id: "42", eventType: string(wsproto.EventHello)},
+				{id: "43", eventType: string(wsproto.EventLobbyUpdate)},
+			},
+		},
+		{
+			name:     "Should accept last event id in query",
+			status:   model.StatusLobby,
+			query:    "7",
+			wantCode: http.StatusOK,
+			wantEvents: []sseEvent{
+				{id: "8", eventType: string(wsproto.EventHello)},
+				{id: "9", eventType: string(wsproto.EventLobbyUpdate)},
+			},
+		},
+		{
+			name:     "Should replay finished voting on reconnect",
+			status:   model.StatusFinished,
+			header:   "41",
+			wantCode: http.StatusOK,
+			wantEvents: []sseEvent{
+				{id: "42", eventType: string(wsproto.EventHello)},
+				{eventType: string(wsproto.EventVotingFinished)},
+			},
+		},
+		{
+			name:     "Should reject invalid Last-Event-ID",
+			status:   model.StatusLobby,
+			header:   "-1",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			server, ownerToken := initServer(t, tc.status)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			query := url.Values{"token": {ownerToken}}
+			if tc.query != "" {
+				query.Set("last_event_id", tc.query)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				server.URL+"/api/v1/rooms/"+testRoomCode+"/events?"+query.Encode(), nil)
+			t.Require().NoError(err)
+			if tc.header != "" {
+				req.Header.Set("Last-Event-ID", tc.header)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			t.Require().NoError(err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.wantCode, resp.StatusCode)
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			last := tc.wantEvents[len(tc.wantEvents)-1]
+			events := readSSEUntil(t, bufio.NewReader(resp.Body), wsproto.EventType(last.eventType))
+			assert.Equal(t, tc.wantEvents[0], events[0], "greeting goes first")
+			if last.id != "" {
+				assert.Equal(t, last, events[len(events)-1])
+			}
+			for i := 1; i < len(events); i++ {
+				prev, _ := strconv.Atoi(events[i-1].id)
+				next, _ := strconv.Atoi(events[i].id)
+				assert.Equal(t, prev+1, next, "ids grow by one")
+			}
+		})
+	}
+}
+
+func (suite *StreamUnitSuite) TestPollSession(t provider.T) {
+	t.Parallel()
+
+	session := &pollSession{notify: make(chan struct{})}
+	for range pollBufferSize + 2 {
+		session.push(wsproto.Envelope{Type: wsproto.EventLobbyUpdate})
+	}
+
+	events, err := session.wait(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, events, pollBufferSize)
+	assert.Equal(t, int64(3), events[0].ID, "oldest unacknowledged events are dropped")
+
+	events, err = session.wait(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), events[0].ID, "events resent until acknowledged")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	events, err = session.wait(ctx, pollBufferSize+2)
+	assert.NoError(t, err)
+	assert.Empty(t, events, "poll times out with no events")
+}
+
+func TestStreamUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(StreamUnitSuite))
+}
@@ -0,0 +1,146 @@
+package infra_embedder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/gen/proto"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var ErrClosed = errors.New("embedder is closed")
+
+// movieBatcher coalesces concurrent movie embedding calls into BatchCreateMovieEmbeddings.
+// The first call of a batch waits for window at most, full batch is sent right away.
+// Send returns embedding of every movie in order, see batchCreate.
+type movieBatcher struct {
+	send    func(ctx context.Context, movies []*proto.MovieEmbeddingRequest) ([]model.Embedding, error)
+	window  time.Duration
+	maxSize int
+
+	pending   chan pendingMovie
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type pendingMovie struct {
+	ctx    context.Context
+	req    *proto.MovieEmbeddingRequest
+	result chan batchResult
+}
+
+type batchResult struct {
+	embedding model.Embedding
+	err       error
+}
+
+func newMovieBatcher(
+	send func(ctx context.Context, movies []*proto.MovieEmbeddingRequest) ([]model.Embedding, error),
+	window time.Duration,
+	maxSize int,
+) *movieBatcher {
+	b := &movieBatcher{
+		send:    send,
+		window:  window,
+		maxSize: max(maxSize, 1),
+		pending: make(chan pendingMovie),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *movieBatcher) embed(ctx context.Context, req *proto.MovieEmbeddingRequest) (model.Embedding, error) {
+	p := pendingMovie{ctx: ctx, req: req, result: make(chan batchResult, 1)}
+
+	select {
+	case b.pending <- p:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-b.done:
+		return nil, ErrClosed
+	}
+
+	select {
+	case r := <-p.result:
+		return r.embedding, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// close stops batching, batches already sent are still answered
+func (b *movieBatcher) close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+func (b *movieBatcher) run() {
+	for {
+		var batch []pendingMovie
+		select {
+		case p := <-b.pending:
+			batch = append(batch, p)
+		case <-b.done:
+			return
+		}
+
+		timer := time.NewTimer(b.window)
+	collect:
+		for len(batch) < b.maxSize {
+			select {
+			case p := <-b.pending:
+				batch = append(batch, p)
+			case <-timer.C:
+				break collect
+			case <-b.done:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		go b.flush(batch)
+	}
+}
+
+// flush sends movies of callers still waiting. Batch is cancelled only once all of them give up.
+func (b *movieBatcher) flush(batch []pendingMovie) {
+	live := make([]pendingMovie, 0, len(batch))
+	for _, p := range batch {
+		if p.ctx.Err() == nil {
+			live = append(live, p)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waiting := atomic.Int32{}
+	waiting.Store(int32(len(live)))
+	for _, p := range live {
+		stop := context.AfterFunc(p.ctx, func() {
+			if waiting.Add(-1) == 0 {
+				cancel()
+			}
+		})
+		defer stop()
+	}
+
+	reqs := make([]*proto.MovieEmbeddingRequest, len(live))
+	for i, p := range live {
+		reqs[i] = p.req
+	}
+
+	embeddings, err := b.send(ctx, reqs)
+	for i, p := range live {
+		if err != nil {
+			p.result <- batchResult{err: err}
+			continue
+		}
+		p.result <- batchResult{embedding: embeddings[i]}
+	}
+}
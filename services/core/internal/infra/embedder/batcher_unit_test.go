@@ -0,0 +1,150 @@
+//go:build !integration
+// +build !integration
+
+package infra_embedder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/gen/proto"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type BatcherUnitSuite struct {
+	suite.Suite
+}
+
+// recorder embeds every movie as its title length and remembers batch sizes
+type recorder struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+}
+
+func (r *recorder) send(ctx context.Context, movies []*proto.MovieEmbeddingRequest) ([]model.Embedding, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, len(movies))
+	r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	embeddings := make([]model.Embedding, len(movies))
+	for i, m := range movies {
+		embeddings[i] = model.Embedding{float32(len(m.GetTitle()))}
+	}
+	return embeddings, nil
+}
+
+func (r *recorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.batches...)
+}
+
+func embedConcurrently(b *movieBatcher, ctx context.Context, titles []string) ([]model.Embedding, []error) {
+	embeddings := make([]model.Embedding, len(titles))
+	errs := make([]error, len(titles))
+	var wg sync.WaitGroup
+	for i, title := range titles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			embeddings[i], errs[i] = b.embed(ctx, &proto.MovieEmbeddingRequest{Title: title})
+		}()
+	}
+	wg.Wait()
+	return embeddings, errs
+}
+
+func (s *BatcherUnitSuite) TestCoalesce(t provider.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	b := newMovieBatcher(r.send, time.Second, 4)
+	defer b.close()
+
+	embeddings, errs := embedConcurrently(b, context.Background(), []string{"a", "bb", "ccc", "dddd"})
+
+	for i := range errs {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, model.Embedding{float32(i + 1)}, embeddings[i], "every caller gets its own embedding")
+	}
+	assert.Equal(t, []int{4}, r.sizes(), "full batch is sent without waiting for window")
+}
+
+func (s *BatcherUnitSuite) TestSplit(t provider.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	b := newMovieBatcher(r.send, 20*time.Millisecond, 2)
+	defer b.close()
+
+	_, errs := embedConcurrently(b, context.Background(), []string{"a", "b", "c", "d", "e"})
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	total := 0
+	for _, size := range r.sizes() {
+		assert.LessOrEqual(t, size, 2)
+		total += size
+	}
+	assert.Equal(t, 5, total)
+}
+
+func (s *BatcherUnitSuite) TestSendError(t provider.T) {
+	t.Parallel()
+
+	r := &recorder{err: errors.New("unavailable")}
+	b := newMovieBatcher(r.send, 10*time.Millisecond, 8)
+	defer b.close()
+
+	_, errs := embedConcurrently(b, context.Background(), []string{"a", "b", "c"})
+
+	for _, err := range errs {
+		assert.ErrorIs(t, err, r.err, "batch failure is reported to every caller")
+	}
+}
+
+func (s *BatcherUnitSuite) TestCancelled(t provider.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	b := newMovieBatcher(r.send, 50*time.Millisecond, 8)
+	defer b.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := b.embed(ctx, &proto.MovieEmbeddingRequest{Title: "a"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, r.sizes(), "abandoned batch is not sent")
+}
+
+func (s *BatcherUnitSuite) TestClosed(t provider.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	b := newMovieBatcher(r.send, time.Millisecond, 8)
+	b.close()
+	b.close()
+
+	_, err := b.embed(context.Background(), &proto.MovieEmbeddingRequest{Title: "a"})
+
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestBatcherUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(BatcherUnitSuite))
+}
@@ -0,0 +1,113 @@
+package infra_embedder
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// BreakerOpenError is returned without calling embedder while breaker is open.
+// It matches model.ErrEmbedderUnavailable.
+type BreakerOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("%s: circuit breaker is open, retry after %s", model.ErrEmbedderUnavailable, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *BreakerOpenError) Unwrap() error {
+	return model.ErrEmbedderUnavailable
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	// A single probe call decides whether breaker closes or opens again
+	breakerHalfOpen
+)
+
+// breaker opens after threshold consecutive failures and lets a probe through once cooldown passes.
+// Nil breaker lets everything through.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if wait := b.cooldown - b.now().Sub(b.openedAt); wait > 0 {
+			return &BreakerOpenError{RetryAfter: wait}
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// Probe is in flight
+		return &BreakerOpenError{RetryAfter: b.cooldown}
+	}
+	return nil
+}
+
+func (b *breaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+func (b *breaker) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release gives probe slot back when probe ended without saying anything about embedder health
+func (b *breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = b.now().Add(-b.cooldown)
+	}
+}
@@ -2,6 +2,13 @@ package infra_embedder
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/humanbelnik/kinoswap/core/gen/proto"
 	"github.com/humanbelnik/kinoswap/core/internal/config"
@@ -9,28 +16,249 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
+// ErrBatchMismatch is returned if embedder answers batch with a different number of embeddings
+var ErrBatchMismatch = errors.New("embedder returned embeddings not matching movies")
+
 type Embedder struct {
-	conn   *grpc.ClientConn
-	client proto.EmbeddingServiceClient
+	conn    *grpc.ClientConn
+	client  proto.EmbeddingServiceClient
+	health  healthpb.HealthClient
+	batcher *movieBatcher
+
+	timeout      time.Duration
+	batchTimeout time.Duration
+	retries      int
+	backoff      time.Duration
+	breaker      *breaker
+
+	replicas        *replicaSet
+	resolveInterval time.Duration
+	stop            context.CancelFunc
+}
+
+type Option func(*Embedder)
+
+// WithMovieBatching coalesces BuildMovieEmbedding calls arriving within window into batches of maxSize at most.
+// Only concurrent callers benefit, a lone one waits for the whole window.
+func WithMovieBatching(window time.Duration, maxSize int) Option {
+	return func(e *Embedder) {
+		if e.batcher != nil {
+			e.batcher.close()
+			e.batcher = nil
+		}
+		if window > 0 && maxSize > 1 {
+			e.batcher = newMovieBatcher(e.batchCreate, window, maxSize)
+		}
+	}
+}
+
+// Replicas are looked for that long on startup
+const startupTimeout = 10 * time.Second
+
+// Replica is probed over a fresh connection, so call timeout is too short for it
+const probeTimeout = 5 * time.Second
+
+// Health of the embedding service itself, not just of the process
+const healthService = "embedding.EmbeddingService"
+
+// Connection is checked while idle, so a dead embedder is noticed before the next call hangs on it
+var keepaliveParams = keepalive.ClientParameters{
+	Time:                30 * time.Second,
+	Timeout:             10 * time.Second,
+	PermitWithoutStream: true,
 }
 
-func MustEstablishConnection(cfg config.Embedder) *Embedder {
-	conn, err := grpc.NewClient(cfg.Host+":"+cfg.Port, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Health checking is supported by round_robin only
+var serviceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": "` + healthService + `"}
+}`
+
+// MustEstablishConnection configures client as set in cfg, options override config.
+// It refuses replicas serving different models or vectors of foreign dimension.
+func MustEstablishConnection(cfg config.Embedder, opts ...Option) *Embedder {
+	e, err := connect(hosts(cfg), append(mustConfigOptions(cfg), opts...))
 	if err != nil {
 		panic(err)
 	}
-	return &Embedder{
-		conn:   conn,
-		client: proto.NewEmbeddingServiceClient(conn),
+	return e
+}
+
+// hosts are host:port pairs of EMBEDDER_HOSTS, EMBEDDER_HOST:EMBEDDER_PORT if it's empty
+func hosts(cfg config.Embedder) []string {
+	var hosts []string
+	for _, host := range strings.Split(cfg.Hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{net.JoinHostPort(cfg.Host, cfg.Port)}
+	}
+	return hosts
+}
+
+func mustConfigOptions(cfg config.Embedder) []Option {
+	var opts []Option
+	if cfg.BatchWindow != "" {
+		window, err := time.ParseDuration(cfg.BatchWindow)
+		if err != nil {
+			panic(err)
+		}
+		size, err := strconv.Atoi(cfg.BatchSize)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithMovieBatching(window, size))
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			panic(err)
+		}
+		batchTimeout, err := time.ParseDuration(cfg.BatchTimeout)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithTimeout(timeout, batchTimeout))
+	}
+	if cfg.Retries != "" {
+		retries, err := strconv.Atoi(cfg.Retries)
+		if err != nil {
+			panic(err)
+		}
+		backoff, err := time.ParseDuration(cfg.RetryBackoff)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithRetries(retries, backoff))
+	}
+	if cfg.BreakerThreshold != "" {
+		threshold, err := strconv.Atoi(cfg.BreakerThreshold)
+		if err != nil {
+			panic(err)
+		}
+		cooldown, err := time.ParseDuration(cfg.BreakerCooldown)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithBreaker(threshold, cooldown))
+	}
+	if cfg.ResolveInterval != "" {
+		interval, err := time.ParseDuration(cfg.ResolveInterval)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithResolveInterval(interval))
+	}
+	if cfg.EjectionThreshold != "" {
+		threshold, err := strconv.Atoi(cfg.EjectionThreshold)
+		if err != nil {
+			panic(err)
+		}
+		ejectionTime, err := time.ParseDuration(cfg.EjectionTime)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, WithEjection(threshold, ejectionTime))
+	}
+	return opts
+}
+
+// connect balances calls over replicas of hosts. Calls are routed only to replicas reporting SERVING
+// to gRPC health checks. Unreachable replicas aren't fatal, they are looked for again every resolve interval.
+func connect(hosts []string, opts []Option, dialOpts ...grpc.DialOption) (*Embedder, error) {
+	e := &Embedder{
+		timeout:         DefaultTimeout,
+		batchTimeout:    DefaultBatchTimeout,
+		retries:         DefaultRetries,
+		backoff:         DefaultRetryBackoff,
+		breaker:         newBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+		resolveInterval: DefaultResolveInterval,
+	}
+
+	dialOpts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, dialOpts...)
+	e.replicas = newReplicaSet(hosts, func(ctx context.Context, addr string) (model.EmbeddingModel, error) {
+		return probeModel(ctx, addr, dialOpts)
+	})
+
+	conn, err := grpc.NewClient("embedder:///replicas", append([]grpc.DialOption{
+		grpc.WithResolvers(e.replicas.resolver),
+		grpc.WithKeepaliveParams(keepaliveParams),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithUnaryInterceptor(e.intercept),
+	}, dialOpts...)...)
+	if err != nil {
+		return nil, err
 	}
+
+	e.conn = conn
+	e.client = proto.NewEmbeddingServiceClient(conn)
+	e.health = healthpb.NewHealthClient(conn)
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+	if err := e.replicas.refresh(ctx); err != nil {
+		if errors.Is(err, ErrMixedModels) || errors.Is(err, ErrInvalidDimension) {
+			_ = e.conn.Close()
+			return nil, err
+		}
+		slog.Warn("failed to find embedder replicas", slog.String("error", err.Error()))
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	e.stop = stop
+	go e.replicas.run(runCtx, e.resolveInterval)
+	return e, nil
+}
+
+// probeModel asks a single replica for its model, bypassing balancing
+func probeModel(ctx context.Context, addr string, dialOpts []grpc.DialOption) (model.EmbeddingModel, error) {
+	conn, err := grpc.NewClient("passthrough:///"+addr, dialOpts...)
+	if err != nil {
+		return model.EmbeddingModel{}, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	resp, err := proto.NewEmbeddingServiceClient(conn).GetModelInfo(ctx, &proto.ModelInfoRequest{})
+	if err != nil {
+		return model.EmbeddingModel{}, err
+	}
+	return modelInfo(resp), nil
 }
 
 func (e *Embedder) Close() error {
+	if e.stop != nil {
+		e.stop()
+	}
+	if e.batcher != nil {
+		e.batcher.close()
+	}
 	return e.conn.Close()
 }
 
+// Health returns nil if embedder is ready to serve
+func (e *Embedder) Health(ctx context.Context) error {
+	resp, err := e.health.Check(ctx, &healthpb.HealthCheckRequest{Service: healthService})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%w: %s", model.ErrEmbedderUnavailable, resp.GetStatus())
+	}
+	return nil
+}
+
 func (e *Embedder) BuildPreferenceEmbedding(ctx context.Context, P model.Preference) (model.Embedding, error) {
 	req := &proto.PreferenceEmbeddingRequest{
 		Text: P.Text,
@@ -40,16 +268,69 @@ func (e *Embedder) BuildPreferenceEmbedding(ctx context.Context, P model.Prefere
 	return model.Embedding(resp.GetEmbedding()), err
 }
 
+func movieRequest(mm model.MovieMeta) *proto.MovieEmbeddingRequest {
+	return &proto.MovieEmbeddingRequest{
+		Title:       mm.Title,
+		Overview:    mm.Overview,
+		Year:        int32(mm.Year),
+		Rating:      float32(mm.Rating),
+		Genres:      mm.Genres,
+		Director:    mm.Director,
+		Cast:        mm.Cast,
+		Runtime:     int32(mm.Runtime),
+		Certificate: mm.Certificate,
+	}
+}
+
 func (e *Embedder) BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error) {
-	req := &proto.MovieEmbeddingRequest{
-		Title:    mm.Title,
-		Overview: mm.Overview,
-		Year:     int32(mm.Year),
-		Rating:   float32(mm.Rating),
-		Genres:   mm.Genres,
+	if e.batcher != nil {
+		return e.batcher.embed(ctx, movieRequest(mm))
 	}
 
-	resp, err := e.client.CreateMovieEmbedding(ctx, req)
+	resp, err := e.client.CreateMovieEmbedding(ctx, movieRequest(mm))
 	return model.Embedding(resp.GetEmbedding()), err
+}
 
+// BuildMovieEmbeddings embeds movies in one round trip, embeddings are in order of movies
+func (e *Embedder) BuildMovieEmbeddings(ctx context.Context, mms []model.MovieMeta) ([]model.Embedding, error) {
+	reqs := make([]*proto.MovieEmbeddingRequest, len(mms))
+	for i, mm := range mms {
+		reqs[i] = movieRequest(mm)
+	}
+	return e.batchCreate(ctx, reqs)
+}
+
+func (e *Embedder) batchCreate(ctx context.Context, reqs []*proto.MovieEmbeddingRequest) ([]model.Embedding, error) {
+	resp, err := e.client.BatchCreateMovieEmbeddings(ctx, &proto.BatchMovieEmbeddingRequest{Movies: reqs})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.GetEmbeddings()) != len(reqs) {
+		return nil, fmt.Errorf("%w: %d embeddings for %d movies", ErrBatchMismatch, len(resp.GetEmbeddings()), len(reqs))
+	}
+
+	embeddings := make([]model.Embedding, len(resp.GetEmbeddings()))
+	for i, embedding := range resp.GetEmbeddings() {
+		embeddings[i] = model.Embedding(embedding.GetEmbedding())
+	}
+	return embeddings, nil
+}
+
+func (e *Embedder) ModelInfo(ctx context.Context) (model.EmbeddingModel, error) {
+	resp, err := e.client.GetModelInfo(ctx, &proto.ModelInfoRequest{})
+	if err != nil {
+		return model.EmbeddingModel{}, err
+	}
+	return modelInfo(resp), nil
+}
+
+func modelInfo(resp *proto.ModelInfo) model.EmbeddingModel {
+	return model.EmbeddingModel{
+		Name:      resp.GetName(),
+		Version:   resp.GetVersion(),
+		Dimension: int(resp.GetDimension()),
+
+		Normalization: resp.GetNormalization(),
+	}
 }
@@ -0,0 +1,501 @@
+//go:build !integration
+// +build !integration
+
+package infra_embedder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/gen/proto"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type EmbedderUnitSuite struct {
+	suite.Suite
+}
+
+// fakeServer embeds preference as its length. It fails the first failures calls
+// with failCode and hangs every call while hang is set.
+type fakeServer struct {
+	proto.UnimplementedEmbeddingServiceServer
+
+	model    model.EmbeddingModel
+	calls    atomic.Int32
+	failures atomic.Int32
+	failCode codes.Code
+	hang     atomic.Bool
+	short    atomic.Bool
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		model:    model.EmbeddingModel{Name: "all-MiniLM-L6-v2", Version: "+text2", Dimension: model.EmbeddingDimension, Normalization: model.NormalizationL2},
+		failCode: codes.Unavailable,
+	}
+}
+
+func (s *fakeServer) CreatePreferenceEmbedding(ctx context.Context, req *proto.PreferenceEmbeddingRequest) (*proto.EmbeddingResponse, error) {
+	s.calls.Add(1)
+	if s.hang.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if s.failures.Add(-1) >= 0 {
+		return nil, status.Error(s.failCode, "fake failure")
+	}
+	return &proto.EmbeddingResponse{Embedding: []float32{float32(len(req.GetText()))}}, nil
+}
+
+// BatchCreateMovieEmbeddings embeds movie as its title length, the last one is lost while short is set
+func (s *fakeServer) BatchCreateMovieEmbeddings(ctx context.Context, req *proto.BatchMovieEmbeddingRequest) (*proto.BatchEmbeddingResponse, error) {
+	s.calls.Add(1)
+	movies := req.GetMovies()
+	if s.short.Load() && len(movies) > 0 {
+		movies = movies[:len(movies)-1]
+	}
+	resp := &proto.BatchEmbeddingResponse{}
+	for _, m := range movies {
+		resp.Embeddings = append(resp.Embeddings, &proto.EmbeddingResponse{Embedding: []float32{float32(len(m.GetTitle()))}})
+	}
+	return resp, nil
+}
+
+func (s *fakeServer) GetModelInfo(ctx context.Context, req *proto.ModelInfoRequest) (*proto.ModelInfo, error) {
+	return &proto.ModelInfo{
+		Name:          s.model.Name,
+		Version:       s.model.Version,
+		Dimension:     int32(s.model.Dimension),
+		Normalization: s.model.Normalization,
+	}, nil
+}
+
+// replicaConn is told apart from other replicas by its remote address, bufconn has the same one for all
+type replicaConn struct {
+	net.Conn
+	addr string
+}
+
+func (c replicaConn) RemoteAddr() net.Addr {
+	return fakeAddr(c.addr)
+}
+
+type fakeAddr string
+
+func (a fakeAddr) Network() string { return "tcp" }
+func (a fakeAddr) String() string  { return string(a) }
+
+type fakeReplica struct {
+	server *fakeServer
+	health *health.Server
+}
+
+// serveReplicas serves fake embedders in process at given addresses, addresses must be IPs
+func serveReplicas(t provider.T, replicas map[string]*fakeServer) (map[string]fakeReplica, grpc.DialOption) {
+	listeners := make(map[string]*bufconn.Listener)
+	served := make(map[string]fakeReplica)
+	for addr, fake := range replicas {
+		lis := bufconn.Listen(1 << 20)
+		srv := grpc.NewServer()
+		healthSrv := health.NewServer()
+		healthSrv.SetServingStatus(healthService, healthpb.HealthCheckResponse_SERVING)
+		proto.RegisterEmbeddingServiceServer(srv, fake)
+		healthpb.RegisterHealthServer(srv, healthSrv)
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+
+		listeners[addr] = lis
+		served[addr] = fakeReplica{server: fake, health: healthSrv}
+	}
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		lis, ok := listeners[addr]
+		if !ok {
+			return nil, errors.New("connection refused")
+		}
+		conn, err := lis.DialContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return replicaConn{Conn: conn, addr: addr}, nil
+	})
+	return served, dialer
+}
+
+type bufconnResources struct {
+	server   *fakeServer
+	health   *health.Server
+	embedder *Embedder
+	ctx      context.Context
+}
+
+const replicaAddr = "10.0.0.1:50051"
+
+// initResources serves a single fake embedder in process, client is connected as in production
+func initResources(t provider.T, opts ...Option) *bufconnResources {
+	replicas, dialer := serveReplicas(t, map[string]*fakeServer{replicaAddr: newFakeServer()})
+
+	e, err := connect([]string{replicaAddr}, opts, dialer)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+
+	return &bufconnResources{
+		server:   replicas[replicaAddr].server,
+		health:   replicas[replicaAddr].health,
+		embedder: e,
+		ctx:      context.Background(),
+	}
+}
+
+func (r *bufconnResources) embed() (model.Embedding, error) {
+	return r.embedder.BuildPreferenceEmbedding(r.ctx, model.Preference{Text: "comedy"})
+}
+
+func (s *EmbedderUnitSuite) TestRetries(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		failures      int32
+		failCode      codes.Code
+		expectedCalls int32
+		expectedError error
+		expectedCode  codes.Code
+	}{
+		{
+			name:          "Should retry transient failures",
+			failures:      2,
+			failCode:      codes.Unavailable,
+			expectedCalls: 3,
+		},
+		{
+			name:          "Should report unavailable embedder once retries are exhausted",
+			failures:      5,
+			failCode:      codes.ResourceExhausted,
+			expectedCalls: 3,
+			expectedError: model.ErrEmbedderUnavailable,
+			expectedCode:  codes.ResourceExhausted,
+		},
+		{
+			name:          "Should not retry refused request",
+			failures:      1,
+			failCode:      codes.InvalidArgument,
+			expectedCalls: 1,
+			expectedCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t, WithRetries(2, time.Millisecond))
+			r.server.failures.Store(tc.failures)
+			r.server.failCode = tc.failCode
+
+			e, err := r.embed()
+
+			assert.Equal(t, tc.expectedCalls, r.server.calls.Load())
+			switch {
+			case tc.expectedError != nil:
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Equal(t, tc.expectedCode, status.Code(err))
+			case tc.expectedCode != codes.OK:
+				assert.Equal(t, tc.expectedCode, status.Code(err))
+				assert.False(t, errors.Is(err, model.ErrEmbedderUnavailable), "embedder is up")
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, model.Embedding{6}, e)
+			}
+		})
+	}
+}
+
+func (s *EmbedderUnitSuite) TestBatchMismatch(t provider.T) {
+	t.Parallel()
+	r := initResources(t, WithMovieBatching(time.Millisecond, 2))
+	movies := []model.MovieMeta{{Title: "Heat"}, {Title: "Alien"}}
+
+	embeddings, err := r.embedder.BuildMovieEmbeddings(r.ctx, movies)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Embedding{{4}, {5}}, embeddings)
+
+	r.server.short.Store(true)
+	embeddings, err = r.embedder.BuildMovieEmbeddings(r.ctx, movies)
+	assert.ErrorIs(t, err, ErrBatchMismatch)
+	assert.Nil(t, embeddings)
+
+	_, err = r.embedder.BuildMovieEmbedding(r.ctx, movies[0])
+	assert.ErrorIs(t, err, ErrBatchMismatch, "batched call gets the same error")
+}
+
+func (s *EmbedderUnitSuite) TestTimeout(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t, WithTimeout(50*time.Millisecond, 0), WithRetries(1, time.Millisecond))
+	r.server.hang.Store(true)
+
+	started := time.Now()
+	_, err := r.embed()
+
+	assert.ErrorIs(t, err, model.ErrEmbedderUnavailable)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, int32(2), r.server.calls.Load(), "timed out attempt is retried")
+	assert.Less(t, time.Since(started), time.Second, "hung embedder doesn't hang caller")
+}
+
+func (s *EmbedderUnitSuite) TestCallerCancel(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t, WithRetries(2, time.Millisecond), WithBreaker(1, time.Minute))
+	r.server.hang.Store(true)
+
+	ctx, cancel := context.WithTimeout(r.ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := r.embedder.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, int32(1), r.server.calls.Load(), "caller deadline isn't retried")
+	assert.NoError(t, r.embedder.breaker.allow(), "caller deadline doesn't open breaker")
+}
+
+func (s *EmbedderUnitSuite) TestCallerCancelDuringBackoff(t provider.T) {
+	t.Parallel()
+
+	// Backoff is capped, enough retries outlast the deadline anyway
+	r := initResources(t, WithRetries(10, time.Minute))
+	r.server.failures.Store(20)
+
+	ctx, cancel := context.WithTimeout(r.ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := r.embedder.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "caller gave up waiting for retry")
+	assert.Equal(t, codes.Unavailable, status.Code(err), "failure of the last attempt is kept")
+}
+
+func (s *EmbedderUnitSuite) TestBreaker(t provider.T) {
+	t.Parallel()
+
+	cooldown := 100 * time.Millisecond
+	r := initResources(t, WithRetries(0, 0), WithBreaker(2, cooldown))
+	r.server.failures.Store(2)
+
+	for range 2 {
+		_, err := r.embed()
+		assert.ErrorIs(t, err, model.ErrEmbedderUnavailable)
+	}
+
+	_, err := r.embed()
+	var open *BreakerOpenError
+	assert.ErrorAs(t, err, &open, "breaker fails fast with typed error")
+	assert.ErrorIs(t, err, model.ErrEmbedderUnavailable)
+	assert.Equal(t, int32(2), r.server.calls.Load(), "open breaker doesn't call embedder")
+
+	time.Sleep(cooldown)
+	e, err := r.embed()
+	assert.NoError(t, err, "successful probe closes breaker")
+	assert.Equal(t, model.Embedding{6}, e)
+
+	_, err = r.embed()
+	assert.NoError(t, err)
+}
+
+func (s *EmbedderUnitSuite) TestHalfOpenProbeFails(t provider.T) {
+	t.Parallel()
+
+	cooldown := 50 * time.Millisecond
+	r := initResources(t, WithRetries(0, 0), WithBreaker(1, cooldown))
+	r.server.failures.Store(2)
+
+	_, err := r.embed()
+	assert.ErrorIs(t, err, model.ErrEmbedderUnavailable)
+
+	time.Sleep(cooldown)
+	_, err = r.embed()
+	assert.Equal(t, codes.Unavailable, status.Code(err), "probe reaches embedder")
+
+	_, err = r.embed()
+	var open *BreakerOpenError
+	assert.ErrorAs(t, err, &open, "failed probe opens breaker again")
+	assert.Equal(t, int32(2), r.server.calls.Load())
+}
+
+func (s *EmbedderUnitSuite) TestHealth(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t, WithRetries(0, 0), WithBreaker(0, 0))
+	assert.NoError(t, r.embedder.Health(r.ctx))
+
+	r.health.SetServingStatus(healthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.ErrorIs(t, r.embedder.Health(r.ctx), model.ErrEmbedderUnavailable)
+
+	assert.Eventually(t, func() bool {
+		_, err := r.embed()
+		return errors.Is(err, model.ErrEmbedderUnavailable)
+	}, time.Second, 10*time.Millisecond, "calls aren't routed to not serving embedder")
+
+	r.health.SetServingStatus(healthService, healthpb.HealthCheckResponse_SERVING)
+	assert.Eventually(t, func() bool {
+		_, err := r.embed()
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *EmbedderUnitSuite) TestBalancing(t provider.T) {
+	t.Parallel()
+
+	addrs := []string{"10.0.0.1:50051", "10.0.0.2:50051", "10.0.0.3:50051"}
+	fakes := make(map[string]*fakeServer)
+	for _, addr := range addrs {
+		fakes[addr] = newFakeServer()
+	}
+	_, dialer := serveReplicas(t, fakes)
+	e, err := connect(addrs, nil, dialer)
+	assert.NoError(t, err)
+	defer e.Close()
+
+	for range 30 {
+		_, err := e.BuildPreferenceEmbedding(context.Background(), model.Preference{Text: "comedy"})
+		assert.NoError(t, err)
+	}
+
+	for addr, fake := range fakes {
+		assert.Positive(t, fake.calls.Load(), "%s serves its share", addr)
+	}
+}
+
+func (s *EmbedderUnitSuite) TestOutlierEjection(t provider.T) {
+	t.Parallel()
+
+	addrs := []string{"10.0.0.1:50051", "10.0.0.2:50051", "10.0.0.3:50051"}
+	fakes := make(map[string]*fakeServer)
+	for _, addr := range addrs {
+		fakes[addr] = newFakeServer()
+	}
+	outlier := fakes[addrs[1]]
+	outlier.failures.Store(1 << 30)
+	_, dialer := serveReplicas(t, fakes)
+	e, err := connect(addrs, []Option{WithEjection(2, time.Minute), WithRetries(2, time.Millisecond)}, dialer)
+	assert.NoError(t, err)
+	defer e.Close()
+
+	for range 30 {
+		_, err := e.BuildPreferenceEmbedding(context.Background(), model.Preference{Text: "comedy"})
+		assert.NoError(t, err, "failed attempts are retried on other replicas")
+	}
+
+	assert.LessOrEqual(t, outlier.calls.Load(), int32(4), "ejected replica gets no calls")
+	e.replicas.mu.Lock()
+	assert.True(t, e.replicas.outliers[addrs[1]].ejectedUntil.After(time.Now()))
+	assert.Equal(t, 1, e.replicas.ejectedLocked(time.Now()))
+	e.replicas.mu.Unlock()
+}
+
+func (s *EmbedderUnitSuite) TestEjectionLimit(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t, WithEjection(1, time.Minute), WithRetries(0, 0), WithBreaker(0, 0))
+	r.server.failures.Store(1 << 30)
+
+	for range 3 {
+		_, err := r.embed()
+		assert.ErrorIs(t, err, model.ErrEmbedderUnavailable)
+	}
+
+	assert.Equal(t, int32(3), r.server.calls.Load(), "the only replica is never ejected")
+}
+
+func (s *EmbedderUnitSuite) TestRefusedModels(t provider.T) {
+	t.Parallel()
+
+	other := newFakeServer()
+	other.model.Name = "bge-small"
+	unnormalized := newFakeServer()
+	unnormalized.model.Normalization = model.NormalizationNone
+	foreign := newFakeServer()
+	foreign.model.Dimension = 768
+
+	testCases := []struct {
+		name          string
+		replica       *fakeServer
+		expectedError error
+	}{
+		{name: "Should refuse replicas of different models", replica: other, expectedError: ErrMixedModels},
+		{name: "Should refuse replicas of different normalization", replica: unnormalized, expectedError: ErrMixedModels},
+		{name: "Should refuse foreign dimension", replica: foreign, expectedError: ErrInvalidDimension},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			addrs := []string{"10.0.0.1:50051", "10.0.0.2:50051"}
+			_, dialer := serveReplicas(t, map[string]*fakeServer{addrs[0]: newFakeServer(), addrs[1]: tc.replica})
+
+			_, err := connect(addrs, nil, dialer)
+
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func (s *EmbedderUnitSuite) TestReplicasChange(t provider.T) {
+	t.Parallel()
+
+	current, other := newFakeServer(), newFakeServer()
+	other.model.Version = "2+text2"
+	replicas, dialer := serveReplicas(t, map[string]*fakeServer{
+		"10.0.0.1:50051": current,
+		"10.0.0.2:50051": current,
+		"10.0.0.3:50051": other,
+	})
+
+	var resolved atomic.Pointer[[]string]
+	resolve := func(ips ...string) { resolved.Store(&ips) }
+	resolve("10.0.0.1")
+	withLookup := func(e *Embedder) {
+		e.replicas.lookup = func(ctx context.Context, host string) ([]string, error) {
+			return *resolved.Load(), nil
+		}
+	}
+
+	e, err := connect([]string{"embedder-app:50051"}, []Option{withLookup}, dialer)
+	assert.NoError(t, err)
+	defer e.Close()
+	ctx := context.Background()
+
+	resolve("10.0.0.1", "10.0.0.2", "10.0.0.3")
+	err = e.replicas.refresh(ctx)
+	assert.ErrorIs(t, err, ErrMixedModels)
+	assert.Equal(t, []string{"10.0.0.1:50051", "10.0.0.2:50051"}, e.replicas.addrs, "replica of other model isn't added")
+
+	for range 10 {
+		_, err := e.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+		assert.NoError(t, err)
+	}
+	assert.Zero(t, replicas["10.0.0.3:50051"].server.calls.Load())
+
+	resolve("10.0.0.3")
+	assert.NoError(t, e.replicas.refresh(ctx), "new model is served once old replicas are gone")
+	em, ok := e.replicas.Model()
+	assert.True(t, ok)
+	assert.Equal(t, other.model, em)
+}
+
+func TestEmbedderUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(EmbedderUnitSuite))
+}
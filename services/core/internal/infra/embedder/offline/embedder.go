@@ -0,0 +1,177 @@
+// Package infra_embedder_offline embeds movies and preferences in process, with no model and no embedder service.
+// It is meant for local runs, imports and tests, similarity it gives is lexical.
+// With no vocabulary it's pure feature hashing of a fixed version.
+package infra_embedder_offline
+
+import (
+	"context"
+	"hash/fnv"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const ModelName = "offline-tfidf"
+
+// Bumped whenever vectors change for the same vocabulary
+const modelVersion = "1"
+
+// Vector starts with a slot per genre, the rest holds hashed TF-IDF of words
+var genres = []string{
+	"action", "adventure", "animation", "biography", "comedy", "crime", "documentary", "drama",
+	"family", "fantasy", "film-noir", "history", "horror", "music", "musical", "mystery",
+	"romance", "sci-fi", "sport", "thriller", "war", "western",
+}
+
+// genreWords tell a genre in preference text besides genre names themselves
+var genreWords = map[string]string{
+	"funny": "comedy", "comedies": "comedy", "laugh": "comedy", "hilarious": "comedy",
+	"scary": "horror", "horrors": "horror", "creepy": "horror", "spooky": "horror",
+	"romantic": "romance", "love": "romance",
+	"animated": "animation", "cartoon": "animation", "cartoons": "animation", "anime": "animation",
+	"documentaries": "documentary", "biopic": "biography",
+	"scifi": "sci-fi", "sci": "sci-fi", "space": "sci-fi", "science": "sci-fi",
+	"thrillers": "thriller", "suspense": "thriller", "detective": "mystery", "noir": "film-noir",
+	"dramas": "drama", "historical": "history", "westerns": "western", "cowboy": "western",
+	"kids": "family", "superhero": "action", "magic": "fantasy", "musicals": "musical",
+	"sports": "sport", "heist": "crime", "gangster": "crime", "mafia": "crime",
+}
+
+// Share of genre slots in vector norm when both genres and words are known
+const genreWeight = 0.4
+
+// Embedder is deterministic: the same text and vocabulary always give the same vector
+type Embedder struct {
+	vocabulary *Vocabulary
+	dimension  int
+}
+
+type Option func(*Embedder)
+
+// WithVocabulary weighs words by how rare they are in catalog the vocabulary was fitted to
+func WithVocabulary(v *Vocabulary) Option {
+	return func(e *Embedder) {
+		e.vocabulary = v
+	}
+}
+
+func New(opts ...Option) *Embedder {
+	e := &Embedder{dimension: model.EmbeddingDimension}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// ModelInfo version includes vocabulary, so catalog embedded with another vocabulary file is re-embedded
+func (e *Embedder) ModelInfo(ctx context.Context) (model.EmbeddingModel, error) {
+	return model.EmbeddingModel{
+		Name:      ModelName,
+		Version:   modelVersion + "-" + e.vocabulary.Fingerprint(),
+		Dimension: e.dimension,
+
+		Normalization: model.NormalizationL2,
+	}, nil
+}
+
+func (e *Embedder) BuildPreferenceEmbedding(ctx context.Context, p model.Preference) (model.Embedding, error) {
+	words := tokens(p.Text)
+
+	found := make([]string, len(words))
+	for i, w := range words {
+		found[i] = w
+		if g, ok := genreWords[w]; ok {
+			found[i] = g
+		}
+	}
+
+	return e.embed(words, found), nil
+}
+
+func (e *Embedder) BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error) {
+	return e.embed(movieTokens(mm), mm.Genres), nil
+}
+
+func (e *Embedder) BuildMovieEmbeddings(ctx context.Context, mms []model.MovieMeta) ([]model.Embedding, error) {
+	embeddings := make([]model.Embedding, len(mms))
+	for i, mm := range mms {
+		embeddings[i], _ = e.BuildMovieEmbedding(ctx, mm)
+	}
+	return embeddings, nil
+}
+
+func (e *Embedder) embed(words []string, movieGenres []string) model.Embedding {
+	vec := make(model.Embedding, e.dimension)
+	genreSlots, wordSlots := vec[:len(genres)], vec[len(genres):]
+
+	for _, g := range movieGenres {
+		if i := genreIndex(g); i >= 0 {
+			genreSlots[i] = 1
+		}
+	}
+
+	tf := make(map[string]int)
+	for _, w := range words {
+		tf[w]++
+	}
+	// Sorted, so colliding words are summed in the same order every time
+	for _, w := range slices.Sorted(maps.Keys(tf)) {
+		n := tf[w]
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(w))
+		sum := h.Sum32()
+
+		weight := (1 + math.Log(float64(n))) * e.vocabulary.idf(w)
+		// Sign bit halves collisions impact
+		if sum>>31 == 1 {
+			weight = -weight
+		}
+		wordSlots[sum%uint32(len(wordSlots))] += float32(weight)
+	}
+
+	genreNorm, wordNorm := normalize(genreSlots), normalize(wordSlots)
+	switch {
+	case genreNorm > 0 && wordNorm > 0:
+		scale(genreSlots, math.Sqrt(genreWeight))
+		scale(wordSlots, math.Sqrt(1-genreWeight))
+	case genreNorm == 0 && wordNorm == 0:
+		// Zero vector has no direction, every blank text gets the same one
+		vec[len(vec)-1] = 1
+	}
+	return vec
+}
+
+func genreIndex(genre string) int {
+	genre = strings.ToLower(strings.TrimSpace(genre))
+	if genre == "scifi" || genre == "science fiction" {
+		genre = "sci-fi"
+	}
+	for i, g := range genres {
+		if g == genre {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalize scales v to unit length in place and returns its former length
+func normalize(v []float32) float64 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		scale(v, 1/norm)
+	}
+	return norm
+}
+
+func scale(v []float32, k float64) {
+	for i := range v {
+		v[i] = float32(float64(v[i]) * k)
+	}
+}
@@ -0,0 +1,186 @@
+//go:build !integration
+// +build !integration
+
+package infra_embedder_offline
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type OfflineEmbedderUnitSuite struct {
+	suite.Suite
+}
+
+func catalog() []*model.MovieMeta {
+	return []*model.MovieMeta{
+		{Title: "Heat", Genres: []string{"Action", "Crime"}, Overview: "A group of bank robbers is chased by a detective.", Director: "Michael Mann", Cast: []string{"Al Pacino", "Robert De Niro"}},
+		{Title: "Alien", Genres: []string{"Horror", "Sci-Fi"}, Overview: "The crew of a spaceship meets a deadly lifeform.", Director: "Ridley Scott"},
+		{Title: "Airplane!", Genres: []string{"Comedy"}, Overview: "A pilot with a drinking problem must land the plane.", Director: "Jim Abrahams"},
+		{Title: "The Godfather", Genres: []string{"Crime", "Drama"}, Overview: "The aging patriarch of a crime dynasty hands control to his son.", Director: "Francis Ford Coppola", Cast: []string{"Al Pacino"}},
+		{Title: "Groundhog Day", Genres: []string{"Comedy", "Fantasy"}, Overview: "A weatherman relives the same day again and again.", Director: "Harold Ramis"},
+	}
+}
+
+func cosine(a, b model.Embedding) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// nearest returns title of the catalog movie closest to e
+func nearest(t provider.T, e *Embedder, target model.Embedding) string {
+	best, bestScore := "", -2.0
+	for _, mm := range catalog() {
+		v, err := e.BuildMovieEmbedding(context.Background(), *mm)
+		assert.NoError(t, err)
+		if score := cosine(target, v); score > bestScore {
+			best, bestScore = mm.Title, score
+		}
+	}
+	return best
+}
+
+func (s *OfflineEmbedderUnitSuite) TestDeterministicUnitVectors(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	texts := []string{"heist movie with Al Pacino", "", "!!!", "comedy"}
+
+	for _, text := range texts {
+		a, err := New(WithVocabulary(Fit(catalog()))).BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+		assert.NoError(t, err)
+		b, err := New(WithVocabulary(Fit(catalog()))).BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+		assert.NoError(t, err)
+
+		assert.Equal(t, a, b, "same text gives same vector")
+		assert.Len(t, a, model.EmbeddingDimension)
+		assert.InDelta(t, 1, cosine(a, a), 1e-6)
+		var norm float64
+		for _, x := range a {
+			norm += float64(x) * float64(x)
+		}
+		assert.InDelta(t, 1, norm, 1e-5, "vector of %q is unit", text)
+	}
+}
+
+func (s *OfflineEmbedderUnitSuite) TestPreferenceFindsMovie(t provider.T) {
+	t.Parallel()
+
+	e := New(WithVocabulary(Fit(catalog())))
+
+	testCases := []struct {
+		preference string
+		expected   string
+	}{
+		{preference: "something scary in space", expected: "Alien"},
+		{preference: "Al Pacino robbing banks", expected: "Heat"},
+		{preference: "crime family drama", expected: "The Godfather"},
+		{preference: "funny movie about a pilot", expected: "Airplane!"},
+		{preference: "weatherman stuck in time", expected: "Groundhog Day"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.preference, func(t provider.T) {
+			t.Parallel()
+			v, err := e.BuildPreferenceEmbedding(context.Background(), model.Preference{Text: tc.preference})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, nearest(t, e, v))
+		})
+	}
+}
+
+func (s *OfflineEmbedderUnitSuite) TestIDF(t provider.T) {
+	t.Parallel()
+
+	v := Fit(catalog())
+
+	assert.Greater(t, v.idf("weatherman"), v.idf("pacino"), "rarer word weighs more")
+	assert.Greater(t, v.idf("unknown"), v.idf("weatherman"))
+	assert.Equal(t, 1.0, (*Vocabulary)(nil).idf("pacino"), "no vocabulary weighs words equally")
+}
+
+func (s *OfflineEmbedderUnitSuite) TestModelInfo(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fitted, err := New(WithVocabulary(Fit(catalog()))).ModelInfo(ctx)
+	assert.NoError(t, err)
+	refitted, err := New(WithVocabulary(Fit(catalog()))).ModelInfo(ctx)
+	assert.NoError(t, err)
+	grown, err := New(WithVocabulary(Fit(append(catalog(), &model.MovieMeta{Title: "Heat", Overview: "Remake"})))).ModelInfo(ctx)
+	assert.NoError(t, err)
+	blank, err := New().ModelInfo(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, model.EmbeddingDimension, fitted.Dimension)
+	assert.Equal(t, ModelName, fitted.Name)
+	assert.Equal(t, fitted.ID(), refitted.ID(), "same catalog gives same model")
+	assert.NotEqual(t, fitted.ID(), grown.ID(), "vectors of another vocabulary aren't comparable")
+	assert.NotEqual(t, fitted.ID(), blank.ID())
+}
+
+func (s *OfflineEmbedderUnitSuite) TestVocabularyFile(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fitted := New(WithVocabulary(Fit(catalog())))
+	want, err := fitted.ModelInfo(ctx)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, fitted.vocabulary.Save(&buf))
+	path := filepath.Join(t.TempDir(), "vocabulary.json")
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	loaded, err := LoadVocabularyFile(path)
+	assert.NoError(t, err)
+	got, err := New(WithVocabulary(loaded)).ModelInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, want.ID(), got.ID(), "instances loading the same file share model")
+
+	q := model.Preference{Text: "Al Pacino robbing banks"}
+	a, _ := fitted.BuildPreferenceEmbedding(ctx, q)
+	b, _ := New(WithVocabulary(loaded)).BuildPreferenceEmbedding(ctx, q)
+	assert.Equal(t, a, b)
+
+	none, err := LoadVocabularyFile("")
+	assert.NoError(t, err)
+	assert.Nil(t, none, "no file is pure hashing")
+
+	testCases := []struct {
+		name string
+		file string
+	}{
+		{name: "Should refuse unknown version", file: `{"version":2,"docs":1,"df":{"heat":1}}`},
+		{name: "Should refuse frequency above documents", file: `{"version":1,"docs":1,"df":{"heat":2}}`},
+		{name: "Should refuse garbage", file: `garbage`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			_, err := LoadVocabulary(strings.NewReader(tc.file))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOfflineEmbedderUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(OfflineEmbedderUnitSuite))
+}
@@ -0,0 +1,166 @@
+package infra_embedder_offline
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// Too common to tell movies apart, catalog of a few movies would not notice that by itself
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "he": true, "her": true, "his": true, "i": true, "in": true,
+	"is": true, "it": true, "its": true, "me": true, "movie": true, "movies": true, "of": true, "on": true,
+	"or": true, "she": true, "something": true, "that": true, "the": true, "their": true, "them": true,
+	"they": true, "to": true, "want": true, "was": true, "we": true, "with": true, "film": true, "films": true,
+}
+
+// tokens are lowercase words without stop words, in text order
+func tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return slices.DeleteFunc(words, func(w string) bool {
+		return stopWords[w]
+	})
+}
+
+// movieTokens weigh title twice, a title word is more telling than an overview one
+func movieTokens(mm model.MovieMeta) []string {
+	title := tokens(mm.Title)
+	return slices.Concat(
+		title,
+		title,
+		tokens(mm.Overview),
+		tokens(strings.Join(mm.Genres, " ")),
+		tokens(mm.Director),
+		tokens(strings.Join(mm.Cast, " ")),
+	)
+}
+
+var ErrVocabularyVersion = errors.New("unsupported vocabulary version")
+
+// Bumped whenever vocabulary file layout changes
+const vocabularyVersion = 1
+
+// Vocabulary holds document frequencies of catalog words. Zero Vocabulary weighs every word equally.
+// It's fitted once and shipped as a file, every instance and import embeds with the same weights then.
+type Vocabulary struct {
+	docs int
+	df   map[string]int
+}
+
+// Fit counts in how many movies every word occurs
+func Fit(movies []*model.MovieMeta) *Vocabulary {
+	v := &Vocabulary{df: make(map[string]int)}
+	for _, mm := range movies {
+		if mm == nil {
+			continue
+		}
+		v.docs++
+		seen := make(map[string]bool)
+		for _, t := range movieTokens(*mm) {
+			if !seen[t] {
+				seen[t] = true
+				v.df[t]++
+			}
+		}
+	}
+	return v
+}
+
+// idf is smoothed, so words unknown to catalog weigh the most and no weight is zero
+func (v *Vocabulary) idf(word string) float64 {
+	if v == nil || v.docs == 0 {
+		return 1
+	}
+	return math.Log(float64(v.docs+1)/float64(v.df[word]+1)) + 1
+}
+
+// Fingerprint changes whenever any weight does, vectors of different vocabularies aren't comparable
+func (v *Vocabulary) Fingerprint() string {
+	if v == nil || v.docs == 0 {
+		return "0000000000000000"
+	}
+
+	words := make([]string, 0, len(v.df))
+	for w := range v.df {
+		words = append(words, w)
+	}
+	slices.Sort(words)
+
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(v.docs))
+	_, _ = h.Write(buf)
+	for _, w := range words {
+		_, _ = h.Write([]byte(w))
+		binary.LittleEndian.PutUint64(buf, uint64(v.df[w]))
+		_, _ = h.Write(buf)
+	}
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+type vocabularyFile struct {
+	Version int            `json:"version"`
+	Docs    int            `json:"docs"`
+	DF      map[string]int `json:"df"`
+}
+
+// Save writes vocabulary to be loaded by every instance, see cmd/vocabulary
+func (v *Vocabulary) Save(w io.Writer) error {
+	f := vocabularyFile{Version: vocabularyVersion, DF: map[string]int{}}
+	if v != nil {
+		f.Docs, f.DF = v.docs, v.df
+	}
+	return json.NewEncoder(w).Encode(f)
+}
+
+// LoadVocabulary reads vocabulary written by Save, its fingerprint is the same as of the saved one
+func LoadVocabulary(r io.Reader) (*Vocabulary, error) {
+	var f vocabularyFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode vocabulary: %w", err)
+	}
+	if f.Version != vocabularyVersion {
+		return nil, fmt.Errorf("%w: %d", ErrVocabularyVersion, f.Version)
+	}
+	if f.Docs < 0 {
+		return nil, errors.New("vocabulary has negative documents count")
+	}
+	for w, n := range f.DF {
+		if n <= 0 || n > f.Docs {
+			return nil, fmt.Errorf("vocabulary word %q has frequency %d of %d documents", w, n, f.Docs)
+		}
+	}
+	if f.DF == nil {
+		f.DF = map[string]int{}
+	}
+	return &Vocabulary{docs: f.Docs, df: f.DF}, nil
+}
+
+// LoadVocabularyFile returns nil vocabulary for empty path
+func LoadVocabularyFile(path string) (*Vocabulary, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadVocabulary(f)
+}
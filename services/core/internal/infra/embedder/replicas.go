@@ -0,0 +1,260 @@
+package infra_embedder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+var (
+	ErrMixedModels      = errors.New("embedder replicas serve different models")
+	ErrInvalidDimension = errors.New("embedder serves vectors of foreign dimension")
+)
+
+const (
+	DefaultResolveInterval    = 30 * time.Second
+	DefaultEjectionThreshold  = 3
+	DefaultEjectionTime       = 30 * time.Second
+	defaultMaxEjectedPercent  = 50
+	maxEjectionTimeMultiplier = 10
+)
+
+// replicaSet feeds round_robin with embedder replicas. Hosts are resolved to every address behind them,
+// replicas of a model other than the one already served are never added,
+// replicas failing in a row are ejected for a while growing with every ejection.
+type replicaSet struct {
+	resolver *manual.Resolver
+	hosts    []string
+	lookup   func(ctx context.Context, host string) ([]string, error)
+	probe    func(ctx context.Context, addr string) (model.EmbeddingModel, error)
+	now      func() time.Time
+	logger   *slog.Logger
+
+	threshold         int
+	ejectionTime      time.Duration
+	maxEjectedPercent int
+
+	mu sync.Mutex
+	// Served by replicas, nil until the first one is verified
+	model    *model.EmbeddingModel
+	addrs    []string
+	outliers map[string]*outlier
+}
+
+type outlier struct {
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+func newReplicaSet(hosts []string, probe func(ctx context.Context, addr string) (model.EmbeddingModel, error)) *replicaSet {
+	return &replicaSet{
+		resolver:          manual.NewBuilderWithScheme("embedder"),
+		hosts:             hosts,
+		lookup:            net.DefaultResolver.LookupHost,
+		probe:             probe,
+		now:               time.Now,
+		logger:            slog.Default(),
+		threshold:         DefaultEjectionThreshold,
+		ejectionTime:      DefaultEjectionTime,
+		maxEjectedPercent: defaultMaxEjectedPercent,
+		outliers:          make(map[string]*outlier),
+	}
+}
+
+// Model returns model served by replicas, false if none was reachable yet
+func (r *replicaSet) Model() (model.EmbeddingModel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.model == nil {
+		return model.EmbeddingModel{}, false
+	}
+	return *r.model, true
+}
+
+// refresh resolves hosts and verifies new replicas. Replicas of another model or dimension are refused
+// and reported by ErrMixedModels or ErrInvalidDimension, unreachable ones are tried again next time.
+func (r *replicaSet) refresh(ctx context.Context) error {
+	var found []string
+	for _, host := range r.hosts {
+		name, port, err := net.SplitHostPort(host)
+		if err != nil {
+			return err
+		}
+		ips, err := r.lookup(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve embedder %s: %w", host, err)
+		}
+		for _, ip := range ips {
+			found = append(found, net.JoinHostPort(ip, port))
+		}
+	}
+	slices.Sort(found)
+	found = slices.Compact(found)
+
+	r.mu.Lock()
+	known := slices.Clone(r.addrs)
+	r.mu.Unlock()
+
+	var (
+		verified []string
+		errs     []error
+	)
+	for _, addr := range found {
+		if slices.Contains(known, addr) {
+			verified = append(verified, addr)
+		}
+	}
+	for _, addr := range found {
+		if slices.Contains(known, addr) {
+			continue
+		}
+
+		em, err := r.probe(ctx, addr)
+		if err != nil {
+			r.logger.Warn("embedder replica isn't reachable", slog.String("addr", addr), slog.String("error", err.Error()))
+			continue
+		}
+		if err := r.admit(em, len(verified) == 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+		verified = append(verified, addr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(verified) == 0 && len(r.addrs) > 0 {
+		r.logger.Warn("no embedder replicas left")
+	}
+	slices.Sort(verified)
+	r.addrs = verified
+	for addr := range r.outliers {
+		if !slices.Contains(verified, addr) {
+			delete(r.outliers, addr)
+		}
+	}
+	r.publishLocked()
+
+	return errors.Join(errs...)
+}
+
+// admit checks replica serves the same model as the others. Once every replica is gone, new ones may serve a new model.
+func (r *replicaSet) admit(em model.EmbeddingModel, first bool) error {
+	if em.Dimension != model.EmbeddingDimension {
+		return fmt.Errorf("%w: %s has %d, expected %d", ErrInvalidDimension, em.ID(), em.Dimension, model.EmbeddingDimension)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.model == nil || first {
+		if r.model != nil && *r.model != em {
+			r.logger.Warn("embedder replicas are replaced by another model",
+				slog.String("from", r.model.ID()), slog.String("to", em.ID()))
+		}
+		r.model = &em
+		return nil
+	}
+	if *r.model != em {
+		return fmt.Errorf("%w: %s (%s) instead of %s (%s)",
+			ErrMixedModels, em.ID(), em.Normalization, r.model.ID(), r.model.Normalization)
+	}
+	return nil
+}
+
+// run refreshes replicas every interval until ctx is done
+func (r *replicaSet) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.refresh(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("failed to refresh embedder replicas", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// record counts transient failures of a replica in a row, successes reset them
+func (r *replicaSet) record(addr string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !slices.Contains(r.addrs, addr) {
+		return
+	}
+	o, ok := r.outliers[addr]
+	if !ok {
+		o = &outlier{}
+		r.outliers[addr] = o
+	}
+
+	now := r.now()
+	if !failed {
+		o.failures = 0
+		if !now.Before(o.ejectedUntil) {
+			o.ejections = 0
+		}
+		return
+	}
+
+	o.failures++
+	if o.failures < r.threshold || now.Before(o.ejectedUntil) {
+		return
+	}
+	// Ejecting too many would overload the rest
+	if r.ejectedLocked(now)+1 > len(r.addrs)*r.maxEjectedPercent/100 {
+		return
+	}
+
+	o.failures = 0
+	o.ejections++
+	d := r.ejectionTime * time.Duration(min(o.ejections, maxEjectionTimeMultiplier))
+	o.ejectedUntil = now.Add(d)
+	r.publishLocked()
+	time.AfterFunc(d, r.publish)
+
+	r.logger.Warn("embedder replica is ejected", slog.String("addr", addr), slog.Duration("for", d))
+}
+
+func (r *replicaSet) ejectedLocked(now time.Time) int {
+	n := 0
+	for _, o := range r.outliers {
+		if now.Before(o.ejectedUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *replicaSet) publish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publishLocked()
+}
+
+func (r *replicaSet) publishLocked() {
+	now := r.now()
+	addrs := make([]resolver.Address, 0, len(r.addrs))
+	for _, addr := range r.addrs {
+		if o, ok := r.outliers[addr]; ok && now.Before(o.ejectedUntil) {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{Addr: addr})
+	}
+	r.resolver.UpdateState(resolver.State{Addresses: addrs})
+}
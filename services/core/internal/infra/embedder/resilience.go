@@ -0,0 +1,155 @@
+package infra_embedder
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/gen/proto"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultTimeout          = 5 * time.Second
+	DefaultBatchTimeout     = time.Minute
+	DefaultRetries          = 2
+	DefaultRetryBackoff     = 100 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+
+	maxRetryBackoff = 2 * time.Second
+)
+
+// WithTimeout limits every attempt of a call, batch is a single call however large it is
+func WithTimeout(call, batch time.Duration) Option {
+	return func(e *Embedder) {
+		if call > 0 {
+			e.timeout = call
+		}
+		if batch > 0 {
+			e.batchTimeout = batch
+		}
+	}
+}
+
+// WithRetries retries transient failures that many times, waiting up to doubling backoff in between
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(e *Embedder) {
+		if n >= 0 {
+			e.retries = n
+		}
+		if backoff > 0 {
+			e.backoff = backoff
+		}
+	}
+}
+
+// WithBreaker fails calls fast for cooldown after threshold consecutive transient failures, zero threshold disables it
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(e *Embedder) {
+		switch {
+		case threshold <= 0:
+			e.breaker = nil
+		case cooldown > 0:
+			e.breaker = newBreaker(threshold, cooldown)
+		}
+	}
+}
+
+// WithResolveInterval sets how often hosts are resolved again to find replicas added or gone
+func WithResolveInterval(d time.Duration) Option {
+	return func(e *Embedder) {
+		if d > 0 {
+			e.resolveInterval = d
+		}
+	}
+}
+
+// WithEjection takes a replica out of balancing after threshold transient failures in a row.
+// It is back after ejection time, which grows with every ejection. At most half of replicas are ejected.
+func WithEjection(threshold int, ejectionTime time.Duration) Option {
+	return func(e *Embedder) {
+		if threshold > 0 {
+			e.replicas.threshold = threshold
+		}
+		if ejectionTime > 0 {
+			e.replicas.ejectionTime = ejectionTime
+		}
+	}
+}
+
+// All embedder calls are idempotent, so every one of them is retried
+func (e *Embedder) intercept(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	timeout := e.timeout
+	if method == proto.EmbeddingService_BatchCreateMovieEmbeddings_FullMethodName {
+		timeout = e.batchTimeout
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := e.breaker.allow(); err != nil {
+			return err
+		}
+
+		var replica peer.Peer
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := invoker(callCtx, method, req, reply, cc, append(opts, grpc.Peer(&replica))...)
+		cancel()
+		if replica.Addr != nil {
+			e.replicas.record(replica.Addr.String(), err != nil && ctx.Err() == nil && transient(err))
+		}
+
+		switch {
+		case err == nil:
+			e.breaker.success()
+			return nil
+		case ctx.Err() != nil:
+			// Caller gave up, that says nothing about embedder
+			e.breaker.release()
+			return err
+		case !transient(err):
+			// Embedder is up and refused the request itself
+			e.breaker.success()
+			return err
+		}
+
+		e.breaker.failure()
+		if attempt >= e.retries {
+			return fmt.Errorf("%w: %w", model.ErrEmbedderUnavailable, err)
+		}
+
+		select {
+		case <-time.After(jitter(e.backoff, attempt)):
+		case <-ctx.Done():
+			return fmt.Errorf("%w after %d attempts: %w", ctx.Err(), attempt+1, err)
+		}
+	}
+}
+
+func transient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// jitter picks uniformly up to backoff doubled on every attempt, so callers failed together don't retry together
+func jitter(backoff time.Duration, attempt int) time.Duration {
+	ceiling := maxRetryBackoff
+	if attempt < 16 {
+		ceiling = min(backoff<<attempt, maxRetryBackoff)
+	}
+	return rand.N(ceiling) + 1
+}
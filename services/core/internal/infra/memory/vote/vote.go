@@ -0,0 +1,378 @@
+// Package infra_memory_vote picks voting candidates from in-memory HNSW index, with no vector compared by database.
+// It's meant for small or offline deployments, every instance keeps the whole catalog in memory.
+package infra_memory_vote
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/hnsw"
+	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	"github.com/lib/pq"
+)
+
+const (
+	DefaultSyncInterval = 5 * time.Minute
+	// Notified with id of every inserted, updated or deleted movie
+	changesChannel = "movie_changes"
+	// Changes are applied once no more arrive for that long
+	changesQuiet = 100 * time.Millisecond
+	// More pending changes than that are applied by reconciling the whole catalog
+	maxPendingChanges = 1000
+)
+
+// Backend serves everything but candidates and tells which movies may be candidates
+type Backend interface {
+	usecase_vote.VoteRepository
+	// Ready movies with vectors among ids, all of them if ids is nil
+	ReadyMovies(ctx context.Context, ids []uuid.UUID) ([]model.EmbeddedMovie, error)
+	CandidatePool(ctx context.Context, roomID uuid.UUID) (model.CandidatePool, error)
+}
+
+// Repository overrides SimilarMovies and NextMovie of backend
+type Repository struct {
+	Backend
+
+	snapshot     string
+	syncInterval time.Duration
+	listener     *pq.Listener
+	indexOpts    []hnsw.Option
+	logger       *slog.Logger
+
+	mu     sync.RWMutex
+	index  *hnsw.Index
+	movies map[uuid.UUID]*model.MovieMeta
+	// Index changed since the last snapshot
+	dirty bool
+}
+
+type Option func(*Repository)
+
+// WithSnapshot saves index to path after every sync it changed, so restart doesn't build it again
+func WithSnapshot(path string) Option {
+	return func(r *Repository) {
+		r.snapshot = path
+	}
+}
+
+// WithSyncInterval sets how often index is reconciled with the whole catalog
+func WithSyncInterval(d time.Duration) Option {
+	return func(r *Repository) {
+		if d > 0 {
+			r.syncInterval = d
+		}
+	}
+}
+
+// WithListener applies changes of movies as soon as database notifies about them, see 000016_movie_changes
+func WithListener(l *pq.Listener) Option {
+	return func(r *Repository) {
+		r.listener = l
+	}
+}
+
+func WithIndexOptions(opts ...hnsw.Option) Option {
+	return func(r *Repository) {
+		r.indexOpts = opts
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Repository) {
+		r.logger = logger
+	}
+}
+
+func New(backend Backend, opts ...Option) *Repository {
+	r := &Repository{
+		Backend:      backend,
+		syncInterval: DefaultSyncInterval,
+		logger:       slog.Default(),
+		movies:       make(map[uuid.UUID]*model.MovieMeta),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.index = hnsw.New(r.indexOpts...)
+	return r
+}
+
+// Load restores index from snapshot, if any, and brings it up to date with catalog
+func (r *Repository) Load(ctx context.Context) error {
+	if r.snapshot != "" {
+		index, err := r.loadSnapshot()
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			r.logger.Warn("vector index snapshot is ignored", slog.String("path", r.snapshot), slog.String("error", err.Error()))
+		default:
+			r.mu.Lock()
+			r.index = index
+			r.mu.Unlock()
+		}
+	}
+
+	return r.Reconcile(ctx)
+}
+
+// Run reconciles index every sync interval and applies notified changes until ctx is done
+func (r *Repository) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.syncInterval)
+	defer ticker.Stop()
+
+	var notify <-chan *pq.Notification
+	if r.listener != nil {
+		if err := r.listener.Listen(changesChannel); err != nil {
+			r.logger.Error("failed to listen to movie changes", slog.String("error", err.Error()))
+		} else {
+			notify = r.listener.Notify
+		}
+	}
+
+	pending := make(map[uuid.UUID]bool)
+	quiet := time.NewTimer(changesQuiet)
+	quiet.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.sync(ctx, r.Reconcile)
+		case n := <-notify:
+			// Nil after reconnect, changes could be missed meanwhile
+			if n == nil {
+				clear(pending)
+				r.sync(ctx, r.Reconcile)
+				continue
+			}
+			id, err := uuid.Parse(n.Extra)
+			if err != nil {
+				continue
+			}
+			pending[id] = true
+			quiet.Reset(changesQuiet)
+		case <-quiet.C:
+			ids := make([]uuid.UUID, 0, len(pending))
+			for id := range pending {
+				ids = append(ids, id)
+			}
+			clear(pending)
+			if len(ids) > maxPendingChanges {
+				r.sync(ctx, r.Reconcile)
+				continue
+			}
+			r.sync(ctx, func(ctx context.Context) error { return r.Apply(ctx, ids) })
+		}
+	}
+}
+
+func (r *Repository) sync(ctx context.Context, fn func(ctx context.Context) error) {
+	if err := fn(ctx); err != nil && ctx.Err() == nil {
+		r.logger.Error("failed to sync vector index", slog.String("error", err.Error()))
+	}
+}
+
+// Reconcile adds, moves and removes movies, so index has exactly ready ones.
+// Index is built anew if most of vectors changed, as after re-embedding.
+func (r *Repository) Reconcile(ctx context.Context) error {
+	movies, err := r.Backend.ReadyMovies(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	index := r.index
+	r.mu.RUnlock()
+
+	changed := 0
+	for _, m := range movies {
+		if v, ok := index.Vector(m.Movie.ID); !ok || !sameDirection(v, m.Vector) {
+			changed++
+		}
+	}
+	if changed > len(movies)/2 {
+		index = hnsw.New(r.indexOpts...)
+	}
+
+	ready := make(map[uuid.UUID]*model.MovieMeta, len(movies))
+	for _, m := range movies {
+		if err := index.Add(m.Movie.ID, m.Vector); err != nil {
+			return err
+		}
+		ready[m.Movie.ID] = m.Movie
+	}
+
+	// Snapshot may keep movies which aren't ready anymore
+	for _, id := range index.IDs() {
+		if _, ok := ready[id]; !ok {
+			index.Delete(id)
+			changed++
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index, r.movies = index, ready
+	r.dirty = r.dirty || changed > 0
+	r.logger.Info("vector index is reconciled", slog.Int("movies", len(ready)), slog.Int("changed", changed))
+
+	return r.saveLocked()
+}
+
+// Apply brings movies of ids up to date, ones not ready anymore are removed.
+// Node is re-added only if vector changed, edits of the rest just replace the served copy.
+func (r *Repository) Apply(ctx context.Context, ids []uuid.UUID) error {
+	movies, err := r.Backend.ReadyMovies(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ready := make(map[uuid.UUID]bool, len(movies))
+	for _, m := range movies {
+		if v, ok := r.index.Vector(m.Movie.ID); !ok || !sameDirection(v, m.Vector) {
+			if err := r.index.Add(m.Movie.ID, m.Vector); err != nil {
+				return err
+			}
+			r.dirty = true
+		}
+		r.movies[m.Movie.ID] = m.Movie
+		ready[m.Movie.ID] = true
+	}
+	for _, id := range ids {
+		if !ready[id] {
+			if _, ok := r.index.Vector(id); ok {
+				r.index.Delete(id)
+				r.dirty = true
+			}
+			delete(r.movies, id)
+		}
+	}
+
+	return nil
+}
+
+// SimilarMovies keeps the pool of room as the database query does
+func (r *Repository) SimilarMovies(ctx context.Context, roomID uuid.UUID, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error) {
+	pool, err := r.Backend.CandidatePool(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.search(queryEmbedding, limit, pool, nil)
+}
+
+func (r *Repository) NextMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, queryEmbedding []float32) (*model.MovieMeta, error) {
+	pool, err := r.Backend.CandidatePool(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	reacted, err := r.Backend.Reacted(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	movies, err := r.search(queryEmbedding, 1, pool, reacted)
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, usecase_vote.ErrResourceNotFound
+	}
+	return movies[0], nil
+}
+
+func (r *Repository) search(q model.Embedding, limit int, pool model.CandidatePool, excluded []uuid.UUID) ([]*model.MovieMeta, error) {
+	var allowed map[uuid.UUID]bool
+	if pool.Allowed != nil {
+		allowed = set(pool.Allowed)
+	}
+	rejected := set(slices.Concat(excluded, pool.Watched))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hits, err := r.index.Search(q, limit, func(id uuid.UUID) bool {
+		return (allowed == nil || allowed[id]) && !rejected[id]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	movies := make([]*model.MovieMeta, 0, len(hits))
+	for _, hit := range hits {
+		if mm, ok := r.movies[hit.ID]; ok {
+			copied := *mm
+			movies = append(movies, &copied)
+		}
+	}
+	return movies, nil
+}
+
+func (r *Repository) loadSnapshot() (*hnsw.Index, error) {
+	f, err := os.Open(r.snapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return hnsw.Load(f, r.indexOpts...)
+}
+
+// saveLocked writes snapshot next to the old one and renames it, so crash never leaves it half written
+func (r *Repository) saveLocked() error {
+	if r.snapshot == "" || !r.dirty {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(r.snapshot), filepath.Base(r.snapshot)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := r.index.Save(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), r.snapshot); err != nil {
+		return err
+	}
+
+	r.dirty = false
+	return nil
+}
+
+func set(ids []uuid.UUID) map[uuid.UUID]bool {
+	s := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		s[id] = true
+	}
+	return s
+}
+
+// sameDirection compares unit vector of index to vector of catalog
+func sameDirection(unit, v model.Embedding) bool {
+	if len(unit) != len(v) {
+		return false
+	}
+	var dot, norm float64
+	for i := range v {
+		dot += float64(unit[i]) * float64(v[i])
+		norm += float64(v[i]) * float64(v[i])
+	}
+	return norm > 0 && dot*dot >= norm*(1-1e-9) && dot > 0
+}
@@ -0,0 +1,233 @@
+//go:build !integration
+// +build !integration
+
+package infra_memory_vote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MemoryVoteUnitSuite struct {
+	suite.Suite
+}
+
+// fakeBackend is the catalog of ready movies, the rest of repository is never called
+type fakeBackend struct {
+	usecase_vote.VoteRepository
+
+	mu      sync.Mutex
+	movies  map[uuid.UUID]model.Embedding
+	titles  map[uuid.UUID]string
+	pool    model.CandidatePool
+	reacted []uuid.UUID
+}
+
+func (b *fakeBackend) ReadyMovies(_ context.Context, ids []uuid.UUID) ([]model.EmbeddedMovie, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var movies []model.EmbeddedMovie
+	for id, v := range b.movies {
+		if ids == nil || slices.Contains(ids, id) {
+			title, ok := b.titles[id]
+			if !ok {
+				title = id.String()
+			}
+			movies = append(movies, model.EmbeddedMovie{Movie: &model.MovieMeta{ID: id, Title: title}, Vector: v})
+		}
+	}
+	return movies, nil
+}
+
+func (b *fakeBackend) CandidatePool(context.Context, uuid.UUID) (model.CandidatePool, error) {
+	return b.pool, nil
+}
+
+func (b *fakeBackend) Reacted(context.Context, uuid.UUID, uuid.UUID) ([]uuid.UUID, error) {
+	return b.reacted, nil
+}
+
+func (b *fakeBackend) set(id uuid.UUID, v model.Embedding) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v == nil {
+		delete(b.movies, id)
+		return
+	}
+	b.movies[id] = v
+}
+
+// The further in the list, the further from query
+var (
+	query   = model.Embedding{1, 0}
+	vectors = []model.Embedding{{1, 0}, {0.9, 0.1}, {0.7, 0.3}, {0.5, 0.5}, {0.3, 0.7}, {0, 1}}
+)
+
+func newBackend() (*fakeBackend, []uuid.UUID) {
+	b := &fakeBackend{movies: make(map[uuid.UUID]model.Embedding)}
+	ids := make([]uuid.UUID, len(vectors))
+	for i, v := range vectors {
+		ids[i] = uuid.New()
+		b.movies[ids[i]] = v
+	}
+	return b, ids
+}
+
+func titles(movies []*model.MovieMeta) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func (s *MemoryVoteUnitSuite) TestSimilarMovies(t provider.T) {
+	t.Parallel()
+
+	b, ids := newBackend()
+	r := New(b)
+	require.NoError(t, r.Load(context.Background()))
+
+	testCases := []struct {
+		name string
+		pool model.CandidatePool
+		want []uuid.UUID
+	}{
+		{
+			name: "Should return the closest movies",
+			want: ids[:3],
+		},
+		{
+			name: "Should keep collections of room",
+			pool: model.CandidatePool{Allowed: []uuid.UUID{ids[5], ids[2]}},
+			want: []uuid.UUID{ids[2], ids[5]},
+		},
+		{
+			name: "Should return nothing for empty collections",
+			pool: model.CandidatePool{Allowed: []uuid.UUID{}},
+			want: []uuid.UUID{},
+		},
+		{
+			name: "Should skip watched movies",
+			pool: model.CandidatePool{Watched: []uuid.UUID{ids[0], ids[2]}},
+			want: []uuid.UUID{ids[1], ids[3], ids[4]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+
+			r := New(&fakeBackend{movies: b.movies, pool: tc.pool})
+			require.NoError(t, r.Load(context.Background()))
+
+			movies, err := r.SimilarMovies(context.Background(), uuid.New(), query, 3)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, titles(movies))
+		})
+	}
+
+	movies, err := r.SimilarMovies(context.Background(), uuid.New(), model.Embedding{1, 0, 0}, 3)
+	assert.Error(t, err, "query of other dimension")
+	assert.Empty(t, movies)
+}
+
+func (s *MemoryVoteUnitSuite) TestNextMovie(t provider.T) {
+	t.Parallel()
+
+	b, ids := newBackend()
+	b.pool = model.CandidatePool{Watched: []uuid.UUID{ids[1]}}
+	b.reacted = []uuid.UUID{ids[0], ids[2]}
+	r := New(b)
+	require.NoError(t, r.Load(context.Background()))
+
+	movie, err := r.NextMovie(context.Background(), uuid.New(), uuid.New(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, ids[3], movie.ID, "watched and reacted movies are skipped")
+
+	b.reacted = ids
+	_, err = r.NextMovie(context.Background(), uuid.New(), uuid.New(), query)
+	assert.ErrorIs(t, err, usecase_vote.ErrResourceNotFound)
+}
+
+func (s *MemoryVoteUnitSuite) TestSync(t provider.T) {
+	t.Parallel()
+
+	b, ids := newBackend()
+	snapshot := filepath.Join(t.TempDir(), "index.gob")
+	r := New(b, WithSnapshot(snapshot))
+	require.NoError(t, r.Load(context.Background()))
+	_, err := os.Stat(snapshot)
+	assert.NoError(t, err, "snapshot is saved after load")
+
+	// The closest movie is gone, the furthest one moves to query
+	added := uuid.New()
+	b.set(ids[0], nil)
+	b.set(ids[5], query)
+	b.set(added, model.Embedding{0.95, 0.05})
+
+	movies, err := r.SimilarMovies(context.Background(), uuid.New(), query, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, ids[:2], titles(movies), "changes aren't seen before sync")
+
+	assert.NoError(t, r.Apply(context.Background(), []uuid.UUID{ids[0], ids[5]}))
+	movies, err = r.SimilarMovies(context.Background(), uuid.New(), query, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[5], ids[1]}, titles(movies), "applied changes are seen")
+
+	assert.NoError(t, r.Reconcile(context.Background()))
+	movies, err = r.SimilarMovies(context.Background(), uuid.New(), query, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ids[5], added}, titles(movies), "reconcile finds missed changes")
+
+	// Restarted instance starts from snapshot, the movie deleted meanwhile is dropped
+	b.set(ids[5], nil)
+	restarted := New(b, WithSnapshot(snapshot))
+	require.NoError(t, restarted.Load(context.Background()))
+	movies, err = restarted.SimilarMovies(context.Background(), uuid.New(), query, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{added, ids[1]}, titles(movies))
+	assert.Equal(t, len(b.movies), restarted.index.Len())
+
+	require.NoError(t, os.WriteFile(snapshot, []byte("garbage"), 0o600))
+	broken := New(b, WithSnapshot(snapshot))
+	assert.NoError(t, broken.Load(context.Background()), "broken snapshot is rebuilt")
+	assert.Equal(t, len(b.movies), broken.index.Len())
+}
+
+func (s *MemoryVoteUnitSuite) TestApplyMetadata(t provider.T) {
+	t.Parallel()
+
+	b, ids := newBackend()
+	b.titles = make(map[uuid.UUID]string)
+	r := New(b, WithSnapshot(filepath.Join(t.TempDir(), "index.gob")))
+	require.NoError(t, r.Load(context.Background()))
+
+	b.mu.Lock()
+	b.titles[ids[0]] = "Renamed"
+	b.mu.Unlock()
+
+	assert.NoError(t, r.Apply(context.Background(), []uuid.UUID{ids[0]}))
+	movie, err := r.NextMovie(context.Background(), uuid.New(), uuid.New(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0], movie.ID)
+	assert.Equal(t, "Renamed", movie.Title, "served card has the edit")
+	assert.False(t, r.dirty, "node isn't re-added for unchanged vector")
+}
+
+func TestMemoryVoteUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(MemoryVoteUnitSuite))
+}
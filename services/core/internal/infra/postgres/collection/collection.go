@@ -0,0 +1,188 @@
+package infra_postgres_collection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_collection "github.com/humanbelnik/kinoswap/core/internal/usecase/collection"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Driver struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Driver {
+	return &Driver{db: db}
+}
+
+type collectionDTO struct {
+	ID          uuid.UUID `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Size        int       `db:"size"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (c collectionDTO) toDomain() model.Collection {
+	return model.Collection{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		Size:        c.Size,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+const selectCollections = `
+	SELECT c.id, c.name, c.description, c.created_at, c.updated_at,
+		(SELECT COUNT(*) FROM collection_movies cm WHERE cm.collection_id = c.id) AS size
+	FROM collections c
+`
+
+func (d *Driver) Create(ctx context.Context, c model.Collection) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO collections (id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, c.ID, c.Name, c.Description, c.CreatedAt, c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return usecase_collection.ErrNameConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+func (d *Driver) Load(ctx context.Context, id uuid.UUID) (model.Collection, error) {
+	var c collectionDTO
+	err := d.db.GetContext(ctx, &c, selectCollections+`WHERE c.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Collection{}, usecase_collection.ErrResourceNotFound
+	}
+	if err != nil {
+		return model.Collection{}, fmt.Errorf("failed to load collection: %w", err)
+	}
+	return c.toDomain(), nil
+}
+
+func (d *Driver) LoadAll(ctx context.Context) ([]model.Collection, error) {
+	var rows []collectionDTO
+	if err := d.db.SelectContext(ctx, &rows, selectCollections+`ORDER BY c.name`); err != nil {
+		return nil, fmt.Errorf("failed to load collections: %w", err)
+	}
+
+	cs := make([]model.Collection, len(rows))
+	for i, row := range rows {
+		cs[i] = row.toDomain()
+	}
+	return cs, nil
+}
+
+func (d *Driver) Update(ctx context.Context, c model.Collection) error {
+	res, err := d.db.ExecContext(ctx, `
+		UPDATE collections SET name = $2, description = $3, updated_at = $4 WHERE id = $1
+	`, c.ID, c.Name, c.Description, c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return usecase_collection.ErrNameConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	return requireAffected(res)
+}
+
+func (d *Driver) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM collections WHERE id = $1`, id)
+	if isForeignKeyViolation(err) {
+		return usecase_collection.ErrCollectionInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return requireAffected(res)
+}
+
+// AddMovies adds all movies or none, unknown movie fails the whole call
+func (d *Driver) AddMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO collection_movies (collection_id, movie_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, id, pq.Array(movieIDs))
+	if isForeignKeyViolation(err) {
+		return usecase_collection.ErrResourceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add movies to collection: %w", err)
+	}
+
+	if err := touch(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Driver) RemoveMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM collection_movies WHERE collection_id = $1 AND movie_id = ANY($2)
+	`, id, pq.Array(movieIDs))
+	if err != nil {
+		return fmt.Errorf("failed to remove movies from collection: %w", err)
+	}
+
+	if err := touch(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// touch bumps updated_at and tells whether collection exists
+func touch(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	res, err := tx.ExecContext(ctx, `UPDATE collections SET updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	return requireAffected(res)
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return usecase_collection.ErrResourceNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
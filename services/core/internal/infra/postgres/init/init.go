@@ -9,8 +9,9 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func MustEstablishConn(cfg config.Postgres) *sqlx.DB {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+// DSN is shared by connection pool and listeners of notifications
+func DSN(cfg config.Postgres) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host,
 		cfg.Port,
 		cfg.User,
@@ -18,7 +19,10 @@ func MustEstablishConn(cfg config.Postgres) *sqlx.DB {
 		cfg.DBName,
 		cfg.SSLMode,
 	)
-	db, err := sqlx.Connect("postgres", dsn)
+}
+
+func MustEstablishConn(cfg config.Postgres) *sqlx.DB {
+	db, err := sqlx.Connect("postgres", DSN(cfg))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/lib/pq"
 )
 
+// MovieDB is a movies row, director and cast are loaded separately, see attachPeople
 type MovieDB struct {
 	ID          uuid.UUID       `db:"id"`
 	PosterLink  string          `db:"poster_link"`
@@ -14,6 +15,13 @@ type MovieDB struct {
 	Year        int             `db:"year"`
 	Rating      float64         `db:"rating"`
 	Overview    string          `db:"overview"`
+	Status      string          `db:"status"`
+	Runtime     int             `db:"runtime"`
+	Certificate string          `db:"certificate"`
+	MetaScore   int             `db:"meta_score"`
+	Votes       int             `db:"votes"`
+	Gross       int64           `db:"gross"`
+	ImdbID      string          `db:"imdb_id"`
 	MovieVector model.Embedding `db:"movie_vector"`
 }
 
@@ -26,6 +34,14 @@ func (m *MovieDB) ToDomain() model.MovieMeta {
 		Year:       m.Year,
 		Rating:     m.Rating,
 		Overview:   m.Overview,
+		Status:     model.MovieStatus(m.Status),
+
+		Runtime:     m.Runtime,
+		Certificate: m.Certificate,
+		MetaScore:   m.MetaScore,
+		Votes:       m.Votes,
+		Gross:       m.Gross,
+		ImdbID:      m.ImdbID,
 	}
 }
 
@@ -38,5 +54,13 @@ func FromDomain(mm model.MovieMeta) MovieDB {
 		Year:       mm.Year,
 		Rating:     mm.Rating,
 		Overview:   mm.Overview,
+		Status:     string(mm.Status),
+
+		Runtime:     mm.Runtime,
+		Certificate: mm.Certificate,
+		MetaScore:   mm.MetaScore,
+		Votes:       mm.Votes,
+		Gross:       mm.Gross,
+		ImdbID:      mm.ImdbID,
 	}
 }
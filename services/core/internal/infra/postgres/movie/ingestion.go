@@ -0,0 +1,200 @@
+package infra_postgres_movie
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/pgvector/pgvector-go"
+)
+
+type ingestionJobDB struct {
+	ID        uuid.UUID `db:"id"`
+	MovieID   uuid.UUID `db:"movie_id"`
+	Poster    []byte    `db:"poster"`
+	Status    string    `db:"status"`
+	Attempts  int       `db:"attempts"`
+	LastError string    `db:"last_error"`
+	NextRunAt time.Time `db:"next_run_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (j ingestionJobDB) toDomain() model.IngestionJob {
+	return model.IngestionJob{
+		ID:        j.ID,
+		MovieID:   j.MovieID,
+		Poster:    j.Poster,
+		Status:    model.IngestionStatus(j.Status),
+		Attempts:  j.Attempts,
+		LastError: j.LastError,
+		NextRunAt: j.NextRunAt,
+		CreatedAt: j.CreatedAt,
+	}
+}
+
+// EnqueueIngestion stores movie and its ingestion job together, so no movie is left pending forever
+func (r *Repository) EnqueueIngestion(ctx context.Context, mm model.MovieMeta, poster []byte) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertMovie(ctx, tx, mm); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO ingestion_jobs (movie_id, poster) VALUES ($1, $2)`, mm.ID, poster)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue ingestion: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ClaimIngestion locks due jobs for lease. Jobs of crashed workers are claimed again once their lease expires.
+func (r *Repository) ClaimIngestion(ctx context.Context, limit int, lease time.Duration) ([]model.IngestionJob, error) {
+	var jobs []ingestionJobDB
+	err := r.db.SelectContext(ctx, &jobs, `
+		WITH due AS (
+			SELECT id FROM ingestion_jobs
+			WHERE (status = 'queued' AND next_run_at <= NOW())
+				OR (status = 'running' AND locked_until < NOW())
+			ORDER BY next_run_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE ingestion_jobs j SET
+			status = 'running',
+			attempts = j.attempts + 1,
+			locked_until = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		FROM due
+		WHERE j.id = due.id
+		RETURNING j.id, j.movie_id, j.poster, j.status, j.attempts, j.last_error, j.next_run_at, j.created_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim ingestion jobs: %w", err)
+	}
+
+	claimed := make([]model.IngestionJob, len(jobs))
+	for i, job := range jobs {
+		claimed[i] = job.toDomain()
+	}
+	return claimed, nil
+}
+
+// CompleteIngestion makes movie ready. Returns false if movie was deleted meanwhile.
+func (r *Repository) CompleteIngestion(ctx context.Context, job model.IngestionJob, posterLink string, e model.Embedding, modelID string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE movies SET
+			poster_link = COALESCE(NULLIF($2, ''), poster_link),
+			movie_vector = $3, movie_vector_model = NULLIF($4, ''),
+			movie_vector_next = NULL, movie_vector_next_model = NULL,
+			status = 'READY'
+		WHERE id = $1
+	`, job.MovieID, posterLink, pgvector.NewVector(e), modelID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update movie: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ingestion_jobs SET status = 'done', poster = NULL, last_error = '',
+			locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, job.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete ingestion job: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// FailIngestion schedules job retry at nextRunAt. Dead job marks its movie failed.
+func (r *Repository) FailIngestion(ctx context.Context, job model.IngestionJob, reason string, nextRunAt time.Time, dead bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	status := model.IngestionQueued
+	if dead {
+		status = model.IngestionDead
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ingestion_jobs SET status = $2, last_error = $3, next_run_at = $4,
+			locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, job.ID, status, reason, nextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to update ingestion job: %w", err)
+	}
+
+	if dead {
+		_, err := tx.ExecContext(ctx, `UPDATE movies SET status = 'FAILED' WHERE id = $1`, job.MovieID)
+		if err != nil {
+			return fmt.Errorf("failed to mark movie failed: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RetryIngestion requeues dead job of movie with fresh attempts. Returns false if there is none.
+func (r *Repository) RetryIngestion(ctx context.Context, movieID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE ingestion_jobs SET status = 'queued', attempts = 0, next_run_at = NOW(), updated_at = NOW()
+		WHERE movie_id = $1 AND status = 'dead'
+	`, movieID)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue ingestion job: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE movies SET status = 'PENDING' WHERE id = $1`, movieID); err != nil {
+		return false, fmt.Errorf("failed to mark movie pending: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// DeadIngestions lists dead letters, the most recent first. Posters aren't loaded.
+func (r *Repository) DeadIngestions(ctx context.Context) ([]model.IngestionJob, error) {
+	var jobs []ingestionJobDB
+	err := r.db.SelectContext(ctx, &jobs, `
+		SELECT id, movie_id, status, attempts, last_error, next_run_at, created_at
+		FROM ingestion_jobs
+		WHERE status = 'dead'
+		ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead ingestion jobs: %w", err)
+	}
+
+	dead := make([]model.IngestionJob, len(jobs))
+	for i, job := range jobs {
+		dead[i] = job.toDomain()
+	}
+	return dead, nil
+}
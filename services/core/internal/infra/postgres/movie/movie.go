@@ -2,55 +2,100 @@ package infra_postgres_movie
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	infra_postgres_vector "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/vector"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
 
 type Repository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	search infra_postgres_vector.Search
 }
 
-func New(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+type Option func(*Repository)
+
+// WithVectorSearch sets metric movies are ordered by, it has to match vector index
+func WithVectorSearch(s infra_postgres_vector.Search) Option {
+	return func(r *Repository) {
+		r.search = s
+	}
+}
+
+func New(db *sqlx.DB, opts ...Option) *Repository {
+	r := &Repository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-func (d *Repository) StoreEmbedding(ctx context.Context, movieID uuid.UUID, e model.Embedding) error {
+// StoreEmbedding drops vector built by running re-embed job, it's stale once the movie is re-embedded here
+func (d *Repository) StoreEmbedding(ctx context.Context, movieID uuid.UUID, e model.Embedding, modelID string) error {
 	v := pgvector.NewVector(e)
 	_, err := d.db.ExecContext(ctx,
 		`UPDATE movies
-	SET movie_vector = $1
-	WHERE id = $2`, v, movieID,
+	SET movie_vector = $1, movie_vector_model = NULLIF($3, ''),
+		movie_vector_next = NULL, movie_vector_next_model = NULL
+	WHERE id = $2`, v, movieID, modelID,
 	)
 
 	return err
 }
 
 func (r *Repository) Store(ctx context.Context, mm model.MovieMeta) error {
-	movieDB := FromDomain(mm)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertMovie(ctx, tx, mm); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) Update(ctx context.Context, mm model.MovieMeta) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
 
 	query := `
-		INSERT INTO movies (id, title, year, rating, genres, overview, poster_link)
-		VALUES (:id, :title, :year, :rating, :genres, :overview, :poster_link)
+		UPDATE movies
+		SET title = :title, year = :year, rating = :rating, genres = :genres,
+			overview = :overview, poster_link = :poster_link,
+			runtime = :runtime, certificate = :certificate, meta_score = :meta_score,
+			votes = :votes, gross = :gross, imdb_id = :imdb_id
+		WHERE id = :id
 	`
 
-	_, err := r.db.NamedExecContext(ctx, query, movieDB)
-	if err != nil {
+	if _, err := tx.NamedExecContext(ctx, query, FromDomain(mm)); err != nil {
+		if isUniqueViolation(err) {
+			return usecase_movie.ErrDuplicate
+		}
+		return err
+	}
+	if err := storePeople(ctx, tx, mm); err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (r *Repository) LoadAll(ctx context.Context) ([]*model.MovieMeta, error) {
-	query := `
-		SELECT id, title, year, rating, genres, overview, poster_link
-		FROM movies
-	`
+	query := `SELECT ` + movieColumns + ` FROM movies`
 
 	var moviesDB []MovieDB
 	err := r.db.SelectContext(ctx, &moviesDB, query)
@@ -64,17 +109,121 @@ func (r *Repository) LoadAll(ctx context.Context) ([]*model.MovieMeta, error) {
 		movies[i] = &domainMovie
 	}
 
+	if err := attachPeople(ctx, r.db, movies); err != nil {
+		return nil, err
+	}
 	return movies, nil
 }
 
+// Sort fields are whitelisted, they go into query as is
+var sortColumns = map[model.MovieSortField]string{
+	model.MovieSortTitle:  "lower(title)",
+	model.MovieSortYear:   "year",
+	model.MovieSortRating: "rating",
+}
+
+// filterConds appends filter arguments to args and returns conditions referencing them
+func filterConds(f model.MovieFilter, args []any) ([]string, []any) {
+	var conds []string
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if len(f.Genres) > 0 {
+		add("genres @> $%d", pq.StringArray(f.Genres))
+	}
+	if f.YearFrom != nil {
+		add("year >= $%d", *f.YearFrom)
+	}
+	if f.YearTo != nil {
+		add("year <= $%d", *f.YearTo)
+	}
+	if f.RatingMin != nil {
+		add("rating >= $%d", *f.RatingMin)
+	}
+	if f.RatingMax != nil {
+		add("rating <= $%d", *f.RatingMax)
+	}
+	if f.Director != nil {
+		add(personCond(roleDirector), escapeLike(*f.Director))
+	}
+	if f.Actor != nil {
+		add(personCond(roleCast), escapeLike(*f.Actor))
+	}
+	if f.RuntimeMin != nil {
+		add("runtime >= $%d", *f.RuntimeMin)
+	}
+	if f.RuntimeMax != nil {
+		add("runtime > 0 AND runtime <= $%d", *f.RuntimeMax)
+	}
+	if len(f.Collections) > 0 {
+		add("movies.id IN (SELECT movie_id FROM collection_movies WHERE collection_id = ANY($%d))", pq.Array(f.Collections))
+	}
+
+	return conds, args
+}
+
+// Pending movies have neither poster nor vector yet, catalog and search show ready ones only
+const readyCond = "movies.status = 'READY'"
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+func (r *Repository) LoadPage(ctx context.Context, q model.MovieQuery) (model.MoviePage, error) {
+	conds, args := filterConds(q.Filter, nil)
+	where := whereClause(append(conds, readyCond))
+
+	var total int
+	err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM movies "+where, args...)
+	if err != nil {
+		return model.MoviePage{}, fmt.Errorf("failed to count movies: %w", err)
+	}
+
+	column, ok := sortColumns[q.SortBy]
+	if !ok {
+		column = sortColumns[model.MovieSortTitle]
+	}
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	args = append(args, q.Limit, q.Offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM movies
+		%s
+		ORDER BY %s %s NULLS LAST, id
+		LIMIT $%d OFFSET $%d
+	`, movieColumns, where, column, direction, len(args)-1, len(args))
+
+	var moviesDB []MovieDB
+	if err := r.db.SelectContext(ctx, &moviesDB, query, args...); err != nil {
+		return model.MoviePage{}, fmt.Errorf("failed to load movies page: %w", err)
+	}
+
+	movies := make([]*model.MovieMeta, len(moviesDB))
+	for i, movieDB := range moviesDB {
+		domainMovie := movieDB.ToDomain()
+		movies[i] = &domainMovie
+	}
+
+	if err := attachPeople(ctx, r.db, movies); err != nil {
+		return model.MoviePage{}, err
+	}
+	return model.MoviePage{Movies: movies, Total: total}, nil
+}
+
 func (r *Repository) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.MovieMeta, error) {
-	query := `
-		SELECT id, title, year, rating, genres, overview, poster_link
-		FROM movies WHERE id = ANY($1)
-	`
+	query := `SELECT ` + movieColumns + ` FROM movies WHERE id = ANY($1)`
 
 	var moviesDB []MovieDB
-	err := r.db.SelectContext(ctx, &moviesDB, query, ids)
+	err := r.db.SelectContext(ctx, &moviesDB, query, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +234,9 @@ func (r *Repository) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.Mo
 		movies[i] = &domainMovie
 	}
 
+	if err := attachPeople(ctx, r.db, movies); err != nil {
+		return nil, err
+	}
 	return movies, nil
 }
 
@@ -112,30 +264,245 @@ func (r *Repository) Delete(ctx context.Context, ID uuid.UUID) error {
 	return nil
 }
 
-func (r *Repository) KNN(ctx context.Context, k int, e model.Embedding) ([]*model.MovieMeta, error) {
+// KNN scores movies by similarity to e in configured metric
+func (r *Repository) KNN(ctx context.Context, k int, e model.Embedding, f model.MovieFilter) ([]model.ScoredMovie, error) {
 	if k <= 0 {
 		return nil, errors.New("k must be positive")
 	}
 
-	query := `
-		SELECT id, title, year, rating, genres, overview, poster_link
-		FROM movies 
-		WHERE movie_vector IS NOT NULL
-		ORDER BY movie_vector <=> $1
+	conds, args := filterConds(f, []any{pgvector.NewVector(e), k})
+	conds = append(conds, readyCond, "movie_vector IS NOT NULL")
+	where := whereClause(r.withCandidates(conds, "$1"))
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			%s AS score
+		FROM movies
+		%s
+		ORDER BY %s
 		LIMIT $2
-	`
+	`, movieColumns, r.search.Similarity("movie_vector", "$1"), where, r.search.Distance("movie_vector", "$1"))
 
-	var moviesDB []MovieDB
-	err := r.db.SelectContext(ctx, &moviesDB, query, e, k)
+	var rows []scoredMovieDB
+	err := r.search.Query(ctx, r.db, func(q sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, q, &rows, query, args...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query KNN: %w", err)
 	}
 
-	movies := make([]*model.MovieMeta, len(moviesDB))
-	for i, movieDB := range moviesDB {
-		domainMovie := movieDB.ToDomain()
-		movies[i] = &domainMovie
+	return r.scoredWithPeople(ctx, rows)
+}
+
+// LexicalSearch ranks movies by full-text match of title and overview, see movies_search_tsv_idx
+func (r *Repository) LexicalSearch(ctx context.Context, k int, text string, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
 	}
 
-	return movies, nil
+	conds, args := filterConds(f, []any{text, k})
+	where := whereClause(append(conds, readyCond, "search_tsv @@ q"))
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			ts_rank_cd(search_tsv, q) AS score
+		FROM movies, websearch_to_tsquery('english', $1) AS q
+		%s
+		ORDER BY score DESC, id
+		LIMIT $2
+	`, movieColumns, where)
+
+	var rows []scoredMovieDB
+	err := r.db.SelectContext(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lexical search: %w", err)
+	}
+
+	return r.scoredWithPeople(ctx, rows)
+}
+
+type scoredMovieDB struct {
+	MovieDB
+	Score float64 `db:"score"`
+}
+
+func toScoredMovies(rows []scoredMovieDB) []model.ScoredMovie {
+	movies := make([]model.ScoredMovie, len(rows))
+	for i, row := range rows {
+		domainMovie := row.ToDomain()
+		movies[i] = model.ScoredMovie{Movie: &domainMovie, Score: row.Score}
+	}
+	return movies
+}
+
+func (r *Repository) scoredWithPeople(ctx context.Context, rows []scoredMovieDB) ([]model.ScoredMovie, error) {
+	hits := toScoredMovies(rows)
+	if err := attachPeopleScored(ctx, r.db, hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// Neighbours reads precomputed neighbours, so filters narrow down the stored top only
+func (r *Repository) Neighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	conds, args := filterConds(f, []any{id, limit})
+
+	query := fmt.Sprintf(`
+		SELECT movies.%s, n.score
+		FROM movie_neighbours n
+		JOIN movies ON movies.id = n.neighbour_id
+		%s
+		ORDER BY n.rank
+		LIMIT $2
+	`, movieColumns, whereClause(append(conds, readyCond, "n.movie_id = $1")))
+
+	var rows []scoredMovieDB
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load neighbours: %w", err)
+	}
+
+	return r.scoredWithPeople(ctx, rows)
+}
+
+// LiveNeighbours runs KNN on movie's own vector, for movies added after the last refresh
+func (r *Repository) LiveNeighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	conds, args := filterConds(f, []any{id, limit})
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			%s AS score
+		FROM movies, (SELECT movie_vector AS v FROM movies WHERE id = $1) src
+		%s
+		ORDER BY %s
+		LIMIT $2
+	`, movieColumns, r.search.Similarity("movie_vector", "src.v"),
+		whereClause(r.withCandidates(append(conds, readyCond, "id <> $1", "movie_vector IS NOT NULL"), sourceVector)),
+		r.search.Distance("movie_vector", "src.v"))
+
+	var rows []scoredMovieDB
+	err := r.search.Query(ctx, r.db, func(q sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, q, &rows, query, args...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query neighbours: %w", err)
+	}
+
+	return r.scoredWithPeople(ctx, rows)
+}
+
+// Vector of movie $1, as a subquery it's read once rather than for every row
+const sourceVector = "(SELECT movie_vector FROM movies WHERE id = $1)"
+
+// withCandidates narrows movies matching conds to the closest to arg by quantized vectors, limited by $2
+func (r *Repository) withCandidates(conds []string, arg string) []string {
+	if c := r.search.Candidates("movies", whereClause(conds), "movie_vector", arg, "$2"); c != "" {
+		return append(conds, "id IN ("+c+")")
+	}
+	return conds
+}
+
+// Movies whose neighbours are replaced by one transaction of refresh
+const refreshBatchSize = 256
+
+// RefreshNeighbours rebuilds top k neighbours of every movie, a batch of movies per short transaction,
+// so readers see either old or new list of a movie and nothing is locked for the whole catalog.
+// Returns false if another instance is refreshing.
+func (r *Repository) RefreshNeighbours(ctx context.Context, k int) (bool, error) {
+	// Session lock outlives batch transactions, so connection is held till refresh is over
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock(hashtext('movie_neighbours'))`); err != nil {
+		return false, fmt.Errorf("failed to lock neighbours: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext('movie_neighbours'))`)
+		if err != nil {
+			// Connection still holding the lock mustn't go back to pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	var lastID uuid.UUID
+	for {
+		var ids []uuid.UUID
+		err := conn.SelectContext(ctx, &ids, `
+			SELECT id FROM movies WHERE movie_vector IS NOT NULL AND id > $1 ORDER BY id LIMIT $2
+		`, lastID, refreshBatchSize)
+		if err != nil {
+			return false, fmt.Errorf("failed to list movies: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := r.refreshNeighboursBatch(ctx, conn, ids, k); err != nil {
+			return false, err
+		}
+		lastID = ids[len(ids)-1]
+	}
+
+	// Movies which lost their vectors since the last refresh
+	_, err = conn.ExecContext(ctx, `
+		DELETE FROM movie_neighbours
+		WHERE movie_id IN (SELECT id FROM movies WHERE movie_vector IS NULL)
+	`)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear neighbours: %w", err)
+	}
+	return true, nil
+}
+
+func (r *Repository) refreshNeighboursBatch(ctx context.Context, conn *sqlx.Conn, ids []uuid.UUID, k int) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_neighbours WHERE movie_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to clear neighbours: %w", err)
+	}
+	if err := r.search.Tune(ctx, tx); err != nil {
+		return err
+	}
+
+	distance := r.search.Distance("o.movie_vector", "m.movie_vector")
+	neighbourConds := []string{"o.id <> m.id", "o.movie_vector IS NOT NULL"}
+	// Candidates of every movie are looked for once, by its own vector
+	if c := r.search.Candidates("movies", "WHERE id <> m.id AND movie_vector IS NOT NULL",
+		"movie_vector", "m.movie_vector", "$1"); c != "" {
+		neighbourConds = append(neighbourConds, "o.id IN ("+c+")")
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO movie_neighbours (movie_id, neighbour_id, rank, score)
+		SELECT m.id, n.id,
+			row_number() OVER (PARTITION BY m.id ORDER BY n.distance),
+			%s
+		FROM movies m
+		CROSS JOIN LATERAL (
+			SELECT o.id, %s AS distance
+			FROM movies o
+			%s
+			ORDER BY %s
+			LIMIT $1
+		) n
+		WHERE m.id = ANY($2) AND m.movie_vector IS NOT NULL
+	`, r.search.Score("n.distance"), distance, whereClause(neighbourConds), distance), k, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to compute neighbours: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
@@ -0,0 +1,154 @@
+package infra_postgres_movie
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	roleDirector = "director"
+	roleCast     = "cast"
+)
+
+// movieColumns are selected into MovieDB, prefix them with table alias where it's ambiguous
+const movieColumns = `id, title, year, rating, genres, overview, poster_link, status,
+	runtime, certificate, meta_score, votes, gross, imdb_id`
+
+// insertMovie stores movie row along with its people.
+// Movie with the same title and year is left as is and ErrDuplicate is returned, see 000017_unique_title_year.
+func insertMovie(ctx context.Context, tx *sqlx.Tx, mm model.MovieMeta) error {
+	res, err := tx.NamedExecContext(ctx, `
+		INSERT INTO movies (id, title, year, rating, genres, overview, poster_link, status,
+			runtime, certificate, meta_score, votes, gross, imdb_id)
+		VALUES (:id, :title, :year, :rating, :genres, :overview, :poster_link, :status,
+			:runtime, :certificate, :meta_score, :votes, :gross, :imdb_id)
+		ON CONFLICT (lower(title), year) DO NOTHING
+	`, FromDomain(mm))
+	if err != nil {
+		return fmt.Errorf("failed to store movie: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return usecase_movie.ErrDuplicate
+	}
+	return storePeople(ctx, tx, mm)
+}
+
+// storePeople replaces director and cast of movie. People are shared between movies by name.
+func storePeople(ctx context.Context, tx *sqlx.Tx, mm model.MovieMeta) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_people WHERE movie_id = $1`, mm.ID); err != nil {
+		return fmt.Errorf("failed to clear people of movie: %w", err)
+	}
+
+	var names, roles []string
+	var positions []int64
+	if mm.Director != "" {
+		names, roles, positions = append(names, mm.Director), append(roles, roleDirector), append(positions, 0)
+	}
+	for i, name := range mm.Cast {
+		names, roles, positions = append(names, name), append(roles, roleCast), append(positions, int64(i))
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO people (name)
+		SELECT DISTINCT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING
+	`, pq.StringArray(names))
+	if err != nil {
+		return fmt.Errorf("failed to store people: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO movie_people (movie_id, person_id, role, position)
+		SELECT $1, p.id, c.role, c.position
+		FROM unnest($2::text[], $3::text[], $4::int[]) AS c(name, role, position)
+		JOIN people p ON p.name = c.name
+	`, mm.ID, pq.StringArray(names), pq.StringArray(roles), pq.Int64Array(positions))
+	if err != nil {
+		return fmt.Errorf("failed to link people to movie: %w", err)
+	}
+	return nil
+}
+
+type creditDB struct {
+	MovieID uuid.UUID `db:"movie_id"`
+	Role    string    `db:"role"`
+	Name    string    `db:"name"`
+}
+
+// attachPeople fills director and cast of movies with a single query
+func attachPeople(ctx context.Context, db sqlx.QueryerContext, movies []*model.MovieMeta) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID][]*model.MovieMeta, len(movies))
+	ids := make([]uuid.UUID, 0, len(movies))
+	for _, mm := range movies {
+		if _, ok := byID[mm.ID]; !ok {
+			ids = append(ids, mm.ID)
+		}
+		byID[mm.ID] = append(byID[mm.ID], mm)
+	}
+
+	var credits []creditDB
+	err := sqlx.SelectContext(ctx, db, &credits, `
+		SELECT mp.movie_id, mp.role, p.name
+		FROM movie_people mp
+		JOIN people p ON p.id = mp.person_id
+		WHERE mp.movie_id = ANY($1)
+		ORDER BY mp.movie_id, mp.role, mp.position
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load people of movies: %w", err)
+	}
+
+	for _, c := range credits {
+		for _, mm := range byID[c.MovieID] {
+			switch c.Role {
+			case roleDirector:
+				mm.Director = c.Name
+			case roleCast:
+				mm.Cast = append(mm.Cast, c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func attachPeopleScored(ctx context.Context, db sqlx.QueryerContext, hits []model.ScoredMovie) error {
+	movies := make([]*model.MovieMeta, len(hits))
+	for i, hit := range hits {
+		movies[i] = hit.Movie
+	}
+	return attachPeople(ctx, db, movies)
+}
+
+// personCond matches movies having person of role whose name contains the given part.
+// It's a filterConds format, see people_name_trgm_idx.
+func personCond(role string) string {
+	return `movies.id IN (
+		SELECT mp.movie_id FROM movie_people mp JOIN people p ON p.id = mp.person_id
+		WHERE mp.role = '` + role + `' AND lower(p.name) LIKE '%%' || lower($%d) || '%%'
+	)`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes user input match literally within LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,303 @@
+package infra_postgres_movie
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pgvector/pgvector-go"
+)
+
+type reembedJobDB struct {
+	ID         uuid.UUID     `db:"id"`
+	Model      string        `db:"model"`
+	Status     string        `db:"status"`
+	LastID     uuid.NullUUID `db:"last_id"`
+	Done       int           `db:"done"`
+	Total      int           `db:"total"`
+	Error      string        `db:"error"`
+	StartedAt  time.Time     `db:"started_at"`
+	UpdatedAt  time.Time     `db:"updated_at"`
+	FinishedAt sql.NullTime  `db:"finished_at"`
+}
+
+func (j reembedJobDB) toDomain() model.ReembedJob {
+	job := model.ReembedJob{
+		ID:        j.ID,
+		Model:     j.Model,
+		Status:    model.ReembedStatus(j.Status),
+		LastID:    j.LastID.UUID,
+		Done:      j.Done,
+		Total:     j.Total,
+		Error:     j.Error,
+		StartedAt: j.StartedAt,
+		UpdatedAt: j.UpdatedAt,
+	}
+	if j.FinishedAt.Valid {
+		job.FinishedAt = &j.FinishedAt.Time
+	}
+	return job
+}
+
+const reembedJobColumns = `id, model, status, last_id, done, total, error, started_at, updated_at, finished_at`
+
+// EmbeddingModels counts movie vectors by model, vectors of unknown model go under empty key
+func (r *Repository) EmbeddingModels(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Model string `db:"model"`
+		Count int    `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT coalesce(movie_vector_model, '') AS model, COUNT(*) AS count
+		FROM movies
+		WHERE movie_vector IS NOT NULL
+		GROUP BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embedding models: %w", err)
+	}
+
+	models := make(map[string]int, len(rows))
+	for _, row := range rows {
+		models[row.Model] = row.Count
+	}
+	return models, nil
+}
+
+// CreateReembedJob returns false if another job is running, see reembed_jobs_running_idx
+func (r *Repository) CreateReembedJob(ctx context.Context, modelID string) (model.ReembedJob, bool, error) {
+	var job reembedJobDB
+	err := r.db.GetContext(ctx, &job, `
+		INSERT INTO reembed_jobs (model) VALUES ($1)
+		ON CONFLICT (status) WHERE status = 'running' DO NOTHING
+		RETURNING `+reembedJobColumns, modelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ReembedJob{}, false, nil
+	}
+	if err != nil {
+		return model.ReembedJob{}, false, fmt.Errorf("failed to create re-embed job: %w", err)
+	}
+	return job.toDomain(), true, nil
+}
+
+// LastReembedJob returns running job if any, the latest one otherwise. Nil if there were no jobs.
+func (r *Repository) LastReembedJob(ctx context.Context) (*model.ReembedJob, error) {
+	return r.getReembedJob(ctx, `
+		SELECT `+reembedJobColumns+` FROM reembed_jobs
+		ORDER BY status = 'running' DESC, started_at DESC
+		LIMIT 1
+	`)
+}
+
+// RunningReembed returns nil if no job is running
+func (r *Repository) RunningReembed(ctx context.Context) (*model.ReembedJob, error) {
+	return r.getReembedJob(ctx, `SELECT `+reembedJobColumns+` FROM reembed_jobs WHERE status = 'running'`)
+}
+
+func (r *Repository) getReembedJob(ctx context.Context, query string) (*model.ReembedJob, error) {
+	var job reembedJobDB
+	err := r.db.GetContext(ctx, &job, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load re-embed job: %w", err)
+	}
+
+	domainJob := job.toDomain()
+	return &domainJob, nil
+}
+
+// CancelReembed drops vectors built so far. Returns false if no job is running.
+func (r *Repository) CancelReembed(ctx context.Context) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE reembed_jobs SET status = 'canceled', updated_at = NOW(), finished_at = NOW()
+		WHERE status = 'running'
+	`)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel re-embed job: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if err := clearNextVectors(ctx, tx); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// PendingReembed returns movies after job cursor having no vector of job model yet
+func (r *Repository) PendingReembed(ctx context.Context, job model.ReembedJob, limit int) ([]*model.MovieMeta, error) {
+	var moviesDB []MovieDB
+	err := r.db.SelectContext(ctx, &moviesDB, `
+		SELECT `+movieColumns+`
+		FROM movies
+		WHERE id > $1
+			AND movie_vector_model IS DISTINCT FROM $2
+			AND movie_vector_next_model IS DISTINCT FROM $2
+		ORDER BY id
+		LIMIT $3
+	`, job.LastID, job.Model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load movies to re-embed: %w", err)
+	}
+
+	movies := make([]*model.MovieMeta, len(moviesDB))
+	for i, movieDB := range moviesDB {
+		domainMovie := movieDB.ToDomain()
+		movies[i] = &domainMovie
+	}
+	if err := attachPeople(ctx, r.db, movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// StoreReembedBatch saves next vectors and moves job cursor to lastID together,
+// so restarted job neither loses nor repeats work. Returns false if job isn't running anymore.
+func (r *Repository) StoreReembedBatch(ctx context.Context, job model.ReembedJob, vectors map[uuid.UUID]model.Embedding, lastID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	running, err := lockRunningReembed(ctx, tx, job.ID)
+	if err != nil || !running {
+		return false, err
+	}
+
+	for id, e := range vectors {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE movies SET movie_vector_next = $1, movie_vector_next_model = $2
+			WHERE id = $3
+		`, pgvector.NewVector(e), job.Model, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to store next vector: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reembed_jobs SET
+			last_id = $2,
+			done = (SELECT COUNT(*) FROM movies WHERE movie_vector_model = $3 OR movie_vector_next_model = $3),
+			total = (SELECT COUNT(*) FROM movies),
+			error = '',
+			updated_at = NOW()
+		WHERE id = $1
+	`, job.ID, lastID, job.Model)
+	if err != nil {
+		return false, fmt.Errorf("failed to update re-embed job: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// FailReembed keeps job running, error is shown in its progress until the next successful batch
+func (r *Repository) FailReembed(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE reembed_jobs SET error = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`, id, reason)
+	return err
+}
+
+// FinishReembed switches every movie to its next vector in a single transaction.
+// Returns false if some movies have no vector of job model yet, job cursor is reset to pick them up.
+// Precomputed neighbours are dropped, they're computed from old vectors.
+func (r *Repository) FinishReembed(ctx context.Context, job model.ReembedJob) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	running, err := lockRunningReembed(ctx, tx, job.ID)
+	if err != nil || !running {
+		return false, err
+	}
+
+	var pending bool
+	err = tx.GetContext(ctx, &pending, `
+		SELECT EXISTS(
+			SELECT 1 FROM movies
+			WHERE movie_vector_model IS DISTINCT FROM $1 AND movie_vector_next_model IS DISTINCT FROM $1
+		)
+	`, job.Model)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending movies: %w", err)
+	}
+
+	if pending {
+		_, err := tx.ExecContext(ctx, `UPDATE reembed_jobs SET last_id = NULL, updated_at = NOW() WHERE id = $1`, job.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to reset re-embed job: %w", err)
+		}
+		return false, tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE movies SET movie_vector = movie_vector_next, movie_vector_model = movie_vector_next_model
+		WHERE movie_vector_next_model = $1
+	`, job.Model)
+	if err != nil {
+		return false, fmt.Errorf("failed to switch vectors: %w", err)
+	}
+
+	if err := clearNextVectors(ctx, tx); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_neighbours`); err != nil {
+		return false, fmt.Errorf("failed to clear neighbours: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reembed_jobs SET status = 'done', error = '',
+			done = (SELECT COUNT(*) FROM movies), total = (SELECT COUNT(*) FROM movies),
+			updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`, job.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to finish re-embed job: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// Job row lock serializes batches, cancel and switch of the same job
+func lockRunningReembed(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (bool, error) {
+	var status string
+	err := tx.GetContext(ctx, &status, `SELECT status FROM reembed_jobs WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock re-embed job: %w", err)
+	}
+	return status == string(model.ReembedRunning), nil
+}
+
+func clearNextVectors(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE movies SET movie_vector_next = NULL, movie_vector_next_model = NULL
+		WHERE movie_vector_next_model IS NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to clear next vectors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package infra_postgres_movie
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/lib/pq"
+)
+
+type sagaDB struct {
+	ID        uuid.UUID      `db:"id"`
+	MovieID   uuid.UUID      `db:"movie_id"`
+	Kind      string         `db:"kind"`
+	Status    string         `db:"status"`
+	Steps     pq.StringArray `db:"steps"`
+	Step      int            `db:"step"`
+	Movie     []byte         `db:"movie"`
+	Prev      []byte         `db:"prev"`
+	Backup    []byte         `db:"backup"`
+	Attempts  int            `db:"attempts"`
+	Error     string         `db:"error"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+func (s sagaDB) toDomain() (model.Saga, error) {
+	saga := model.Saga{
+		ID:        s.ID,
+		MovieID:   s.MovieID,
+		Kind:      model.SagaKind(s.Kind),
+		Status:    model.SagaStatus(s.Status),
+		Steps:     []string(s.Steps),
+		Step:      s.Step,
+		Backup:    s.Backup,
+		Attempts:  s.Attempts,
+		Error:     s.Error,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+	if err := json.Unmarshal(s.Movie, &saga.Movie); err != nil {
+		return model.Saga{}, fmt.Errorf("failed to decode movie of saga %s: %w", s.ID, err)
+	}
+	if s.Prev != nil {
+		saga.Prev = &model.MovieMeta{}
+		if err := json.Unmarshal(s.Prev, saga.Prev); err != nil {
+			return model.Saga{}, fmt.Errorf("failed to decode previous movie of saga %s: %w", s.ID, err)
+		}
+	}
+	return saga, nil
+}
+
+// BeginSaga stores saga leased to the caller, so recovery doesn't pick it up while it runs
+func (r *Repository) BeginSaga(ctx context.Context, saga model.Saga, lease time.Duration) error {
+	movie, err := json.Marshal(saga.Movie)
+	if err != nil {
+		return err
+	}
+	var prev []byte
+	if saga.Prev != nil {
+		if prev, err = json.Marshal(saga.Prev); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO movie_sagas (id, movie_id, kind, steps, movie, prev, locked_until)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW() + make_interval(secs => $7))
+	`, saga.ID, saga.MovieID, saga.Kind, pq.StringArray(saga.Steps), movie, prev, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to begin saga: %w", err)
+	}
+	return nil
+}
+
+// RecordSagaStep appends step to saga log, moves saga to it and extends its lease
+func (r *Repository) RecordSagaStep(ctx context.Context, step model.SagaStep, lease time.Duration) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO movie_saga_steps (saga_id, step, name, action, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, step.SagaID, step.Step, step.Name, step.Action, step.Status, step.Error)
+	if err != nil {
+		return fmt.Errorf("failed to log saga step: %w", err)
+	}
+
+	// Started step might have been applied, done one is. Compensation walks back from the last one applied.
+	status, current := model.SagaRunning, step.Step
+	switch {
+	case step.Action == model.SagaExec && step.Status == model.SagaStepDone:
+		current = step.Step + 1
+	case step.Action == model.SagaCompensate && step.Status == model.SagaStepDone:
+		status = model.SagaCompensating
+	case step.Action == model.SagaCompensate:
+		status, current = model.SagaCompensating, step.Step+1
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE movie_sagas SET status = $2, step = $3, error = $4,
+			locked_until = NOW() + make_interval(secs => $5), updated_at = NOW()
+		WHERE id = $1
+	`, step.SagaID, status, current, step.Error, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SetSagaBackup keeps poster the saga is about to overwrite
+func (r *Repository) SetSagaBackup(ctx context.Context, id uuid.UUID, backup []byte) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE movie_sagas SET backup = $2, updated_at = NOW() WHERE id = $1`, id, backup)
+	if err != nil {
+		return fmt.Errorf("failed to store saga backup: %w", err)
+	}
+	return nil
+}
+
+// FinishSaga drops backup of finished saga, its log is kept
+func (r *Repository) FinishSaga(ctx context.Context, id uuid.UUID, status model.SagaStatus) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE movie_sagas SET status = $2, backup = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to finish saga: %w", err)
+	}
+	return nil
+}
+
+// FailSaga records why saga is stuck. It's left leased, so recovery retries it once lease expires.
+func (r *Repository) FailSaga(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE movie_sagas SET error = $2, updated_at = NOW() WHERE id = $1`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to fail saga: %w", err)
+	}
+	return nil
+}
+
+// ClaimSagas leases unfinished sagas whose owner is gone
+func (r *Repository) ClaimSagas(ctx context.Context, limit int, lease time.Duration) ([]model.Saga, error) {
+	var rows []sagaDB
+	err := r.db.SelectContext(ctx, &rows, `
+		WITH stale AS (
+			SELECT id FROM movie_sagas
+			WHERE status IN ('running', 'compensating')
+				AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE movie_sagas s SET
+			attempts = s.attempts + 1,
+			locked_until = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		FROM stale
+		WHERE s.id = stale.id
+		RETURNING s.id, s.movie_id, s.kind, s.status, s.steps, s.step, s.movie, s.prev, s.backup,
+			s.attempts, s.error, s.created_at, s.updated_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim sagas: %w", err)
+	}
+
+	sagas := make([]model.Saga, 0, len(rows))
+	for _, row := range rows {
+		saga, err := row.toDomain()
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, saga)
+	}
+	return sagas, nil
+}
@@ -3,6 +3,7 @@ package infra_postgres_room
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/humanbelnik/kinoswap/core/internal/model"
 	usecase_room "github.com/humanbelnik/kinoswap/core/internal/usecase/room"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
 
@@ -28,22 +30,34 @@ type roomDTO struct {
 	IDAdmin uuid.UUID `db:"id_admin"`
 	Code    string    `db:"code"`
 	Status  string    `db:"status"`
+	Mode    string    `db:"mode"`
 }
 
+// CreateAndBook stores room along with its shortlist
 func (d *Driver) CreateAndBook(ctx context.Context, room model.Room, ownerID uuid.UUID) error {
 	roomDTO := roomDTO{
 		ID:      room.ID,
 		IDAdmin: ownerID,
 		Code:    room.PublicCode,
 		Status:  room.Status,
+		Mode:    room.Mode,
 	}
+	if roomDTO.Mode == "" {
+		roomDTO.Mode = model.ModeRecommend
+	}
+
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
 
 	query := `
-		INSERT INTO rooms (id, id_admin, code, status)
-		VALUES (:id, :id_admin, :code, :status)
+		INSERT INTO rooms (id, id_admin, code, status, mode)
+		VALUES (:id, :id_admin, :code, :status, :mode)
 	`
 
-	_, err := d.db.NamedExecContext(ctx, query, roomDTO)
+	_, err = tx.NamedExecContext(ctx, query, roomDTO)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") ||
 			strings.Contains(err.Error(), "duplicate key") {
@@ -51,7 +65,19 @@ func (d *Driver) CreateAndBook(ctx context.Context, room model.Room, ownerID uui
 		}
 		return err
 	}
-	return nil
+
+	if len(room.Shortlist) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO room_shortlist (room_id, movie_id, position)
+			SELECT $1, s.movie_id, s.position - 1
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS s(movie_id, position)
+		`, room.ID, pq.Array(room.Shortlist))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (d *Driver) IsOwner(ctx context.Context, code string, ownerID uuid.UUID) (bool, error) {
@@ -141,7 +167,7 @@ func (d *Driver) SetStatusByCode(ctx context.Context, code string, status string
 	return nil
 }
 
-func (d *Driver) AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, embedding model.Embedding) error {
+func (d *Driver) AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, pref model.Preference, embedding model.Embedding) error {
 	var roomID uuid.UUID
 	queryGetRoomID := `SELECT id FROM rooms WHERE code = $1`
 
@@ -154,13 +180,20 @@ func (d *Driver) AddPreferenceEmbedding(ctx context.Context, code string, userID
 	}
 
 	query := `
-        INSERT INTO participants (id, room_id, preference) 
-        VALUES ($1, $2, $3)
+        INSERT INTO participants (id, room_id, preference, preference_text) 
+        VALUES ($1, $2, $3, $4)
         ON CONFLICT (id) 
-        DO UPDATE SET preference = $3
+        DO UPDATE SET preference = $3, preference_text = $4
     `
 
-	_, err = d.db.ExecContext(ctx, query, userID, roomID, pgvector.NewVector(embedding))
+	// Participant of shortlist room may come without preference
+	var vector *pgvector.Vector
+	if len(embedding) > 0 {
+		v := pgvector.NewVector(embedding)
+		vector = &v
+	}
+
+	_, err = d.db.ExecContext(ctx, query, userID, roomID, vector, pref.Text)
 
 	if err != nil {
 		return err
@@ -240,3 +273,123 @@ func (d *Driver) CleanupOrphantRooms(ctx context.Context, lobbiesDeadline, votin
 	}
 	return nil
 }
+
+// SetCollections replaces room collections. Room is locked, so it can't start voting meanwhile.
+func (d *Driver) SetCollections(ctx context.Context, code string, collectionIDs []uuid.UUID) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var room roomDTO
+	err = tx.GetContext(ctx, &room, `SELECT id, status FROM rooms WHERE code = $1 FOR UPDATE`, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return usecase_room.ErrResourceNotFound
+		}
+		return err
+	}
+	if room.Status != model.StatusLobby {
+		return usecase_room.ErrVotingStarted
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM room_collections WHERE room_id = $1`, room.ID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO room_collections (room_id, collection_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, room.ID, pq.Array(collectionIDs))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return usecase_room.ErrResourceNotFound
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type collectionDTO struct {
+	ID          uuid.UUID `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Size        int       `db:"size"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (d *Driver) Collections(ctx context.Context, code string) ([]model.Collection, error) {
+	if _, err := d.UUIDByCode(ctx, code); err != nil {
+		return nil, err
+	}
+
+	var rows []collectionDTO
+	query := `
+		SELECT c.id, c.name, c.description, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM collection_movies cm WHERE cm.collection_id = c.id) AS size
+		FROM collections c
+		JOIN room_collections rc ON rc.collection_id = c.id
+		JOIN rooms r ON r.id = rc.room_id
+		WHERE r.code = $1
+		ORDER BY c.name
+	`
+	if err := d.db.SelectContext(ctx, &rows, query, code); err != nil {
+		return nil, err
+	}
+
+	cs := make([]model.Collection, len(rows))
+	for i, row := range rows {
+		cs[i] = model.Collection{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description,
+			Size:        row.Size,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		}
+	}
+	return cs, nil
+}
+
+func (d *Driver) ModeByCode(ctx context.Context, code string) (model.RoomMode, error) {
+	var mode string
+	if err := d.db.GetContext(ctx, &mode, `SELECT mode FROM rooms WHERE code = $1`, code); err != nil {
+		if err == sql.ErrNoRows {
+			return "", usecase_room.ErrResourceNotFound
+		}
+		return "", err
+	}
+	return mode, nil
+}
+
+func (d *Driver) ReadyMovies(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	var ready []uuid.UUID
+	query := `SELECT id FROM movies WHERE id = ANY($1) AND status = 'READY'`
+	if err := d.db.SelectContext(ctx, &ready, query, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return ready, nil
+}
+
+// MatchTitle uses trigram similarity, see movies_title_trgm_idx. Better known movie wins a tie.
+func (d *Driver) MatchTitle(ctx context.Context, title string, year int) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := `
+		SELECT id FROM movies
+		WHERE status = 'READY' AND lower(title) % lower($1) AND ($2 = 0 OR year = $2)
+		ORDER BY similarity(lower(title), lower($1)) DESC, votes DESC, rating DESC
+		LIMIT 1
+	`
+	if err := d.db.GetContext(ctx, &id, query, title, year); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, usecase_room.ErrResourceNotFound
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
@@ -0,0 +1,344 @@
+//go:build integration
+// +build integration
+
+package infra_postgres_vector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/config"
+	infra_pg_init "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/init"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Synthetic catalog is built once per run in its own table, movies are left alone.
+// Size is VECTOR_BENCH_ROWS, 100k by default:
+//
+//	go test -tags=integration -run=^$ -bench=Search -benchtime=200x ./internal/infra/postgres/vector/
+const (
+	benchTable = "vector_bench"
+	benchK     = 10
+	// Queries recall is measured over
+	benchQueries = 50
+)
+
+type catalog struct {
+	db      *sqlx.DB
+	vectors [][]float32
+	queries [][]float32
+}
+
+var (
+	benchCatalog     *catalog
+	benchCatalogOnce sync.Once
+)
+
+func loadCatalog(b testing.TB) *catalog {
+	benchCatalogOnce.Do(func() {
+		rows := 100_000
+		if s := os.Getenv("VECTOR_BENCH_ROWS"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				b.Fatalf("bad VECTOR_BENCH_ROWS: %v", err)
+			}
+			rows = n
+		}
+
+		db := infra_pg_init.MustEstablishConn(config.Load().Postgres)
+		c := &catalog{db: db, vectors: synthetic(rand.New(rand.NewPCG(1, 2)), rows)}
+		c.queries = synthetic(rand.New(rand.NewPCG(3, 4)), benchQueries)
+		if err := c.store(context.Background()); err != nil {
+			b.Fatalf("failed to store catalog: %v", err)
+		}
+		benchCatalog = c
+	})
+	if benchCatalog == nil {
+		b.Fatal("catalog isn't stored")
+	}
+	return benchCatalog
+}
+
+// synthetic vectors are unit and clustered around genre-like centroids, as sentence embeddings are
+func synthetic(rnd *rand.Rand, n int) [][]float32 {
+	centroids := make([][]float32, 200)
+	for i := range centroids {
+		centroids[i] = unit(rnd, nil, 1)
+	}
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = unit(rnd, centroids[rnd.IntN(len(centroids))], 0.6)
+	}
+	return vectors
+}
+
+func unit(rnd *rand.Rand, around []float32, noise float64) []float32 {
+	v := make([]float32, model.EmbeddingDimension)
+	var norm float64
+	for i := range v {
+		x := rnd.NormFloat64() * noise
+		if around != nil {
+			x += float64(around[i]) * math.Sqrt(float64(model.EmbeddingDimension))
+		}
+		v[i] = float32(x)
+		norm += x * x
+	}
+	for i := range v {
+		v[i] = float32(float64(v[i]) / math.Sqrt(norm))
+	}
+	return v
+}
+
+func (c *catalog) store(ctx context.Context) error {
+	if _, err := c.db.ExecContext(ctx, `DROP TABLE IF EXISTS `+benchTable); err != nil {
+		return err
+	}
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf(
+		`CREATE UNLOGGED TABLE %s (id INT PRIMARY KEY, v VECTOR(%d))`, benchTable, model.EmbeddingDimension))
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(benchTable, "id", "v"))
+	if err != nil {
+		return err
+	}
+	for id, v := range c.vectors {
+		if _, err := stmt.ExecContext(ctx, id, pgvector.NewVector(v)); err != nil {
+			return err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// index rebuilds the only index of catalog
+func (c *catalog) index(ctx context.Context, s Search) error {
+	if _, err := c.db.ExecContext(ctx, `DROP INDEX IF EXISTS `+benchTable+`_v_idx`); err != nil {
+		return err
+	}
+
+	with := "m = 16, ef_construction = 64"
+	if s.index() == IndexIVFFlat {
+		with = fmt.Sprintf("lists = %d", max(len(c.vectors)/1000, 1))
+	}
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX %s_v_idx ON %s USING %s ((%s) %s) WITH (%s)`,
+		benchTable, benchTable, s.index(), s.Quantized("v"), s.OpClass(), with))
+	return err
+}
+
+// search is shaped as repositories query, candidates of quantized index are re-ranked
+func (c *catalog) search(ctx context.Context, s Search, q []float32) ([]int, error) {
+	where := ""
+	if candidates := s.Candidates(benchTable, "", "v", "$1", strconv.Itoa(benchK)); candidates != "" {
+		where = "WHERE id IN (" + candidates + ")"
+	}
+
+	var ids []int
+	err := s.Query(ctx, c.db, func(tx sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, tx, &ids, fmt.Sprintf(`SELECT id FROM %s %s ORDER BY %s LIMIT %d`,
+			benchTable, where, s.Distance("v", "$1"), benchK), pgvector.NewVector(q))
+	})
+	return ids, err
+}
+
+// scan is the exact baseline, index isn't used
+func (c *catalog) scan(ctx context.Context, q []float32) ([]int, error) {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('enable_indexscan', 'off', true)`); err != nil {
+		return nil, err
+	}
+	var ids []int
+	err = tx.SelectContext(ctx, &ids, fmt.Sprintf(`SELECT id FROM %s ORDER BY v <=> $1 LIMIT %d`, benchTable, benchK),
+		pgvector.NewVector(q))
+	return ids, err
+}
+
+// recall of search over every query, along with its mean latency
+func (c *catalog) recall(ctx context.Context, exact [][]int, search func(q []float32) ([]int, error)) (float64, time.Duration, error) {
+	var found, total int
+	started := time.Now()
+	for i, q := range c.queries {
+		ids, err := search(q)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, id := range ids {
+			if slices.Contains(exact[i], id) {
+				found++
+			}
+		}
+		total += benchK
+	}
+	return float64(found) / float64(total), time.Since(started) / time.Duration(len(c.queries)), nil
+}
+
+func (c *catalog) exactAll() [][]int {
+	exact := make([][]int, len(c.queries))
+	for i, q := range c.queries {
+		exact[i] = c.exact(q)
+	}
+	return exact
+}
+
+// exact top k by brute force, vectors are unit, so every metric ranks them the same
+func (c *catalog) exact(q []float32) []int {
+	type hit struct {
+		id  int
+		dot float64
+	}
+	hits := make([]hit, len(c.vectors))
+	for id, v := range c.vectors {
+		var dot float64
+		for i := range v {
+			dot += float64(v[i]) * float64(q[i])
+		}
+		hits[id] = hit{id: id, dot: dot}
+	}
+	slices.SortFunc(hits, func(a, b hit) int {
+		switch {
+		case a.dot > b.dot:
+			return -1
+		case a.dot < b.dot:
+			return 1
+		}
+		return 0
+	})
+
+	ids := make([]int, benchK)
+	for i := range ids {
+		ids[i] = hits[i].id
+	}
+	return ids
+}
+
+func BenchmarkSearch(b *testing.B) {
+	c := loadCatalog(b)
+	ctx := context.Background()
+
+	exact := c.exactAll()
+
+	cases := []Search{
+		{Metric: MetricCosine, Index: IndexHNSW},
+		{Metric: MetricCosine, Index: IndexHNSW, EfSearch: 100},
+		{Metric: MetricCosine, Index: IndexHNSW, EfSearch: 200},
+		{Metric: MetricL2, Index: IndexHNSW},
+		{Metric: MetricInnerProduct, Index: IndexHNSW},
+		{Metric: MetricCosine, Index: IndexIVFFlat},
+		{Metric: MetricCosine, Index: IndexIVFFlat, Probes: 10},
+		{Metric: MetricCosine, Index: IndexIVFFlat, Probes: 30},
+		{Metric: MetricCosine, Index: IndexHNSW, Quantization: QuantizationHalfvec},
+		{Metric: MetricCosine, Index: IndexHNSW, Quantization: QuantizationBinary},
+		{Metric: MetricCosine, Index: IndexHNSW, Quantization: QuantizationBinary, Overfetch: 10},
+		{Metric: MetricCosine, Index: IndexIVFFlat, Probes: 10, Quantization: QuantizationHalfvec},
+	}
+
+	// Index is rebuilt only when kind or metric change
+	var built string
+	for _, s := range cases {
+		name := fmt.Sprintf("%s/%s/ef=%d/probes=%d/%s/overfetch=%d",
+			s.index(), s.metric(), s.EfSearch, s.Probes, s.Quantization, s.Overfetch)
+		b.Run(name, func(b *testing.B) {
+			if built != s.String() {
+				if err := c.index(ctx, s); err != nil {
+					b.Fatalf("failed to build index: %v", err)
+				}
+				built = s.String()
+			}
+
+			recall, _, err := c.recall(ctx, exact, func(q []float32) ([]int, error) {
+				return c.search(ctx, s, q)
+			})
+			if err != nil {
+				b.Fatalf("failed to search: %v", err)
+			}
+
+			i := 0
+			for b.Loop() {
+				if _, err := c.search(ctx, s, c.queries[i%len(c.queries)]); err != nil {
+					b.Fatalf("failed to search: %v", err)
+				}
+				i++
+			}
+			b.ReportMetric(recall, "recall@10")
+		})
+	}
+}
+
+// TestQuantizedRecall compares quantized index with re-ranking to exact scan of the synthetic catalog
+func TestQuantizedRecall(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds catalog of VECTOR_BENCH_ROWS movies")
+	}
+	c := loadCatalog(t)
+	ctx := context.Background()
+	exact := c.exactAll()
+
+	baseline, scanLatency, err := c.recall(ctx, exact, func(q []float32) ([]int, error) {
+		return c.scan(ctx, q)
+	})
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	t.Logf("exact scan: recall@10 %.3f, %s per query", baseline, scanLatency)
+
+	testCases := []struct {
+		search    Search
+		minRecall float64
+	}{
+		{search: Search{Quantization: QuantizationNone}, minRecall: 0.9},
+		{search: Search{Quantization: QuantizationHalfvec}, minRecall: 0.9},
+		{search: Search{Quantization: QuantizationBinary, Overfetch: 10}, minRecall: 0.8},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.search.Quantization), func(t *testing.T) {
+			if err := c.index(ctx, tc.search); err != nil {
+				t.Fatalf("failed to build index: %v", err)
+			}
+
+			recall, latency, err := c.recall(ctx, exact, func(q []float32) ([]int, error) {
+				return c.search(ctx, tc.search, q)
+			})
+			if err != nil {
+				t.Fatalf("failed to search: %v", err)
+			}
+			t.Logf("%s: recall@10 %.3f, %s per query, %.1fx faster than exact scan",
+				tc.search, recall, latency, float64(scanLatency)/float64(latency))
+
+			if recall < tc.minRecall {
+				t.Errorf("recall@10 %.3f is below %.2f", recall, tc.minRecall)
+			}
+			if latency > scanLatency {
+				t.Errorf("index search takes %s, exact scan %s", latency, scanLatency)
+			}
+		})
+	}
+}
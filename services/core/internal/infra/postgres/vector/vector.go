@@ -0,0 +1,294 @@
+// Package infra_postgres_vector keeps distance metric, index and its tuning the same for every repository
+// ordering movies by pgvector distance. Index itself is created by 000014_vector_index migration.
+package infra_postgres_vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrUnknownMetric       = errors.New("unknown vector metric")
+	ErrUnknownIndex        = errors.New("unknown vector index")
+	ErrUnknownQuantization = errors.New("unknown vector quantization")
+	// Queries still work, but scan the whole table
+	ErrNoIndex = errors.New("no vector index matches metric")
+)
+
+type Metric string
+
+const (
+	// Angle between vectors, the usual choice for sentence embeddings
+	MetricCosine Metric = "cosine"
+	// Euclidean distance, ranks unit vectors the same as cosine
+	MetricL2 Metric = "l2"
+	// Negated inner product, the cheapest, meant for unit vectors only
+	MetricInnerProduct Metric = "ip"
+)
+
+func ParseMetric(s string) (Metric, error) {
+	switch m := Metric(strings.ToLower(strings.TrimSpace(s))); m {
+	case MetricCosine, MetricL2, MetricInnerProduct:
+		return m, nil
+	}
+	return "", errors.Join(ErrUnknownMetric, errors.New(s))
+}
+
+// Operator is the pgvector distance operator, the smaller the closer
+func (m Metric) Operator() string {
+	switch m {
+	case MetricL2:
+		return "<->"
+	case MetricInnerProduct:
+		return "<#>"
+	}
+	return "<=>"
+}
+
+// OpClass of index serving Operator
+func (m Metric) OpClass() string {
+	switch m {
+	case MetricL2:
+		return "vector_l2_ops"
+	case MetricInnerProduct:
+		return "vector_ip_ops"
+	}
+	return "vector_cosine_ops"
+}
+
+// Similarity turns distance expression into score, the bigger the closer
+func (m Metric) Similarity(distance string) string {
+	switch m {
+	case MetricL2:
+		return "1 / (1 + (" + distance + "))"
+	case MetricInnerProduct:
+		return "-(" + distance + ")"
+	}
+	return "1 - (" + distance + ")"
+}
+
+type Index string
+
+const (
+	// Graph index, the best recall for speed, slower to build
+	IndexHNSW Index = "hnsw"
+	// Clustered index, quick to build, recall depends on lists built over existing rows
+	IndexIVFFlat Index = "ivfflat"
+)
+
+func ParseIndex(s string) (Index, error) {
+	switch i := Index(strings.ToLower(strings.TrimSpace(s))); i {
+	case IndexHNSW, IndexIVFFlat:
+		return i, nil
+	}
+	return "", errors.Join(ErrUnknownIndex, errors.New(s))
+}
+
+// Quantization of vectors index is built over, table keeps full ones for re-ranking
+type Quantization string
+
+const (
+	// Full precision vectors are indexed
+	QuantizationNone Quantization = "none"
+	// Half precision floats, half the index size with little recall lost
+	QuantizationHalfvec Quantization = "halfvec"
+	// A bit per dimension compared by hamming distance, 32 times smaller, needs larger over-fetch
+	QuantizationBinary Quantization = "binary"
+)
+
+func ParseQuantization(s string) (Quantization, error) {
+	switch q := Quantization(strings.ToLower(strings.TrimSpace(s))); q {
+	case QuantizationNone, QuantizationHalfvec, QuantizationBinary:
+		return q, nil
+	}
+	return "", errors.Join(ErrUnknownQuantization, errors.New(s))
+}
+
+// DefaultOverfetch is how many times more candidates quantized index gives for re-ranking than asked for
+const DefaultOverfetch = 4
+
+// Search builds distance expressions of metric and tunes index for every query.
+// Zero Search orders by cosine distance with server defaults.
+type Search struct {
+	Metric Metric
+	Index  Index
+	// Candidates HNSW keeps while searching, 0 keeps server default of 40
+	EfSearch int
+	// Lists IVFFlat searches, 0 keeps server default of 1
+	Probes int
+	// Index scan of quantized vectors gives candidates, exact distances re-rank them
+	Quantization Quantization
+	// Candidates per result, DefaultOverfetch if 0
+	Overfetch int
+}
+
+func (s Search) metric() Metric {
+	if s.Metric == "" {
+		return MetricCosine
+	}
+	return s.Metric
+}
+
+func (s Search) quantized() bool {
+	return s.Quantization != "" && s.Quantization != QuantizationNone
+}
+
+func (s Search) overfetch() int {
+	if s.Overfetch <= 0 {
+		return DefaultOverfetch
+	}
+	return s.Overfetch
+}
+
+func (s Search) index() Index {
+	if s.Index == "" {
+		return IndexHNSW
+	}
+	return s.Index
+}
+
+// Distance between vector column and arg, index is used when query is ordered by it ascending
+func (s Search) Distance(column, arg string) string {
+	return column + " " + s.metric().Operator() + " " + arg
+}
+
+// Similarity between vector column and arg
+func (s Search) Similarity(column, arg string) string {
+	return s.Score(s.Distance(column, arg))
+}
+
+// Score of distance expression computed by Distance
+func (s Search) Score(distance string) string {
+	return s.metric().Similarity(distance)
+}
+
+// Candidates selects ids of rows of table matching where that are the closest to arg by quantized column,
+// limit times overfetch of them. Query keeping only them and ordered by Distance re-ranks them exactly.
+// Arg referring to rows of the query makes candidates looked for again for every row. Empty if vectors aren't quantized.
+func (s Search) Candidates(table, where, column, arg, limit string) string {
+	if !s.quantized() {
+		return ""
+	}
+	return fmt.Sprintf("SELECT id FROM %s %s ORDER BY %s LIMIT (%s) * %d",
+		table, where, s.quantizedDistance(column, arg), limit, s.overfetch())
+}
+
+// quantizedDistance is served by index over Quantized(column)
+func (s Search) quantizedDistance(column, arg string) string {
+	// Parameter typed by the first use only would be halfvec in the rest of the query otherwise
+	arg = "(" + arg + ")::vector"
+	if s.Quantization == QuantizationBinary {
+		return s.Quantized(column) + " <~> " + s.Quantized(arg)
+	}
+	return s.Quantized(column) + " " + s.metric().Operator() + " " + s.Quantized(arg)
+}
+
+// Quantized vector expression, index is built over it
+func (s Search) Quantized(vector string) string {
+	switch s.Quantization {
+	case QuantizationHalfvec:
+		return fmt.Sprintf("(%s)::halfvec(%d)", vector, model.EmbeddingDimension)
+	case QuantizationBinary:
+		return fmt.Sprintf("binary_quantize(%s)::bit(%d)", vector, model.EmbeddingDimension)
+	}
+	return vector
+}
+
+// OpClass of index over Quantized column
+func (s Search) OpClass() string {
+	switch s.Quantization {
+	case QuantizationHalfvec:
+		return strings.Replace(s.metric().OpClass(), "vector_", "halfvec_", 1)
+	case QuantizationBinary:
+		return "bit_hamming_ops"
+	}
+	return s.metric().OpClass()
+}
+
+func (s Search) settings() map[string]string {
+	settings := make(map[string]string)
+	switch {
+	case s.index() == IndexHNSW && s.EfSearch > 0:
+		settings["hnsw.ef_search"] = strconv.Itoa(s.EfSearch)
+	case s.index() == IndexIVFFlat && s.Probes > 0:
+		settings["ivfflat.probes"] = strconv.Itoa(s.Probes)
+	}
+	// Index alone gives no more than ef_search or probed lists hold, over-fetch may want more.
+	// Order of candidates doesn't matter, they are re-ranked anyway.
+	if s.quantized() {
+		settings[string(s.index())+".iterative_scan"] = "relaxed_order"
+	}
+	return settings
+}
+
+// Query runs fn with index tuned. Settings are local to a transaction,
+// so fn gets one if there is anything to tune and db itself otherwise.
+func (s Search) Query(ctx context.Context, db *sqlx.DB, fn func(q sqlx.QueryerContext) error) error {
+	if len(s.settings()) == 0 {
+		return fn(db)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.Tune(ctx, tx); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tune sets index settings till the end of tx
+func (s Search) Tune(ctx context.Context, tx *sqlx.Tx) error {
+	for name, value := range s.settings() {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config($1, $2, true)`, name, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CheckIndex reports ErrNoIndex if column of table has no index of configured kind and metric
+func (s Search) CheckIndex(ctx context.Context, db *sqlx.DB, table, column string) error {
+	var defs []string
+	err := db.SelectContext(ctx, &defs, `SELECT indexdef FROM pg_indexes WHERE tablename = $1`, table)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	for _, def := range defs {
+		if s.matches(def, column) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s on %s.%s", ErrNoIndex, s, table, column)
+}
+
+// matches tells if index definition as pg_indexes shows it serves column
+func (s Search) matches(def, column string) bool {
+	if !strings.Contains(def, "USING "+string(s.index())+" ") {
+		return false
+	}
+	if !s.quantized() {
+		return strings.Contains(def, "("+column+" "+s.OpClass()+")")
+	}
+	return strings.Contains(def, "("+column+")") && strings.Contains(def, " "+s.OpClass()+")")
+}
+
+func (s Search) String() string {
+	if s.quantized() {
+		return string(s.index()) + " " + string(s.metric()) + " " + string(s.Quantization)
+	}
+	return string(s.index()) + " " + string(s.metric())
+}
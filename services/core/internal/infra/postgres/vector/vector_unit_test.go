@@ -0,0 +1,147 @@
+//go:build !integration
+// +build !integration
+
+package infra_postgres_vector
+
+import (
+	"testing"
+
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type VectorUnitSuite struct {
+	suite.Suite
+}
+
+func (s *VectorUnitSuite) TestParse(t provider.T) {
+	t.Parallel()
+
+	metric, err := ParseMetric(" L2 ")
+	assert.NoError(t, err)
+	assert.Equal(t, MetricL2, metric)
+	_, err = ParseMetric("manhattan")
+	assert.ErrorIs(t, err, ErrUnknownMetric)
+
+	index, err := ParseIndex("IVFFlat")
+	assert.NoError(t, err)
+	assert.Equal(t, IndexIVFFlat, index)
+	_, err = ParseIndex("btree")
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+
+	quantization, err := ParseQuantization("halfvec")
+	assert.NoError(t, err)
+	assert.Equal(t, QuantizationHalfvec, quantization)
+	_, err = ParseQuantization("pq")
+	assert.ErrorIs(t, err, ErrUnknownQuantization)
+}
+
+func (s *VectorUnitSuite) TestExpressions(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		search             Search
+		expectedDistance   string
+		expectedSimilarity string
+	}{
+		{
+			name:               "Should default to cosine",
+			search:             Search{},
+			expectedDistance:   "movie_vector <=> $1",
+			expectedSimilarity: "1 - (movie_vector <=> $1)",
+		},
+		{
+			name:               "Should order by euclidean distance",
+			search:             Search{Metric: MetricL2},
+			expectedDistance:   "movie_vector <-> $1",
+			expectedSimilarity: "1 / (1 + (movie_vector <-> $1))",
+		},
+		{
+			name:               "Should negate negative inner product",
+			search:             Search{Metric: MetricInnerProduct},
+			expectedDistance:   "movie_vector <#> $1",
+			expectedSimilarity: "-(movie_vector <#> $1)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expectedDistance, tc.search.Distance("movie_vector", "$1"))
+			assert.Equal(t, tc.expectedSimilarity, tc.search.Similarity("movie_vector", "$1"))
+		})
+	}
+}
+
+func (s *VectorUnitSuite) TestSettings(t provider.T) {
+	t.Parallel()
+
+	assert.Empty(t, Search{}.settings(), "server defaults need no transaction")
+	assert.Equal(t, map[string]string{"hnsw.ef_search": "100"}, Search{EfSearch: 100, Probes: 10}.settings())
+	assert.Equal(t, map[string]string{"ivfflat.probes": "10"}, Search{Index: IndexIVFFlat, EfSearch: 100, Probes: 10}.settings())
+	assert.Equal(t, map[string]string{"hnsw.iterative_scan": "relaxed_order"}, Search{Quantization: QuantizationBinary}.settings(),
+		"over-fetch isn't limited by ef_search")
+}
+
+func (s *VectorUnitSuite) TestCandidates(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		search   Search
+		expected string
+	}{
+		{
+			name:     "Should take no candidates from full precision index",
+			search:   Search{Quantization: QuantizationNone},
+			expected: "",
+		},
+		{
+			name:     "Should over-fetch half precision candidates",
+			search:   Search{Metric: MetricL2, Quantization: QuantizationHalfvec},
+			expected: "SELECT id FROM movies WHERE year > 2000 ORDER BY (movie_vector)::halfvec(384) <-> (($1)::vector)::halfvec(384) LIMIT ($2) * 4",
+		},
+		{
+			name:     "Should compare binary candidates by hamming distance",
+			search:   Search{Quantization: QuantizationBinary, Overfetch: 10},
+			expected: "SELECT id FROM movies WHERE year > 2000 ORDER BY binary_quantize(movie_vector)::bit(384) <~> binary_quantize(($1)::vector)::bit(384) LIMIT ($2) * 10",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, tc.search.Candidates("movies", "WHERE year > 2000", "movie_vector", "$1", "$2"))
+			assert.Equal(t, "movie_vector <=> $1", Search{Quantization: tc.search.Quantization}.Distance("movie_vector", "$1"),
+				"candidates are re-ranked by exact distance")
+		})
+	}
+}
+
+func (s *VectorUnitSuite) TestMatches(t provider.T) {
+	t.Parallel()
+
+	hnsw := "CREATE INDEX movies_vector_idx ON public.movies USING hnsw (movie_vector vector_cosine_ops) WITH (m='16', ef_construction='64')"
+	ivfflat := "CREATE INDEX movies_vector_idx ON public.movies USING ivfflat (movie_vector vector_l2_ops) WITH (lists='100')"
+
+	assert.True(t, Search{}.matches(hnsw, "movie_vector"))
+	assert.False(t, Search{Metric: MetricL2}.matches(hnsw, "movie_vector"), "cosine index doesn't serve l2")
+	assert.False(t, Search{}.matches(hnsw, "movie_vector_next"))
+	assert.True(t, Search{Metric: MetricL2, Index: IndexIVFFlat}.matches(ivfflat, "movie_vector"))
+	assert.False(t, Search{Metric: MetricL2}.matches(ivfflat, "movie_vector"))
+
+	halfvec := "CREATE INDEX movies_vector_quantized_idx ON public.movies USING hnsw (((movie_vector)::halfvec(384)) halfvec_cosine_ops) WITH (m='16', ef_construction='64')"
+	binary := "CREATE INDEX movies_vector_quantized_idx ON public.movies USING hnsw (((binary_quantize(movie_vector))::bit(384)) bit_hamming_ops) WITH (m='16', ef_construction='64')"
+
+	assert.True(t, Search{Quantization: QuantizationHalfvec}.matches(halfvec, "movie_vector"))
+	assert.False(t, Search{}.matches(halfvec, "movie_vector"), "full precision queries can't use quantized index")
+	assert.False(t, Search{Quantization: QuantizationHalfvec}.matches(hnsw, "movie_vector"))
+	assert.True(t, Search{Metric: MetricInnerProduct, Quantization: QuantizationBinary}.matches(binary, "movie_vector"))
+	assert.False(t, Search{Quantization: QuantizationBinary}.matches(halfvec, "movie_vector"))
+}
+
+func TestVectorUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(VectorUnitSuite))
+}
@@ -0,0 +1,97 @@
+package infra_postgres_vote
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Queries below let in-memory index pick candidates with no vector compared by database
+
+type embeddedMovieDTO struct {
+	movieDTO
+	Vector pgvector.Vector `db:"movie_vector"`
+}
+
+// ReadyMovies returns ready movies with vectors among ids, all of them if ids is nil
+func (d *Driver) ReadyMovies(ctx context.Context, ids []uuid.UUID) ([]model.EmbeddedMovie, error) {
+	var rows []embeddedMovieDTO
+
+	query := `
+		SELECT id, title, year, rating, genres, overview, poster_link, movie_vector
+		FROM movies
+		WHERE movie_vector IS NOT NULL AND status = 'READY' AND ($1::uuid[] IS NULL OR id = ANY($1))
+	`
+
+	var filter any
+	if ids != nil {
+		filter = pq.Array(ids)
+	}
+	if err := d.db.SelectContext(ctx, &rows, query, filter); err != nil {
+		return nil, err
+	}
+
+	movies := make([]model.EmbeddedMovie, 0, len(rows))
+	for _, row := range rows {
+		movies = append(movies, model.EmbeddedMovie{
+			Movie: &model.MovieMeta{
+				ID:         row.ID,
+				Title:      row.Title,
+				Year:       row.Year,
+				Rating:     row.Rating,
+				Genres:     []string(row.Genres),
+				Overview:   row.Overview,
+				PosterLink: row.PosterLink,
+				Status:     model.MovieStatusReady,
+			},
+			Vector: model.Embedding(row.Vector.Slice()),
+		})
+	}
+
+	return movies, nil
+}
+
+// CandidatePool tells the same as inRoomPool does
+func (d *Driver) CandidatePool(ctx context.Context, roomID uuid.UUID) (model.CandidatePool, error) {
+	var pool model.CandidatePool
+
+	var collections bool
+	query := `SELECT EXISTS (SELECT 1 FROM room_collections WHERE room_id = $1)`
+	if err := d.db.GetContext(ctx, &collections, query, roomID); err != nil {
+		return pool, err
+	}
+
+	if collections {
+		pool.Allowed = []uuid.UUID{}
+		query = `
+			SELECT DISTINCT cm.movie_id FROM collection_movies cm
+			JOIN room_collections rc ON rc.collection_id = cm.collection_id
+			WHERE rc.room_id = $1
+		`
+		if err := d.db.SelectContext(ctx, &pool.Allowed, query, roomID); err != nil {
+			return pool, err
+		}
+	}
+
+	query = `SELECT DISTINCT movie_id FROM participant_watchlists WHERE room_id = $1 AND kind = 'WATCHED'`
+	if err := d.db.SelectContext(ctx, &pool.Watched, query, roomID); err != nil {
+		return pool, err
+	}
+
+	return pool, nil
+}
+
+// Reacted returns movies participant has already swiped
+func (d *Driver) Reacted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	query := `SELECT movie_id FROM participant_reactions WHERE participant_id = $1 AND room_id = $2`
+	if err := d.db.SelectContext(ctx, &ids, query, userID, roomID); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
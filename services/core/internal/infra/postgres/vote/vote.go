@@ -3,9 +3,11 @@ package infra_postgres_vote
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	infra_postgres_vector "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/vector"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
 	usecase_vote "github.com/humanbelnik/kinoswap/core/internal/usecase/vote"
 	"github.com/jmoiron/sqlx"
@@ -14,11 +16,25 @@ import (
 )
 
 type Driver struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	search infra_postgres_vector.Search
 }
 
-func New(db *sqlx.DB) *Driver {
-	return &Driver{db: db}
+type Option func(*Driver)
+
+// WithVectorSearch sets metric candidates are ordered by, it has to match vector index
+func WithVectorSearch(s infra_postgres_vector.Search) Option {
+	return func(d *Driver) {
+		d.search = s
+	}
+}
+
+func New(db *sqlx.DB, opts ...Option) *Driver {
+	d := &Driver{db: db}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 type roomDTO struct {
@@ -86,18 +102,164 @@ func (d *Driver) ParticipantsEmbeddings(ctx context.Context, roomID uuid.UUID) (
 	return embeddings, nil
 }
 
-func (d *Driver) SimilarMovies(ctx context.Context, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error) {
+func (d *Driver) ParticipantsPreferences(ctx context.Context, roomID uuid.UUID) ([]string, error) {
+	var texts []string
+
+	query := `
+		SELECT preference_text
+		FROM participants
+		WHERE room_id = $1 AND coalesce(preference_text, '') <> ''
+	`
+
+	err := d.db.SelectContext(ctx, &texts, query, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return texts, nil
+}
+
+// inRoomPool limits movies to collections of room, if it has any, and drops ones any participant has watched.
+// It's formatted with room id parameter.
+const inRoomPool = `(
+	NOT EXISTS (SELECT 1 FROM room_collections WHERE room_id = $%[1]d)
+	OR movies.id IN (
+		SELECT cm.movie_id FROM collection_movies cm
+		JOIN room_collections rc ON rc.collection_id = cm.collection_id
+		WHERE rc.room_id = $%[1]d
+	)
+) AND NOT EXISTS (
+	SELECT 1 FROM participant_watchlists w
+	WHERE w.room_id = $%[1]d AND w.kind = 'WATCHED' AND w.movie_id = movies.id
+)`
+
+// wantedMovies are movies of room pool on participants' watchlists. It's formatted with extra conditions.
+const wantedMovies = `
+	SELECT movies.id, movies.title, movies.year, movies.rating, movies.genres, movies.overview, movies.poster_link
+	FROM participant_watchlists w
+	JOIN movies ON movies.id = w.movie_id
+	WHERE w.room_id = $1 AND w.kind = 'WATCHLIST' AND movies.status = 'READY' AND %s
+	GROUP BY movies.id
+`
+
+// WatchlistMovies returns movies wanted by the most participants first
+func (d *Driver) WatchlistMovies(ctx context.Context, roomID uuid.UUID, limit int) ([]*model.MovieMeta, error) {
+	var movies []movieDTO
+
+	query := fmt.Sprintf(wantedMovies, fmt.Sprintf(inRoomPool, 1)) + `
+		ORDER BY COUNT(*) DESC, movies.rating DESC, movies.id
+		LIMIT $2
+	`
+
+	if err := d.db.SelectContext(ctx, &movies, query, roomID, limit); err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.MovieMeta, 0, len(movies))
+	for _, movie := range movies {
+		result = append(result, &model.MovieMeta{
+			ID:         movie.ID,
+			Title:      movie.Title,
+			Year:       movie.Year,
+			Rating:     movie.Rating,
+			Genres:     []string(movie.Genres),
+			Overview:   movie.Overview,
+			PosterLink: movie.PosterLink,
+		})
+	}
+
+	return result, nil
+}
+
+// NextWatchlistMovie picks the most wanted movie participant hasn't reacted to yet
+func (d *Driver) NextWatchlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error) {
+	var movie movieDTO
+
+	query := fmt.Sprintf(wantedMovies, fmt.Sprintf(inRoomPool, 1)+`
+		AND NOT EXISTS (
+			SELECT 1
+			FROM participant_reactions pr
+			WHERE pr.participant_id = $2 AND pr.room_id = $1 AND pr.movie_id = movies.id
+		)`) + `
+		ORDER BY COUNT(*) DESC, movies.rating DESC, movies.id
+		LIMIT 1
+	`
+
+	err := d.db.GetContext(ctx, &movie, query, roomID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase_vote.ErrResourceNotFound
+		}
+		return nil, err
+	}
+
+	return &model.MovieMeta{
+		ID:         movie.ID,
+		Title:      movie.Title,
+		Year:       movie.Year,
+		Rating:     movie.Rating,
+		Genres:     []string(movie.Genres),
+		Overview:   movie.Overview,
+		PosterLink: movie.PosterLink,
+	}, nil
+}
+
+// LexicalMovies matches any word of the text, preferences are lists of wishes rather than exact queries
+func (d *Driver) LexicalMovies(ctx context.Context, roomID uuid.UUID, text string, limit int) ([]*model.MovieMeta, error) {
 	var movies []movieDTO
 
+	query := `
+		SELECT id, title, year, rating, genres, overview, poster_link
+		FROM movies, replace(plainto_tsquery('english', $1)::text, '&', '|')::tsquery AS q
+		WHERE search_tsv @@ q AND status = 'READY' AND ` + fmt.Sprintf(inRoomPool, 3) + `
+		ORDER BY ts_rank_cd(search_tsv, q) DESC, id
+		LIMIT $2
+	`
+
+	err := d.db.SelectContext(ctx, &movies, query, text, limit, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.MovieMeta, 0, len(movies))
+	for _, movie := range movies {
+		result = append(result, &model.MovieMeta{
+			ID:         movie.ID,
+			Title:      movie.Title,
+			Year:       movie.Year,
+			Rating:     movie.Rating,
+			Genres:     []string(movie.Genres),
+			Overview:   movie.Overview,
+			PosterLink: movie.PosterLink,
+		})
+	}
+
+	return result, nil
+}
+
+// candidates narrows movies matching where to the closest to $1 by quantized vectors, see Search.Candidates
+func (d *Driver) candidates(where, limit string) string {
+	if c := d.search.Candidates("movies", "WHERE "+where, "movie_vector", "$1", limit); c != "" {
+		return " AND id IN (" + c + ")"
+	}
+	return ""
+}
+
+func (d *Driver) SimilarMovies(ctx context.Context, roomID uuid.UUID, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error) {
+	var movies []movieDTO
+
+	where := `movie_vector IS NOT NULL AND status = 'READY' AND ` + fmt.Sprintf(inRoomPool, 3)
 	query := `
 		SELECT id, title, year, rating, genres, overview, poster_link
 		FROM movies 
-		WHERE movie_vector IS NOT NULL
-		ORDER BY movie_vector <-> $1
+		WHERE ` + where + d.candidates(where, "$2") + `
+		ORDER BY ` + d.search.Distance("movie_vector", "$1") + `
 		LIMIT $2
 	`
 
-	err := d.db.SelectContext(ctx, &movies, query, pgvector.NewVector(queryEmbedding), limit)
+	err := d.search.Query(ctx, d.db, func(q sqlx.QueryerContext) error {
+		return sqlx.SelectContext(ctx, q, &movies, query, pgvector.NewVector(queryEmbedding), limit, roomID)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -261,3 +423,261 @@ func (d *Driver) IsAllReady(ctx context.Context, roomID uuid.UUID) (bool, error)
 
 	return result.ReadyCount == result.ParticipantsCount, nil
 }
+
+// Swipe is stored once per participant and movie.
+// Likes are aggregated only for freshly inserted swipes so resending the same reaction is harmless
+func (d *Driver) AddReaction(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, movieID uuid.UUID, reaction int) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var voted bool
+	checkParticipantQuery := `
+		SELECT voted 
+		FROM participants 
+		WHERE id = $1 AND room_id = $2
+	`
+
+	err = tx.GetContext(ctx, &voted, checkParticipantQuery, userID, roomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return usecase_vote.ErrResourceNotFound
+		}
+		return err
+	}
+
+	if voted {
+		return nil
+	}
+
+	// Pending movies aren't votable yet
+	var ready bool
+	err = tx.GetContext(ctx, &ready, `SELECT EXISTS(SELECT 1 FROM movies WHERE id = $1 AND status = 'READY')`, movieID)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return usecase_vote.ErrResourceNotFound
+	}
+
+	insertQuery := `
+		INSERT INTO participant_reactions (participant_id, room_id, movie_id, reaction)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (participant_id, movie_id) DO NOTHING
+	`
+
+	result, err := tx.ExecContext(ctx, insertQuery, userID, roomID, movieID, reaction)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return usecase_vote.ErrResourceNotFound
+		}
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted > 0 {
+		if err := d.insertReactions(ctx, map[uuid.UUID]int{movieID: reaction}, tx, roomID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *Driver) ReactionsCount(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (int, error) {
+	var count int
+
+	query := `
+		SELECT COUNT(*) 
+		FROM participant_reactions 
+		WHERE participant_id = $1 AND room_id = $2
+	`
+
+	if err := d.db.GetContext(ctx, &count, query, userID, roomID); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (d *Driver) NextMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, queryEmbedding []float32) (*model.MovieMeta, error) {
+	var movie movieDTO
+
+	where := `movie_vector IS NOT NULL AND status = 'READY' AND ` + fmt.Sprintf(inRoomPool, 3) + `
+		AND NOT EXISTS (
+			SELECT 1 
+			FROM participant_reactions pr 
+			WHERE pr.participant_id = $2 AND pr.room_id = $3 AND pr.movie_id = movies.id
+		)`
+	query := `
+		SELECT id, title, year, rating, genres, overview, poster_link
+		FROM movies
+		WHERE ` + where + d.candidates(where, "1") + `
+		ORDER BY ` + d.search.Distance("movie_vector", "$1") + `
+		LIMIT 1
+	`
+
+	err := d.search.Query(ctx, d.db, func(q sqlx.QueryerContext) error {
+		return sqlx.GetContext(ctx, q, &movie, query, pgvector.NewVector(queryEmbedding), userID, roomID)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase_vote.ErrResourceNotFound
+		}
+		return nil, err
+	}
+
+	return &model.MovieMeta{
+		ID:         movie.ID,
+		Title:      movie.Title,
+		Year:       movie.Year,
+		Rating:     movie.Rating,
+		Genres:     []string(movie.Genres),
+		Overview:   movie.Overview,
+		PosterLink: movie.PosterLink,
+	}, nil
+}
+
+// RoomMode tells shortlist room even when none of its movies is ready
+func (d *Driver) RoomMode(ctx context.Context, roomID uuid.UUID) (model.RoomMode, error) {
+	var mode string
+	if err := d.db.GetContext(ctx, &mode, `SELECT mode FROM rooms WHERE id = $1`, roomID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", usecase_vote.ErrResourceNotFound
+		}
+		return "", err
+	}
+	return mode, nil
+}
+
+// Shortlist returns ready movies of shortlist room by position, none for other rooms
+func (d *Driver) Shortlist(ctx context.Context, roomID uuid.UUID) ([]*model.MovieMeta, error) {
+	var movies []movieDTO
+
+	query := `
+		SELECT m.id, m.title, m.year, m.rating, m.genres, m.overview, m.poster_link
+		FROM room_shortlist s
+		JOIN movies m ON m.id = s.movie_id
+		WHERE s.room_id = $1 AND m.status = 'READY'
+		ORDER BY s.position
+	`
+
+	if err := d.db.SelectContext(ctx, &movies, query, roomID); err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.MovieMeta, 0, len(movies))
+	for _, movie := range movies {
+		result = append(result, &model.MovieMeta{
+			ID:         movie.ID,
+			Title:      movie.Title,
+			Year:       movie.Year,
+			Rating:     movie.Rating,
+			Genres:     []string(movie.Genres),
+			Overview:   movie.Overview,
+			PosterLink: movie.PosterLink,
+		})
+	}
+
+	return result, nil
+}
+
+// NextShortlistMovie picks the first shortlisted movie participant hasn't reacted to yet
+func (d *Driver) NextShortlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error) {
+	var movie movieDTO
+
+	query := `
+		SELECT m.id, m.title, m.year, m.rating, m.genres, m.overview, m.poster_link
+		FROM room_shortlist s
+		JOIN movies m ON m.id = s.movie_id
+		WHERE s.room_id = $1 AND m.status = 'READY'
+		AND NOT EXISTS (
+			SELECT 1
+			FROM participant_reactions pr
+			WHERE pr.participant_id = $2 AND pr.room_id = $1 AND pr.movie_id = m.id
+		)
+		ORDER BY s.position
+		LIMIT 1
+	`
+
+	err := d.db.GetContext(ctx, &movie, query, roomID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, usecase_vote.ErrResourceNotFound
+		}
+		return nil, err
+	}
+
+	return &model.MovieMeta{
+		ID:         movie.ID,
+		Title:      movie.Title,
+		Year:       movie.Year,
+		Rating:     movie.Rating,
+		Genres:     []string(movie.Genres),
+		Overview:   movie.Overview,
+		PosterLink: movie.PosterLink,
+	}, nil
+}
+
+// Room readiness is bumped only on the first transition so the method is idempotent
+func (d *Driver) MarkVoted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	updateVotedQuery := `
+		UPDATE participants 
+		SET voted = true 
+		WHERE id = $1 AND room_id = $2 AND voted = false
+	`
+
+	result, err := tx.ExecContext(ctx, updateVotedQuery, userID, roomID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		var exists bool
+		existsQuery := `SELECT EXISTS(SELECT 1 FROM participants WHERE id = $1 AND room_id = $2)`
+		if err := tx.GetContext(ctx, &exists, existsQuery, userID, roomID); err != nil {
+			return err
+		}
+		if !exists {
+			return usecase_vote.ErrResourceNotFound
+		}
+		return nil
+	}
+
+	updateReadyQuery := `
+		UPDATE rooms 
+		SET ready = ready + 1
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, updateReadyQuery, roomID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	return false
+}
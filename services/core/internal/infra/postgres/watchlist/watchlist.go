@@ -0,0 +1,113 @@
+package infra_postgres_watchlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_watchlist "github.com/humanbelnik/kinoswap/core/internal/usecase/watchlist"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Driver struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) *Driver {
+	return &Driver{db: db}
+}
+
+type candidateDTO struct {
+	N      int       `db:"n"`
+	ID     uuid.UUID `db:"id"`
+	Title  string    `db:"title"`
+	Year   int       `db:"year"`
+	ImdbID string    `db:"imdb_id"`
+}
+
+// Candidates looks titles up by movies_title_trgm_idx, entries are numbered from 1 by ordinality
+func (d *Driver) Candidates(ctx context.Context, entries []model.WatchlistEntry, perEntry int) ([][]model.MovieMeta, error) {
+	titles := make([]string, len(entries))
+	imdbIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		titles[i], imdbIDs[i] = entry.Title, entry.ImdbID
+	}
+
+	var rows []candidateDTO
+	err := d.db.SelectContext(ctx, &rows, `
+		SELECT q.n, m.id, m.title, m.year, m.imdb_id
+		FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS q(title, imdb_id, n)
+		CROSS JOIN LATERAL (
+			(
+				SELECT id, title, year, imdb_id FROM movies
+				WHERE status = 'READY' AND q.imdb_id <> '' AND imdb_id = q.imdb_id
+			)
+			UNION ALL
+			(
+				SELECT id, title, year, imdb_id FROM movies
+				WHERE status = 'READY' AND lower(title) % lower(q.title)
+				ORDER BY similarity(lower(title), lower(q.title)) DESC, votes DESC
+				LIMIT $3
+			)
+		) m
+		ORDER BY q.n
+	`, pq.Array(titles), pq.Array(imdbIDs), perEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find watchlist candidates: %w", err)
+	}
+
+	candidates := make([][]model.MovieMeta, len(entries))
+	for _, row := range rows {
+		candidates[row.N-1] = append(candidates[row.N-1], model.MovieMeta{
+			ID:     row.ID,
+			Title:  row.Title,
+			Year:   row.Year,
+			ImdbID: row.ImdbID,
+		})
+	}
+	return candidates, nil
+}
+
+func (d *Driver) Replace(ctx context.Context, code string, userID uuid.UUID, kind model.WatchlistKind, movieIDs []uuid.UUID) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var roomID uuid.UUID
+	err = tx.GetContext(ctx, &roomID, `
+		SELECT p.room_id
+		FROM participants p
+		JOIN rooms r ON r.id = p.room_id
+		WHERE r.code = $1 AND p.id = $2
+	`, code, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usecase_watchlist.ErrResourceNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM participant_watchlists WHERE participant_id = $1 AND kind = $2
+	`, userID, kind)
+	if err != nil {
+		return fmt.Errorf("failed to clear watchlist: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO participant_watchlists (participant_id, room_id, movie_id, kind)
+		SELECT $1, $2, unnest($3::uuid[]), $4
+		ON CONFLICT DO NOTHING
+	`, userID, roomID, pq.Array(movieIDs), kind)
+	if err != nil {
+		return fmt.Errorf("failed to store watchlist: %w", err)
+	}
+
+	return tx.Commit()
+}
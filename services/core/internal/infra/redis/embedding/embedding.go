@@ -0,0 +1,98 @@
+package infra_embedding_cache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// Keys deleted by a single DEL on purge
+const purgeBatch = 500
+
+type Driver struct {
+	client *redis.Client
+	key    string
+}
+
+func New(
+	client *redis.Client,
+	key string,
+) *Driver {
+	return &Driver{
+		client: client,
+		key:    key,
+	}
+}
+
+func (d *Driver) Get(ctx context.Context, key string) (model.Embedding, error) {
+	val, err := d.client.WithContext(ctx).Get(d.getFullKey(key)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return decode(val)
+}
+
+func (d *Driver) Set(ctx context.Context, key string, embedding model.Embedding, ttl time.Duration) error {
+	return d.client.WithContext(ctx).Set(d.getFullKey(key), encode(embedding), ttl).Err()
+}
+
+func (d *Driver) Purge(ctx context.Context) (int, error) {
+	client := d.client.WithContext(ctx)
+
+	purged := 0
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, d.getFullKey("*"), purgeBatch).Result()
+		if err != nil {
+			return purged, err
+		}
+		if len(keys) > 0 {
+			n, err := client.Del(keys...).Result()
+			if err != nil {
+				return purged, err
+			}
+			purged += int(n)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return purged, nil
+		}
+	}
+}
+
+func (d *Driver) getFullKey(key string) string {
+	if d.key != "" {
+		return d.key + ":" + key
+	}
+	return key
+}
+
+// Vectors are stored as little-endian float32
+func encode(e model.Embedding) []byte {
+	buf := make([]byte, 4*len(e))
+	for i, v := range e {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decode(buf []byte) (model.Embedding, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("malformed cached embedding of %d bytes", len(buf))
+	}
+	e := make(model.Embedding, len(buf)/4)
+	for i := range e {
+		e[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return e, nil
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is admin-curated set of movies, ex. "Christmas classics"
+type Collection struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	// Amount of movies in collection, ready or not
+	Size      int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -1,5 +1,33 @@
 package model
 
+import "errors"
+
+// ErrEmbedderUnavailable is returned instead of calling embedder known to be down
+var ErrEmbedderUnavailable = errors.New("embedder unavailable")
+
 type Embedding []float32
 
 const EmbeddingDimension = 384
+
+// EmbeddingModel identifies vector space produced by embedder.
+// Vectors of different models can't be compared even if dimensions match.
+type EmbeddingModel struct {
+	Name      string
+	Version   string
+	Dimension int
+	// NormalizationL2 or NormalizationNone, empty if embedder doesn't tell
+	Normalization string
+}
+
+const (
+	NormalizationL2   = "l2"
+	NormalizationNone = "none"
+)
+
+// ID is stored next to every vector
+func (m EmbeddingModel) ID() string {
+	if m.Version == "" {
+		return m.Name
+	}
+	return m.Name + "@" + m.Version
+}
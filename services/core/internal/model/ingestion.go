@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IngestionStatus string
+
+const (
+	IngestionQueued  IngestionStatus = "queued"
+	IngestionRunning IngestionStatus = "running"
+	IngestionDone    IngestionStatus = "done"
+	// Out of attempts, waits for admin to retry
+	IngestionDead IngestionStatus = "dead"
+)
+
+// IngestionJob stores poster and embedding of uploaded movie in background
+type IngestionJob struct {
+	ID      uuid.UUID
+	MovieID uuid.UUID
+	// Nil if movie has no poster or it's stored already
+	Poster []byte
+	Status IngestionStatus
+	// Including the running one
+	Attempts  int
+	LastError string
+	NextRunAt time.Time
+	CreatedAt time.Time
+}
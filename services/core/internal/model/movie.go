@@ -4,6 +4,17 @@ import "github.com/google/uuid"
 
 const EmptyTitle string = ""
 
+type MovieStatus string
+
+const (
+	// Waits for poster and embedding to be stored by ingestion worker
+	MovieStatusPending MovieStatus = "PENDING"
+	// Ingestion gave up, see dead ingestion job
+	MovieStatusFailed MovieStatus = "FAILED"
+	// Only ready movies are voted for
+	MovieStatusReady MovieStatus = "READY"
+)
+
 type MovieMeta struct {
 	ID         uuid.UUID
 	PosterLink string
@@ -11,8 +22,24 @@ type MovieMeta struct {
 	Genres     []string
 	Year       int
 	Rating     float64
+	Status     MovieStatus
 
 	Overview string
+
+	// People are stored normalized, see people table
+	Director string
+	// Stars in billing order
+	Cast []string
+	// Minutes, zero if unknown
+	Runtime     int
+	Certificate string
+	// Metacritic score out of 100, zero if unknown
+	MetaScore int
+	// IMDb user votes
+	Votes int
+	// Box office in dollars, zero if unknown
+	Gross  int64
+	ImdbID string
 }
 
 type Poster struct {
@@ -45,3 +72,57 @@ type Movie struct {
 	MM     *MovieMeta
 	Poster *Poster
 }
+
+type MovieSortField string
+
+const (
+	MovieSortTitle  MovieSortField = "title"
+	MovieSortYear   MovieSortField = "year"
+	MovieSortRating MovieSortField = "rating"
+)
+
+// MovieFilter narrows catalog down. Zero value matches every movie
+type MovieFilter struct {
+	// Movie must have all of them
+	Genres []string
+
+	YearFrom  *int
+	YearTo    *int
+	RatingMin *float64
+	RatingMax *float64
+
+	// Names are matched case insensitive, part of a name is enough
+	Director *string
+	Actor    *string
+	// Minutes, movies of unknown runtime don't match
+	RuntimeMin *int
+	RuntimeMax *int
+
+	// Movie must be in any of them
+	Collections []uuid.UUID
+}
+
+type MovieQuery struct {
+	Filter MovieFilter
+	SortBy MovieSortField
+	Desc   bool
+
+	Limit  int
+	Offset int
+}
+
+type MoviePage struct {
+	Movies []*MovieMeta
+	// Movies matching filter, regardless of limit and offset
+	Total int
+
+	// Effective ones, after defaults are applied
+	Limit  int
+	Offset int
+}
+
+// ScoredMovie is a search hit, higher score means closer match
+type ScoredMovie struct {
+	Movie *MovieMeta
+	Score float64
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReembedStatus string
+
+const (
+	ReembedRunning  ReembedStatus = "running"
+	ReembedDone     ReembedStatus = "done"
+	ReembedCanceled ReembedStatus = "canceled"
+)
+
+// ReembedJob rebuilds catalog vectors with Model next to current ones,
+// movies switch to new vectors all at once when every one of them is embedded.
+type ReembedJob struct {
+	ID     uuid.UUID
+	Model  string
+	Status ReembedStatus
+
+	// Movies are embedded in id order, job resumes after LastID
+	LastID uuid.UUID
+	// Movies having vector of Model out of Total
+	Done  int
+	Total int
+	// Last error, job is retried until done or canceled
+	Error string
+
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Progress in percents
+func (j ReembedJob) Progress() float64 {
+	if j.Total == 0 {
+		return 100
+	}
+	return float64(j.Done) * 100 / float64(j.Total)
+}
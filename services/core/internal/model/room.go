@@ -10,8 +10,21 @@ const (
 	StatusFinished RoomStatus = "FINISHED"
 )
 
+type RoomMode = string
+
+const (
+	// Candidates are found by participants preferences
+	ModeRecommend RoomMode = "RECOMMEND"
+	// Participants vote on movies picked by owner, preferences are optional
+	ModeShortlist RoomMode = "SHORTLIST"
+)
+
 type Room struct {
 	ID         uuid.UUID
 	PublicCode string
 	Status     string
+	Mode       RoomMode
+
+	// Movies of shortlist room in the order they're voted on
+	Shortlist []uuid.UUID
 }
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SagaKind string
+
+const (
+	SagaCreate SagaKind = "create"
+	SagaUpdate SagaKind = "update"
+	SagaDelete SagaKind = "delete"
+)
+
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompensating SagaStatus = "compensating"
+	SagaDone         SagaStatus = "done"
+	SagaCompensated  SagaStatus = "compensated"
+)
+
+type SagaAction string
+
+const (
+	SagaExec       SagaAction = "exec"
+	SagaCompensate SagaAction = "compensate"
+)
+
+type SagaStepStatus string
+
+const (
+	SagaStepStarted SagaStepStatus = "started"
+	SagaStepDone    SagaStepStatus = "done"
+	SagaStepFailed  SagaStepStatus = "failed"
+)
+
+// Saga is a durable record of a movie write spanning database and poster storage.
+// Unfinished sagas of crashed instances are finished or compensated by recovery worker.
+type Saga struct {
+	ID      uuid.UUID
+	MovieID uuid.UUID
+	Kind    SagaKind
+	Status  SagaStatus
+	// Names of steps in execution order
+	Steps []string
+	// Index of step being executed, or compensated while compensating
+	Step int
+	// Movie as it's written by the saga and as it was before for updates
+	Movie MovieMeta
+	Prev  *MovieMeta
+	// Poster overwritten by the saga, kept to restore it on compensation
+	Backup    []byte
+	Attempts  int
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SagaStep is an entry of saga log
+type SagaStep struct {
+	SagaID uuid.UUID
+	Step   int
+	Name   string
+	Action SagaAction
+	Status SagaStepStatus
+	Error  string
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrUnknownSearchMode = errors.New("unknown search mode")
+
+type SearchMode string
+
+const (
+	// Closest by meaning, misses exact titles
+	SearchModeVector SearchMode = "vector"
+	// Postgres full-text search over title and overview, misses vibes
+	SearchModeLexical SearchMode = "lexical"
+	// Both rankings merged by reciprocal rank fusion
+	SearchModeHybrid SearchMode = "hybrid"
+)
+
+func ParseSearchMode(s string) (SearchMode, error) {
+	switch mode := SearchMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case SearchModeVector, SearchModeLexical, SearchModeHybrid:
+		return mode, nil
+	}
+	return "", errors.Join(ErrUnknownSearchMode, errors.New(s))
+}
+
+type SearchQuery struct {
+	Text   string
+	Mode   SearchMode
+	Limit  int
+	Filter MovieFilter
+}
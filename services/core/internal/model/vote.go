@@ -20,3 +20,17 @@ type Result struct {
 	MM    MovieMeta
 	Likes int
 }
+
+// CandidatePool limits movies a room votes for
+type CandidatePool struct {
+	// Movies of room collections, nil if room has none and every movie may be a candidate
+	Allowed []uuid.UUID
+	// Movies any participant has watched are never candidates
+	Watched []uuid.UUID
+}
+
+// EmbeddedMovie is a ready movie along with its vector
+type EmbeddedMovie struct {
+	Movie  *MovieMeta
+	Vector Embedding
+}
@@ -0,0 +1,23 @@
+package model
+
+// WatchlistKind tells how participant's list affects candidates of the room
+type WatchlistKind string
+
+const (
+	// Movies participant wants to see are boosted
+	WatchlistWant WatchlistKind = "WATCHLIST"
+	// Movies participant has seen are excluded
+	WatchlistWatched WatchlistKind = "WATCHED"
+)
+
+// WatchlistEntry is a row of a list exported from another service, ex. Letterboxd or IMDb.
+// Err is set by the reader if the row couldn't be parsed, such rows are reported as unmatched.
+type WatchlistEntry struct {
+	Line  int
+	Title string
+	// Zero if unknown
+	Year int
+	// Only IMDb exports have it
+	ImdbID string
+	Err    error
+}
@@ -0,0 +1,227 @@
+// Package embedding_cache saves embedder round trips for preferences typed again and again.
+package embedding_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const (
+	DefaultSize               = 1024
+	DefaultTTL                = 7 * 24 * time.Hour
+	DefaultModelCheckInterval = time.Minute
+)
+
+type Embedder interface {
+	BuildPreferenceEmbedding(ctx context.Context, p model.Preference) (model.Embedding, error)
+	ModelInfo(ctx context.Context) (model.EmbeddingModel, error)
+}
+
+// Store is shared by all instances
+type Store interface {
+	// Returns nil if key is missing
+	Get(ctx context.Context, key string) (model.Embedding, error)
+	Set(ctx context.Context, key string, embedding model.Embedding, ttl time.Duration) error
+	// Purge drops every stored embedding and returns their amount
+	Purge(ctx context.Context) (int, error)
+}
+
+type Stats struct {
+	Model string
+	// Served from memory of this instance
+	LocalHits int64
+	// Served from store
+	StoreHits int64
+	Misses    int64
+	// Store and model lookups failed, cache was bypassed or not filled
+	Errors    int64
+	LocalSize int
+}
+
+func (s Stats) HitRate() float64 {
+	total := s.LocalHits + s.StoreHits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.LocalHits+s.StoreHits) / float64(total)
+}
+
+// Cache is a usecase_room.Embedder. Entries are keyed by model, which is asked again every
+// check interval, so other instances stop serving vectors of the previous model on their own.
+type Cache struct {
+	embedder      Embedder
+	store         Store
+	local         *lru
+	ttl           time.Duration
+	checkInterval time.Duration
+	logger        *slog.Logger
+
+	mu             sync.Mutex
+	model          string
+	modelCheckedAt time.Time
+
+	localHits atomic.Int64
+	storeHits atomic.Int64
+	misses    atomic.Int64
+	errors    atomic.Int64
+}
+
+type Option func(*Cache)
+
+func WithSize(n int) Option {
+	return func(c *Cache) {
+		if n >= 0 {
+			c.local = newLRU(n)
+		}
+	}
+}
+
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) {
+		if d > 0 {
+			c.ttl = d
+		}
+	}
+}
+
+func WithModelCheckInterval(d time.Duration) Option {
+	return func(c *Cache) {
+		if d > 0 {
+			c.checkInterval = d
+		}
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Cache) {
+		c.logger = logger
+	}
+}
+
+func New(embedder Embedder, store Store, opts ...Option) *Cache {
+	c := &Cache{
+		embedder:      embedder,
+		store:         store,
+		local:         newLRU(DefaultSize),
+		ttl:           DefaultTTL,
+		checkInterval: DefaultModelCheckInterval,
+		logger:        slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NormalizeText makes "Comedy " and "comedy" the same preference
+func NormalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+func Key(text string, modelID string) string {
+	sum := sha256.Sum256([]byte(modelID + "\x00" + NormalizeText(text)))
+	return modelID + ":" + hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) BuildPreferenceEmbedding(ctx context.Context, p model.Preference) (model.Embedding, error) {
+	modelID, err := c.modelID(ctx)
+	if err != nil {
+		c.errors.Add(1)
+		c.logger.Warn("preference cache bypassed, model is unknown", slog.String("error", err.Error()))
+		return c.embedder.BuildPreferenceEmbedding(ctx, p)
+	}
+	key := Key(p.Text, modelID)
+
+	if e, ok := c.local.get(key); ok {
+		c.localHits.Add(1)
+		return clone(e), nil
+	}
+
+	e, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.errors.Add(1)
+		c.logger.Warn("failed to read preference cache", slog.String("error", err.Error()))
+	}
+	if e != nil {
+		c.storeHits.Add(1)
+		c.local.add(key, e)
+		return clone(e), nil
+	}
+
+	c.misses.Add(1)
+	e, err = c.embedder.BuildPreferenceEmbedding(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.local.add(key, clone(e))
+	if err := c.store.Set(ctx, key, e, c.ttl); err != nil {
+		c.errors.Add(1)
+		c.logger.Warn("failed to fill preference cache", slog.String("error", err.Error()))
+	}
+	return e, nil
+}
+
+// Invalidate drops cached embeddings of every instance. Model is asked again,
+// so call it once embedder serves the new model.
+func (c *Cache) Invalidate(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	c.model = ""
+	c.mu.Unlock()
+	c.local.purge()
+
+	return c.store.Purge(ctx)
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	modelID := c.model
+	c.mu.Unlock()
+
+	return Stats{
+		Model:     modelID,
+		LocalHits: c.localHits.Load(),
+		StoreHits: c.storeHits.Load(),
+		Misses:    c.misses.Load(),
+		Errors:    c.errors.Load(),
+		LocalSize: c.local.len(),
+	}
+}
+
+func (c *Cache) modelID(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.model != "" && time.Since(c.modelCheckedAt) < c.checkInterval {
+		return c.model, nil
+	}
+	em, err := c.embedder.ModelInfo(ctx)
+	if err != nil {
+		// Model rarely changes, known one is better than no cache at all
+		if c.model != "" {
+			c.errors.Add(1)
+			c.modelCheckedAt = time.Now()
+			return c.model, nil
+		}
+		return "", err
+	}
+	if c.model != "" && c.model != em.ID() {
+		c.local.purge()
+		c.logger.Info("embedding model changed, local preference cache dropped",
+			slog.String("from", c.model), slog.String("to", em.ID()))
+	}
+	c.model, c.modelCheckedAt = em.ID(), time.Now()
+	return c.model, nil
+}
+
+func clone(e model.Embedding) model.Embedding {
+	return append(model.Embedding(nil), e...)
+}
@@ -0,0 +1,236 @@
+//go:build !integration
+// +build !integration
+
+package embedding_cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type EmbeddingCacheUnitSuite struct {
+	suite.Suite
+}
+
+// embedderStub embeds text as its length and counts calls
+type embedderStub struct {
+	mu       sync.Mutex
+	model    model.EmbeddingModel
+	modelErr error
+	embedErr error
+	calls    int
+}
+
+func (e *embedderStub) BuildPreferenceEmbedding(ctx context.Context, p model.Preference) (model.Embedding, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	if e.embedErr != nil {
+		return nil, e.embedErr
+	}
+	return model.Embedding{float32(len(p.Text))}, nil
+}
+
+func (e *embedderStub) ModelInfo(ctx context.Context) (model.EmbeddingModel, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.model, e.modelErr
+}
+
+func (e *embedderStub) setModel(m model.EmbeddingModel) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.model = m
+}
+
+func (e *embedderStub) callCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+type storeStub struct {
+	mu    sync.Mutex
+	items map[string]model.Embedding
+	err   error
+}
+
+func newStoreStub() *storeStub {
+	return &storeStub{items: make(map[string]model.Embedding)}
+}
+
+func (s *storeStub) Get(ctx context.Context, key string) (model.Embedding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.items[key], nil
+}
+
+func (s *storeStub) Set(ctx context.Context, key string, e model.Embedding, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.items[key] = e
+	return nil
+}
+
+func (s *storeStub) Purge(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.items)
+	s.items = make(map[string]model.Embedding)
+	return n, s.err
+}
+
+var testModel = model.EmbeddingModel{Name: "all-MiniLM-L6-v2", Version: "1", Dimension: 1}
+
+func (s *EmbeddingCacheUnitSuite) TestNormalizedKey(t provider.T) {
+	t.Parallel()
+
+	assert.Equal(t, Key("comedy", "m@1"), Key("  Comedy\t", "m@1"))
+	assert.Equal(t, Key("dark comedy", "m@1"), Key("Dark   COMEDY", "m@1"))
+	assert.NotEqual(t, Key("comedy", "m@1"), Key("comedy", "m@2"), "model is a part of the key")
+	assert.True(t, strings.HasPrefix(Key("comedy", "m@1"), "m@1:"))
+}
+
+func (s *EmbeddingCacheUnitSuite) TestHits(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	embedder := &embedderStub{model: testModel}
+	store := newStoreStub()
+	c := New(embedder, store)
+
+	for _, text := range []string{"comedy", "Comedy", " comedy "} {
+		e, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+		assert.NoError(t, err)
+		assert.Equal(t, model.Embedding{6}, e, "embedding of the first text is served")
+	}
+
+	other := New(embedder, store)
+	_, err := other.BuildPreferenceEmbedding(ctx, model.Preference{Text: "COMEDY"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, embedder.callCount())
+	assert.Equal(t, Stats{Model: testModel.ID(), LocalHits: 2, Misses: 1, LocalSize: 1}, c.Stats())
+	assert.Equal(t, int64(1), other.Stats().StoreHits, "other instance shares store")
+	assert.InDelta(t, 2.0/3, c.Stats().HitRate(), 1e-9)
+}
+
+func (s *EmbeddingCacheUnitSuite) TestEviction(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	embedder := &embedderStub{model: testModel}
+	c := New(embedder, newStoreStub(), WithSize(2))
+
+	for _, text := range []string{"a", "bb", "ccc", "a"} {
+		_, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+		assert.NoError(t, err)
+	}
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.LocalSize)
+	assert.Equal(t, int64(1), stats.StoreHits, "evicted embedding is found in store")
+}
+
+func (s *EmbeddingCacheUnitSuite) TestModelChange(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	embedder := &embedderStub{model: testModel}
+	c := New(embedder, newStoreStub(), WithModelCheckInterval(time.Millisecond))
+
+	_, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+	assert.NoError(t, err)
+
+	embedder.setModel(model.EmbeddingModel{Name: "bge-base", Dimension: 768})
+	time.Sleep(5 * time.Millisecond)
+	_, err = c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, embedder.callCount(), "vector of the previous model isn't served")
+	assert.Equal(t, "bge-base", c.Stats().Model)
+}
+
+func (s *EmbeddingCacheUnitSuite) TestInvalidate(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	embedder := &embedderStub{model: testModel}
+	store := newStoreStub()
+	c := New(embedder, store)
+
+	for _, text := range []string{"comedy", "horror"} {
+		_, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+		assert.NoError(t, err)
+	}
+
+	purged, err := c.Invalidate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Zero(t, c.Stats().LocalSize)
+
+	_, err = c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, embedder.callCount())
+}
+
+func (s *EmbeddingCacheUnitSuite) TestFailures(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Should embed without cache when model is unknown", func(t provider.T) {
+		embedder := &embedderStub{modelErr: errors.New("unavailable")}
+		store := newStoreStub()
+		c := New(embedder, store)
+
+		e, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Embedding{6}, e)
+		assert.Empty(t, store.items)
+		assert.Equal(t, int64(1), c.Stats().Errors)
+	})
+
+	t.Run("Should embed when store is down", func(t provider.T) {
+		embedder := &embedderStub{model: testModel}
+		store := newStoreStub()
+		store.err = errors.New("connection refused")
+		c := New(embedder, store)
+
+		e, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Embedding{6}, e)
+		assert.Equal(t, int64(2), c.Stats().Errors, "failed read and fill")
+	})
+
+	t.Run("Should not cache embedder failure", func(t provider.T) {
+		embedder := &embedderStub{model: testModel, embedErr: errors.New("unavailable")}
+		c := New(embedder, newStoreStub())
+
+		_, err := c.BuildPreferenceEmbedding(ctx, model.Preference{Text: "comedy"})
+
+		assert.ErrorIs(t, err, embedder.embedErr)
+		assert.Zero(t, c.Stats().LocalSize)
+	})
+}
+
+func TestEmbeddingCacheUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(EmbeddingCacheUnitSuite))
+}
@@ -0,0 +1,423 @@
+// Package hnsw is an in-memory approximate nearest neighbour index of movie vectors,
+// a hierarchical navigable small world graph compared by cosine similarity.
+package hnsw
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var ErrDimension = errors.New("vector dimension differs from index")
+
+const (
+	// Links of a node per layer, twice as many on the bottom one
+	DefaultM              = 16
+	DefaultEfConstruction = 200
+	DefaultEfSearch       = 64
+)
+
+type Hit struct {
+	ID uuid.UUID
+	// Cosine similarity
+	Score float64
+}
+
+type node struct {
+	id uuid.UUID
+	// Unit length, so distance is 1 - dot product
+	vector []float32
+	// Neighbours of every layer node is on
+	links [][]int32
+	// Nodes linking this one on every layer, so delete finds them with no scan of the index
+	linkedBy [][]int32
+}
+
+// Index is safe for concurrent use. Deleted nodes are unlinked, so search never meets them.
+type Index struct {
+	m              int
+	efConstruction int
+	efSearch       int
+	rnd            *rand.Rand
+
+	mu    sync.RWMutex
+	dim   int
+	nodes []*node
+	// Slots of deleted nodes reused by next inserts
+	free  []int32
+	ids   map[uuid.UUID]int32
+	entry int32
+}
+
+type Option func(*Index)
+
+func WithM(m int) Option {
+	return func(x *Index) {
+		if m > 1 {
+			x.m = m
+		}
+	}
+}
+
+// WithEfConstruction sets candidates kept while linking a new node, more builds slower with better recall
+func WithEfConstruction(ef int) Option {
+	return func(x *Index) {
+		if ef > 0 {
+			x.efConstruction = ef
+		}
+	}
+}
+
+// WithEfSearch sets candidates kept while searching, more searches slower with better recall
+func WithEfSearch(ef int) Option {
+	return func(x *Index) {
+		if ef > 0 {
+			x.efSearch = ef
+		}
+	}
+}
+
+// WithSeed makes layers of nodes and so the whole graph reproducible
+func WithSeed(seed uint64) Option {
+	return func(x *Index) {
+		x.rnd = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+func New(opts ...Option) *Index {
+	x := &Index{
+		m:              DefaultM,
+		efConstruction: DefaultEfConstruction,
+		efSearch:       DefaultEfSearch,
+		rnd:            rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		ids:            make(map[uuid.UUID]int32),
+		entry:          -1,
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
+}
+
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.ids)
+}
+
+func (x *Index) IDs() []uuid.UUID {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	ids := make([]uuid.UUID, 0, len(x.ids))
+	for id := range x.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Vector returns unit vector of id
+func (x *Index) Vector(id uuid.UUID) (model.Embedding, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i, ok := x.ids[id]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(x.nodes[i].vector), true
+}
+
+// Add inserts vector of id or replaces the one it has. Zero vector has no direction and isn't added.
+func (x *Index) Add(id uuid.UUID, e model.Embedding) error {
+	v, ok := unit(e)
+	if !ok {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.dim != 0 && len(v) != x.dim {
+		return fmt.Errorf("%w: %d instead of %d", ErrDimension, len(v), x.dim)
+	}
+	x.dim = len(v)
+
+	if i, ok := x.ids[id]; ok {
+		if slices.Equal(x.nodes[i].vector, v) {
+			return nil
+		}
+		x.deleteLocked(i)
+	}
+	x.insertLocked(&node{id: id, vector: v, links: make([][]int32, x.randomLevel()+1)})
+	return nil
+}
+
+// Delete removes id, if index has it
+func (x *Index) Delete(id uuid.UUID) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if i, ok := x.ids[id]; ok {
+		x.deleteLocked(i)
+	}
+}
+
+// Search returns k closest to e vectors among ones accepted by filter, nil filter accepts all.
+// Search widens until k accepted are found, filters rejecting most of index make it a full scan.
+func (x *Index) Search(e model.Embedding, k int, filter func(uuid.UUID) bool) ([]Hit, error) {
+	q, ok := unit(e)
+	if !ok || k <= 0 {
+		return nil, nil
+	}
+	if filter == nil {
+		filter = func(uuid.UUID) bool { return true }
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if x.entry < 0 {
+		return nil, nil
+	}
+	if len(q) != x.dim {
+		return nil, fmt.Errorf("%w: %d instead of %d", ErrDimension, len(q), x.dim)
+	}
+
+	ep := x.descend(q, 0)
+	for ef := max(x.efSearch, k); ef < len(x.ids); ef *= 4 {
+		hits := x.accepted(x.searchLayer(q, []int32{ep}, ef, 0), k, filter)
+		if len(hits) == k {
+			return hits, nil
+		}
+	}
+	return x.scan(q, k, filter), nil
+}
+
+func (x *Index) accepted(found []candidate, k int, filter func(uuid.UUID) bool) []Hit {
+	hits := make([]Hit, 0, k)
+	for _, c := range found {
+		if len(hits) == k {
+			break
+		}
+		if id := x.nodes[c.i].id; filter(id) {
+			hits = append(hits, Hit{ID: id, Score: 1 - c.dist})
+		}
+	}
+	return hits
+}
+
+// scan is exact, graph left disconnected by deletes can't hide anything from it
+func (x *Index) scan(q []float32, k int, filter func(uuid.UUID) bool) []Hit {
+	var found []candidate
+	for i, n := range x.nodes {
+		if n != nil && filter(n.id) {
+			found = append(found, candidate{i: int32(i), dist: distance(q, n.vector)})
+		}
+	}
+	slices.SortFunc(found, compareCandidates)
+	return x.accepted(found, k, filter)
+}
+
+func (x *Index) randomLevel() int {
+	return int(math.Floor(-math.Log(1-x.rnd.Float64()) / math.Log(float64(x.m))))
+}
+
+func (x *Index) maxLinks(layer int) int {
+	if layer == 0 {
+		return 2 * x.m
+	}
+	return x.m
+}
+
+func (x *Index) topLayer() int {
+	return len(x.nodes[x.entry].links) - 1
+}
+
+// descend greedily goes from entry point down to layer and returns the closest node found
+func (x *Index) descend(q []float32, layer int) int32 {
+	ep := x.entry
+	for l := x.topLayer(); l > layer; l-- {
+		ep = x.searchLayer(q, []int32{ep}, 1, l)[0].i
+	}
+	return ep
+}
+
+func (x *Index) insertLocked(n *node) {
+	var i int32
+	if len(x.free) > 0 {
+		i, x.free = x.free[len(x.free)-1], x.free[:len(x.free)-1]
+		x.nodes[i] = n
+	} else {
+		i = int32(len(x.nodes))
+		x.nodes = append(x.nodes, n)
+	}
+	x.ids[n.id] = i
+	n.linkedBy = make([][]int32, len(n.links))
+
+	if x.entry < 0 {
+		x.entry = i
+		return
+	}
+
+	level := len(n.links) - 1
+	ep := x.descend(n.vector, level)
+	eps := []int32{ep}
+	for l := min(level, x.topLayer()); l >= 0; l-- {
+		found := x.searchLayer(n.vector, eps, x.efConstruction, l)
+		x.setLinks(i, l, x.selectNeighbours(found, x.m))
+		for _, nb := range n.links[l] {
+			x.link(nb, i, l)
+		}
+		eps = eps[:0]
+		for _, c := range found {
+			eps = append(eps, c.i)
+		}
+	}
+
+	if level > x.topLayer() {
+		x.entry = i
+	}
+}
+
+// link adds link from a to b, pruning links of a down to the most diverse ones
+func (x *Index) link(a, b int32, layer int) {
+	n := x.nodes[a]
+	if len(n.links[layer]) < x.maxLinks(layer) {
+		n.links[layer] = append(n.links[layer], b)
+		x.nodes[b].linkedBy[layer] = append(x.nodes[b].linkedBy[layer], a)
+		return
+	}
+	links := append(slices.Clone(n.links[layer]), b)
+	x.setLinks(a, layer, x.selectNeighbours(x.candidates(n.vector, links), x.maxLinks(layer)))
+}
+
+// setLinks replaces links of a on layer and keeps reverse links of both old and new neighbours
+func (x *Index) setLinks(a int32, layer int, links []int32) {
+	n := x.nodes[a]
+	for _, b := range n.links[layer] {
+		if !slices.Contains(links, b) {
+			x.unlinkedBy(b, a, layer)
+		}
+	}
+	for _, b := range links {
+		if !slices.Contains(n.links[layer], b) {
+			x.nodes[b].linkedBy[layer] = append(x.nodes[b].linkedBy[layer], a)
+		}
+	}
+	n.links[layer] = links
+}
+
+func (x *Index) unlinkedBy(b, a int32, layer int) {
+	n := x.nodes[b]
+	if j := slices.Index(n.linkedBy[layer], a); j >= 0 {
+		n.linkedBy[layer] = slices.Delete(n.linkedBy[layer], j, j+1)
+	}
+}
+
+func (x *Index) candidates(q []float32, nodes []int32) []candidate {
+	found := make([]candidate, 0, len(nodes))
+	for _, i := range nodes {
+		found = append(found, candidate{i: i, dist: distance(q, x.nodes[i].vector)})
+	}
+	slices.SortFunc(found, compareCandidates)
+	return found
+}
+
+// selectNeighbours prefers candidates closer to the node than to any already selected one,
+// so links lead in different directions. Others fill the rest. Candidates are sorted by distance.
+func (x *Index) selectNeighbours(found []candidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var pruned []int32
+	for _, c := range found {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if distance(x.nodes[c.i].vector, x.nodes[s].vector) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.i)
+		} else {
+			pruned = append(pruned, c.i)
+		}
+	}
+	for _, i := range pruned {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, i)
+	}
+	return selected
+}
+
+// deleteLocked unlinks node and relinks nodes that linked it to its neighbours.
+// Reverse links lead to them, so delete costs as much as relinking them.
+func (x *Index) deleteLocked(i int32) {
+	n := x.nodes[i]
+	for l, links := range n.links {
+		for _, b := range links {
+			x.unlinkedBy(b, i, l)
+		}
+		// Relinking changes reverse links of deleted node too
+		for _, j := range slices.Clone(n.linkedBy[l]) {
+			other := x.nodes[j]
+			// Neighbours of deleted node are the most likely to be close
+			candidates := slices.DeleteFunc(slices.Concat(other.links[l], links), func(c int32) bool {
+				return c == i || c == j
+			})
+			slices.Sort(candidates)
+			candidates = slices.Compact(candidates)
+			x.setLinks(j, l, x.selectNeighbours(x.candidates(other.vector, candidates), x.maxLinks(l)))
+		}
+	}
+
+	x.nodes[i] = nil
+	x.free = append(x.free, i)
+	delete(x.ids, n.id)
+
+	if x.entry != i {
+		return
+	}
+	// Entry point is one of the few nodes of the top layer, it's rarely deleted
+	x.entry = -1
+	for j, other := range x.nodes {
+		if other != nil && (x.entry < 0 || len(other.links) > len(x.nodes[x.entry].links)) {
+			x.entry = int32(j)
+		}
+	}
+	if x.entry < 0 {
+		x.nodes, x.free, x.dim = nil, nil, 0
+	}
+}
+
+// unit returns normalized copy of e, false for zero vector
+func unit(e model.Embedding) ([]float32, bool) {
+	var norm float64
+	for _, f := range e {
+		norm += float64(f) * float64(f)
+	}
+	if norm == 0 {
+		return nil, false
+	}
+	norm = math.Sqrt(norm)
+
+	v := make([]float32, len(e))
+	for i, f := range e {
+		v[i] = float32(float64(f) / norm)
+	}
+	return v, true
+}
+
+func distance(a, b []float32) float64 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - float64(dot)
+}
@@ -0,0 +1,270 @@
+//go:build !integration
+// +build !integration
+
+package hnsw
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type HNSWUnitSuite struct {
+	suite.Suite
+}
+
+const (
+	testDim     = 64
+	testK       = 10
+	testQueries = 100
+)
+
+type catalog struct {
+	ids     []uuid.UUID
+	vectors []model.Embedding
+}
+
+// clustered vectors, as movies of a genre are close to each other
+func newCatalog(seed uint64, n int) catalog {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	centroids := make([]model.Embedding, 20)
+	for i := range centroids {
+		centroids[i] = random(rnd, nil)
+	}
+
+	c := catalog{ids: make([]uuid.UUID, n), vectors: make([]model.Embedding, n)}
+	for i := range n {
+		c.ids[i] = uuid.New()
+		c.vectors[i] = random(rnd, centroids[rnd.IntN(len(centroids))])
+	}
+	return c
+}
+
+func random(rnd *rand.Rand, around model.Embedding) model.Embedding {
+	v := make(model.Embedding, testDim)
+	for i := range v {
+		v[i] = float32(rnd.NormFloat64())
+		if around != nil {
+			v[i] = 2*around[i] + v[i]/2
+		}
+	}
+	return v
+}
+
+func (c catalog) index(opts ...Option) *Index {
+	x := New(append([]Option{WithSeed(1), WithEfConstruction(100)}, opts...)...)
+	for i, id := range c.ids {
+		if err := x.Add(id, c.vectors[i]); err != nil {
+			panic(err)
+		}
+	}
+	return x
+}
+
+// exact top k by brute force
+func (c catalog) exact(q model.Embedding, k int, filter func(uuid.UUID) bool) []uuid.UUID {
+	qv, _ := unit(q)
+	type hit struct {
+		id   uuid.UUID
+		dist float64
+	}
+	var hits []hit
+	for i, id := range c.ids {
+		if filter == nil || filter(id) {
+			v, _ := unit(c.vectors[i])
+			hits = append(hits, hit{id: id, dist: distance(qv, v)})
+		}
+	}
+	slices.SortFunc(hits, func(a, b hit) int {
+		return compareCandidates(candidate{dist: a.dist}, candidate{dist: b.dist})
+	})
+
+	ids := make([]uuid.UUID, 0, k)
+	for _, h := range hits[:min(k, len(hits))] {
+		ids = append(ids, h.id)
+	}
+	return ids
+}
+
+func recall(t provider.T, x *Index, c catalog, filter func(uuid.UUID) bool) float64 {
+	rnd := rand.New(rand.NewPCG(7, 7))
+	var found, total int
+	for range testQueries {
+		q := c.vectors[rnd.IntN(len(c.vectors))]
+		q = random(rnd, q)
+
+		hits, err := x.Search(q, testK, filter)
+		assert.NoError(t, err)
+		exact := c.exact(q, testK, filter)
+		assert.Len(t, hits, len(exact))
+		for _, h := range hits {
+			if slices.Contains(exact, h.ID) {
+				found++
+			}
+		}
+		total += len(exact)
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(found) / float64(total)
+}
+
+// assertReverseLinks checks every link is known to the node it leads to and nothing else is
+func assertReverseLinks(t provider.T, x *Index) {
+	want := make(map[[3]int32]int)
+	got := make(map[[3]int32]int)
+	for i, n := range x.nodes {
+		if n == nil {
+			continue
+		}
+		for l := range n.links {
+			for _, nb := range n.links[l] {
+				want[[3]int32{int32(i), nb, int32(l)}]++
+			}
+			for _, by := range n.linkedBy[l] {
+				got[[3]int32{by, int32(i), int32(l)}]++
+			}
+		}
+	}
+	assert.Equal(t, want, got)
+}
+
+func (s *HNSWUnitSuite) TestRecall(t provider.T) {
+	t.Parallel()
+
+	c := newCatalog(1, 5000)
+	x := c.index()
+
+	assert.Equal(t, len(c.ids), x.Len())
+	assert.GreaterOrEqual(t, recall(t, x, c, nil), 0.95)
+}
+
+func (s *HNSWUnitSuite) TestFilter(t provider.T) {
+	t.Parallel()
+
+	c := newCatalog(2, 3000)
+	x := c.index()
+
+	testCases := []struct {
+		name   string
+		filter func(uuid.UUID) bool
+	}{
+		{name: "Should skip excluded movies", filter: func(id uuid.UUID) bool { return id[0]%10 != 0 }},
+		{name: "Should find the few allowed movies", filter: func(id uuid.UUID) bool { return id[0] < 3 }},
+		{name: "Should find nothing if nothing is allowed", filter: func(uuid.UUID) bool { return false }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			assert.GreaterOrEqual(t, recall(t, x, c, tc.filter), 0.95)
+
+			hits, err := x.Search(c.vectors[0], testK, tc.filter)
+			assert.NoError(t, err)
+			for _, h := range hits {
+				assert.True(t, tc.filter(h.ID), "rejected movie %s is found", h.ID)
+			}
+		})
+	}
+}
+
+func (s *HNSWUnitSuite) TestDeleteAndReplace(t provider.T) {
+	t.Parallel()
+
+	c := newCatalog(3, 2000)
+	x := c.index()
+
+	// Every other movie is deleted, a tenth of the rest moves elsewhere
+	var kept catalog
+	rnd := rand.New(rand.NewPCG(5, 5))
+	for i, id := range c.ids {
+		switch {
+		case i%2 == 0:
+			x.Delete(id)
+		case i%10 == 1:
+			v := random(rnd, nil)
+			assert.NoError(t, x.Add(id, v))
+			kept.ids, kept.vectors = append(kept.ids, id), append(kept.vectors, v)
+		default:
+			kept.ids, kept.vectors = append(kept.ids, id), append(kept.vectors, c.vectors[i])
+		}
+	}
+
+	assert.Equal(t, len(kept.ids), x.Len())
+	assertReverseLinks(t, x)
+	assert.GreaterOrEqual(t, recall(t, x, kept, nil), 0.9)
+
+	hits, err := x.Search(c.vectors[0], 1, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, c.ids[0], hits[0].ID, "deleted movie isn't found")
+
+	hits, err = x.Search(kept.vectors[0], 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, kept.ids[0], hits[0].ID)
+	assert.InDelta(t, 1, hits[0].Score, 1e-5)
+
+	for _, id := range kept.ids {
+		x.Delete(id)
+	}
+	assert.Zero(t, x.Len())
+	hits, err = x.Search(c.vectors[0], testK, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+	assert.NoError(t, x.Add(c.ids[0], c.vectors[0][:testDim/2]), "emptied index takes any dimension")
+}
+
+func (s *HNSWUnitSuite) TestDimension(t provider.T) {
+	t.Parallel()
+
+	x := New()
+	assert.NoError(t, x.Add(uuid.New(), model.Embedding{1, 0, 0}))
+	assert.NoError(t, x.Add(uuid.New(), model.Embedding{0, 0, 0}), "zero vector is skipped")
+
+	assert.ErrorIs(t, x.Add(uuid.New(), model.Embedding{1, 0}), ErrDimension)
+	_, err := x.Search(model.Embedding{1, 0}, 1, nil)
+	assert.ErrorIs(t, err, ErrDimension)
+	assert.Equal(t, 1, x.Len())
+}
+
+func (s *HNSWUnitSuite) TestSnapshot(t provider.T) {
+	t.Parallel()
+
+	c := newCatalog(4, 1000)
+	x := c.index()
+	for _, id := range c.ids[:100] {
+		x.Delete(id)
+	}
+	c.ids, c.vectors = c.ids[100:], c.vectors[100:]
+
+	var buf bytes.Buffer
+	assert.NoError(t, x.Save(&buf))
+	restored, err := Load(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, x.Len(), restored.Len())
+	assertReverseLinks(t, restored)
+	q := c.vectors[0]
+	want, err := x.Search(q, testK, nil)
+	assert.NoError(t, err)
+	got, err := restored.Search(q, testK, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got, "restored graph is the same")
+
+	assert.NoError(t, restored.Add(uuid.New(), q), "deleted slots are reused")
+	assert.GreaterOrEqual(t, recall(t, restored, c, nil), 0.95)
+
+	_, err = Load(bytes.NewReader([]byte("garbage")))
+	assert.Error(t, err)
+}
+
+func TestHNSWUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(HNSWUnitSuite))
+}
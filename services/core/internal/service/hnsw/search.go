@@ -0,0 +1,82 @@
+package hnsw
+
+import (
+	"cmp"
+	"container/heap"
+	"slices"
+)
+
+type candidate struct {
+	i    int32
+	dist float64
+}
+
+func compareCandidates(a, b candidate) int {
+	return cmp.Compare(a.dist, b.dist)
+}
+
+// queue is the closest first, or the furthest first if far is set
+type queue struct {
+	items []candidate
+	far   bool
+}
+
+func (q queue) Len() int { return len(q.items) }
+func (q queue) Less(i, j int) bool {
+	if q.far {
+		return q.items[i].dist > q.items[j].dist
+	}
+	return q.items[i].dist < q.items[j].dist
+}
+func (q queue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *queue) Push(x any)   { q.items = append(q.items, x.(candidate)) }
+func (q *queue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// searchLayer returns up to ef nodes of layer closest to q, the closest first.
+// It walks from entry points to their neighbours while they get closer than the furthest found.
+func (x *Index) searchLayer(q []float32, eps []int32, ef, layer int) []candidate {
+	visited := make(map[int32]bool, ef*x.m)
+	next := &queue{}
+	found := &queue{far: true}
+	for _, ep := range eps {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		c := candidate{i: ep, dist: distance(q, x.nodes[ep].vector)}
+		heap.Push(next, c)
+		heap.Push(found, c)
+		if found.Len() > ef {
+			heap.Pop(found)
+		}
+	}
+
+	for next.Len() > 0 {
+		c := heap.Pop(next).(candidate)
+		if found.Len() >= ef && c.dist > found.items[0].dist {
+			break
+		}
+		for _, nb := range x.nodes[c.i].links[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+
+			d := distance(q, x.nodes[nb].vector)
+			if found.Len() < ef || d < found.items[0].dist {
+				heap.Push(next, candidate{i: nb, dist: d})
+				heap.Push(found, candidate{i: nb, dist: d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(found.items, compareCandidates)
+	return found.items
+}
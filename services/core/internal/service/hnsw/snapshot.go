@@ -0,0 +1,100 @@
+package hnsw
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+// Bumped whenever snapshot layout changes
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version int
+	M       int
+	Dim     int
+	Entry   int32
+	Nodes   []snapshotNode
+}
+
+// Deleted nodes keep their slots, so links stay valid. Reverse links are rebuilt on load.
+type snapshotNode struct {
+	Deleted bool
+	ID      uuid.UUID
+	Vector  []float32
+	Links   [][]int32
+}
+
+// Save writes the whole graph, Load restores it with no vector compared again
+func (x *Index) Save(w io.Writer) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	s := snapshot{Version: snapshotVersion, M: x.m, Dim: x.dim, Entry: x.entry, Nodes: make([]snapshotNode, len(x.nodes))}
+	for i, n := range x.nodes {
+		if n == nil {
+			s.Nodes[i].Deleted = true
+			continue
+		}
+		s.Nodes[i] = snapshotNode{ID: n.id, Vector: n.vector, Links: n.links}
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// Load reads graph written by Save. M of the snapshot wins over WithM, links were pruned by it.
+func Load(r io.Reader, opts ...Option) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, s.Version)
+	}
+
+	x := New(append(opts, WithM(s.M))...)
+	x.dim, x.entry = s.Dim, s.Entry
+	x.nodes = make([]*node, len(s.Nodes))
+	for i, sn := range s.Nodes {
+		if sn.Deleted {
+			x.free = append(x.free, int32(i))
+			continue
+		}
+		if len(sn.Vector) != s.Dim || len(sn.Links) == 0 {
+			return nil, fmt.Errorf("snapshot node %s is broken", sn.ID)
+		}
+		x.nodes[i] = &node{id: sn.ID, vector: sn.Vector, links: sn.Links, linkedBy: make([][]int32, len(sn.Links))}
+		x.ids[sn.ID] = int32(i)
+	}
+
+	for _, n := range x.nodes {
+		if n == nil {
+			continue
+		}
+		for l, links := range n.links {
+			for _, nb := range links {
+				if nb < 0 || int(nb) >= len(x.nodes) || x.nodes[nb] == nil || len(x.nodes[nb].links) <= l {
+					return nil, fmt.Errorf("snapshot node %s links missing node %d", n.id, nb)
+				}
+			}
+		}
+	}
+	for i, n := range x.nodes {
+		if n == nil {
+			continue
+		}
+		for l, links := range n.links {
+			for _, nb := range links {
+				x.nodes[nb].linkedBy[l] = append(x.nodes[nb].linkedBy[l], int32(i))
+			}
+		}
+	}
+	if (x.entry < 0) != (len(x.ids) == 0) || int(x.entry) >= len(x.nodes) || (x.entry >= 0 && x.nodes[x.entry] == nil) {
+		return nil, errors.New("snapshot entry point is broken")
+	}
+	return x, nil
+}
@@ -0,0 +1,177 @@
+// Package ingestion stores posters and embeddings of uploaded movies in background.
+package ingestion
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const (
+	DefaultInterval    = 2 * time.Second
+	DefaultBatchSize   = 8
+	DefaultLease       = 2 * time.Minute
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 5 * time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+)
+
+type Repository interface {
+	ClaimIngestion(ctx context.Context, limit int, lease time.Duration) ([]model.IngestionJob, error)
+	FailIngestion(ctx context.Context, job model.IngestionJob, reason string, nextRunAt time.Time, dead bool) error
+}
+
+type Ingester interface {
+	Ingest(ctx context.Context, job model.IngestionJob) error
+}
+
+// Worker retries failed jobs with exponential backoff and dead-letters them after max attempts.
+// Claimed jobs are leased, so jobs of a crashed instance are picked up by others.
+type Worker struct {
+	repo        Repository
+	ingester    Ingester
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+type Option func(*Worker)
+
+func WithInterval(d time.Duration) Option {
+	return func(w *Worker) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+func WithMaxAttempts(n int) Option {
+	return func(w *Worker) {
+		if n > 0 {
+			w.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets delay before the first retry, it doubles with every next one up to max
+func WithBackoff(base, max time.Duration) Option {
+	return func(w *Worker) {
+		if base > 0 && max >= base {
+			w.backoff, w.maxBackoff = base, max
+		}
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+func New(repo Repository, ingester Ingester, opts ...Option) *Worker {
+	w := &Worker{
+		repo:        repo,
+		ingester:    ingester,
+		interval:    DefaultInterval,
+		batchSize:   DefaultBatchSize,
+		lease:       DefaultLease,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		logger:      slog.Default(),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run processes due jobs right away and then every interval until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Step(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step processes due jobs until there are none left
+func (w *Worker) Step(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.repo.ClaimIngestion(ctx, w.batchSize, w.lease)
+		if err != nil {
+			w.logger.Error("failed to claim ingestion jobs", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, job := range jobs {
+			w.process(ctx, job)
+		}
+
+		if len(jobs) < w.batchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job model.IngestionJob) {
+	// Job outlived its lease on every attempt, worker crashes on it
+	if job.Attempts > w.maxAttempts {
+		w.fail(ctx, job, "worker crashed on every attempt")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.lease)
+	defer cancel()
+
+	if err := w.ingester.Ingest(ctx, job); err != nil {
+		w.fail(ctx, job, err.Error())
+		return
+	}
+
+	w.logger.Info("movie ingested", slog.String("movie", job.MovieID.String()), slog.Int("attempt", job.Attempts))
+}
+
+func (w *Worker) fail(ctx context.Context, job model.IngestionJob, reason string) {
+	dead := job.Attempts >= w.maxAttempts
+	nextRunAt := w.now().Add(w.retryDelay(job.Attempts))
+
+	if dead {
+		w.logger.Error("movie ingestion dead-lettered",
+			slog.String("movie", job.MovieID.String()), slog.Int("attempts", job.Attempts), slog.String("error", reason))
+	} else {
+		w.logger.Warn("movie ingestion failed, will retry",
+			slog.String("movie", job.MovieID.String()), slog.Int("attempt", job.Attempts),
+			slog.Time("next_run_at", nextRunAt), slog.String("error", reason))
+	}
+
+	if err := w.repo.FailIngestion(context.WithoutCancel(ctx), job, reason, nextRunAt, dead); err != nil {
+		w.logger.Error("failed to save ingestion failure", slog.String("error", err.Error()))
+	}
+}
+
+// retryDelay doubles with every attempt, half of it is jittered so retries of a burst spread out
+func (w *Worker) retryDelay(attempt int) time.Duration {
+	d := w.backoff
+	for i := 1; i < attempt && d < w.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, w.maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
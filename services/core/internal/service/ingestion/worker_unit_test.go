@@ -0,0 +1,247 @@
+//go:build !integration
+// +build !integration
+
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type WorkerUnitSuite struct {
+	suite.Suite
+}
+
+// queueStub keeps jobs in memory and mimics postgres queue semantics with a fake clock
+type queueStub struct {
+	mu          sync.Mutex
+	now         time.Time
+	jobs        []*model.IngestionJob
+	lockedUntil map[uuid.UUID]time.Time
+}
+
+func newQueueStub(n int) *queueStub {
+	q := &queueStub{now: time.Unix(0, 0), lockedUntil: map[uuid.UUID]time.Time{}}
+	for range n {
+		q.jobs = append(q.jobs, &model.IngestionJob{
+			ID: uuid.New(), MovieID: uuid.New(), Status: model.IngestionQueued, NextRunAt: q.now,
+		})
+	}
+	return q
+}
+
+func (q *queueStub) clock() time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.now
+}
+
+func (q *queueStub) advance(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.now = q.now.Add(d)
+}
+
+func (q *queueStub) ClaimIngestion(ctx context.Context, limit int, lease time.Duration) ([]model.IngestionJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var claimed []model.IngestionJob
+	for _, job := range q.jobs {
+		due := job.Status == model.IngestionQueued && !job.NextRunAt.After(q.now)
+		expired := job.Status == model.IngestionRunning && q.lockedUntil[job.ID].Before(q.now)
+		if !due && !expired {
+			continue
+		}
+		job.Status = model.IngestionRunning
+		job.Attempts++
+		q.lockedUntil[job.ID] = q.now.Add(lease)
+		claimed = append(claimed, *job)
+		if len(claimed) == limit {
+			break
+		}
+	}
+	return claimed, nil
+}
+
+func (q *queueStub) FailIngestion(ctx context.Context, job model.IngestionJob, reason string, nextRunAt time.Time, dead bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stored := q.find(job.ID)
+	stored.Status, stored.LastError, stored.NextRunAt = model.IngestionQueued, reason, nextRunAt
+	if dead {
+		stored.Status = model.IngestionDead
+	}
+	return nil
+}
+
+func (q *queueStub) complete(id uuid.UUID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.find(id).Status = model.IngestionDone
+}
+
+func (q *queueStub) find(id uuid.UUID) *model.IngestionJob {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+func (q *queueStub) job(i int) model.IngestionJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *q.jobs[i]
+}
+
+// ingesterStub fails first failures calls of every movie
+type ingesterStub struct {
+	mu       sync.Mutex
+	queue    *queueStub
+	failures int
+	calls    map[uuid.UUID]int
+}
+
+func (i *ingesterStub) Ingest(ctx context.Context, job model.IngestionJob) error {
+	i.mu.Lock()
+	i.calls[job.MovieID]++
+	calls := i.calls[job.MovieID]
+	i.mu.Unlock()
+
+	if calls <= i.failures {
+		return errors.New("embedder unavailable")
+	}
+	i.queue.complete(job.ID)
+	return nil
+}
+
+func newWorker(q *queueStub, ingester Ingester, opts ...Option) *Worker {
+	w := New(q, ingester, opts...)
+	w.now = q.clock
+	return w
+}
+
+func (suite *WorkerUnitSuite) TestStepIngestsAllDueJobs(t provider.T) {
+	t.Parallel()
+
+	q := newQueueStub(DefaultBatchSize*2 + 1)
+	ingester := &ingesterStub{queue: q, calls: map[uuid.UUID]int{}}
+
+	newWorker(q, ingester).Step(context.Background())
+
+	for i := range q.jobs {
+		assert.Equal(t, model.IngestionDone, q.job(i).Status)
+		assert.Equal(t, 1, q.job(i).Attempts)
+	}
+}
+
+func (suite *WorkerUnitSuite) TestStepRetriesWithBackoff(t provider.T) {
+	t.Parallel()
+
+	q := newQueueStub(1)
+	ingester := &ingesterStub{queue: q, failures: 2, calls: map[uuid.UUID]int{}}
+	w := newWorker(q, ingester, WithBackoff(time.Second, time.Minute))
+
+	w.Step(context.Background())
+
+	job := q.job(0)
+	assert.Equal(t, model.IngestionQueued, job.Status)
+	assert.Contains(t, job.LastError, "embedder unavailable")
+	assert.WithinRange(t, job.NextRunAt, q.clock().Add(time.Second/2), q.clock().Add(time.Second))
+
+	// Not due yet
+	w.Step(context.Background())
+	assert.Equal(t, 1, q.job(0).Attempts)
+
+	q.advance(time.Second)
+	w.Step(context.Background())
+
+	job = q.job(0)
+	assert.Equal(t, 2, job.Attempts)
+	assert.WithinRange(t, job.NextRunAt, q.clock().Add(time.Second), q.clock().Add(2*time.Second))
+
+	q.advance(2 * time.Second)
+	w.Step(context.Background())
+
+	assert.Equal(t, model.IngestionDone, q.job(0).Status)
+	assert.Equal(t, 3, q.job(0).Attempts)
+}
+
+func (suite *WorkerUnitSuite) TestStepDeadLettersAfterMaxAttempts(t provider.T) {
+	t.Parallel()
+
+	q := newQueueStub(1)
+	ingester := &ingesterStub{queue: q, failures: 10, calls: map[uuid.UUID]int{}}
+	w := newWorker(q, ingester, WithMaxAttempts(3), WithBackoff(time.Second, time.Second))
+
+	for range 5 {
+		w.Step(context.Background())
+		q.advance(time.Second)
+	}
+
+	job := q.job(0)
+	assert.Equal(t, model.IngestionDead, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, 3, ingester.calls[job.MovieID])
+}
+
+func (suite *WorkerUnitSuite) TestStepReclaimsJobOfCrashedWorker(t provider.T) {
+	t.Parallel()
+
+	q := newQueueStub(1)
+	// Crashed worker claimed the job and never reported back
+	_, _ = q.ClaimIngestion(context.Background(), 1, DefaultLease)
+	ingester := &ingesterStub{queue: q, calls: map[uuid.UUID]int{}}
+	w := newWorker(q, ingester)
+
+	w.Step(context.Background())
+	assert.Zero(t, ingester.calls[q.job(0).MovieID], "job is leased to crashed worker")
+
+	q.advance(DefaultLease + time.Second)
+	w.Step(context.Background())
+
+	assert.Equal(t, model.IngestionDone, q.job(0).Status)
+	assert.Equal(t, 2, q.job(0).Attempts)
+}
+
+func (suite *WorkerUnitSuite) TestStepDeadLettersJobCrashingEveryWorker(t provider.T) {
+	t.Parallel()
+
+	q := newQueueStub(1)
+	for range 3 {
+		_, _ = q.ClaimIngestion(context.Background(), 1, DefaultLease)
+		q.advance(DefaultLease + time.Second)
+	}
+	ingester := &ingesterStub{queue: q, calls: map[uuid.UUID]int{}}
+
+	newWorker(q, ingester, WithMaxAttempts(3)).Step(context.Background())
+
+	assert.Equal(t, model.IngestionDead, q.job(0).Status)
+	assert.Zero(t, ingester.calls[q.job(0).MovieID])
+}
+
+func (suite *WorkerUnitSuite) TestRetryDelayIsCapped(t provider.T) {
+	t.Parallel()
+
+	w := New(nil, nil, WithBackoff(time.Second, 10*time.Second))
+
+	for attempt := 1; attempt < 100; attempt++ {
+		d := w.retryDelay(attempt)
+		assert.LessOrEqual(t, d, 10*time.Second)
+		assert.GreaterOrEqual(t, d, time.Second/2)
+	}
+}
+
+func TestWorkerUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(WorkerUnitSuite))
+}
@@ -0,0 +1,41 @@
+package movie_import
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Posters bigger than that are reported as failed rows
+const maxPosterSize = 10 << 20
+
+// PosterFetcher downloads posters by links from the catalog file
+func PosterFetcher(client *http.Client) func(ctx context.Context, url string) ([]byte, error) {
+	return func(ctx context.Context, url string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poster link: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch poster: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch poster: %s", resp.Status)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(resp.Body, maxPosterSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch poster: %w", err)
+		}
+		if len(content) > maxPosterSize {
+			return nil, errors.New("poster is too large")
+		}
+		return content, nil
+	}
+}
@@ -0,0 +1,384 @@
+// Package movie_import reads catalog files into records for usecase_movie.Import.
+// CSV columns follow data/imdb_top_1000.csv, JSONL objects follow movie creation API.
+// Credits and IMDb stats are optional in both.
+package movie_import
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrMissingColumn = errors.New("missing required column")
+)
+
+// JSONL lines longer than that are reported as failed rows
+const maxLineSize = 1 << 20
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, s)
+}
+
+// FormatOf guesses format by file extension
+func FormatOf(filename string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// Read streams records of the file. Broken rows are yielded with Err set, so the import goes on.
+func Read(r io.Reader, format Format) (iter.Seq[usecase_movie.ImportRecord], error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatJSONL:
+		return ReadJSONL(r), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+type column int
+
+const (
+	columnTitle column = iota
+	columnYear
+	columnRating
+	columnGenres
+	columnOverview
+	columnPoster
+	columnDirector
+	columnCast
+	columnRuntime
+	columnCertificate
+	columnMetaScore
+	columnVotes
+	columnGross
+	columnImdbID
+)
+
+// Header names are matched case insensitive
+var columnAliases = map[string]column{
+	"series_title":  columnTitle,
+	"title":         columnTitle,
+	"released_year": columnYear,
+	"year":          columnYear,
+	"imdb_rating":   columnRating,
+	"rating":        columnRating,
+	"genre":         columnGenres,
+	"genres":        columnGenres,
+	"overview":      columnOverview,
+	"poster_link":   columnPoster,
+	"director":      columnDirector,
+	"cast":          columnCast,
+	"runtime":       columnRuntime,
+	"certificate":   columnCertificate,
+	"meta_score":    columnMetaScore,
+	"no_of_votes":   columnVotes,
+	"votes":         columnVotes,
+	"gross":         columnGross,
+	"imdb_id":       columnImdbID,
+	"const":         columnImdbID,
+}
+
+// Star1..Star4 of the dataset, billing order is kept
+var castColumns = []string{"star1", "star2", "star3", "star4"}
+
+var requiredColumns = map[column]string{
+	columnTitle:    "Series_Title",
+	columnGenres:   "Genre",
+	columnOverview: "Overview",
+}
+
+// ReadCSV fails right away only if header lacks required columns
+func ReadCSV(r io.Reader) (iter.Seq[usecase_movie.ImportRecord], error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	positions := make(map[column]int)
+	stars := make([]int, 0, len(castColumns))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		if slices.Contains(castColumns, name) {
+			stars = append(stars, i)
+			continue
+		}
+		if c, ok := columnAliases[name]; ok {
+			if _, dup := positions[c]; !dup {
+				positions[c] = i
+			}
+		}
+	}
+	for c, name := range requiredColumns {
+		if _, ok := positions[c]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, name)
+		}
+	}
+
+	return func(yield func(usecase_movie.ImportRecord) bool) {
+		for {
+			row, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			var rec usecase_movie.ImportRecord
+
+			var parseErr *csv.ParseError
+			switch {
+			case errors.As(err, &parseErr):
+				rec.Line = parseErr.StartLine
+				rec.Err = fmt.Errorf("%w: %w", usecase_movie.ErrInvalidRecord, err)
+			case err != nil:
+				// Reader can't go on after I/O error
+				rec.Err = err
+				yield(rec)
+				return
+			default:
+				rec.Line, _ = reader.FieldPos(0)
+				at := func(i int) string {
+					if i < len(row) {
+						return strings.TrimSpace(row[i])
+					}
+					return ""
+				}
+				field := func(c column) string {
+					if i, ok := positions[c]; ok {
+						return at(i)
+					}
+					return ""
+				}
+
+				cast := splitList(field(columnCast))
+				for _, i := range stars {
+					if star := at(i); star != "" {
+						cast = append(cast, star)
+					}
+				}
+
+				rec.Movie, rec.Err = parseMovie(rawMovie{
+					Title:       field(columnTitle),
+					Year:        field(columnYear),
+					Rating:      field(columnRating),
+					Genres:      splitList(field(columnGenres)),
+					Overview:    field(columnOverview),
+					Director:    field(columnDirector),
+					Cast:        cast,
+					Runtime:     field(columnRuntime),
+					Certificate: field(columnCertificate),
+					MetaScore:   field(columnMetaScore),
+					Votes:       field(columnVotes),
+					Gross:       field(columnGross),
+					ImdbID:      field(columnImdbID),
+				})
+				rec.PosterURL = field(columnPoster)
+			}
+
+			if !yield(rec) {
+				return
+			}
+		}
+	}, nil
+}
+
+type jsonlMovie struct {
+	Title      string          `json:"title"`
+	Year       json.RawMessage `json:"year"`
+	Rating     json.RawMessage `json:"rating"`
+	Genres     []string        `json:"genres"`
+	Overview   string          `json:"overview"`
+	PosterLink string          `json:"poster_link"`
+
+	Director    string          `json:"director"`
+	Cast        []string        `json:"cast"`
+	Runtime     json.RawMessage `json:"runtime"`
+	Certificate string          `json:"certificate"`
+	MetaScore   json.RawMessage `json:"meta_score"`
+	Votes       json.RawMessage `json:"votes"`
+	Gross       json.RawMessage `json:"gross"`
+	ImdbID      string          `json:"imdb_id"`
+}
+
+func ReadJSONL(r io.Reader) iter.Seq[usecase_movie.ImportRecord] {
+	return func(yield func(usecase_movie.ImportRecord) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+		line := 0
+		for scanner.Scan() {
+			line++
+			raw := strings.TrimSpace(scanner.Text())
+			if raw == "" {
+				continue
+			}
+
+			rec := usecase_movie.ImportRecord{Line: line}
+
+			var m jsonlMovie
+			if err := json.Unmarshal([]byte(raw), &m); err != nil {
+				rec.Err = fmt.Errorf("%w: %w", usecase_movie.ErrInvalidRecord, err)
+			} else {
+				rec.Movie, rec.Err = parseMovie(rawMovie{
+					Title:       strings.TrimSpace(m.Title),
+					Year:        unquote(m.Year),
+					Rating:      unquote(m.Rating),
+					Genres:      m.Genres,
+					Overview:    strings.TrimSpace(m.Overview),
+					Director:    strings.TrimSpace(m.Director),
+					Cast:        m.Cast,
+					Runtime:     unquote(m.Runtime),
+					Certificate: strings.TrimSpace(m.Certificate),
+					MetaScore:   unquote(m.MetaScore),
+					Votes:       unquote(m.Votes),
+					Gross:       unquote(m.Gross),
+					ImdbID:      strings.TrimSpace(m.ImdbID),
+				})
+				rec.PosterURL = strings.TrimSpace(m.PosterLink)
+			}
+
+			if !yield(rec) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			yield(usecase_movie.ImportRecord{
+				Line: line + 1,
+				Err:  fmt.Errorf("%w: %w", usecase_movie.ErrInvalidRecord, err),
+			})
+		}
+	}
+}
+
+// rawMovie holds fields as they come in the file, empty ones are left zero
+type rawMovie struct {
+	Title, Year, Rating string
+	Genres              []string
+	Overview            string
+
+	Director    string
+	Cast        []string
+	Runtime     string
+	Certificate string
+	MetaScore   string
+	Votes       string
+	Gross       string
+	ImdbID      string
+}
+
+func parseMovie(raw rawMovie) (model.MovieMeta, error) {
+	mm := model.MovieMeta{
+		Title:       raw.Title,
+		Genres:      raw.Genres,
+		Overview:    raw.Overview,
+		Director:    raw.Director,
+		Cast:        raw.Cast,
+		Certificate: raw.Certificate,
+		ImdbID:      raw.ImdbID,
+	}
+
+	var err error
+	if mm.Year, err = parseInt("year", raw.Year); err != nil {
+		return mm, err
+	}
+	if raw.Rating != "" {
+		r, err := strconv.ParseFloat(raw.Rating, 64)
+		if err != nil {
+			return mm, fmt.Errorf("%w: invalid rating %q", usecase_movie.ErrInvalidRecord, raw.Rating)
+		}
+		mm.Rating = r
+	}
+
+	// "142 min"
+	runtime := strings.TrimSpace(strings.TrimSuffix(raw.Runtime, "min"))
+	if mm.Runtime, err = parseInt("runtime", runtime); err != nil {
+		return mm, err
+	}
+	if mm.Runtime < 0 {
+		return mm, fmt.Errorf("%w: invalid runtime %q", usecase_movie.ErrInvalidRecord, raw.Runtime)
+	}
+
+	// Meta_score comes as "80.0"
+	if raw.MetaScore != "" {
+		score, err := strconv.ParseFloat(raw.MetaScore, 64)
+		if err != nil {
+			return mm, fmt.Errorf("%w: invalid meta score %q", usecase_movie.ErrInvalidRecord, raw.MetaScore)
+		}
+		mm.MetaScore = int(score)
+	}
+
+	// "28,341,469"
+	if mm.Votes, err = parseInt("votes", strings.ReplaceAll(raw.Votes, ",", "")); err != nil {
+		return mm, err
+	}
+	if gross := strings.ReplaceAll(raw.Gross, ",", ""); gross != "" {
+		if mm.Gross, err = strconv.ParseInt(gross, 10, 64); err != nil {
+			return mm, fmt.Errorf("%w: invalid gross %q", usecase_movie.ErrInvalidRecord, raw.Gross)
+		}
+	}
+
+	return mm, nil
+}
+
+func parseInt(name, raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s %q", usecase_movie.ErrInvalidRecord, name, raw)
+	}
+	return v, nil
+}
+
+// "Crime, Drama" -> [Crime Drama]
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Numbers in JSONL may come either as numbers or as strings
+func unquote(raw json.RawMessage) string {
+	s := strings.TrimSpace(string(raw))
+	if s == "null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return strings.TrimSpace(unquoted)
+	}
+	return s
+}
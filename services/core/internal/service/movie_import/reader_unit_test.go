@@ -0,0 +1,150 @@
+//go:build !integration
+// +build !integration
+
+package movie_import
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type ReaderUnitSuite struct {
+	suite.Suite
+}
+
+func readFixture(t provider.T, name string) []usecase_movie.ImportRecord {
+	f, err := os.Open("testdata/" + name)
+	t.Require().NoError(err)
+	defer f.Close()
+
+	format, err := FormatOf(name)
+	t.Require().NoError(err)
+
+	records, err := Read(f, format)
+	t.Require().NoError(err)
+
+	return slices.Collect(records)
+}
+
+func (suite *ReaderUnitSuite) TestReadCSV(t provider.T) {
+	t.Parallel()
+
+	records := readFixture(t, "movies.csv")
+	t.Require().Len(records, 3)
+
+	assert.Equal(t, usecase_movie.ImportRecord{
+		Line: 2,
+		Movie: model.MovieMeta{
+			Title:    "Heat",
+			Year:     1995,
+			Rating:   8.3,
+			Genres:   []string{"Action", "Crime", "Drama"},
+			Overview: "A group of professional bank robbers start to feel the heat from police.",
+
+			Director:    "Michael Mann",
+			Cast:        []string{"Al Pacino", "Robert De Niro", "Val Kilmer", "Jon Voight"},
+			Runtime:     170,
+			Certificate: "R",
+			MetaScore:   76,
+			Votes:       577113,
+			Gross:       67436818,
+		},
+		PosterURL: "https://example.com/heat.jpg",
+	}, records[0])
+
+	assert.Equal(t, 3, records[1].Line)
+	assert.ErrorIs(t, records[1].Err, usecase_movie.ErrInvalidRecord)
+	assert.Equal(t, "Apollo 13", records[1].Movie.Title)
+
+	assert.Equal(t, 4, records[2].Line)
+	assert.NoError(t, records[2].Err)
+	assert.Contains(t, records[2].Movie.Overview, "\nafter investigating")
+	assert.Equal(t, []string{"Sigourney Weaver", "Tom Skerritt", "John Hurt"}, records[2].Movie.Cast, "empty stars are dropped")
+	assert.Zero(t, records[2].Movie.Gross)
+}
+
+func (suite *ReaderUnitSuite) TestReadCSVInvalidStats(t provider.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		row   string
+		error string
+	}{
+		{name: "runtime", row: `Heat,Crime,Robbers,two hours,,`, error: `invalid runtime "two hours"`},
+		{name: "negative runtime", row: `Heat,Crime,Robbers,-1,,`, error: `invalid runtime "-1"`},
+		{name: "votes", row: `Heat,Crime,Robbers,,many,`, error: `invalid votes "many"`},
+		{name: "gross", row: `Heat,Crime,Robbers,,,$67M`, error: `invalid gross "$67M"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t provider.T) {
+			records, err := ReadCSV(strings.NewReader("Series_Title,Genre,Overview,Runtime,No_of_Votes,Gross\n" + tt.row + "\n"))
+			t.Require().NoError(err)
+
+			rec := slices.Collect(records)[0]
+			assert.ErrorIs(t, rec.Err, usecase_movie.ErrInvalidRecord)
+			assert.ErrorContains(t, rec.Err, tt.error)
+		})
+	}
+}
+
+func (suite *ReaderUnitSuite) TestReadCSVMissingColumn(t provider.T) {
+	t.Parallel()
+
+	_, err := ReadCSV(strings.NewReader("Series_Title,Genre\nHeat,Crime\n"))
+	assert.ErrorIs(t, err, ErrMissingColumn)
+	assert.ErrorContains(t, err, "Overview")
+}
+
+func (suite *ReaderUnitSuite) TestReadJSONL(t provider.T) {
+	t.Parallel()
+
+	records := readFixture(t, "movies.jsonl")
+	t.Require().Len(records, 3)
+
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, 1, records[0].Line)
+	assert.Equal(t, "https://example.com/heat.jpg", records[0].PosterURL)
+	assert.Equal(t, "Michael Mann", records[0].Movie.Director)
+	assert.Equal(t, []string{"Al Pacino", "Robert De Niro"}, records[0].Movie.Cast)
+	assert.Equal(t, 170, records[0].Movie.Runtime)
+	assert.Equal(t, "tt0113277", records[0].Movie.ImdbID)
+
+	assert.NoError(t, records[1].Err)
+	assert.Equal(t, 3, records[1].Line, "blank lines are counted")
+	assert.Equal(t, 1979, records[1].Movie.Year)
+	assert.Equal(t, 8.4, records[1].Movie.Rating)
+	assert.Equal(t, 117, records[1].Movie.Runtime)
+	assert.Equal(t, 787806, records[1].Movie.Votes)
+
+	assert.Equal(t, 4, records[2].Line)
+	assert.ErrorIs(t, records[2].Err, usecase_movie.ErrInvalidRecord)
+}
+
+func (suite *ReaderUnitSuite) TestFormatOf(t provider.T) {
+	t.Parallel()
+
+	format, err := FormatOf("imdb_top_1000.CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = FormatOf("dump.ndjson")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSONL, format)
+
+	_, err = FormatOf("movies.xlsx")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestReaderUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(ReaderUnitSuite))
+}
@@ -0,0 +1,87 @@
+// Package neighbours keeps precomputed "more like this" lists of movies up to date.
+package neighbours
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	usecase_movie "github.com/humanbelnik/kinoswap/core/internal/usecase/movie"
+)
+
+const (
+	DefaultInterval = 10 * time.Minute
+	// Stored per movie, that's the most Similar returns
+	DefaultK = usecase_movie.MaxSimilarLimit
+)
+
+type Repository interface {
+	// Returns false if refresh is already running elsewhere
+	RefreshNeighbours(ctx context.Context, k int) (bool, error)
+}
+
+type Refresher struct {
+	repo     Repository
+	interval time.Duration
+	k        int
+	logger   *slog.Logger
+}
+
+type Option func(*Refresher)
+
+func WithInterval(d time.Duration) Option {
+	return func(r *Refresher) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Refresher) {
+		r.logger = logger
+	}
+}
+
+func New(repo Repository, opts ...Option) *Refresher {
+	r := &Refresher{
+		repo:     repo,
+		interval: DefaultInterval,
+		k:        DefaultK,
+		logger:   slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run refreshes neighbours right away and then every interval until ctx is done
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) Refresh(ctx context.Context) {
+	start := time.Now()
+
+	refreshed, err := r.repo.RefreshNeighbours(ctx, r.k)
+	switch {
+	case err != nil:
+		r.logger.Error("failed to refresh movie neighbours", slog.String("error", err.Error()))
+	case !refreshed:
+		r.logger.Info("movie neighbours are being refreshed by another instance")
+	default:
+		r.logger.Info("movie neighbours refreshed", slog.Duration("took", time.Since(start)))
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !integration
+// +build !integration
+
+package neighbours
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type RefresherUnitSuite struct {
+	suite.Suite
+}
+
+type repositoryStub struct {
+	calls atomic.Int32
+	k     atomic.Int32
+	err   error
+}
+
+func (r *repositoryStub) RefreshNeighbours(ctx context.Context, k int) (bool, error) {
+	r.calls.Add(1)
+	r.k.Store(int32(k))
+	return r.err == nil, r.err
+}
+
+func (suite *RefresherUnitSuite) TestRun(t provider.T) {
+	t.Parallel()
+
+	repo := &repositoryStub{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		New(repo, WithInterval(10*time.Millisecond)).Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return repo.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(DefaultK), repo.k.Load())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("refresher didn't stop")
+	}
+}
+
+func (suite *RefresherUnitSuite) TestRefreshSurvivesErrors(t provider.T) {
+	t.Parallel()
+
+	repo := &repositoryStub{err: errors.New("connection refused")}
+	refresher := New(repo)
+
+	refresher.Refresh(context.Background())
+	refresher.Refresh(context.Background())
+
+	assert.Equal(t, int32(2), repo.calls.Load())
+}
+
+func TestRefresherUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(RefresherUnitSuite))
+}
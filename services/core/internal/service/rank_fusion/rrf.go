@@ -0,0 +1,59 @@
+// Package rank_fusion merges rankings of incomparable scores, such as text rank and vector distance.
+package rank_fusion
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// Dampens the impact of top positions, 60 is the value from the original RRF paper
+const DefaultK = 60
+
+// Fuse scores every movie by sum of 1/(k+rank) over rankings it's in, best first.
+// Movies with equal score keep order of the first appearance.
+func Fuse(k int, rankings ...[]*model.MovieMeta) []model.ScoredMovie {
+	if k <= 0 {
+		k = DefaultK
+	}
+
+	var (
+		fused []model.ScoredMovie
+		index = make(map[uuid.UUID]int)
+	)
+
+	for _, ranking := range rankings {
+		for rank, movie := range ranking {
+			score := 1 / float64(k+rank+1)
+
+			if i, ok := index[movie.ID]; ok {
+				fused[i].Score += score
+				continue
+			}
+			index[movie.ID] = len(fused)
+			fused = append(fused, model.ScoredMovie{Movie: movie, Score: score})
+		}
+	}
+
+	slices.SortStableFunc(fused, func(a, b model.ScoredMovie) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	return fused
+}
+
+// Movies of scored ranking, order is kept
+func Movies(ranking []model.ScoredMovie) []*model.MovieMeta {
+	movies := make([]*model.MovieMeta, len(ranking))
+	for i, hit := range ranking {
+		movies[i] = hit.Movie
+	}
+	return movies
+}
@@ -0,0 +1,56 @@
+//go:build !integration
+// +build !integration
+
+package rank_fusion
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type RankFusionUnitSuite struct {
+	suite.Suite
+}
+
+func movie(title string) *model.MovieMeta {
+	return &model.MovieMeta{ID: uuid.New(), Title: title}
+}
+
+func (suite *RankFusionUnitSuite) TestFuse(t provider.T) {
+	t.Parallel()
+
+	godfather, godfather2, goodfellas, heat := movie("The Godfather"), movie("The Godfather Part II"), movie("Goodfellas"), movie("Heat")
+
+	lexical := []*model.MovieMeta{godfather2, godfather}
+	vector := []*model.MovieMeta{goodfellas, godfather, heat, godfather2}
+
+	fused := Fuse(DefaultK, lexical, vector)
+
+	titles := make([]string, len(fused))
+	for i, hit := range fused {
+		titles[i] = hit.Movie.Title
+	}
+	assert.Equal(t, []string{"The Godfather", "The Godfather Part II", "Goodfellas", "Heat"}, titles)
+	assert.InDelta(t, 1.0/62+1.0/62, fused[0].Score, 1e-9)
+	assert.InDelta(t, 1.0/61+1.0/64, fused[1].Score, 1e-9)
+}
+
+func (suite *RankFusionUnitSuite) TestFuseKeepsOrderOnTies(t provider.T) {
+	t.Parallel()
+
+	a, b := movie("A"), movie("B")
+
+	fused := Fuse(0, []*model.MovieMeta{a}, []*model.MovieMeta{b})
+
+	assert.Equal(t, []*model.MovieMeta{a, b}, Movies(fused))
+	assert.Empty(t, Fuse(DefaultK))
+}
+
+func TestRankFusionUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(RankFusionUnitSuite))
+}
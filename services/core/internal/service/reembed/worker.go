@@ -0,0 +1,182 @@
+// Package reembed runs catalog re-embedding jobs in background.
+package reembed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const (
+	DefaultInterval  = 5 * time.Second
+	DefaultBatchSize = 64
+)
+
+type Repository interface {
+	// Returns nil if no job is running
+	RunningReembed(ctx context.Context) (*model.ReembedJob, error)
+	PendingReembed(ctx context.Context, job model.ReembedJob, limit int) ([]*model.MovieMeta, error)
+	// Returns false if job isn't running anymore
+	StoreReembedBatch(ctx context.Context, job model.ReembedJob, vectors map[uuid.UUID]model.Embedding, lastID uuid.UUID) (bool, error)
+	FailReembed(ctx context.Context, id uuid.UUID, reason string) error
+	// Returns false if some movies are still pending
+	FinishReembed(ctx context.Context, job model.ReembedJob) (bool, error)
+}
+
+type Embedder interface {
+	BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error)
+	ModelInfo(ctx context.Context) (model.EmbeddingModel, error)
+}
+
+// Worker picks up running job, so a job interrupted by restart resumes from its last batch.
+// Failed batches are retried every interval until job is done or canceled.
+type Worker struct {
+	repo      Repository
+	embedder  Embedder
+	interval  time.Duration
+	batchSize int
+	logger    *slog.Logger
+}
+
+type Option func(*Worker)
+
+func WithInterval(d time.Duration) Option {
+	return func(w *Worker) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+func WithBatchSize(n int) Option {
+	return func(w *Worker) {
+		if n > 0 {
+			w.batchSize = n
+		}
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+func New(repo Repository, embedder Embedder, opts ...Option) *Worker {
+	w := &Worker{
+		repo:      repo,
+		embedder:  embedder,
+		interval:  DefaultInterval,
+		batchSize: DefaultBatchSize,
+		logger:    slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run checks for running job right away and then every interval until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Step(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step works on running job until it's done, canceled or fails
+func (w *Worker) Step(ctx context.Context) {
+	job, err := w.repo.RunningReembed(ctx)
+	if err != nil {
+		w.logger.Error("failed to load re-embed job", slog.String("error", err.Error()))
+		return
+	}
+	if job == nil {
+		return
+	}
+
+	if err := w.process(ctx, *job); err != nil {
+		w.logger.Error("re-embed job failed, will retry",
+			slog.String("job", job.ID.String()), slog.String("error", err.Error()))
+		if err := w.repo.FailReembed(ctx, job.ID, err.Error()); err != nil {
+			w.logger.Error("failed to save re-embed job error", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job model.ReembedJob) error {
+	// Vectors of another model would be switched to as if they were of job model
+	em, err := w.embedder.ModelInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get embedder model: %w", err)
+	}
+	if em.ID() != job.Model {
+		return fmt.Errorf("embedder serves %s, job is for %s", em.ID(), job.Model)
+	}
+
+	for ctx.Err() == nil {
+		movies, err := w.repo.PendingReembed(ctx, job, w.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(movies) == 0 {
+			finished, err := w.repo.FinishReembed(ctx, job)
+			if err != nil {
+				return err
+			}
+			if finished {
+				w.logger.Info("re-embed job done, switched to new vectors",
+					slog.String("job", job.ID.String()), slog.String("model", job.Model))
+				return nil
+			}
+			// Either job isn't running anymore or movies came in meanwhile, next step sorts it out
+			if job.LastID == uuid.Nil {
+				return nil
+			}
+			// Movies came in behind cursor
+			job.LastID = uuid.Nil
+			continue
+		}
+
+		vectors := make(map[uuid.UUID]model.Embedding, len(movies))
+		for _, mm := range movies {
+			e, err := w.embedder.BuildMovieEmbedding(ctx, *mm)
+			if err != nil {
+				return fmt.Errorf("failed to embed movie %s: %w", mm.ID, err)
+			}
+			if len(e) != model.EmbeddingDimension {
+				return fmt.Errorf("embedding of movie %s has dimension %d", mm.ID, len(e))
+			}
+			vectors[mm.ID] = e
+		}
+
+		lastID := movies[len(movies)-1].ID
+		running, err := w.repo.StoreReembedBatch(ctx, job, vectors, lastID)
+		if err != nil {
+			return err
+		}
+		if !running {
+			w.logger.Info("re-embed job canceled", slog.String("job", job.ID.String()))
+			return nil
+		}
+		job.LastID = lastID
+
+		w.logger.Info("re-embed batch stored",
+			slog.String("job", job.ID.String()), slog.Int("movies", len(movies)))
+	}
+
+	return nil
+}
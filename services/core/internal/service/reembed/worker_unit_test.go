@@ -0,0 +1,234 @@
+//go:build !integration
+// +build !integration
+
+package reembed
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type WorkerUnitSuite struct {
+	suite.Suite
+}
+
+type movieRow struct {
+	mm        model.MovieMeta
+	vecModel  string
+	nextModel string
+}
+
+// repositoryStub keeps catalog in memory and mimics postgres repository semantics
+type repositoryStub struct {
+	mu       sync.Mutex
+	job      *model.ReembedJob
+	movies   []*movieRow
+	switched bool
+}
+
+func newRepositoryStub(n int, vecModel string) *repositoryStub {
+	r := &repositoryStub{}
+	for range n {
+		r.movies = append(r.movies, &movieRow{mm: model.MovieMeta{ID: uuid.New()}, vecModel: vecModel})
+	}
+	slices.SortFunc(r.movies, func(a, b *movieRow) int { return slices.Compare(a.mm.ID[:], b.mm.ID[:]) })
+	return r
+}
+
+func (r *repositoryStub) RunningReembed(ctx context.Context) (*model.ReembedJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job == nil || r.job.Status != model.ReembedRunning {
+		return nil, nil
+	}
+	job := *r.job
+	return &job, nil
+}
+
+func (r *repositoryStub) PendingReembed(ctx context.Context, job model.ReembedJob, limit int) ([]*model.MovieMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []*model.MovieMeta
+	for _, row := range r.movies {
+		if slices.Compare(row.mm.ID[:], job.LastID[:]) > 0 && row.vecModel != job.Model && row.nextModel != job.Model {
+			pending = append(pending, &row.mm)
+		}
+		if len(pending) == limit {
+			break
+		}
+	}
+	return pending, nil
+}
+
+func (r *repositoryStub) StoreReembedBatch(ctx context.Context, job model.ReembedJob, vectors map[uuid.UUID]model.Embedding, lastID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Status != model.ReembedRunning {
+		return false, nil
+	}
+	r.job.Done = 0
+	for _, row := range r.movies {
+		if _, ok := vectors[row.mm.ID]; ok {
+			row.nextModel = job.Model
+		}
+		if row.vecModel == job.Model || row.nextModel == job.Model {
+			r.job.Done++
+		}
+	}
+	r.job.LastID, r.job.Total, r.job.Error = lastID, len(r.movies), ""
+	return true, nil
+}
+
+func (r *repositoryStub) FailReembed(ctx context.Context, id uuid.UUID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job.Error = reason
+	return nil
+}
+
+func (r *repositoryStub) FinishReembed(ctx context.Context, job model.ReembedJob) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Status != model.ReembedRunning {
+		return false, nil
+	}
+	for _, row := range r.movies {
+		if row.vecModel != job.Model && row.nextModel != job.Model {
+			r.job.LastID = uuid.Nil
+			return false, nil
+		}
+	}
+	for _, row := range r.movies {
+		if row.nextModel == job.Model {
+			row.vecModel, row.nextModel = row.nextModel, ""
+		}
+	}
+	r.job.Status, r.switched = model.ReembedDone, true
+	return true, nil
+}
+
+func (r *repositoryStub) models() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var models []string
+	for _, row := range r.movies {
+		models = append(models, row.vecModel)
+	}
+	return models
+}
+
+type embedderStub struct {
+	mu    sync.Mutex
+	model model.EmbeddingModel
+	// Movie embedding fails once calls reach failAt
+	calls  int
+	failAt int
+}
+
+func (e *embedderStub) BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	if e.calls == e.failAt {
+		return nil, errors.New("embedder unavailable")
+	}
+	return make(model.Embedding, model.EmbeddingDimension), nil
+}
+
+func (e *embedderStub) ModelInfo(ctx context.Context) (model.EmbeddingModel, error) {
+	return e.model, nil
+}
+
+var newModel = model.EmbeddingModel{Name: "new-model", Dimension: model.EmbeddingDimension}
+
+func (suite *WorkerUnitSuite) TestStepSwitchesWhenAllEmbedded(t provider.T) {
+	t.Parallel()
+
+	repo := newRepositoryStub(10, "old-model")
+	repo.job = &model.ReembedJob{ID: uuid.New(), Model: newModel.ID(), Status: model.ReembedRunning}
+	embedder := &embedderStub{model: newModel}
+
+	New(repo, embedder, WithBatchSize(3)).Step(context.Background())
+
+	assert.True(t, repo.switched)
+	assert.Equal(t, model.ReembedDone, repo.job.Status)
+	assert.Equal(t, slices.Repeat([]string{newModel.ID()}, 10), repo.models())
+	assert.Equal(t, 10, embedder.calls)
+}
+
+func (suite *WorkerUnitSuite) TestStepResumesAfterFailure(t provider.T) {
+	t.Parallel()
+
+	repo := newRepositoryStub(10, "old-model")
+	repo.job = &model.ReembedJob{ID: uuid.New(), Model: newModel.ID(), Status: model.ReembedRunning}
+	embedder := &embedderStub{model: newModel, failAt: 8}
+	worker := New(repo, embedder, WithBatchSize(3))
+
+	worker.Step(context.Background())
+
+	// Two batches are stored, third one failed
+	assert.False(t, repo.switched)
+	assert.Equal(t, 6, repo.job.Done)
+	assert.Equal(t, 10, repo.job.Total)
+	assert.Equal(t, repo.movies[5].mm.ID, repo.job.LastID)
+	assert.Contains(t, repo.job.Error, "embedder unavailable")
+	assert.Equal(t, slices.Repeat([]string{"old-model"}, 10), repo.models(), "old vectors are served until switch")
+
+	worker.Step(context.Background())
+
+	assert.True(t, repo.switched)
+	assert.Empty(t, repo.job.Error)
+	// Movies of stored batches aren't embedded again
+	assert.Equal(t, 8+4, embedder.calls)
+}
+
+func (suite *WorkerUnitSuite) TestStepPicksUpMoviesBehindCursor(t provider.T) {
+	t.Parallel()
+
+	repo := newRepositoryStub(4, "old-model")
+	lastID := repo.movies[3].mm.ID
+	repo.job = &model.ReembedJob{ID: uuid.New(), Model: newModel.ID(), Status: model.ReembedRunning, LastID: lastID}
+
+	New(repo, &embedderStub{model: newModel}).Step(context.Background())
+
+	assert.True(t, repo.switched)
+	assert.Equal(t, slices.Repeat([]string{newModel.ID()}, 4), repo.models())
+}
+
+func (suite *WorkerUnitSuite) TestStepRefusesOtherModel(t provider.T) {
+	t.Parallel()
+
+	repo := newRepositoryStub(3, "old-model")
+	repo.job = &model.ReembedJob{ID: uuid.New(), Model: newModel.ID(), Status: model.ReembedRunning}
+	embedder := &embedderStub{model: model.EmbeddingModel{Name: "other-model", Dimension: model.EmbeddingDimension}}
+
+	New(repo, embedder).Step(context.Background())
+
+	assert.False(t, repo.switched)
+	assert.Zero(t, embedder.calls)
+	assert.Contains(t, repo.job.Error, "other-model")
+}
+
+func (suite *WorkerUnitSuite) TestStepWithoutJob(t provider.T) {
+	t.Parallel()
+
+	repo := newRepositoryStub(3, "old-model")
+	embedder := &embedderStub{model: newModel}
+
+	New(repo, embedder).Step(context.Background())
+
+	assert.Zero(t, embedder.calls)
+}
+
+func TestWorkerUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(WorkerUnitSuite))
+}
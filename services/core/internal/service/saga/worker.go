@@ -0,0 +1,117 @@
+// Package saga finishes or rolls back movie writes cut by crashed instances.
+package saga
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultBatchSize = 16
+	// Failed recovery is retried once lease expires
+	DefaultLease = time.Minute
+)
+
+type Repository interface {
+	ClaimSagas(ctx context.Context, limit int, lease time.Duration) ([]model.Saga, error)
+}
+
+type Recoverer interface {
+	RecoverSaga(ctx context.Context, saga model.Saga) error
+}
+
+type Worker struct {
+	repo      Repository
+	recoverer Recoverer
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+	logger    *slog.Logger
+}
+
+type Option func(*Worker)
+
+func WithInterval(d time.Duration) Option {
+	return func(w *Worker) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+func New(repo Repository, recoverer Recoverer, opts ...Option) *Worker {
+	w := &Worker{
+		repo:      repo,
+		recoverer: recoverer,
+		interval:  DefaultInterval,
+		batchSize: DefaultBatchSize,
+		lease:     DefaultLease,
+		logger:    slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run recovers sagas right away, so writes cut by restart are settled on startup, and then every interval
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Step(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step recovers unfinished sagas until there are none left unleased
+func (w *Worker) Step(ctx context.Context) {
+	for ctx.Err() == nil {
+		sagas, err := w.repo.ClaimSagas(ctx, w.batchSize, w.lease)
+		if err != nil {
+			w.logger.Error("failed to claim sagas", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, saga := range sagas {
+			w.recover(ctx, saga)
+		}
+
+		if len(sagas) < w.batchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) recover(ctx context.Context, saga model.Saga) {
+	attrs := []any{
+		slog.String("saga", saga.ID.String()),
+		slog.String("kind", string(saga.Kind)),
+		slog.String("movie", saga.MovieID.String()),
+		slog.String("status", string(saga.Status)),
+		slog.Int("step", saga.Step),
+		slog.Int("attempt", saga.Attempts),
+	}
+
+	if err := w.recoverer.RecoverSaga(ctx, saga); err != nil {
+		w.logger.Error("failed to recover saga", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+	w.logger.Info("saga recovered", attrs...)
+}
@@ -0,0 +1,84 @@
+//go:build !integration
+// +build !integration
+
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type WorkerUnitSuite struct {
+	suite.Suite
+}
+
+// repositoryStub hands out sagas in batches, failed ones stay leased
+type repositoryStub struct {
+	unclaimed []model.Saga
+	claims    int
+}
+
+func (r *repositoryStub) ClaimSagas(ctx context.Context, limit int, lease time.Duration) ([]model.Saga, error) {
+	r.claims++
+	n := min(limit, len(r.unclaimed))
+	claimed := r.unclaimed[:n]
+	r.unclaimed = r.unclaimed[n:]
+	return claimed, nil
+}
+
+type recovererStub struct {
+	recovered []uuid.UUID
+	failing   uuid.UUID
+}
+
+func (r *recovererStub) RecoverSaga(ctx context.Context, saga model.Saga) error {
+	if saga.ID == r.failing {
+		return errors.New("s3 unavailable")
+	}
+	r.recovered = append(r.recovered, saga.ID)
+	return nil
+}
+
+func sagas(n int) []model.Saga {
+	sagas := make([]model.Saga, n)
+	for i := range sagas {
+		sagas[i] = model.Saga{ID: uuid.New(), Kind: model.SagaCreate, Status: model.SagaRunning}
+	}
+	return sagas
+}
+
+func (suite *WorkerUnitSuite) TestStepRecoversAllClaimed(t provider.T) {
+	t.Parallel()
+
+	repo := &repositoryStub{unclaimed: sagas(DefaultBatchSize*2 + 1)}
+	recoverer := &recovererStub{}
+
+	New(repo, recoverer).Step(context.Background())
+
+	assert.Len(t, recoverer.recovered, DefaultBatchSize*2+1)
+	assert.Equal(t, 3, repo.claims)
+}
+
+func (suite *WorkerUnitSuite) TestStepGoesOnAfterFailure(t provider.T) {
+	t.Parallel()
+
+	unfinished := sagas(3)
+	repo := &repositoryStub{unclaimed: unfinished}
+	recoverer := &recovererStub{failing: unfinished[0].ID}
+
+	New(repo, recoverer).Step(context.Background())
+
+	assert.Equal(t, []uuid.UUID{unfinished[1].ID, unfinished[2].ID}, recoverer.recovered)
+}
+
+func TestWorkerUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(WorkerUnitSuite))
+}
@@ -0,0 +1,113 @@
+package watchlist_import
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// Titles less similar than that are different movies, 1 stands for equal titles
+	MinSimilarity = 0.5
+	// Release year differs by one between services for festival premieres
+	maxYearDiff = 1
+	// Exact year beats slightly more similar title
+	yearDiffPenalty = 0.1
+)
+
+// Match picks the catalog movie entry refers to among candidates.
+// IMDb id match wins, otherwise the most similar title of close enough year does.
+func Match(entry model.WatchlistEntry, candidates []model.MovieMeta) (model.MovieMeta, bool) {
+	if entry.ImdbID != "" {
+		for _, c := range candidates {
+			if c.ImdbID == entry.ImdbID {
+				return c, true
+			}
+		}
+	}
+
+	var (
+		best      model.MovieMeta
+		bestScore = math.Inf(-1)
+		title     = trigrams(NormalizeTitle(entry.Title))
+	)
+	for _, c := range candidates {
+		diff := 0
+		if entry.Year != 0 && c.Year != 0 {
+			diff = abs(entry.Year - c.Year)
+		}
+		if diff > maxYearDiff {
+			continue
+		}
+
+		similarity := jaccard(title, trigrams(NormalizeTitle(c.Title)))
+		if similarity < MinSimilarity {
+			continue
+		}
+
+		if score := similarity - yearDiffPenalty*float64(diff); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	return best, !math.IsInf(bestScore, -1)
+}
+
+// NormalizeTitle lowercases title, drops diacritics, punctuation and leading article
+func NormalizeTitle(title string) string {
+	// Chain keeps state, so it's not shared
+	unmarked, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), title)
+	if err == nil {
+		title = unmarked
+	}
+	title = strings.ReplaceAll(strings.ToLower(title), "&", " and ")
+
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > 1 {
+		switch words[0] {
+		case "the", "a", "an":
+			words = words[1:]
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Trigrams of every word padded like pg_trgm does, so scores are close to similarity() of the catalog query
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
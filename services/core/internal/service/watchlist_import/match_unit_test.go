@@ -0,0 +1,146 @@
+//go:build !integration
+// +build !integration
+
+package watchlist_import
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type MatchUnitSuite struct {
+	suite.Suite
+}
+
+// catalog fixture has title, year and imdb_id columns
+func readCatalog(t provider.T) []model.MovieMeta {
+	f, err := os.Open("testdata/catalog.csv")
+	t.Require().NoError(err)
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	t.Require().NoError(err)
+
+	catalog := make([]model.MovieMeta, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		year, err := strconv.Atoi(row[1])
+		t.Require().NoError(err)
+		catalog = append(catalog, model.MovieMeta{ID: uuid.New(), Title: row[0], Year: year, ImdbID: row[2]})
+	}
+	return catalog
+}
+
+type matched struct {
+	Title string
+	Year  int
+}
+
+func matchFixture(t provider.T, name string) []*matched {
+	catalog := readCatalog(t)
+	export := readFixture(t, name)
+
+	result := make([]*matched, len(export.Entries))
+	for i, entry := range export.Entries {
+		if entry.Err != nil {
+			continue
+		}
+		if movie, ok := Match(entry, catalog); ok {
+			result[i] = &matched{Title: movie.Title, Year: movie.Year}
+		}
+	}
+	return result
+}
+
+func (suite *MatchUnitSuite) TestMatchLetterboxd(t provider.T) {
+	t.Parallel()
+
+	assert.Equal(t, []*matched{
+		{Title: "Heat", Year: 1995},
+		{Title: "The Good, the Bad and the Ugly", Year: 1966},
+		{Title: "Amélie", Year: 2001},
+		nil,
+		nil,
+		nil,
+		{Title: "Spirited Away", Year: 2001},
+	}, matchFixture(t, "letterboxd_watchlist.csv"))
+}
+
+func (suite *MatchUnitSuite) TestMatchIMDb(t provider.T) {
+	t.Parallel()
+
+	assert.Equal(t, []*matched{
+		{Title: "The Matrix", Year: 1999},
+		nil,
+		{Title: "Se7en", Year: 1995},
+		{Title: "Interstellar", Year: 2014},
+	}, matchFixture(t, "imdb_ratings.csv"))
+}
+
+func (suite *MatchUnitSuite) TestMatchYear(t provider.T) {
+	t.Parallel()
+
+	premiere := model.MovieMeta{ID: uuid.New(), Title: "Parasite", Year: 2019}
+	remake := model.MovieMeta{ID: uuid.New(), Title: "Parasite", Year: 1982}
+	sequel := model.MovieMeta{ID: uuid.New(), Title: "Parasites", Year: 2020}
+
+	tests := []struct {
+		name     string
+		entry    model.WatchlistEntry
+		expected *model.MovieMeta
+	}{
+		{
+			name:     "Should prefer exact year",
+			entry:    model.WatchlistEntry{Title: "Parasite", Year: 2019},
+			expected: &premiere,
+		},
+		{
+			name:     "Should tolerate year off by one",
+			entry:    model.WatchlistEntry{Title: "Parasite", Year: 2018},
+			expected: &premiere,
+		},
+		{
+			name:  "Should refuse distant year",
+			entry: model.WatchlistEntry{Title: "Parasite", Year: 2005},
+		},
+		{
+			name:     "Should pick the most similar title without year",
+			entry:    model.WatchlistEntry{Title: "PARASITES!"},
+			expected: &sequel,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			movie, ok := Match(tc.entry, []model.MovieMeta{remake, premiere, sequel})
+
+			if tc.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, *tc.expected, movie)
+		})
+	}
+}
+
+func (suite *MatchUnitSuite) TestNormalizeTitle(t provider.T) {
+	t.Parallel()
+
+	assert.Equal(t, "good the bad and the ugly", NormalizeTitle("The Good, the Bad & the Ugly"))
+	assert.Equal(t, "amelie", NormalizeTitle("Amélie"))
+	assert.Equal(t, "wall e", NormalizeTitle("WALL·E"))
+	assert.Equal(t, "the", NormalizeTitle("The"), "lone article is a title")
+}
+
+func TestMatchUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(MatchUnitSuite))
+}
@@ -0,0 +1,178 @@
+// Package watchlist_import reads lists exported from Letterboxd or IMDb and matches them against catalog.
+// Letterboxd watchlist.csv, watched.csv, ratings.csv and diary.csv are supported as well as
+// IMDb watchlist and ratings exports.
+package watchlist_import
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+type Source string
+
+const (
+	SourceLetterboxd Source = "letterboxd"
+	SourceIMDb       Source = "imdb"
+)
+
+var (
+	ErrUnknownSource = errors.New("neither Letterboxd nor IMDb export")
+	ErrInvalidEntry  = errors.New("invalid entry")
+	// Series and episodes are never in catalog
+	ErrNotMovie = errors.New("not a movie")
+)
+
+// Export is a parsed list, broken rows are kept with Err set
+type Export struct {
+	Source  Source
+	Entries []model.WatchlistEntry
+}
+
+type column int
+
+const (
+	columnTitle column = iota
+	columnYear
+	columnImdbID
+	columnTitleType
+)
+
+// Header names are matched case insensitive. Source is told by the column only it has.
+var sourceColumns = map[Source]struct {
+	marker  string
+	columns map[string]column
+}{
+	SourceLetterboxd: {
+		marker: "letterboxd uri",
+		columns: map[string]column{
+			"name": columnTitle,
+			"year": columnYear,
+		},
+	},
+	SourceIMDb: {
+		marker: "const",
+		columns: map[string]column{
+			"title":      columnTitle,
+			"year":       columnYear,
+			"const":      columnImdbID,
+			"title type": columnTitleType,
+		},
+	},
+}
+
+// Read fails right away only if header is of unknown source or lacks title
+func Read(r io.Reader) (Export, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return Export{}, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+	}
+
+	source, positions, err := detectSource(header)
+	if err != nil {
+		return Export{}, err
+	}
+
+	export := Export{Source: source}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				export.Entries = append(export.Entries, model.WatchlistEntry{
+					Line: parseErr.StartLine,
+					Err:  errors.Join(ErrInvalidEntry, err),
+				})
+				continue
+			}
+			return Export{}, err
+		}
+
+		entry := parseEntry(row, positions)
+		entry.Line, _ = reader.FieldPos(0)
+		export.Entries = append(export.Entries, entry)
+	}
+
+	return export, nil
+}
+
+func detectSource(header []string) (Source, map[column]int, error) {
+	for _, source := range []Source{SourceLetterboxd, SourceIMDb} {
+		spec := sourceColumns[source]
+		positions := make(map[column]int)
+		marked := false
+		for i, name := range header {
+			if name == spec.marker {
+				marked = true
+			}
+			if c, ok := spec.columns[name]; ok {
+				if _, dup := positions[c]; !dup {
+					positions[c] = i
+				}
+			}
+		}
+		if !marked {
+			continue
+		}
+		if _, ok := positions[columnTitle]; !ok {
+			return "", nil, fmt.Errorf("%w: %s export has no title column", ErrUnknownSource, source)
+		}
+		return source, positions, nil
+	}
+	return "", nil, ErrUnknownSource
+}
+
+func parseEntry(row []string, positions map[column]int) model.WatchlistEntry {
+	field := func(c column) string {
+		i, ok := positions[c]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	entry := model.WatchlistEntry{
+		Title:  field(columnTitle),
+		ImdbID: field(columnImdbID),
+	}
+
+	if entry.Title == "" {
+		entry.Err = fmt.Errorf("%w: title is empty", ErrInvalidEntry)
+		return entry
+	}
+
+	if raw := field(columnYear); raw != "" {
+		year, err := strconv.Atoi(raw)
+		if err != nil || year <= 0 {
+			entry.Err = fmt.Errorf("%w: year %q", ErrInvalidEntry, raw)
+			return entry
+		}
+		entry.Year = year
+	}
+
+	if !isMovie(field(columnTitleType)) {
+		entry.Err = fmt.Errorf("%w: %s", ErrNotMovie, field(columnTitleType))
+	}
+
+	return entry
+}
+
+// IMDb spells types either "TV Series" or "tvSeries" depending on export age
+func isMovie(titleType string) bool {
+	t := strings.ToLower(strings.ReplaceAll(titleType, " ", ""))
+	return !strings.Contains(t, "series") && !strings.Contains(t, "episode") && !strings.Contains(t, "game")
+}
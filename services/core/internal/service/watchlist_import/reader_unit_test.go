@@ -0,0 +1,101 @@
+//go:build !integration
+// +build !integration
+
+package watchlist_import
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type ReaderUnitSuite struct {
+	suite.Suite
+}
+
+func readFixture(t provider.T, name string) Export {
+	f, err := os.Open("testdata/" + name)
+	t.Require().NoError(err)
+	defer f.Close()
+
+	export, err := Read(f)
+	t.Require().NoError(err)
+
+	return export
+}
+
+func (suite *ReaderUnitSuite) TestReadLetterboxd(t provider.T) {
+	t.Parallel()
+
+	export := readFixture(t, "letterboxd_watchlist.csv")
+	assert.Equal(t, SourceLetterboxd, export.Source)
+	t.Require().Len(export.Entries, 7)
+
+	assert.Equal(t, model.WatchlistEntry{Line: 2, Title: "Heat", Year: 1995}, export.Entries[0])
+	assert.Equal(t, "The Good, the Bad and the Ugly", export.Entries[1].Title)
+
+	assert.Equal(t, 5, export.Entries[3].Line)
+	assert.ErrorIs(t, export.Entries[3].Err, ErrInvalidEntry, "title is required")
+	assert.ErrorIs(t, export.Entries[4].Err, ErrInvalidEntry, "year is a number")
+
+	assert.NoError(t, export.Entries[6].Err)
+	assert.Zero(t, export.Entries[6].Year, "year is optional")
+}
+
+func (suite *ReaderUnitSuite) TestReadIMDb(t provider.T) {
+	t.Parallel()
+
+	export := readFixture(t, "imdb_ratings.csv")
+	assert.Equal(t, SourceIMDb, export.Source)
+	t.Require().Len(export.Entries, 4)
+
+	assert.Equal(t, model.WatchlistEntry{Line: 2, Title: "The Matrix", Year: 1999, ImdbID: "tt0133093"}, export.Entries[0])
+	assert.ErrorIs(t, export.Entries[1].Err, ErrNotMovie)
+	assert.Equal(t, "Seven", export.Entries[2].Title, "localized title is used")
+	assert.NoError(t, export.Entries[3].Err, "lowercase title type of newer exports")
+}
+
+func (suite *ReaderUnitSuite) TestReadUnknownSource(t provider.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "Should refuse catalog file", header: "Series_Title,Released_Year,Genre,Overview"},
+		{name: "Should refuse IMDb export without title", header: "Const,Your Rating,Year"},
+		{name: "Should refuse empty file", header: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			_, err := Read(strings.NewReader(tc.header))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func (suite *ReaderUnitSuite) TestReadBrokenQuotes(t provider.T) {
+	t.Parallel()
+
+	export, err := Read(strings.NewReader("Date,Name,Year,Letterboxd URI\n" +
+		"2024-01-03,Heat,1995,https://boxd.it/2a8I\n" +
+		"2024-01-04,\"Alien\"x,1979,https://boxd.it/2b0k\n" +
+		"2024-01-05,Up,2009,https://boxd.it/1ZBv\n"))
+
+	t.Require().NoError(err)
+	t.Require().Len(export.Entries, 3)
+	assert.Equal(t, 3, export.Entries[1].Line)
+	assert.ErrorIs(t, export.Entries[1].Err, ErrInvalidEntry)
+	assert.Equal(t, "Up", export.Entries[2].Title, "reading goes on")
+}
+
+func TestReaderUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(ReaderUnitSuite))
+}
@@ -0,0 +1,151 @@
+package usecase_collection
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var (
+	ErrInternal          = errors.New("internal error")
+	ErrResourceNotFound  = errors.New("no such resource")
+	ErrInvalidCollection = errors.New("invalid collection")
+	ErrNameConflict      = errors.New("collection name is taken")
+	// Rooms keep their candidate pool, so collection they draw from can't be deleted
+	ErrCollectionInUse = errors.New("collection is used by room")
+)
+
+// Names longer than that are surely a mistake
+const maxNameLength = 200
+
+//go:generate mockery --name=Repository --output=./mocks/collection/repository --filename=repository.go
+type Repository interface {
+	Create(ctx context.Context, c model.Collection) error
+	Load(ctx context.Context, id uuid.UUID) (model.Collection, error)
+	LoadAll(ctx context.Context) ([]model.Collection, error)
+	Update(ctx context.Context, c model.Collection) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error
+	RemoveMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error
+}
+
+type Usecase struct {
+	Repository Repository
+}
+
+func New(repository Repository) *Usecase {
+	return &Usecase{Repository: repository}
+}
+
+// CollectionPatch holds fields to change, nil fields are left as is
+type CollectionPatch struct {
+	Name        *string
+	Description *string
+}
+
+func (p CollectionPatch) Empty() bool {
+	return p.Name == nil && p.Description == nil
+}
+
+func (u *Usecase) Create(ctx context.Context, name, description string) (model.Collection, error) {
+	c := model.Collection{
+		ID:          uuid.New(),
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		CreatedAt:   time.Now().UTC(),
+	}
+	c.UpdatedAt = c.CreatedAt
+	if err := validate(c); err != nil {
+		return model.Collection{}, err
+	}
+
+	if err := u.Repository.Create(ctx, c); err != nil {
+		return model.Collection{}, wrap(err)
+	}
+	return c, nil
+}
+
+func (u *Usecase) Get(ctx context.Context, id uuid.UUID) (model.Collection, error) {
+	c, err := u.Repository.Load(ctx, id)
+	if err != nil {
+		return model.Collection{}, wrap(err)
+	}
+	return c, nil
+}
+
+// List returns collections ordered by name
+func (u *Usecase) List(ctx context.Context) ([]model.Collection, error) {
+	cs, err := u.Repository.LoadAll(ctx)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return cs, nil
+}
+
+func (u *Usecase) Update(ctx context.Context, id uuid.UUID, patch CollectionPatch) (model.Collection, error) {
+	c, err := u.Repository.Load(ctx, id)
+	if err != nil {
+		return model.Collection{}, wrap(err)
+	}
+
+	if patch.Name != nil {
+		c.Name = strings.TrimSpace(*patch.Name)
+	}
+	if patch.Description != nil {
+		c.Description = strings.TrimSpace(*patch.Description)
+	}
+	if err := validate(c); err != nil {
+		return model.Collection{}, err
+	}
+
+	c.UpdatedAt = time.Now().UTC()
+	if err := u.Repository.Update(ctx, c); err != nil {
+		return model.Collection{}, wrap(err)
+	}
+	return c, nil
+}
+
+func (u *Usecase) Delete(ctx context.Context, id uuid.UUID) error {
+	return wrap(u.Repository.Delete(ctx, id))
+}
+
+// AddMovies is idempotent, movies already in collection are left there
+func (u *Usecase) AddMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error {
+	if len(movieIDs) == 0 {
+		return errors.Join(ErrInvalidCollection, errors.New("no movies given"))
+	}
+	return wrap(u.Repository.AddMovies(ctx, id, movieIDs))
+}
+
+// RemoveMovies ignores movies not in collection
+func (u *Usecase) RemoveMovies(ctx context.Context, id uuid.UUID, movieIDs []uuid.UUID) error {
+	if len(movieIDs) == 0 {
+		return errors.Join(ErrInvalidCollection, errors.New("no movies given"))
+	}
+	return wrap(u.Repository.RemoveMovies(ctx, id, movieIDs))
+}
+
+func validate(c model.Collection) error {
+	switch {
+	case c.Name == "":
+		return errors.Join(ErrInvalidCollection, errors.New("name is required"))
+	case len([]rune(c.Name)) > maxNameLength:
+		return errors.Join(ErrInvalidCollection, errors.New("name is too long"))
+	}
+	return nil
+}
+
+// wrap passes errors client can act on and hides the rest behind ErrInternal
+func wrap(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrResourceNotFound), errors.Is(err, ErrNameConflict), errors.Is(err, ErrCollectionInUse):
+		return err
+	}
+	return errors.Join(ErrInternal, err)
+}
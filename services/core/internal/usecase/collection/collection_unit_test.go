@@ -0,0 +1,239 @@
+//go:build !integration
+// +build !integration
+
+package usecase_collection
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	repo_mocks "github.com/humanbelnik/kinoswap/core/internal/usecase/collection/mocks/collection/repository"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type UsecaseCollectionUnitSuite struct {
+	suite.Suite
+}
+
+type resources struct {
+	usecase *Usecase
+	repo    *repo_mocks.Repository
+	ctx     context.Context
+}
+
+func initResources(t provider.T) *resources {
+	repo := repo_mocks.NewRepository(t)
+	return &resources{
+		usecase: New(repo),
+		repo:    repo,
+		ctx:     context.Background(),
+	}
+}
+
+func validCollection() model.Collection {
+	return model.Collection{
+		ID:          uuid.New(),
+		Name:        "Christmas classics",
+		Description: "Movies for the holidays",
+		Size:        3,
+	}
+}
+
+func (suite *UsecaseCollectionUnitSuite) TestCreate(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		collection    string
+		setupMocks    func(r *resources)
+		expectedError error
+	}{
+		{
+			name:       "Should create collection with trimmed name",
+			collection: "  Oscar winners 2020 ",
+			setupMocks: func(r *resources) {
+				r.repo.On("Create", r.ctx, mock.MatchedBy(func(c model.Collection) bool {
+					return c.Name == "Oscar winners 2020" && c.ID != uuid.Nil
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:          "Should reject blank name",
+			collection:    "   ",
+			setupMocks:    func(r *resources) {},
+			expectedError: ErrInvalidCollection,
+		},
+		{
+			name:          "Should reject too long name",
+			collection:    strings.Repeat("a", maxNameLength+1),
+			setupMocks:    func(r *resources) {},
+			expectedError: ErrInvalidCollection,
+		},
+		{
+			name:       "Should pass name conflict through",
+			collection: "Oscar winners 2020",
+			setupMocks: func(r *resources) {
+				r.repo.On("Create", r.ctx, mock.Anything).Return(ErrNameConflict).Once()
+			},
+			expectedError: ErrNameConflict,
+		},
+		{
+			name:       "Should wrap repository failure",
+			collection: "Oscar winners 2020",
+			setupMocks: func(r *resources) {
+				r.repo.On("Create", r.ctx, mock.Anything).Return(errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			c, err := r.usecase.Create(r.ctx, tc.collection, "")
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tc.collection), c.Name)
+		})
+	}
+}
+
+func (suite *UsecaseCollectionUnitSuite) TestUpdate(t provider.T) {
+	t.Parallel()
+
+	name := "Oscar winners 2021"
+	blank := " "
+
+	testCases := []struct {
+		name          string
+		patch         CollectionPatch
+		setupMocks    func(r *resources, c model.Collection)
+		expectedError error
+	}{
+		{
+			name:  "Should rename collection and keep description",
+			patch: CollectionPatch{Name: &name},
+			setupMocks: func(r *resources, c model.Collection) {
+				r.repo.On("Load", r.ctx, c.ID).Return(c, nil).Once()
+				r.repo.On("Update", r.ctx, mock.MatchedBy(func(next model.Collection) bool {
+					return next.Name == name && next.Description == c.Description
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:  "Should reject blank name",
+			patch: CollectionPatch{Name: &blank},
+			setupMocks: func(r *resources, c model.Collection) {
+				r.repo.On("Load", r.ctx, c.ID).Return(c, nil).Once()
+			},
+			expectedError: ErrInvalidCollection,
+		},
+		{
+			name:  "Should return not found for unknown collection",
+			patch: CollectionPatch{Name: &name},
+			setupMocks: func(r *resources, c model.Collection) {
+				r.repo.On("Load", r.ctx, c.ID).Return(model.Collection{}, ErrResourceNotFound).Once()
+			},
+			expectedError: ErrResourceNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			c := validCollection()
+			tc.setupMocks(r, c)
+
+			_, err := r.usecase.Update(r.ctx, c.ID, tc.patch)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func (suite *UsecaseCollectionUnitSuite) TestDelete(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		repoErr       error
+		expectedError error
+	}{
+		{name: "Should delete collection"},
+		{name: "Should refuse collection used by room", repoErr: ErrCollectionInUse, expectedError: ErrCollectionInUse},
+		{name: "Should return not found for unknown collection", repoErr: ErrResourceNotFound, expectedError: ErrResourceNotFound},
+		{name: "Should wrap repository failure", repoErr: errors.New("connection refused"), expectedError: ErrInternal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			id := uuid.New()
+			r.repo.On("Delete", r.ctx, id).Return(tc.repoErr).Once()
+
+			err := r.usecase.Delete(r.ctx, id)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func (suite *UsecaseCollectionUnitSuite) TestMovies(t provider.T) {
+	t.Parallel()
+
+	t.Run("Should add and remove movies", func(t provider.T) {
+		t.Parallel()
+		r := initResources(t)
+		id, movieIDs := uuid.New(), []uuid.UUID{uuid.New(), uuid.New()}
+		r.repo.On("AddMovies", r.ctx, id, movieIDs).Return(nil).Once()
+		r.repo.On("RemoveMovies", r.ctx, id, movieIDs[:1]).Return(nil).Once()
+
+		assert.NoError(t, r.usecase.AddMovies(r.ctx, id, movieIDs))
+		assert.NoError(t, r.usecase.RemoveMovies(r.ctx, id, movieIDs[:1]))
+	})
+
+	t.Run("Should reject empty movie list", func(t provider.T) {
+		t.Parallel()
+		r := initResources(t)
+
+		assert.ErrorIs(t, r.usecase.AddMovies(r.ctx, uuid.New(), nil), ErrInvalidCollection)
+		assert.ErrorIs(t, r.usecase.RemoveMovies(r.ctx, uuid.New(), nil), ErrInvalidCollection)
+	})
+
+	t.Run("Should return not found for unknown movie", func(t provider.T) {
+		t.Parallel()
+		r := initResources(t)
+		id, movieIDs := uuid.New(), []uuid.UUID{uuid.New()}
+		r.repo.On("AddMovies", r.ctx, id, movieIDs).Return(ErrResourceNotFound).Once()
+
+		assert.ErrorIs(t, r.usecase.AddMovies(r.ctx, id, movieIDs), ErrResourceNotFound)
+	})
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseCollectionUnitSuite))
+}
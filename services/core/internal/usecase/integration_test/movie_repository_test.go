@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package integrationtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	infra_pg_init "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/init"
+	infra_postgres_movie "github.com/humanbelnik/kinoswap/core/internal/infra/postgres/movie"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MovieRepositoryIntegrationSuite struct {
+	suite.Suite
+	repo *infra_postgres_movie.Repository
+}
+
+func (s *MovieRepositoryIntegrationSuite) BeforeAll(t provider.T) {
+	s.repo = infra_postgres_movie.New(infra_pg_init.MustEstablishConn(getConfig().Postgres))
+}
+
+func ids(movies []model.ScoredMovie) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(movies))
+	for _, m := range movies {
+		out = append(out, m.Movie.ID)
+	}
+	return out
+}
+
+// Pending movie has a vector and a matching text, only its status keeps it out
+func (s *MovieRepositoryIntegrationSuite) TestIntegrationPendingHidden(t provider.T) {
+	ctx := context.Background()
+
+	// Unique word and year, so rows of other tests don't get in the way
+	word := "pending" + uuid.NewString()[:8]
+	year := 1890
+	filter := model.MovieFilter{YearFrom: &year, YearTo: &year}
+	vector := make(model.Embedding, model.EmbeddingDimension)
+	vector[0] = 1
+
+	ready := model.MovieMeta{
+		ID: uuid.New(), Title: "Ready " + word, Year: year, Genres: []string{"Drama"},
+		Overview: word, Status: model.MovieStatusReady,
+	}
+	pending := model.MovieMeta{
+		ID: uuid.New(), Title: "Pending " + word, Year: year, Genres: []string{"Drama"},
+		Overview: word, Status: model.MovieStatusPending,
+	}
+	require.NoError(t, s.repo.Store(ctx, ready))
+	require.NoError(t, s.repo.EnqueueIngestion(ctx, pending, nil))
+	defer func() {
+		_ = s.repo.Delete(ctx, ready.ID)
+		_ = s.repo.Delete(ctx, pending.ID)
+	}()
+	require.NoError(t, s.repo.StoreEmbedding(ctx, ready.ID, vector, ""))
+	require.NoError(t, s.repo.StoreEmbedding(ctx, pending.ID, vector, ""))
+	_, err := s.repo.RefreshNeighbours(ctx, 10)
+	require.NoError(t, err)
+
+	page, err := s.repo.LoadPage(ctx, model.MovieQuery{Filter: filter, Limit: 10})
+	require.NoError(t, err)
+	loaded := make([]uuid.UUID, 0, len(page.Movies))
+	for _, mm := range page.Movies {
+		loaded = append(loaded, mm.ID)
+	}
+	assert.Equal(t, []uuid.UUID{ready.ID}, loaded, "catalog page")
+	assert.Equal(t, 1, page.Total)
+
+	hits, err := s.repo.LexicalSearch(ctx, 10, word, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ready.ID}, ids(hits), "lexical search")
+
+	hits, err = s.repo.KNN(ctx, 10, vector, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{ready.ID}, ids(hits), "vector search")
+
+	hits, err = s.repo.Neighbours(ctx, ready.ID, 10, filter)
+	require.NoError(t, err)
+	assert.NotContains(t, ids(hits), pending.ID, "precomputed neighbours")
+
+	hits, err = s.repo.LiveNeighbours(ctx, ready.ID, 10, filter)
+	require.NoError(t, err)
+	assert.NotContains(t, ids(hits), pending.ID, "live neighbours")
+}
+
+func TestMovieRepositoryIntegrationSuite(t *testing.T) {
+	suite.RunSuite(t, new(MovieRepositoryIntegrationSuite))
+}
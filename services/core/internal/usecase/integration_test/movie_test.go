@@ -37,7 +37,8 @@ func initUsecase(t provider.T) *movie_usecase.Usecase {
 	movieRepository := infra_postgres_movie.New(pgConn)
 	embedder := mocks_embedder.NewEmbedder(t)
 
-	return movie_usecase.New(movieRepository, posterRepository, embedder, embeddingReducer)
+	return movie_usecase.New(movieRepository, movieRepository, movieRepository, movieRepository,
+		posterRepository, embedder, embeddingReducer)
 }
 
 func (s *UsecaseMovieIntegrationSuite) BeforeAll(t provider.T) {
@@ -68,7 +69,7 @@ func (s *UsecaseMovieIntegrationSuite) TestIntegrationExists(t provider.T) {
 					MM: &model.MovieMeta{
 						ID:         movieID,
 						PosterLink: "",
-						Title:      "Test name",
+						Title:      "Test name " + movieID.String(),
 						Genres:     []string{"Drama", "Romance"},
 						Year:       2023,
 						Rating:     8.2,
@@ -127,7 +128,7 @@ func (s *UsecaseMovieIntegrationSuite) TestIntegrationUpload(t provider.T) {
 					MM: &model.MovieMeta{
 						ID:         movieID,
 						PosterLink: "",
-						Title:      "Test name",
+						Title:      "Test name " + movieID.String(),
 						Genres:     []string{"Drama", "Romance"},
 						Year:       2023,
 						Rating:     8.2,
@@ -0,0 +1,180 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var ErrInvalidRecord = errors.New("invalid record")
+
+const defaultImportConcurrency = 4
+
+// ImportRecord is a single parsed row of the catalog file.
+// Err is set by the reader if the row couldn't be parsed, such rows are reported as failed.
+type ImportRecord struct {
+	Line      int
+	Movie     model.MovieMeta
+	PosterURL string
+	Err       error
+}
+
+type ImportFailure struct {
+	Line  int
+	Title string
+	Error string
+}
+
+type ImportReport struct {
+	Total    int
+	Imported int
+	Skipped  int
+	Failed   int
+	Failures []ImportFailure
+}
+
+type ImportOptions struct {
+	// Movies are embedded in parallel, embedder is the bottleneck of the import
+	Concurrency int
+
+	// Downloads poster to upload it into poster storage. Posters are skipped if nil
+	FetchPoster func(ctx context.Context, url string) ([]byte, error)
+}
+
+type importOutcome int
+
+const (
+	outcomeImported importOutcome = iota
+	outcomeSkipped
+	outcomeFailed
+)
+
+// Import uploads movies which aren't in catalog yet.
+// Movie is a duplicate if there's one with the same title (case insensitive) and year,
+// so running import twice over the same file is a no-op.
+// Failed rows don't stop the import, they're listed in report.
+func (u *Usecase) Import(ctx context.Context, records iter.Seq[ImportRecord], opts ImportOptions) (ImportReport, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+
+	var (
+		report ImportReport
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		// Duplicates within the file are caught here, as they may be imported concurrently
+		seen = make(map[string]bool)
+		sem  = make(chan struct{}, concurrency)
+	)
+
+	record := func(rec ImportRecord, outcome importOutcome, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch outcome {
+		case outcomeImported:
+			report.Imported++
+		case outcomeSkipped:
+			report.Skipped++
+		case outcomeFailed:
+			report.Failed++
+			report.Failures = append(report.Failures, ImportFailure{
+				Line:  rec.Line,
+				Title: rec.Movie.Title,
+				Error: err.Error(),
+			})
+		}
+	}
+
+	for rec := range records {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Total++
+
+		if rec.Err == nil {
+			rec.Err = validateImportRecord(rec)
+		}
+		if rec.Err != nil {
+			record(rec, outcomeFailed, rec.Err)
+			continue
+		}
+
+		key := dedupKey(rec.Movie)
+		if seen[key] {
+			record(rec, outcomeSkipped, nil)
+			continue
+		}
+		seen[key] = true
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			outcome, err := u.importOne(ctx, rec, opts)
+			record(rec, outcome, err)
+		}()
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (u *Usecase) importOne(ctx context.Context, rec ImportRecord, opts ImportOptions) (importOutcome, error) {
+	mm := rec.Movie
+	mm.PosterLink = ""
+	movie := model.Movie{MM: &mm}
+
+	if rec.PosterURL != "" && opts.FetchPoster != nil {
+		content, err := opts.FetchPoster(ctx, rec.PosterURL)
+		if err != nil {
+			return outcomeFailed, err
+		}
+		movie.Poster = &model.Poster{
+			Filename: rec.PosterURL,
+			Content:  content,
+		}
+	}
+
+	// Movie already in catalog is rejected by database, so concurrent imports can't store it twice
+	err := u.Upload(ctx, movie)
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		return outcomeSkipped, nil
+	case err != nil:
+		return outcomeFailed, err
+	}
+	return outcomeImported, nil
+}
+
+// Same requirements as for a movie created through API
+func validateImportRecord(rec ImportRecord) error {
+	switch {
+	case strings.TrimSpace(rec.Movie.Title) == model.EmptyTitle:
+		return fmt.Errorf("%w: title is required", ErrInvalidRecord)
+	case strings.TrimSpace(rec.Movie.Overview) == "":
+		return fmt.Errorf("%w: overview is required", ErrInvalidRecord)
+	case len(rec.Movie.Genres) == 0:
+		return fmt.Errorf("%w: genres are required", ErrInvalidRecord)
+	}
+	return nil
+}
+
+func dedupKey(mm model.MovieMeta) string {
+	return strings.ToLower(strings.TrimSpace(mm.Title)) + "|" + strconv.Itoa(mm.Year)
+}
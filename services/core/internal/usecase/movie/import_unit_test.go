@@ -0,0 +1,139 @@
+//go:build !integration
+// +build !integration
+
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type UsecaseMovieImportUnitSuite struct {
+	suite.Suite
+}
+
+func importRecord(line int, title string, year int) ImportRecord {
+	mm := NewMovieMetaBuilder().WithTitle(title).Build()
+	mm.Year = year
+	return ImportRecord{Line: line, Movie: mm}
+}
+
+func expectUpload(r *resources, title string) {
+	r.metaRepository.On("Store", mock.Anything, mock.MatchedBy(func(mm model.MovieMeta) bool {
+		return mm.Title == title
+	})).Return(nil).Once()
+	r.embedder.On("BuildMovieEmbedding", mock.Anything, mock.MatchedBy(func(mm model.MovieMeta) bool {
+		return mm.Title == title
+	})).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once()
+	r.metaRepository.On("StoreEmbedding", mock.Anything, mock.Anything, mock.AnythingOfType("model.Embedding"), testEmbeddingModel.ID()).Return(nil).Once()
+}
+
+func (suite *UsecaseMovieImportUnitSuite) TestImport(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		records    []ImportRecord
+		setupMocks func(r *resources)
+		expected   ImportReport
+	}{
+		{
+			name: "Should import new movies",
+			records: []ImportRecord{
+				importRecord(2, "Heat", 1995),
+				importRecord(3, "Alien", 1979),
+			},
+			setupMocks: func(r *resources) {
+				expectUpload(r, "Heat")
+				expectUpload(r, "Alien")
+			},
+			expected: ImportReport{Total: 2, Imported: 2},
+		},
+		{
+			name: "Should skip movies already in catalog and duplicates within file",
+			records: []ImportRecord{
+				importRecord(2, "Heat", 1995),
+				importRecord(3, "HEAT", 1995),
+				importRecord(4, "Alien", 1979),
+			},
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("Store", mock.Anything, mock.MatchedBy(func(mm model.MovieMeta) bool {
+					return mm.Title == "Heat"
+				})).Return(ErrDuplicate).Once()
+				expectUpload(r, "Alien")
+			},
+			expected: ImportReport{Total: 3, Imported: 1, Skipped: 2},
+		},
+		{
+			name: "Should report invalid and failed rows and go on",
+			records: []ImportRecord{
+				{Line: 2, Err: ErrInvalidRecord},
+				func() ImportRecord {
+					rec := importRecord(3, "Heat", 1995)
+					rec.Movie.Genres = nil
+					return rec
+				}(),
+				importRecord(4, "Alien", 1979),
+			},
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("Store", mock.Anything, mock.Anything).Return(errors.New("connection refused")).Once()
+			},
+			expected: ImportReport{
+				Total:  3,
+				Failed: 3,
+				Failures: []ImportFailure{
+					{Line: 2, Error: "invalid record"},
+					{Line: 3, Title: "Heat", Error: "invalid record: genres are required"},
+					{Line: 4, Title: "Alien", Error: "internal error\nconnection refused"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			report, err := r.usecase.Import(r.ctx, slices.Values(tc.records), ImportOptions{Concurrency: 2})
+			assert.NoError(t, err)
+
+			// Failures of concurrent uploads come in any order
+			slices.SortFunc(report.Failures, func(a, b ImportFailure) int { return a.Line - b.Line })
+			assert.Equal(t, tc.expected, report)
+		})
+	}
+}
+
+func (suite *UsecaseMovieImportUnitSuite) TestImportCanceled(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	var records iter.Seq[ImportRecord] = func(yield func(ImportRecord) bool) {
+		cancel()
+		for i := range 10 {
+			if !yield(importRecord(i+2, "Heat", 1995+i)) {
+				return
+			}
+		}
+	}
+
+	report, err := r.usecase.Import(ctx, records, ImportOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, report.Total)
+}
+
+func TestImportUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseMovieImportUnitSuite))
+}
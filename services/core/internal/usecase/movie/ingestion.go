@@ -0,0 +1,118 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// IngestionQueue keeps jobs of pending movies, they're claimed by ingestion worker
+//
+//go:generate mockery --name=IngestionQueue --output=./mocks/movie/repository --filename=ingestion_queue.go
+type IngestionQueue interface {
+	// Stores pending movie along with its job
+	EnqueueIngestion(ctx context.Context, mm model.MovieMeta, poster []byte) error
+	CompleteIngestion(ctx context.Context, job model.IngestionJob, posterLink string, e model.Embedding, modelID string) (bool, error)
+	RetryIngestion(ctx context.Context, movieID uuid.UUID) (bool, error)
+	DeadIngestions(ctx context.Context) ([]model.IngestionJob, error)
+}
+
+// Enqueue stores movie as pending and leaves poster and embedding to ingestion worker,
+// so embedder hiccups don't fail uploads.
+func (u *Usecase) Enqueue(ctx context.Context, movie model.Movie) (model.MovieMeta, error) {
+	mm := *movie.MM
+	if mm.ID == uuid.Nil {
+		mm.ID = uuid.New()
+	}
+	mm.Status = model.MovieStatusPending
+	mm.PosterLink = ""
+
+	var poster []byte
+	if movie.Poster != nil {
+		poster = movie.Poster.Content
+	}
+
+	err := u.IngestionQueue.EnqueueIngestion(ctx, mm, poster)
+	if errors.Is(err, ErrDuplicate) {
+		return model.MovieMeta{}, err
+	}
+	if err != nil {
+		return model.MovieMeta{}, errors.Join(ErrInternal, err)
+	}
+	return mm, nil
+}
+
+// Ingest stores poster and embedding of pending movie. Both are keyed by movie,
+// so retrying after partial failure overwrites what's been stored.
+func (u *Usecase) Ingest(ctx context.Context, job model.IngestionJob) error {
+	mms, err := u.MetaRepository.LoadSome(ctx, []uuid.UUID{job.MovieID})
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	// Deleted meanwhile, job is gone with it
+	if len(mms) == 0 {
+		return nil
+	}
+	mm := *mms[0]
+
+	var posterLink string
+	if job.Poster != nil {
+		key := mm.ID.String()
+		_, err := u.PosterRepository.Save(ctx, &model.Poster{
+			Filename: key,
+			Content:  job.Poster,
+			MovieID:  key,
+		}, &key)
+		if err != nil {
+			return errors.Join(ErrInternal, err)
+		}
+		posterLink = key
+	}
+
+	emb, err := u.Embedder.BuildMovieEmbedding(ctx, mm)
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	if len(emb) != model.EmbeddingDimension {
+		return ErrInvalidEmbeddingDimension
+	}
+	em, err := u.EmbeddingModel(ctx)
+	if err != nil {
+		return err
+	}
+
+	completed, err := u.IngestionQueue.CompleteIngestion(ctx, job, posterLink, emb, em.ID())
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	if !completed && posterLink != "" {
+		if err := u.PosterRepository.Delete(ctx, posterLink); err != nil {
+			log.Println("failed to delete poster of deleted movie")
+		}
+	}
+	return nil
+}
+
+// RetryIngestion gives dead ingestion of movie another round of attempts
+func (u *Usecase) RetryIngestion(ctx context.Context, movieID uuid.UUID) error {
+	retried, err := u.IngestionQueue.RetryIngestion(ctx, movieID)
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	if !retried {
+		return ErrResourceNotFound
+	}
+	return nil
+}
+
+// DeadIngestions may be empty
+func (u *Usecase) DeadIngestions(ctx context.Context) ([]model.IngestionJob, error) {
+	jobs, err := u.IngestionQueue.DeadIngestions(ctx)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return jobs, nil
+}
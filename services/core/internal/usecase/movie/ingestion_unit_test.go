@@ -0,0 +1,160 @@
+//go:build !integration
+// +build !integration
+
+package usecase_movie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type UsecaseMovieIngestionUnitSuite struct {
+	suite.Suite
+}
+
+func (suite *UsecaseMovieIngestionUnitSuite) TestEnqueue(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	mm := NewMovieMetaBuilder().Build()
+	poster := []byte("poster")
+
+	expected := mm
+	expected.Status = model.MovieStatusPending
+	expected.PosterLink = ""
+	r.ingestionQueue.On("EnqueueIngestion", r.ctx, expected, poster).Return(nil).Once()
+
+	stored, err := r.usecase.Enqueue(r.ctx, model.Movie{MM: &mm, Poster: &model.Poster{Content: poster}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, stored)
+	assert.Equal(t, model.MovieStatusReady, mm.Status, "caller's movie is left intact")
+}
+
+func (suite *UsecaseMovieIngestionUnitSuite) TestEnqueueFails(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	mm := NewMovieMetaBuilder().Build()
+	r.ingestionQueue.On("EnqueueIngestion", r.ctx, mock.AnythingOfType("model.MovieMeta"), []byte(nil)).
+		Return(errors.New("connection refused")).Once()
+
+	_, err := r.usecase.Enqueue(r.ctx, model.Movie{MM: &mm})
+
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
+func (suite *UsecaseMovieIngestionUnitSuite) TestEnqueueDuplicate(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	mm := NewMovieMetaBuilder().Build()
+	r.ingestionQueue.On("EnqueueIngestion", r.ctx, mock.AnythingOfType("model.MovieMeta"), []byte(nil)).
+		Return(ErrDuplicate).Once()
+
+	_, err := r.usecase.Enqueue(r.ctx, model.Movie{MM: &mm})
+
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.NotErrorIs(t, err, ErrInternal)
+}
+
+func (suite *UsecaseMovieIngestionUnitSuite) TestIngest(t provider.T) {
+	t.Parallel()
+
+	mm := NewMovieMetaBuilder().Build()
+	mm.Status = model.MovieStatusPending
+	mm.PosterLink = ""
+	job := model.IngestionJob{ID: uuid.New(), MovieID: mm.ID, Poster: []byte("poster"), Attempts: 1}
+	key := mm.ID.String()
+	emb := model.Embedding(make([]float32, model.EmbeddingDimension))
+
+	testCases := []struct {
+		name          string
+		setupMocks    func(r *resources)
+		expectedError error
+	}{
+		{
+			name: "Should make movie ready",
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{mm.ID}).Return([]*model.MovieMeta{&mm}, nil).Once()
+				r.posterRepository.On("Save", r.ctx, &model.Poster{Filename: key, Content: job.Poster, MovieID: key}, &key).
+					Return(key, nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, mm).Return(emb, nil).Once()
+				r.ingestionQueue.On("CompleteIngestion", r.ctx, job, key, emb, testEmbeddingModel.ID()).Return(true, nil).Once()
+			},
+		},
+		{
+			name: "Should skip movie deleted before ingestion",
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{mm.ID}).Return([]*model.MovieMeta{}, nil).Once()
+			},
+		},
+		{
+			name: "Should delete poster of movie deleted during ingestion",
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{mm.ID}).Return([]*model.MovieMeta{&mm}, nil).Once()
+				r.posterRepository.On("Save", r.ctx, mock.AnythingOfType("*model.Poster"), &key).Return(key, nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, mm).Return(emb, nil).Once()
+				r.ingestionQueue.On("CompleteIngestion", r.ctx, job, key, emb, testEmbeddingModel.ID()).Return(false, nil).Once()
+				r.posterRepository.On("Delete", r.ctx, key).Return(nil).Once()
+			},
+		},
+		{
+			name: "Should fail when embedder is unavailable",
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{mm.ID}).Return([]*model.MovieMeta{&mm}, nil).Once()
+				r.posterRepository.On("Save", r.ctx, mock.AnythingOfType("*model.Poster"), &key).Return(key, nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, mm).Return(nil, errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+		{
+			name: "Should refuse embedding of wrong dimension",
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{mm.ID}).Return([]*model.MovieMeta{&mm}, nil).Once()
+				r.posterRepository.On("Save", r.ctx, mock.AnythingOfType("*model.Poster"), &key).Return(key, nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, mm).Return(model.Embedding{1, 2, 3}, nil).Once()
+			},
+			expectedError: ErrInvalidEmbeddingDimension,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			err := r.usecase.Ingest(r.ctx, job)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func (suite *UsecaseMovieIngestionUnitSuite) TestRetryIngestion(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+	movieID := uuid.New()
+
+	r.ingestionQueue.On("RetryIngestion", r.ctx, movieID).Return(false, nil).Once()
+	assert.ErrorIs(t, r.usecase.RetryIngestion(r.ctx, movieID), ErrResourceNotFound)
+
+	r.ingestionQueue.On("RetryIngestion", r.ctx, movieID).Return(true, nil).Once()
+	assert.NoError(t, r.usecase.RetryIngestion(r.ctx, movieID))
+}
+
+func TestIngestionUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseMovieIngestionUnitSuite))
+}
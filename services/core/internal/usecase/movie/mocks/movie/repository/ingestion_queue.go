@@ -0,0 +1,135 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/humanbelnik/kinoswap/core/internal/model"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// IngestionQueue is an autogenerated mock type for the IngestionQueue type
+type IngestionQueue struct {
+	mock.Mock
+}
+
+// CompleteIngestion provides a mock function with given fields: ctx, job, posterLink, e, modelID
+func (_m *IngestionQueue) CompleteIngestion(ctx context.Context, job model.IngestionJob, posterLink string, e model.Embedding, modelID string) (bool, error) {
+	ret := _m.Called(ctx, job, posterLink, e, modelID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteIngestion")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.IngestionJob, string, model.Embedding, string) (bool, error)); ok {
+		return rf(ctx, job, posterLink, e, modelID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.IngestionJob, string, model.Embedding, string) bool); ok {
+		r0 = rf(ctx, job, posterLink, e, modelID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.IngestionJob, string, model.Embedding, string) error); ok {
+		r1 = rf(ctx, job, posterLink, e, modelID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeadIngestions provides a mock function with given fields: ctx
+func (_m *IngestionQueue) DeadIngestions(ctx context.Context) ([]model.IngestionJob, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeadIngestions")
+	}
+
+	var r0 []model.IngestionJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.IngestionJob, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.IngestionJob); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.IngestionJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnqueueIngestion provides a mock function with given fields: ctx, mm, poster
+func (_m *IngestionQueue) EnqueueIngestion(ctx context.Context, mm model.MovieMeta, poster []byte) error {
+	ret := _m.Called(ctx, mm, poster)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnqueueIngestion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.MovieMeta, []byte) error); ok {
+		r0 = rf(ctx, mm, poster)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryIngestion provides a mock function with given fields: ctx, movieID
+func (_m *IngestionQueue) RetryIngestion(ctx context.Context, movieID uuid.UUID) (bool, error) {
+	ret := _m.Called(ctx, movieID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryIngestion")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (bool, error)); ok {
+		return rf(ctx, movieID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) bool); ok {
+		r0 = rf(ctx, movieID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, movieID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIngestionQueue creates a new instance of IngestionQueue. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIngestionQueue(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IngestionQueue {
+	mock := &IngestionQueue{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
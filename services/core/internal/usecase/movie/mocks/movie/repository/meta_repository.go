@@ -62,6 +62,96 @@ func (_m *MetaRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error
 	return r0, r1
 }
 
+// KNN provides a mock function with given fields: ctx, k, e, f
+func (_m *MetaRepository) KNN(ctx context.Context, k int, e model.Embedding, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	ret := _m.Called(ctx, k, e, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KNN")
+	}
+
+	var r0 []model.ScoredMovie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Embedding, model.MovieFilter) ([]model.ScoredMovie, error)); ok {
+		return rf(ctx, k, e, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Embedding, model.MovieFilter) []model.ScoredMovie); ok {
+		r0 = rf(ctx, k, e, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ScoredMovie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.Embedding, model.MovieFilter) error); ok {
+		r1 = rf(ctx, k, e, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LexicalSearch provides a mock function with given fields: ctx, k, text, f
+func (_m *MetaRepository) LexicalSearch(ctx context.Context, k int, text string, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	ret := _m.Called(ctx, k, text, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LexicalSearch")
+	}
+
+	var r0 []model.ScoredMovie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, model.MovieFilter) ([]model.ScoredMovie, error)); ok {
+		return rf(ctx, k, text, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, model.MovieFilter) []model.ScoredMovie); ok {
+		r0 = rf(ctx, k, text, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ScoredMovie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, model.MovieFilter) error); ok {
+		r1 = rf(ctx, k, text, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LiveNeighbours provides a mock function with given fields: ctx, id, limit, f
+func (_m *MetaRepository) LiveNeighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	ret := _m.Called(ctx, id, limit, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LiveNeighbours")
+	}
+
+	var r0 []model.ScoredMovie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int, model.MovieFilter) ([]model.ScoredMovie, error)); ok {
+		return rf(ctx, id, limit, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int, model.MovieFilter) []model.ScoredMovie); ok {
+		r0 = rf(ctx, id, limit, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ScoredMovie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int, model.MovieFilter) error); ok {
+		r1 = rf(ctx, id, limit, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LoadAll provides a mock function with given fields: ctx
 func (_m *MetaRepository) LoadAll(ctx context.Context) ([]*model.MovieMeta, error) {
 	ret := _m.Called(ctx)
@@ -92,6 +182,34 @@ func (_m *MetaRepository) LoadAll(ctx context.Context) ([]*model.MovieMeta, erro
 	return r0, r1
 }
 
+// LoadPage provides a mock function with given fields: ctx, q
+func (_m *MetaRepository) LoadPage(ctx context.Context, q model.MovieQuery) (model.MoviePage, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadPage")
+	}
+
+	var r0 model.MoviePage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.MovieQuery) (model.MoviePage, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.MovieQuery) model.MoviePage); ok {
+		r0 = rf(ctx, q)
+	} else {
+		r0 = ret.Get(0).(model.MoviePage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.MovieQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LoadSome provides a mock function with given fields: ctx, ids
 func (_m *MetaRepository) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.MovieMeta, error) {
 	ret := _m.Called(ctx, ids)
@@ -122,6 +240,36 @@ func (_m *MetaRepository) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*mod
 	return r0, r1
 }
 
+// Neighbours provides a mock function with given fields: ctx, id, limit, f
+func (_m *MetaRepository) Neighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	ret := _m.Called(ctx, id, limit, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Neighbours")
+	}
+
+	var r0 []model.ScoredMovie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int, model.MovieFilter) ([]model.ScoredMovie, error)); ok {
+		return rf(ctx, id, limit, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int, model.MovieFilter) []model.ScoredMovie); ok {
+		r0 = rf(ctx, id, limit, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ScoredMovie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int, model.MovieFilter) error); ok {
+		r1 = rf(ctx, id, limit, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Store provides a mock function with given fields: ctx, mm
 func (_m *MetaRepository) Store(ctx context.Context, mm model.MovieMeta) error {
 	ret := _m.Called(ctx, mm)
@@ -140,17 +288,35 @@ func (_m *MetaRepository) Store(ctx context.Context, mm model.MovieMeta) error {
 	return r0
 }
 
-// StoreEmbedding provides a mock function with given fields: ctx, id, e
-func (_m *MetaRepository) StoreEmbedding(ctx context.Context, id uuid.UUID, e model.Embedding) error {
-	ret := _m.Called(ctx, id, e)
+// StoreEmbedding provides a mock function with given fields: ctx, id, e, modelID
+func (_m *MetaRepository) StoreEmbedding(ctx context.Context, id uuid.UUID, e model.Embedding, modelID string) error {
+	ret := _m.Called(ctx, id, e, modelID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for StoreEmbedding")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, model.Embedding) error); ok {
-		r0 = rf(ctx, id, e)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, model.Embedding, string) error); ok {
+		r0 = rf(ctx, id, e, modelID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, mm
+func (_m *MetaRepository) Update(ctx context.Context, mm model.MovieMeta) error {
+	ret := _m.Called(ctx, mm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.MovieMeta) error); ok {
+		r0 = rf(ctx, mm)
 	} else {
 		r0 = ret.Error(0)
 	}
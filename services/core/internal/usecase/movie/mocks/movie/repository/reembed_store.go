@@ -0,0 +1,152 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/humanbelnik/kinoswap/core/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReembedStore is an autogenerated mock type for the ReembedStore type
+type ReembedStore struct {
+	mock.Mock
+}
+
+// CancelReembed provides a mock function with given fields: ctx
+func (_m *ReembedStore) CancelReembed(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelReembed")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReembedJob provides a mock function with given fields: ctx, modelID
+func (_m *ReembedStore) CreateReembedJob(ctx context.Context, modelID string) (model.ReembedJob, bool, error) {
+	ret := _m.Called(ctx, modelID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReembedJob")
+	}
+
+	var r0 model.ReembedJob
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.ReembedJob, bool, error)); ok {
+		return rf(ctx, modelID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.ReembedJob); ok {
+		r0 = rf(ctx, modelID)
+	} else {
+		r0 = ret.Get(0).(model.ReembedJob)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, modelID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, modelID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// EmbeddingModels provides a mock function with given fields: ctx
+func (_m *ReembedStore) EmbeddingModels(ctx context.Context) (map[string]int, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EmbeddingModels")
+	}
+
+	var r0 map[string]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string]int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastReembedJob provides a mock function with given fields: ctx
+func (_m *ReembedStore) LastReembedJob(ctx context.Context) (*model.ReembedJob, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastReembedJob")
+	}
+
+	var r0 *model.ReembedJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*model.ReembedJob, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *model.ReembedJob); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ReembedJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReembedStore creates a new instance of ReembedStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReembedStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReembedStore {
+	mock := &ReembedStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,123 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/humanbelnik/kinoswap/core/internal/model"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// SagaLog is an autogenerated mock type for the SagaLog type
+type SagaLog struct {
+	mock.Mock
+}
+
+// BeginSaga provides a mock function with given fields: ctx, saga, lease
+func (_m *SagaLog) BeginSaga(ctx context.Context, saga model.Saga, lease time.Duration) error {
+	ret := _m.Called(ctx, saga, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginSaga")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Saga, time.Duration) error); ok {
+		r0 = rf(ctx, saga, lease)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FailSaga provides a mock function with given fields: ctx, id, reason
+func (_m *SagaLog) FailSaga(ctx context.Context, id uuid.UUID, reason string) error {
+	ret := _m.Called(ctx, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FailSaga")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishSaga provides a mock function with given fields: ctx, id, status
+func (_m *SagaLog) FinishSaga(ctx context.Context, id uuid.UUID, status model.SagaStatus) error {
+	ret := _m.Called(ctx, id, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishSaga")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, model.SagaStatus) error); ok {
+		r0 = rf(ctx, id, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordSagaStep provides a mock function with given fields: ctx, step, lease
+func (_m *SagaLog) RecordSagaStep(ctx context.Context, step model.SagaStep, lease time.Duration) error {
+	ret := _m.Called(ctx, step, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordSagaStep")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.SagaStep, time.Duration) error); ok {
+		r0 = rf(ctx, step, lease)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSagaBackup provides a mock function with given fields: ctx, id, backup
+func (_m *SagaLog) SetSagaBackup(ctx context.Context, id uuid.UUID, backup []byte) error {
+	ret := _m.Called(ctx, id, backup)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSagaBackup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []byte) error); ok {
+		r0 = rf(ctx, id, backup)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSagaLog creates a new instance of SagaLog. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSagaLog(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SagaLog {
+	mock := &SagaLog{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,21 +18,33 @@ var (
 	ErrInternal                  = errors.New("internal error")
 	ErrResourceNotFound          = errors.New("no such resource")
 	ErrInvalidEmbeddingDimension = errors.New("invalid embedding dimension")
+	// Catalog has movie with the same title and year
+	ErrDuplicate = errors.New("movie already exists")
 )
 
+// MetaRepository keeps catalog. Sagas, ingestion and re-embed jobs have stores of their own
+//
 //go:generate mockery --name=MetaRepository --output=./mocks/movie/repository --filename=meta_repository.go
 type MetaRepository interface {
 	Store(ctx context.Context, mm model.MovieMeta) error
+	Update(ctx context.Context, mm model.MovieMeta) error
 	Delete(ectx context.Context, id uuid.UUID) error
 	LoadAll(ctx context.Context) ([]*model.MovieMeta, error)
+	LoadPage(ctx context.Context, q model.MovieQuery) (model.MoviePage, error)
 	LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.MovieMeta, error)
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
-	StoreEmbedding(ctx context.Context, id uuid.UUID, e model.Embedding) error
+	StoreEmbedding(ctx context.Context, id uuid.UUID, e model.Embedding, modelID string) error
+	KNN(ctx context.Context, k int, e model.Embedding, f model.MovieFilter) ([]model.ScoredMovie, error)
+	LexicalSearch(ctx context.Context, k int, text string, f model.MovieFilter) ([]model.ScoredMovie, error)
+	Neighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error)
+	LiveNeighbours(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error)
 }
 
 //go:generate mockery --name=Embedder --output=./mocks/movie/embedder --filename=embedder.go
 type Embedder interface {
 	BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error)
+	BuildPreferenceEmbedding(ctx context.Context, p model.Preference) (model.Embedding, error)
+	ModelInfo(ctx context.Context) (model.EmbeddingModel, error)
 }
 
 //go:generate mockery --name=EmbeddingReducer --output=./mocks/movie/embedder --filename=embedding_reducer.go
@@ -49,19 +62,31 @@ type PosterRepository interface {
 
 type Usecase struct {
 	MetaRepository   MetaRepository
+	SagaLog          SagaLog
+	IngestionQueue   IngestionQueue
+	ReembedStore     ReembedStore
 	PosterRepository PosterRepository
 	Embedder         Embedder
 	EmbeddingReducer EmbeddingReducer
+
+	// Model of embedder, asked once and recorded next to every stored vector
+	embeddingModel atomic.Pointer[model.EmbeddingModel]
 }
 
 func New(
 	metaRepository MetaRepository,
+	sagaLog SagaLog,
+	ingestionQueue IngestionQueue,
+	reembedStore ReembedStore,
 	posterRepository PosterRepository,
 	embedder Embedder,
 	embeddingReducer EmbeddingReducer,
 ) *Usecase {
 	return &Usecase{
 		MetaRepository:   metaRepository,
+		SagaLog:          sagaLog,
+		IngestionQueue:   ingestionQueue,
+		ReembedStore:     reembedStore,
 		PosterRepository: posterRepository,
 		Embedder:         embedder,
 		EmbeddingReducer: embeddingReducer,
@@ -73,9 +98,11 @@ func New(
 // - Store Poster file (if specified)
 // - Get embedding from external embedder
 // - Store embedding in meta
-// If something goes wrong, rollback to the beginning state
+// If something goes wrong, rollback to the beginning state.
+// Every step is logged as a saga, so writes cut by a crash are finished or rolled back by recovery.
 
 type Op struct {
+	name     string
 	exec     func(ctx context.Context) error
 	rollback func(ctx context.Context) error
 }
@@ -84,6 +111,12 @@ type MovieBuilder struct {
 	ops   []Op
 	uc    *Usecase
 	movie model.Movie
+
+	sagaID uuid.UUID
+	kind   model.SagaKind
+	prev   *model.MovieMeta
+	// Poster overwritten by the saga
+	backup *model.Poster
 }
 
 func NewMovieBuilder(u *Usecase, movie model.Movie) *MovieBuilder {
@@ -92,15 +125,21 @@ func NewMovieBuilder(u *Usecase, movie model.Movie) *MovieBuilder {
 	}
 
 	return &MovieBuilder{
-		movie: movie,
-		uc:    u,
+		movie:  movie,
+		uc:     u,
+		sagaID: uuid.New(),
+		kind:   model.SagaCreate,
 	}
 }
 
 func (b *MovieBuilder) WithMeta(ctx context.Context) *MovieBuilder {
 	op := Op{
+		name: stepMeta,
 		exec: func(ctx context.Context) error {
 			err := b.uc.MetaRepository.Store(ctx, *b.movie.MM)
+			if errors.Is(err, ErrDuplicate) {
+				return err
+			}
 			if err != nil {
 				return errors.Join(ErrInternal, err)
 			}
@@ -123,6 +162,7 @@ func (b *MovieBuilder) WithPoster(ctx context.Context) *MovieBuilder {
 	b.movie.MM.PosterLink = strID
 
 	op := Op{
+		name: stepPoster,
 		exec: func(ctx context.Context) error {
 			_, err := b.uc.PosterRepository.Save(ctx, &model.Poster{
 				Filename: strID,
@@ -145,6 +185,7 @@ func (b *MovieBuilder) WithPoster(ctx context.Context) *MovieBuilder {
 
 func (b *MovieBuilder) WithEmbedding(ctx context.Context) *MovieBuilder {
 	op := Op{
+		name: stepEmbedding,
 		exec: func(ctx context.Context) error {
 			emb, err := b.uc.Embedder.BuildMovieEmbedding(ctx, *b.movie.MM)
 			if err != nil {
@@ -153,7 +194,11 @@ func (b *MovieBuilder) WithEmbedding(ctx context.Context) *MovieBuilder {
 			if len(emb) != model.EmbeddingDimension {
 				return ErrInvalidEmbeddingDimension
 			}
-			err = b.uc.MetaRepository.StoreEmbedding(ctx, b.movie.MM.ID, emb)
+			em, err := b.uc.EmbeddingModel(ctx)
+			if err != nil {
+				return err
+			}
+			err = b.uc.MetaRepository.StoreEmbedding(ctx, b.movie.MM.ID, emb, em.ID())
 			if err != nil {
 				return errors.Join(ErrInternal, err)
 			}
@@ -168,30 +213,34 @@ func (b *MovieBuilder) WithEmbedding(ctx context.Context) *MovieBuilder {
 }
 
 func (b *MovieBuilder) Execute(ctx context.Context) error {
-	var successfullyCompletedOps int
-
-	for _, op := range b.ops {
-		if err := op.exec(ctx); err != nil {
-			b.rollback(ctx, successfullyCompletedOps)
-			return err
-		}
-		successfullyCompletedOps++
+	if err := b.uc.SagaLog.BeginSaga(ctx, b.saga(), SagaLease); err != nil {
+		return errors.Join(ErrInternal, err)
 	}
-
-	return nil
+	return b.run(ctx, 0)
 }
 
-func (b *MovieBuilder) rollback(ctx context.Context, completedOpsCount int) {
-	for i := completedOpsCount; i > -1; i-- {
+// Failed op has done nothing to undo, only completed ones are rolled back.
+// Rollback cut short is finished by recovery.
+func (b *MovieBuilder) rollback(ctx context.Context, completedOpsCount int) error {
+	for i := completedOpsCount - 1; i >= 0; i-- {
+		b.record(ctx, i, model.SagaCompensate, model.SagaStepStarted, nil)
 		if b.ops[i].rollback != nil {
 			if err := b.ops[i].rollback(ctx); err != nil {
 				log.Println("rollback failed")
+				b.record(ctx, i, model.SagaCompensate, model.SagaStepFailed, err)
+				b.fail(ctx, err)
+				return err
 			}
 		}
+		b.record(ctx, i, model.SagaCompensate, model.SagaStepDone, nil)
 	}
+
+	return b.finish(ctx, model.SagaCompensated)
 }
 
+// Upload stores movie synchronously, it's ready once stored. Used by catalog import, see Enqueue for single uploads.
 func (u *Usecase) Upload(ctx context.Context, movie model.Movie) error {
+	movie.MM.Status = model.MovieStatusReady
 	return NewMovieBuilder(u, movie).
 		WithMeta(ctx).
 		WithPoster(ctx).
@@ -199,6 +248,37 @@ func (u *Usecase) Upload(ctx context.Context, movie model.Movie) error {
 		Execute(ctx)
 }
 
+func (b *MovieBuilder) WithMetaDelete(ctx context.Context) *MovieBuilder {
+	b.kind = model.SagaDelete
+
+	op := Op{
+		name: stepMetaDelete,
+		exec: func(ctx context.Context) error {
+			if err := b.uc.MetaRepository.Delete(ctx, b.movie.MM.ID); err != nil {
+				return errors.Join(ErrInternal, err)
+			}
+			return nil
+		},
+	}
+	b.ops = append(b.ops, op)
+	return b
+}
+
+func (b *MovieBuilder) WithPosterDelete(ctx context.Context) *MovieBuilder {
+	op := Op{
+		name: stepPosterDelete,
+		exec: func(ctx context.Context) error {
+			if err := b.uc.PosterRepository.Delete(ctx, b.movie.MM.ID.String()); err != nil {
+				return errors.Join(ErrInternal, err)
+			}
+			return nil
+		},
+	}
+	b.ops = append(b.ops, op)
+	return b
+}
+
+// Delete succeeds once movie row is deleted, poster is deleted by recovery if it fails here
 func (u *Usecase) Delete(ctx context.Context, id uuid.UUID) error {
 	exists, err := u.Exists(ctx, id)
 	if err != nil {
@@ -209,15 +289,10 @@ func (u *Usecase) Delete(ctx context.Context, id uuid.UUID) error {
 		return ErrResourceNotFound
 	}
 
-	if err := u.MetaRepository.Delete(ctx, id); err != nil {
-		return errors.Join(ErrInternal, err)
-	}
-
-	if err := u.PosterRepository.Delete(ctx, id.String()); err != nil {
-		return errors.Join(ErrInternal, err)
-	}
-
-	return nil
+	return NewMovieBuilder(u, model.Movie{MM: &model.MovieMeta{ID: id}}).
+		WithMetaDelete(ctx).
+		WithPosterDelete(ctx).
+		Execute(ctx)
 }
 
 func (u *Usecase) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
@@ -251,6 +326,38 @@ func (u *Usecase) LoadAll(ctx context.Context) ([]*model.MovieMeta, error) {
 	return mm, nil
 }
 
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// LoadPage presigns posters of returned movies only.
+// Empty page isn't an error, it's a valid answer to filter matching nothing.
+func (u *Usecase) LoadPage(ctx context.Context, q model.MovieQuery) (model.MoviePage, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	q.Limit = min(q.Limit, MaxPageSize)
+	q.Offset = max(q.Offset, 0)
+
+	page, err := u.MetaRepository.LoadPage(ctx, q)
+	if err != nil {
+		return model.MoviePage{}, errors.Join(ErrInternal, err)
+	}
+	page.Limit, page.Offset = q.Limit, q.Offset
+
+	for _, m := range page.Movies {
+		if m.PosterLink != "" {
+			m.PosterLink, err = u.PosterRepository.GeneratePresignedURL(ctx, m.PosterLink, 10*time.Minute)
+			if err != nil {
+				return model.MoviePage{}, errors.Join(ErrInternal, err)
+			}
+		}
+	}
+
+	return page, nil
+}
+
 func (u *Usecase) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.MovieMeta, error) {
 	mm, err := u.MetaRepository.LoadSome(ctx, ids)
 	if err != nil {
@@ -29,6 +29,9 @@ type UsecaseMovieUnitSuite struct {
 type resources struct {
 	usecase          *Usecase
 	metaRepository   *repo_mocks.MetaRepository
+	sagaLog          *repo_mocks.SagaLog
+	ingestionQueue   *repo_mocks.IngestionQueue
+	reembedStore     *repo_mocks.ReembedStore
 	posterRepository *repo_mocks.PosterRepository
 	embedder         *embedder_mocks.Embedder
 	ctx              context.Context
@@ -49,6 +52,7 @@ func NewMovieMetaBuilder() *MovieMetaBuilder {
 			Year:       2024,
 			Rating:     8.5,
 			Overview:   "Test overview",
+			Status:     model.MovieStatusReady,
 		},
 	}
 }
@@ -67,16 +71,31 @@ func (b *MovieMetaBuilder) Build() model.MovieMeta {
 	return b.mm
 }
 
+var testEmbeddingModel = model.EmbeddingModel{Name: "test-model", Version: "1", Dimension: model.EmbeddingDimension}
+
 func initResources(t provider.T) *resources {
 	metaRepository := repo_mocks.NewMetaRepository(t)
+	sagaLog := repo_mocks.NewSagaLog(t)
+	ingestionQueue := repo_mocks.NewIngestionQueue(t)
+	reembedStore := repo_mocks.NewReembedStore(t)
 	posterRepository := repo_mocks.NewPosterRepository(t)
 	embedder := embedder_mocks.NewEmbedder(t)
+	embedder.On("ModelInfo", mock.Anything).Return(testEmbeddingModel, nil).Maybe()
+	// Saga log is covered by saga tests
+	sagaLog.On("BeginSaga", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	sagaLog.On("RecordSagaStep", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	sagaLog.On("FinishSaga", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	sagaLog.On("FailSaga", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	sagaLog.On("SetSagaBackup", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 	embeddingReducer := embedding_reducer.New()
-	usecase := New(metaRepository, posterRepository, embedder, embeddingReducer)
+	usecase := New(metaRepository, sagaLog, ingestionQueue, reembedStore, posterRepository, embedder, embeddingReducer)
 
 	return &resources{
 		usecase:          usecase,
 		metaRepository:   metaRepository,
+		sagaLog:          sagaLog,
+		ingestionQueue:   ingestionQueue,
+		reembedStore:     reembedStore,
 		posterRepository: posterRepository,
 		embedder:         embedder,
 		ctx:              context.Background(),
@@ -102,7 +121,7 @@ func (suite *UsecaseMovieUnitSuite) TestUpload(t provider.T) {
 				r.embedder.On("BuildMovieEmbedding", mock.Anything, *movie.MM).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once().Run(func(args mock.Arguments) {
 					r.wg.Done()
 				})
-				r.metaRepository.On("StoreEmbedding", mock.Anything, movie.MM.ID, mock.AnythingOfType("model.Embedding")).Return(nil).Once().Run(func(args mock.Arguments) {
+				r.metaRepository.On("StoreEmbedding", mock.Anything, movie.MM.ID, mock.AnythingOfType("model.Embedding"), testEmbeddingModel.ID()).Return(nil).Once().Run(func(args mock.Arguments) {
 					r.wg.Done()
 				})
 			},
@@ -119,7 +138,6 @@ func (suite *UsecaseMovieUnitSuite) TestUpload(t provider.T) {
 			setupMocks: func(r *resources, movie model.Movie) {
 				repoError := errors.New("store error")
 				r.metaRepository.On("Store", r.ctx, *movie.MM).Return(repoError).Once()
-				r.metaRepository.On("Delete", r.ctx, movie.MM.ID).Return(nil).Once()
 			},
 			movie: model.Movie{
 				MM: func() *model.MovieMeta {
@@ -130,6 +148,24 @@ func (suite *UsecaseMovieUnitSuite) TestUpload(t provider.T) {
 			expectError:   true,
 			errorContains: "store error",
 		},
+		{
+			name: "Should roll back only completed steps when poster storage fails",
+			setupMocks: func(r *resources, movie model.Movie) {
+				r.metaRepository.On("Store", r.ctx, mock.AnythingOfType("model.MovieMeta")).Return(nil).Once()
+				r.posterRepository.On("Save", r.ctx, mock.AnythingOfType("*model.Poster"), mock.Anything).
+					Return("", errors.New("s3 unavailable")).Once()
+				r.metaRepository.On("Delete", r.ctx, movie.MM.ID).Return(nil).Once()
+			},
+			movie: model.Movie{
+				MM: func() *model.MovieMeta {
+					mm := NewMovieMetaBuilder().Build()
+					return &mm
+				}(),
+				Poster: &model.Poster{Content: []byte("poster")},
+			},
+			expectError:   true,
+			errorContains: "s3 unavailable",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -174,6 +210,16 @@ func (suite *UsecaseMovieUnitSuite) TestDelete(t provider.T) {
 			movieID:     uuid.New(),
 			expectError: false,
 		},
+		{
+			name: "Should delete movie when poster deletion fails, it's left to recovery",
+			setupMocks: func(r *resources, movieID uuid.UUID) {
+				r.metaRepository.On("Exists", r.ctx, movieID).Return(true, nil).Once()
+				r.metaRepository.On("Delete", r.ctx, movieID).Return(nil).Once()
+				r.posterRepository.On("Delete", r.ctx, movieID.String()).Return(errors.New("s3 unavailable")).Once()
+			},
+			movieID:     uuid.New(),
+			expectError: false,
+		},
 		{
 			name: "Should return ErrResourceNotFound when movie doesn't exist",
 			setupMocks: func(r *resources, movieID uuid.UUID) {
@@ -304,6 +350,390 @@ func (suite *UsecaseMovieUnitSuite) TestLoadAll(t provider.T) {
 	}
 }
 
+func (suite *UsecaseMovieUnitSuite) TestUpdate(t provider.T) {
+	t.Parallel()
+
+	overview := "Fixed overview"
+	rating := 9.1
+	director := "Christopher Nolan"
+	runtime := 169
+	negative := -1
+
+	testCases := []struct {
+		name        string
+		patch       MoviePatch
+		setupMocks  func(r *resources, prev model.MovieMeta)
+		expectError error
+	}{
+		{
+			name:  "Should re-embed movie when overview changes",
+			patch: MoviePatch{Overview: &overview},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				next := prev
+				next.Overview = overview
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, next).Return(nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, next).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once()
+				r.metaRepository.On("StoreEmbedding", r.ctx, prev.ID, mock.AnythingOfType("model.Embedding"), testEmbeddingModel.ID()).Return(nil).Once()
+			},
+		},
+		{
+			name:  "Should keep embedding when only rating changes",
+			patch: MoviePatch{Rating: &rating},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				next := prev
+				next.Rating = rating
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, next).Return(nil).Once()
+			},
+		},
+		{
+			name:  "Should re-embed movie when director changes",
+			patch: MoviePatch{Director: &director},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				next := prev
+				next.Director = director
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, next).Return(nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, next).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once()
+				r.metaRepository.On("StoreEmbedding", r.ctx, prev.ID, mock.AnythingOfType("model.Embedding"), testEmbeddingModel.ID()).Return(nil).Once()
+			},
+		},
+		{
+			name:  "Should keep embedding when only runtime changes",
+			patch: MoviePatch{Runtime: &runtime},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				next := prev
+				next.Runtime = runtime
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, next).Return(nil).Once()
+			},
+		},
+		{
+			name:  "Should reject negative runtime",
+			patch: MoviePatch{Runtime: &negative},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+			},
+			expectError: ErrInvalidMovie,
+		},
+		{
+			name:  "Should replace poster and keep previous one for rollback",
+			patch: MoviePatch{Poster: &model.Poster{Content: []byte("new")}},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, prev).Return(nil).Once()
+				r.posterRepository.On("Load", r.ctx, prev.PosterLink).Return(&model.Poster{Content: []byte("old")}, nil).Once()
+				r.posterRepository.On("Save", r.ctx, mock.MatchedBy(func(p *model.Poster) bool {
+					return string(p.Content) == "new"
+				}), &prev.PosterLink).Return(prev.PosterLink, nil).Once()
+			},
+		},
+		{
+			name:  "Should restore previous meta when embedder fails",
+			patch: MoviePatch{Overview: &overview},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				next := prev
+				next.Overview = overview
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+				r.metaRepository.On("Update", r.ctx, next).Return(nil).Once()
+				r.embedder.On("BuildMovieEmbedding", r.ctx, next).Return(nil, errors.New("embedder unavailable")).Once()
+				r.metaRepository.On("Update", r.ctx, prev).Return(nil).Once()
+			},
+			expectError: ErrInternal,
+		},
+		{
+			name:  "Should reject empty title",
+			patch: MoviePatch{Title: new(string)},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{&prev}, nil).Once()
+			},
+			expectError: ErrInvalidMovie,
+		},
+		{
+			name:  "Should return not found for unknown movie",
+			patch: MoviePatch{Rating: &rating},
+			setupMocks: func(r *resources, prev model.MovieMeta) {
+				r.metaRepository.On("LoadSome", r.ctx, []uuid.UUID{prev.ID}).Return([]*model.MovieMeta{}, nil).Once()
+			},
+			expectError: ErrResourceNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			prev := NewMovieMetaBuilder().Build()
+			prev.PosterLink = prev.ID.String()
+			tc.setupMocks(r, prev)
+
+			err := r.usecase.Update(r.ctx, prev.ID, tc.patch)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func (suite *UsecaseMovieUnitSuite) TestLoadPage(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		query         model.MovieQuery
+		setupMocks    func(r *resources)
+		expectedTotal int
+		expectedLen   int
+		expectError   bool
+	}{
+		{
+			name:  "Should presign posters of the page only",
+			query: model.MovieQuery{SortBy: model.MovieSortRating, Desc: true, Limit: 2, Offset: 2},
+			setupMocks: func(r *resources) {
+				mm1 := NewMovieMetaBuilder().Build()
+				mm2 := NewMovieMetaBuilder().Build()
+				mm2.PosterLink = ""
+				r.metaRepository.On("LoadPage", r.ctx, model.MovieQuery{SortBy: model.MovieSortRating, Desc: true, Limit: 2, Offset: 2}).
+					Return(model.MoviePage{Movies: []*model.MovieMeta{&mm1, &mm2}, Total: 10}, nil).Once()
+				r.posterRepository.On("GeneratePresignedURL", r.ctx, mm1.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+			},
+			expectedTotal: 10,
+			expectedLen:   2,
+		},
+		{
+			name:  "Should apply default and max page size",
+			query: model.MovieQuery{Limit: 1000, Offset: -5},
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadPage", r.ctx, model.MovieQuery{Limit: MaxPageSize}).
+					Return(model.MoviePage{Movies: []*model.MovieMeta{}}, nil).Once()
+			},
+		},
+		{
+			name:  "Should return empty page without error",
+			query: model.MovieQuery{Filter: model.MovieFilter{Genres: []string{"Western"}}},
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadPage", r.ctx, model.MovieQuery{Filter: model.MovieFilter{Genres: []string{"Western"}}, Limit: DefaultPageSize}).
+					Return(model.MoviePage{Movies: []*model.MovieMeta{}}, nil).Once()
+			},
+		},
+		{
+			name:  "Should return error when repository fails",
+			query: model.MovieQuery{},
+			setupMocks: func(r *resources) {
+				r.metaRepository.On("LoadPage", r.ctx, mock.Anything).Return(model.MoviePage{}, errors.New("load error")).Once()
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			page, err := r.usecase.LoadPage(r.ctx, tc.query)
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInternal)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTotal, page.Total)
+			assert.Len(t, page.Movies, tc.expectedLen)
+			assert.Positive(t, page.Limit)
+		})
+	}
+}
+
+func (suite *UsecaseMovieUnitSuite) TestSearch(t provider.T) {
+	t.Parallel()
+
+	filter := model.MovieFilter{Genres: []string{"Sci-Fi"}}
+
+	testCases := []struct {
+		name        string
+		query       string
+		mode        model.SearchMode
+		limit       int
+		setupMocks  func(r *resources, hits []model.ScoredMovie)
+		expectError error
+	}{
+		{
+			name:  "Should return nearest movies with scores",
+			query: "  space travel through a wormhole ",
+			setupMocks: func(r *resources, hits []model.ScoredMovie) {
+				emb := model.Embedding(make([]float32, model.EmbeddingDimension))
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, model.Preference{Text: "space travel through a wormhole"}).Return(emb, nil).Once()
+				r.metaRepository.On("KNN", r.ctx, DefaultSearchLimit, emb, filter).Return(hits, nil).Once()
+				r.posterRepository.On("GeneratePresignedURL", r.ctx, hits[0].Movie.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+			},
+		},
+		{
+			name:  "Should fuse lexical and vector rankings in hybrid mode",
+			query: "godfather",
+			mode:  model.SearchModeHybrid,
+			limit: 1,
+			setupMocks: func(r *resources, hits []model.ScoredMovie) {
+				other := NewMovieMetaBuilder().WithTitle("The Godfather Part II").Build()
+				other.PosterLink = ""
+				emb := model.Embedding(make([]float32, model.EmbeddingDimension))
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, model.Preference{Text: "godfather"}).Return(emb, nil).Once()
+				r.metaRepository.On("KNN", r.ctx, hybridOverfetch, emb, filter).Return([]model.ScoredMovie{{Movie: &other, Score: 0.8}, hits[0]}, nil).Once()
+				r.metaRepository.On("LexicalSearch", r.ctx, hybridOverfetch, "godfather", filter).Return(hits, nil).Once()
+				r.posterRepository.On("GeneratePresignedURL", r.ctx, hits[0].Movie.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+			},
+		},
+		{
+			name:  "Should search by text only in lexical mode",
+			query: "godfather",
+			mode:  model.SearchModeLexical,
+			setupMocks: func(r *resources, hits []model.ScoredMovie) {
+				r.metaRepository.On("LexicalSearch", r.ctx, DefaultSearchLimit, "godfather", filter).Return([]model.ScoredMovie{}, nil).Once()
+			},
+		},
+		{
+			name:  "Should cap limit",
+			query: "heist",
+			limit: 1000,
+			setupMocks: func(r *resources, hits []model.ScoredMovie) {
+				emb := model.Embedding(make([]float32, model.EmbeddingDimension))
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, mock.Anything).Return(emb, nil).Once()
+				r.metaRepository.On("KNN", r.ctx, MaxSearchLimit, emb, filter).Return([]model.ScoredMovie{}, nil).Once()
+			},
+		},
+		{
+			name:        "Should reject blank query",
+			query:       "   ",
+			setupMocks:  func(r *resources, hits []model.ScoredMovie) {},
+			expectError: ErrEmptyQuery,
+		},
+		{
+			name:  "Should return error when embedder fails",
+			query: "heist",
+			setupMocks: func(r *resources, hits []model.ScoredMovie) {
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, mock.Anything).Return(nil, errors.New("embedder unavailable")).Once()
+			},
+			expectError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			mm := NewMovieMetaBuilder().Build()
+			hits := []model.ScoredMovie{{Movie: &mm, Score: 0.71}}
+			tc.setupMocks(r, hits)
+
+			result, err := r.usecase.Search(r.ctx, model.SearchQuery{
+				Text:   tc.query,
+				Mode:   tc.mode,
+				Limit:  tc.limit,
+				Filter: filter,
+			})
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			if len(result) > 0 {
+				assert.Equal(t, "http://presigned.url/poster", result[0].Movie.PosterLink)
+			}
+			if tc.mode == "" && len(result) > 0 {
+				assert.Equal(t, 0.71, result[0].Score)
+			}
+		})
+	}
+}
+
+func (suite *UsecaseMovieUnitSuite) TestSimilar(t provider.T) {
+	t.Parallel()
+
+	filter := model.MovieFilter{Genres: []string{"Crime"}}
+
+	testCases := []struct {
+		name        string
+		limit       int
+		setupMocks  func(r *resources, id uuid.UUID, hits []model.ScoredMovie)
+		expectLen   int
+		expectError error
+	}{
+		{
+			name: "Should serve precomputed neighbours",
+			setupMocks: func(r *resources, id uuid.UUID, hits []model.ScoredMovie) {
+				r.metaRepository.On("Exists", r.ctx, id).Return(true, nil).Once()
+				r.metaRepository.On("Neighbours", r.ctx, id, DefaultSimilarLimit, filter).Return(hits, nil).Once()
+				r.posterRepository.On("GeneratePresignedURL", r.ctx, hits[0].Movie.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+			},
+			expectLen: 1,
+		},
+		{
+			name:  "Should fall back to live KNN for movies not refreshed yet",
+			limit: 100,
+			setupMocks: func(r *resources, id uuid.UUID, hits []model.ScoredMovie) {
+				r.metaRepository.On("Exists", r.ctx, id).Return(true, nil).Once()
+				r.metaRepository.On("Neighbours", r.ctx, id, MaxSimilarLimit, filter).Return([]model.ScoredMovie{}, nil).Once()
+				r.metaRepository.On("LiveNeighbours", r.ctx, id, MaxSimilarLimit, filter).Return(hits, nil).Once()
+				r.posterRepository.On("GeneratePresignedURL", r.ctx, hits[0].Movie.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+			},
+			expectLen: 1,
+		},
+		{
+			name: "Should return not found for unknown movie",
+			setupMocks: func(r *resources, id uuid.UUID, hits []model.ScoredMovie) {
+				r.metaRepository.On("Exists", r.ctx, id).Return(false, nil).Once()
+			},
+			expectError: ErrResourceNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			id := uuid.New()
+			mm := NewMovieMetaBuilder().Build()
+			hits := []model.ScoredMovie{{Movie: &mm, Score: 0.9}}
+			tc.setupMocks(r, id, hits)
+
+			result, err := r.usecase.Similar(r.ctx, id, tc.limit, filter)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result, tc.expectLen)
+		})
+	}
+}
+
+func (suite *UsecaseMovieUnitSuite) TestSimilarWithinCollections(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t)
+	id := uuid.New()
+	filter := model.MovieFilter{Collections: []uuid.UUID{uuid.New()}}
+	mm := NewMovieMetaBuilder().Build()
+	hits := []model.ScoredMovie{{Movie: &mm, Score: 0.8}}
+
+	r.metaRepository.On("Exists", r.ctx, id).Return(true, nil).Once()
+	r.metaRepository.On("LiveNeighbours", r.ctx, id, DefaultSimilarLimit, filter).Return(hits, nil).Once()
+	r.posterRepository.On("GeneratePresignedURL", r.ctx, mm.PosterLink, mock.AnythingOfType("time.Duration")).Return("http://presigned.url/poster", nil).Once()
+
+	result, err := r.usecase.Similar(r.ctx, id, 0, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	r.metaRepository.AssertNotCalled(t, "Neighbours", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestUnitSuite(t *testing.T) {
 	suite.RunSuite(t, new(UsecaseMovieUnitSuite))
 }
@@ -0,0 +1,113 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var ErrReembedRunning = errors.New("re-embedding is already running")
+
+// ReembedStore keeps re-embedding jobs, they're run by re-embed worker
+//
+//go:generate mockery --name=ReembedStore --output=./mocks/movie/repository --filename=reembed_store.go
+type ReembedStore interface {
+	// Number of movies embedded by every model
+	EmbeddingModels(ctx context.Context) (map[string]int, error)
+	CreateReembedJob(ctx context.Context, modelID string) (model.ReembedJob, bool, error)
+	LastReembedJob(ctx context.Context) (*model.ReembedJob, error)
+	CancelReembed(ctx context.Context) (bool, error)
+}
+
+// EmbeddingModel returns model served by embedder, it's asked once
+func (u *Usecase) EmbeddingModel(ctx context.Context) (model.EmbeddingModel, error) {
+	if em := u.embeddingModel.Load(); em != nil {
+		return *em, nil
+	}
+	return u.refreshEmbeddingModel(ctx)
+}
+
+func (u *Usecase) refreshEmbeddingModel(ctx context.Context) (model.EmbeddingModel, error) {
+	em, err := u.Embedder.ModelInfo(ctx)
+	if err != nil {
+		return model.EmbeddingModel{}, errors.Join(ErrInternal, err)
+	}
+	if em.Dimension != model.EmbeddingDimension {
+		return model.EmbeddingModel{}, errors.Join(ErrInvalidEmbeddingDimension,
+			fmt.Errorf("model %s has dimension %d, expected %d", em.ID(), em.Dimension, model.EmbeddingDimension))
+	}
+
+	u.embeddingModel.Store(&em)
+	return em, nil
+}
+
+// CheckEmbeddingModel is run on startup. Embedder model of foreign dimension is an error,
+// vectors built by other models are counted as stale, they're fixed by re-embedding.
+func (u *Usecase) CheckEmbeddingModel(ctx context.Context) (model.EmbeddingModel, int, error) {
+	em, err := u.refreshEmbeddingModel(ctx)
+	if err != nil {
+		return model.EmbeddingModel{}, 0, err
+	}
+
+	models, err := u.ReembedStore.EmbeddingModels(ctx)
+	if err != nil {
+		return model.EmbeddingModel{}, 0, errors.Join(ErrInternal, err)
+	}
+
+	var stale int
+	for id, count := range models {
+		if id != em.ID() {
+			stale += count
+		}
+	}
+	return em, stale, nil
+}
+
+// StartReembed queues re-embedding of the whole catalog with model embedder serves now.
+// Running job is returned along with ErrReembedRunning.
+func (u *Usecase) StartReembed(ctx context.Context) (model.ReembedJob, error) {
+	em, err := u.refreshEmbeddingModel(ctx)
+	if err != nil {
+		return model.ReembedJob{}, err
+	}
+
+	job, created, err := u.ReembedStore.CreateReembedJob(ctx, em.ID())
+	if err != nil {
+		return model.ReembedJob{}, errors.Join(ErrInternal, err)
+	}
+	if created {
+		return job, nil
+	}
+
+	running, err := u.ReembedStatus(ctx)
+	if err != nil {
+		return model.ReembedJob{}, err
+	}
+	return running, ErrReembedRunning
+}
+
+// ReembedStatus returns running job or the last finished one
+func (u *Usecase) ReembedStatus(ctx context.Context) (model.ReembedJob, error) {
+	job, err := u.ReembedStore.LastReembedJob(ctx)
+	if err != nil {
+		return model.ReembedJob{}, errors.Join(ErrInternal, err)
+	}
+	if job == nil {
+		return model.ReembedJob{}, ErrResourceNotFound
+	}
+	return *job, nil
+}
+
+// CancelReembed keeps current vectors, ones built by the job are dropped
+func (u *Usecase) CancelReembed(ctx context.Context) error {
+	canceled, err := u.ReembedStore.CancelReembed(ctx)
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	if !canceled {
+		return ErrResourceNotFound
+	}
+	return nil
+}
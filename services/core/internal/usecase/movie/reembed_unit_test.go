@@ -0,0 +1,176 @@
+//go:build !integration
+// +build !integration
+
+package usecase_movie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type UsecaseMovieReembedUnitSuite struct {
+	suite.Suite
+}
+
+func (suite *UsecaseMovieReembedUnitSuite) TestCheckEmbeddingModel(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		setupMocks    func(r *resources)
+		expectedStale int
+		expectedError error
+	}{
+		{
+			name: "Should count vectors of other models as stale",
+			setupMocks: func(r *resources) {
+				r.reembedStore.On("EmbeddingModels", r.ctx).Return(map[string]int{
+					testEmbeddingModel.ID(): 10,
+					"old-model":             3,
+					"":                      2,
+				}, nil).Once()
+			},
+			expectedStale: 5,
+		},
+		{
+			name: "Should refuse model of another dimension",
+			setupMocks: func(r *resources) {
+				r.embedder.ExpectedCalls = nil
+				r.embedder.On("ModelInfo", r.ctx).Return(model.EmbeddingModel{Name: "big-model", Dimension: 768}, nil).Once()
+			},
+			expectedError: ErrInvalidEmbeddingDimension,
+		},
+		{
+			name: "Should fail if embedder is unavailable",
+			setupMocks: func(r *resources) {
+				r.embedder.ExpectedCalls = nil
+				r.embedder.On("ModelInfo", r.ctx).Return(model.EmbeddingModel{}, errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			em, stale, err := r.usecase.CheckEmbeddingModel(r.ctx)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testEmbeddingModel, em)
+			assert.Equal(t, tc.expectedStale, stale)
+		})
+	}
+}
+
+func (suite *UsecaseMovieReembedUnitSuite) TestEmbeddingModelIsCached(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+	r.embedder.ExpectedCalls = nil
+	r.embedder.On("ModelInfo", r.ctx).Return(testEmbeddingModel, nil).Once()
+
+	for range 3 {
+		em, err := r.usecase.EmbeddingModel(r.ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, testEmbeddingModel, em)
+	}
+}
+
+func (suite *UsecaseMovieReembedUnitSuite) TestStartReembed(t provider.T) {
+	t.Parallel()
+
+	running := model.ReembedJob{ID: uuid.New(), Model: "old-model", Status: model.ReembedRunning}
+
+	testCases := []struct {
+		name          string
+		setupMocks    func(r *resources)
+		expectedJob   model.ReembedJob
+		expectedError error
+	}{
+		{
+			name: "Should create job for model embedder serves",
+			setupMocks: func(r *resources) {
+				r.reembedStore.On("CreateReembedJob", r.ctx, testEmbeddingModel.ID()).
+					Return(model.ReembedJob{ID: running.ID, Model: testEmbeddingModel.ID(), Status: model.ReembedRunning}, true, nil).Once()
+			},
+			expectedJob: model.ReembedJob{ID: running.ID, Model: testEmbeddingModel.ID(), Status: model.ReembedRunning},
+		},
+		{
+			name: "Should return running job",
+			setupMocks: func(r *resources) {
+				r.reembedStore.On("CreateReembedJob", r.ctx, testEmbeddingModel.ID()).Return(model.ReembedJob{}, false, nil).Once()
+				r.reembedStore.On("LastReembedJob", r.ctx).Return(&running, nil).Once()
+			},
+			expectedJob:   running,
+			expectedError: ErrReembedRunning,
+		},
+		{
+			name: "Should fail on repository error",
+			setupMocks: func(r *resources) {
+				r.reembedStore.On("CreateReembedJob", r.ctx, testEmbeddingModel.ID()).
+					Return(model.ReembedJob{}, false, errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+
+			job, err := r.usecase.StartReembed(r.ctx)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedJob, job)
+		})
+	}
+}
+
+func (suite *UsecaseMovieReembedUnitSuite) TestReembedStatus(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	r.reembedStore.On("LastReembedJob", r.ctx).Return(nil, nil).Once()
+	_, err := r.usecase.ReembedStatus(r.ctx)
+	assert.ErrorIs(t, err, ErrResourceNotFound)
+
+	job := model.ReembedJob{ID: uuid.New(), Status: model.ReembedDone, Done: 3, Total: 4}
+	r.reembedStore.On("LastReembedJob", r.ctx).Return(&job, nil).Once()
+	status, err := r.usecase.ReembedStatus(r.ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, job, status)
+	assert.Equal(t, 75.0, status.Progress())
+}
+
+func (suite *UsecaseMovieReembedUnitSuite) TestCancelReembed(t provider.T) {
+	t.Parallel()
+	r := initResources(t)
+
+	r.reembedStore.On("CancelReembed", r.ctx).Return(false, nil).Once()
+	assert.ErrorIs(t, r.usecase.CancelReembed(r.ctx), ErrResourceNotFound)
+
+	r.reembedStore.On("CancelReembed", r.ctx).Return(true, nil).Once()
+	assert.NoError(t, r.usecase.CancelReembed(r.ctx))
+}
+
+func TestReembedUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseMovieReembedUnitSuite))
+}
@@ -0,0 +1,189 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+// SagaLease is how long running saga is left to its instance. It's extended with every step.
+const SagaLease = 5 * time.Minute
+
+const (
+	stepMeta              = "meta"
+	stepPoster            = "poster"
+	stepEmbedding         = "embedding"
+	stepMetaUpdate        = "meta_update"
+	stepPosterReplacement = "poster_replacement"
+	stepMetaDelete        = "meta_delete"
+	stepPosterDelete      = "poster_delete"
+)
+
+var ErrUnknownSagaStep = errors.New("unknown saga step")
+
+// SagaLog is the outbox of movie writes, recovery finishes sagas left running by crashed instances
+//
+//go:generate mockery --name=SagaLog --output=./mocks/movie/repository --filename=saga_log.go
+type SagaLog interface {
+	BeginSaga(ctx context.Context, saga model.Saga, lease time.Duration) error
+	RecordSagaStep(ctx context.Context, step model.SagaStep, lease time.Duration) error
+	SetSagaBackup(ctx context.Context, id uuid.UUID, backup []byte) error
+	FinishSaga(ctx context.Context, id uuid.UUID, status model.SagaStatus) error
+	FailSaga(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+func (b *MovieBuilder) saga() model.Saga {
+	steps := make([]string, len(b.ops))
+	for i, op := range b.ops {
+		steps[i] = op.name
+	}
+	return model.Saga{
+		ID:      b.sagaID,
+		MovieID: b.movie.MM.ID,
+		Kind:    b.kind,
+		Status:  model.SagaRunning,
+		Steps:   steps,
+		Movie:   *b.movie.MM,
+		Prev:    b.prev,
+	}
+}
+
+// run executes ops starting from the given one. Deleted movie is gone for good once its row is,
+// so failed delete is left to recovery to finish instead of being rolled back.
+func (b *MovieBuilder) run(ctx context.Context, from int) error {
+	for i := from; i < len(b.ops); i++ {
+		completed, err := b.exec(ctx, i)
+		if err == nil {
+			continue
+		}
+
+		if b.kind == model.SagaDelete && completed > 0 {
+			log.Printf("movie %s is deleted, the rest is left to recovery: %v", b.movie.MM.ID, err)
+			b.fail(context.WithoutCancel(ctx), err)
+			return nil
+		}
+		_ = b.rollback(ctx, completed)
+		return err
+	}
+
+	return b.finish(ctx, model.SagaDone)
+}
+
+// exec returns how many ops are completed, failed op counts if it's applied but not logged
+func (b *MovieBuilder) exec(ctx context.Context, i int) (int, error) {
+	if err := b.record(ctx, i, model.SagaExec, model.SagaStepStarted, nil); err != nil {
+		return i, errors.Join(ErrInternal, err)
+	}
+	if err := b.ops[i].exec(ctx); err != nil {
+		b.record(context.WithoutCancel(ctx), i, model.SagaExec, model.SagaStepFailed, err)
+		return i, err
+	}
+	if err := b.record(ctx, i, model.SagaExec, model.SagaStepDone, nil); err != nil {
+		return i + 1, errors.Join(ErrInternal, err)
+	}
+	return i + 1, nil
+}
+
+// record logs step. Failure to log compensation doesn't stop it, compensations are safe to repeat.
+func (b *MovieBuilder) record(ctx context.Context, i int, action model.SagaAction, status model.SagaStepStatus, stepErr error) error {
+	step := model.SagaStep{
+		SagaID: b.sagaID,
+		Step:   i,
+		Name:   b.ops[i].name,
+		Action: action,
+		Status: status,
+	}
+	if stepErr != nil {
+		step.Error = stepErr.Error()
+	}
+
+	err := b.uc.SagaLog.RecordSagaStep(ctx, step, SagaLease)
+	if err != nil {
+		log.Printf("failed to log step %s of saga %s: %v", step.Name, b.sagaID, err)
+	}
+	return err
+}
+
+func (b *MovieBuilder) fail(ctx context.Context, err error) {
+	if err := b.uc.SagaLog.FailSaga(ctx, b.sagaID, err.Error()); err != nil {
+		log.Printf("failed to record failure of saga %s: %v", b.sagaID, err)
+	}
+}
+
+func (b *MovieBuilder) finish(ctx context.Context, status model.SagaStatus) error {
+	if err := b.uc.SagaLog.FinishSaga(ctx, b.sagaID, status); err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	return nil
+}
+
+// RecoverSaga finishes or rolls back saga left by crashed instance.
+// Delete is finished, create and update are rolled back unless every step is applied.
+func (u *Usecase) RecoverSaga(ctx context.Context, saga model.Saga) error {
+	b, err := u.restore(ctx, saga)
+	if err != nil {
+		if err := u.SagaLog.FailSaga(ctx, saga.ID, err.Error()); err != nil {
+			log.Printf("failed to record failure of saga %s: %v", saga.ID, err)
+		}
+		return err
+	}
+
+	switch {
+	case saga.Status == model.SagaCompensating:
+		return b.rollback(ctx, saga.Step)
+	case saga.Kind == model.SagaDelete || saga.Step >= len(b.ops):
+		return b.run(ctx, saga.Step)
+	default:
+		// Current step might have been applied before the crash
+		return b.rollback(ctx, saga.Step+1)
+	}
+}
+
+// restore rebuilds ops of saga. Restored create and update are only rolled back, so poster content isn't needed.
+func (u *Usecase) restore(ctx context.Context, saga model.Saga) (*MovieBuilder, error) {
+	if saga.Kind == model.SagaUpdate && saga.Prev == nil {
+		return nil, fmt.Errorf("update saga %s has no previous movie", saga.ID)
+	}
+
+	mm := saga.Movie
+	b := NewMovieBuilder(u, model.Movie{MM: &mm})
+	b.sagaID = saga.ID
+
+	for _, name := range saga.Steps {
+		switch name {
+		case stepMeta:
+			b.WithMeta(ctx)
+		case stepPoster:
+			b.movie.Poster = &model.Poster{}
+			b.WithPoster(ctx)
+		case stepEmbedding:
+			b.WithEmbedding(ctx)
+		case stepMetaUpdate:
+			b.WithMetaUpdate(ctx, *saga.Prev)
+		case stepPosterReplacement:
+			b.movie.Poster = &model.Poster{}
+			b.WithPosterReplacement(ctx, *saga.Prev)
+			if saga.Backup != nil {
+				b.backup = &model.Poster{Filename: b.movie.MM.PosterLink, Content: saga.Backup}
+			}
+		case stepMetaDelete:
+			b.WithMetaDelete(ctx)
+		case stepPosterDelete:
+			b.WithPosterDelete(ctx)
+		default:
+			return nil, errors.Join(ErrUnknownSagaStep, fmt.Errorf("step %q of saga %s", name, saga.ID))
+		}
+	}
+
+	// Restored ops must reproduce the sequence they were logged under
+	if len(b.ops) != len(saga.Steps) {
+		return nil, fmt.Errorf("saga %s has %d steps, %d restored", saga.ID, len(saga.Steps), len(b.ops))
+	}
+	b.kind = saga.Kind
+	return b, nil
+}
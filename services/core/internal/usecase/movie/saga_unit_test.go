@@ -0,0 +1,488 @@
+//go:build !integration
+// +build !integration
+
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/embedding_reducer"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type UsecaseMovieSagaUnitSuite struct {
+	suite.Suite
+}
+
+// crash is thrown by store at crash point, it stands for process dying there
+type crash struct{}
+
+// sagaStore keeps movies, posters and sagas in memory, they outlive crashes like database and S3 do.
+// Every write is a point where the process may crash right after it.
+type sagaStore struct {
+	mu      sync.Mutex
+	movies  map[uuid.UUID]model.MovieMeta
+	vectors map[uuid.UUID]bool
+	posters map[string][]byte
+	sagas   map[uuid.UUID]*model.Saga
+
+	points  []string
+	crashAt string
+	// Writes of that point fail
+	failAt string
+}
+
+func newSagaStore() *sagaStore {
+	return &sagaStore{
+		movies:  map[uuid.UUID]model.MovieMeta{},
+		vectors: map[uuid.UUID]bool{},
+		posters: map[string][]byte{},
+		sagas:   map[uuid.UUID]*model.Saga{},
+	}
+}
+
+// write is called with store locked after write is applied
+func (s *sagaStore) write(point string) {
+	s.points = append(s.points, point)
+	if point == s.crashAt {
+		panic(crash{})
+	}
+}
+
+func (s *sagaStore) fails(point string) error {
+	if point == s.failAt {
+		return fmt.Errorf("%s failed", point)
+	}
+	return nil
+}
+
+type metaStub struct {
+	MetaRepository
+	s *sagaStore
+}
+
+func (m metaStub) Store(ctx context.Context, mm model.MovieMeta) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	m.s.movies[mm.ID] = mm
+	m.s.write("store")
+	return nil
+}
+
+func (m metaStub) Update(ctx context.Context, mm model.MovieMeta) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if _, ok := m.s.movies[mm.ID]; ok {
+		m.s.movies[mm.ID] = mm
+	}
+	m.s.write("update")
+	return nil
+}
+
+func (m metaStub) Delete(ctx context.Context, id uuid.UUID) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	delete(m.s.movies, id)
+	delete(m.s.vectors, id)
+	m.s.write("delete")
+	return nil
+}
+
+func (m metaStub) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	_, ok := m.s.movies[id]
+	return ok, nil
+}
+
+func (m metaStub) LoadSome(ctx context.Context, ids []uuid.UUID) ([]*model.MovieMeta, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	var mms []*model.MovieMeta
+	for _, id := range ids {
+		if mm, ok := m.s.movies[id]; ok {
+			mms = append(mms, &mm)
+		}
+	}
+	return mms, nil
+}
+
+func (m metaStub) StoreEmbedding(ctx context.Context, id uuid.UUID, e model.Embedding, modelID string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if _, ok := m.s.movies[id]; ok {
+		m.s.vectors[id] = true
+	}
+	m.s.write("store_embedding")
+	return nil
+}
+
+func (m metaStub) BeginSaga(ctx context.Context, saga model.Saga, lease time.Duration) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	m.s.sagas[saga.ID] = &saga
+	m.s.write("begin_saga")
+	return nil
+}
+
+// RecordSagaStep mimics postgres repository
+func (m metaStub) RecordSagaStep(ctx context.Context, step model.SagaStep, lease time.Duration) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	saga := m.s.sagas[step.SagaID]
+	saga.Status, saga.Step, saga.Error = model.SagaRunning, step.Step, step.Error
+	switch {
+	case step.Action == model.SagaExec && step.Status == model.SagaStepDone:
+		saga.Step = step.Step + 1
+	case step.Action == model.SagaCompensate && step.Status == model.SagaStepDone:
+		saga.Status = model.SagaCompensating
+	case step.Action == model.SagaCompensate:
+		saga.Status, saga.Step = model.SagaCompensating, step.Step+1
+	}
+	m.s.write(fmt.Sprintf("%s %s %s", step.Action, step.Name, step.Status))
+	return nil
+}
+
+func (m metaStub) SetSagaBackup(ctx context.Context, id uuid.UUID, backup []byte) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	m.s.sagas[id].Backup = backup
+	m.s.write("saga_backup")
+	return nil
+}
+
+func (m metaStub) FinishSaga(ctx context.Context, id uuid.UUID, status model.SagaStatus) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	m.s.sagas[id].Status, m.s.sagas[id].Backup = status, nil
+	m.s.write("finish_saga")
+	return nil
+}
+
+func (m metaStub) FailSaga(ctx context.Context, id uuid.UUID, reason string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	m.s.sagas[id].Error = reason
+	return nil
+}
+
+// unfinished returns sagas as recovery claims them once their leases expire
+func (s *sagaStore) unfinished() []model.Saga {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sagas []model.Saga
+	for _, saga := range s.sagas {
+		if saga.Status == model.SagaRunning || saga.Status == model.SagaCompensating {
+			sagas = append(sagas, *saga)
+		}
+	}
+	return sagas
+}
+
+type posterStub struct {
+	PosterRepository
+	s *sagaStore
+}
+
+func (p posterStub) Save(ctx context.Context, object *model.Poster, readyKey *string) (string, error) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if err := p.s.fails("save_poster"); err != nil {
+		return "", err
+	}
+	p.s.posters[*readyKey] = slices.Clone(object.Content)
+	p.s.write("save_poster")
+	return *readyKey, nil
+}
+
+func (p posterStub) Load(ctx context.Context, key string) (*model.Poster, error) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	content, ok := p.s.posters[key]
+	if !ok {
+		return nil, errors.New("no such poster")
+	}
+	return &model.Poster{Filename: key, Content: content}, nil
+}
+
+func (p posterStub) Delete(ctx context.Context, key string) error {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if err := p.s.fails("delete_poster"); err != nil {
+		return err
+	}
+	delete(p.s.posters, key)
+	p.s.write("delete_poster")
+	return nil
+}
+
+type sagaEmbedderStub struct {
+	Embedder
+	s *sagaStore
+}
+
+func (e sagaEmbedderStub) BuildMovieEmbedding(ctx context.Context, mm model.MovieMeta) (model.Embedding, error) {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	if err := e.s.fails("embed"); err != nil {
+		return nil, err
+	}
+	return make(model.Embedding, model.EmbeddingDimension), nil
+}
+
+func (e sagaEmbedderStub) ModelInfo(ctx context.Context) (model.EmbeddingModel, error) {
+	return testEmbeddingModel, nil
+}
+
+// newSagaUsecase stands for a fresh process over the same store
+func newSagaUsecase(s *sagaStore) *Usecase {
+	meta := metaStub{s: s}
+	return New(meta, meta, nil, nil, posterStub{s: s}, sagaEmbedderStub{s: s}, embedding_reducer.New())
+}
+
+// runUntilCrash reports whether the process crashed
+func runUntilCrash(f func()) (crashed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(crash); !ok {
+				panic(r)
+			}
+			crashed = true
+		}
+	}()
+	f()
+	return false
+}
+
+func recoverAll(t provider.T, s *sagaStore) {
+	s.crashAt = ""
+	uc := newSagaUsecase(s)
+	for _, saga := range s.unfinished() {
+		assert.NoError(t, uc.RecoverSaga(context.Background(), saga), "saga %s", saga.Kind)
+	}
+	assert.Empty(t, s.unfinished())
+}
+
+type sagaScenario struct {
+	name string
+	// Store as it's before the write
+	seed func(s *sagaStore)
+	// Write under test
+	write func(uc *Usecase) error
+	// Write fails at that point, so it's rolled back
+	failAt string
+	// Checks store is consistent after recovery
+	check func(t provider.T, s *sagaStore)
+}
+
+var (
+	sagaMovie = model.MovieMeta{
+		ID:       uuid.New(),
+		Title:    "Heat",
+		Genres:   []string{"Crime"},
+		Year:     1995,
+		Overview: "A group of professional bank robbers",
+	}
+	oldPoster = []byte("old poster")
+	newPoster = []byte("new poster")
+)
+
+func seedMovie(s *sagaStore) {
+	mm := sagaMovie
+	mm.PosterLink = mm.ID.String()
+	s.movies[mm.ID] = mm
+	s.vectors[mm.ID] = true
+	s.posters[mm.PosterLink] = oldPoster
+}
+
+func checkCreated(t provider.T, s *sagaStore) {
+	mm, ok := s.movies[sagaMovie.ID]
+	if !ok {
+		assert.Empty(t, s.posters, "poster of rolled back movie is deleted")
+		return
+	}
+	assert.True(t, s.vectors[mm.ID], "created movie has embedding")
+	assert.Equal(t, newPoster, s.posters[mm.PosterLink], "created movie has poster")
+}
+
+func checkRolledBack(t provider.T, s *sagaStore) {
+	assert.Empty(t, s.movies)
+	assert.Empty(t, s.posters)
+}
+
+func checkUpdated(t provider.T, s *sagaStore) {
+	mm := s.movies[sagaMovie.ID]
+	switch mm.Title {
+	case "Heat 2":
+		assert.Equal(t, newPoster, s.posters[mm.PosterLink], "updated movie has new poster")
+	case sagaMovie.Title:
+		assert.Equal(t, oldPoster, s.posters[mm.PosterLink], "rolled back movie has old poster")
+	default:
+		t.Errorf("unexpected title %q", mm.Title)
+	}
+}
+
+func checkDeleted(t provider.T, s *sagaStore) {
+	assert.Empty(t, s.movies)
+	assert.Empty(t, s.posters, "poster of deleted movie is deleted")
+}
+
+var sagaScenarios = []sagaScenario{
+	{
+		name: "create",
+		seed: func(s *sagaStore) {},
+		write: func(uc *Usecase) error {
+			mm := sagaMovie
+			return uc.Upload(context.Background(), model.Movie{MM: &mm, Poster: &model.Poster{Content: newPoster}})
+		},
+		check: checkCreated,
+	},
+	{
+		name: "create with failing embedder",
+		seed: func(s *sagaStore) {},
+		write: func(uc *Usecase) error {
+			mm := sagaMovie
+			return uc.Upload(context.Background(), model.Movie{MM: &mm, Poster: &model.Poster{Content: newPoster}})
+		},
+		failAt: "embed",
+		check:  checkRolledBack,
+	},
+	{
+		name: "update",
+		seed: seedMovie,
+		write: func(uc *Usecase) error {
+			title := "Heat 2"
+			return uc.Update(context.Background(), sagaMovie.ID, MoviePatch{Title: &title, Poster: &model.Poster{Content: newPoster}})
+		},
+		check: checkUpdated,
+	},
+	{
+		name: "update with failing embedder",
+		seed: seedMovie,
+		write: func(uc *Usecase) error {
+			title := "Heat 2"
+			return uc.Update(context.Background(), sagaMovie.ID, MoviePatch{Title: &title, Poster: &model.Poster{Content: newPoster}})
+		},
+		failAt: "embed",
+		check: func(t provider.T, s *sagaStore) {
+			assert.Equal(t, sagaMovie.Title, s.movies[sagaMovie.ID].Title)
+			checkUpdated(t, s)
+		},
+	},
+	{
+		name: "delete",
+		seed: seedMovie,
+		write: func(uc *Usecase) error {
+			return uc.Delete(context.Background(), sagaMovie.ID)
+		},
+		check: checkDeleted,
+	},
+	{
+		name: "delete with failing poster storage",
+		seed: seedMovie,
+		write: func(uc *Usecase) error {
+			return uc.Delete(context.Background(), sagaMovie.ID)
+		},
+		failAt: "delete_poster",
+		check:  checkDeleted,
+	},
+}
+
+func (suite *UsecaseMovieSagaUnitSuite) TestRecoveryAfterCrash(t provider.T) {
+	t.Parallel()
+
+	for _, sc := range sagaScenarios {
+		// Clean run tells where the write may crash
+		traced := newSagaStore()
+		sc.seed(traced)
+		traced.failAt = sc.failAt
+		_ = sc.write(newSagaUsecase(traced))
+		require.NotEmpty(t, traced.points)
+
+		for _, point := range traced.points {
+			t.Run(fmt.Sprintf("%s crashed after %s", sc.name, point), func(t provider.T) {
+				s := newSagaStore()
+				sc.seed(s)
+				s.failAt, s.crashAt = sc.failAt, point
+
+				crashed := runUntilCrash(func() { _ = sc.write(newSagaUsecase(s)) })
+				require.True(t, crashed)
+
+				// Poster storage is back by the time recovery runs
+				s.failAt = ""
+				recoverAll(t, s)
+				sc.check(t, s)
+			})
+		}
+	}
+}
+
+func (suite *UsecaseMovieSagaUnitSuite) TestRecoveryAfterCrashDuringRecovery(t provider.T) {
+	t.Parallel()
+
+	s := newSagaStore()
+	seedMovie(s)
+	s.crashAt = "exec poster_replacement done"
+
+	title := "Heat 2"
+	require.True(t, runUntilCrash(func() {
+		_ = newSagaUsecase(s).Update(context.Background(), sagaMovie.ID, MoviePatch{Title: &title, Poster: &model.Poster{Content: newPoster}})
+	}))
+
+	s.crashAt = "compensate meta_update done"
+	require.True(t, runUntilCrash(func() {
+		for _, saga := range s.unfinished() {
+			_ = newSagaUsecase(s).RecoverSaga(context.Background(), saga)
+		}
+	}))
+
+	recoverAll(t, s)
+	assert.Equal(t, sagaMovie.Title, s.movies[sagaMovie.ID].Title)
+	assert.Equal(t, oldPoster, s.posters[sagaMovie.ID.String()])
+}
+
+func (suite *UsecaseMovieSagaUnitSuite) TestSagaIsLogged(t provider.T) {
+	t.Parallel()
+
+	s := newSagaStore()
+	mm := sagaMovie
+	err := newSagaUsecase(s).Upload(context.Background(), model.Movie{MM: &mm, Poster: &model.Poster{Content: newPoster}})
+
+	require.NoError(t, err)
+	assert.Empty(t, s.unfinished())
+	assert.Equal(t, []string{
+		"begin_saga",
+		"exec meta started", "store", "exec meta done",
+		"exec poster started", "save_poster", "exec poster done",
+		"exec embedding started", "store_embedding", "exec embedding done",
+		"finish_saga",
+	}, s.points)
+}
+
+func (suite *UsecaseMovieSagaUnitSuite) TestRecoverRefusesUnknownStep(t provider.T) {
+	t.Parallel()
+
+	s := newSagaStore()
+	saga := model.Saga{ID: uuid.New(), Kind: model.SagaCreate, Status: model.SagaRunning, Steps: []string{stepMeta, "teleport"}}
+	s.sagas[saga.ID] = &saga
+
+	err := newSagaUsecase(s).RecoverSaga(context.Background(), saga)
+
+	assert.ErrorIs(t, err, ErrUnknownSagaStep)
+	assert.Contains(t, s.sagas[saga.ID].Error, "teleport")
+}
+
+func TestSagaUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseMovieSagaUnitSuite))
+}
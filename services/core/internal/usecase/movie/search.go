@@ -0,0 +1,104 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/rank_fusion"
+)
+
+var ErrEmptyQuery = errors.New("empty search query")
+
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 50
+)
+
+// Each ranking is deeper than the result, so movies ranked moderately by both can make it to the top
+const hybridOverfetch = 3
+
+// Search returns movies matching free text, best match first.
+// Vector mode embeds text the same way as participant preferences, it's the default.
+// Scores are comparable within a single mode only.
+func (u *Usecase) Search(ctx context.Context, q model.SearchQuery) ([]model.ScoredMovie, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	q.Limit = min(q.Limit, MaxSearchLimit)
+
+	var (
+		hits []model.ScoredMovie
+		err  error
+	)
+	switch q.Mode {
+	case model.SearchModeLexical:
+		hits, err = u.lexicalSearch(ctx, q.Text, q.Limit, q.Filter)
+	case model.SearchModeHybrid:
+		hits, err = u.hybridSearch(ctx, q.Text, q.Limit, q.Filter)
+	default:
+		hits, err = u.vectorSearch(ctx, q.Text, q.Limit, q.Filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		if hit.Movie.PosterLink != "" {
+			hit.Movie.PosterLink, err = u.PosterRepository.GeneratePresignedURL(ctx, hit.Movie.PosterLink, 10*time.Minute)
+			if err != nil {
+				return nil, errors.Join(ErrInternal, err)
+			}
+		}
+	}
+
+	return hits, nil
+}
+
+func (u *Usecase) vectorSearch(ctx context.Context, text string, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	emb, err := u.Embedder.BuildPreferenceEmbedding(ctx, model.Preference{Text: text})
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	if len(emb) != model.EmbeddingDimension {
+		return nil, ErrInvalidEmbeddingDimension
+	}
+
+	hits, err := u.MetaRepository.KNN(ctx, limit, emb, f)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return hits, nil
+}
+
+func (u *Usecase) lexicalSearch(ctx context.Context, text string, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	hits, err := u.MetaRepository.LexicalSearch(ctx, limit, text, f)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return hits, nil
+}
+
+func (u *Usecase) hybridSearch(ctx context.Context, text string, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	vector, err := u.vectorSearch(ctx, text, limit*hybridOverfetch, f)
+	if err != nil {
+		return nil, err
+	}
+	lexical, err := u.lexicalSearch(ctx, text, limit*hybridOverfetch, f)
+	if err != nil {
+		return nil, err
+	}
+
+	fused := rank_fusion.Fuse(rank_fusion.DefaultK, rank_fusion.Movies(lexical), rank_fusion.Movies(vector))
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused, nil
+}
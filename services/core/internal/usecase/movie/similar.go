@@ -0,0 +1,61 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+const (
+	DefaultSimilarLimit = 10
+	// Neighbours refresher stores that many per movie, asking for more makes no sense
+	MaxSimilarLimit = 50
+)
+
+// Similar returns movies closest to the given one, the movie itself excluded.
+// Neighbours are precomputed in background, movies added since last refresh are served by live KNN.
+// Neighbours within collections are always found by live KNN.
+func (u *Usecase) Similar(ctx context.Context, id uuid.UUID, limit int, f model.MovieFilter) ([]model.ScoredMovie, error) {
+	exists, err := u.MetaRepository.Exists(ctx, id)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	if !exists {
+		return nil, ErrResourceNotFound
+	}
+
+	if limit <= 0 {
+		limit = DefaultSimilarLimit
+	}
+	limit = min(limit, MaxSimilarLimit)
+
+	// Precomputed lists are ranked over whole catalog, few of them would make it into a collection
+	var hits []model.ScoredMovie
+	if len(f.Collections) == 0 {
+		hits, err = u.MetaRepository.Neighbours(ctx, id, limit, f)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+	}
+
+	if len(hits) == 0 {
+		hits, err = u.MetaRepository.LiveNeighbours(ctx, id, limit, f)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+	}
+
+	for _, hit := range hits {
+		if hit.Movie.PosterLink != "" {
+			hit.Movie.PosterLink, err = u.PosterRepository.GeneratePresignedURL(ctx, hit.Movie.PosterLink, 10*time.Minute)
+			if err != nil {
+				return nil, errors.Join(ErrInternal, err)
+			}
+		}
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,187 @@
+package usecase_movie
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+)
+
+var ErrInvalidMovie = errors.New("invalid movie")
+
+// MoviePatch holds fields to change, nil fields are left as is
+type MoviePatch struct {
+	Title    *string
+	Genres   []string
+	Year     *int
+	Rating   *float64
+	Overview *string
+
+	Director    *string
+	Cast        []string
+	Runtime     *int
+	Certificate *string
+
+	// Replaces current poster if set
+	Poster *model.Poster
+}
+
+func (p MoviePatch) Empty() bool {
+	return p.Title == nil && p.Genres == nil && p.Year == nil &&
+		p.Rating == nil && p.Overview == nil && p.Director == nil && p.Cast == nil &&
+		p.Runtime == nil && p.Certificate == nil && p.Poster == nil
+}
+
+func (p MoviePatch) apply(mm model.MovieMeta) model.MovieMeta {
+	if p.Title != nil {
+		mm.Title = *p.Title
+	}
+	if p.Genres != nil {
+		mm.Genres = p.Genres
+	}
+	if p.Year != nil {
+		mm.Year = *p.Year
+	}
+	if p.Rating != nil {
+		mm.Rating = *p.Rating
+	}
+	if p.Overview != nil {
+		mm.Overview = *p.Overview
+	}
+	if p.Director != nil {
+		mm.Director = *p.Director
+	}
+	if p.Cast != nil {
+		mm.Cast = p.Cast
+	}
+	if p.Runtime != nil {
+		mm.Runtime = *p.Runtime
+	}
+	if p.Certificate != nil {
+		mm.Certificate = *p.Certificate
+	}
+	return mm
+}
+
+// Embedding is mostly about what the movie is and who made it, so year, rating and runtime changes don't move it
+func textChanged(prev, next model.MovieMeta) bool {
+	return prev.Title != next.Title ||
+		prev.Overview != next.Overview ||
+		!slices.Equal(prev.Genres, next.Genres) ||
+		prev.Director != next.Director ||
+		!slices.Equal(prev.Cast, next.Cast)
+}
+
+func (b *MovieBuilder) WithMetaUpdate(ctx context.Context, prev model.MovieMeta) *MovieBuilder {
+	b.kind, b.prev = model.SagaUpdate, &prev
+
+	op := Op{
+		name: stepMetaUpdate,
+		exec: func(ctx context.Context) error {
+			err := b.uc.MetaRepository.Update(ctx, *b.movie.MM)
+			if errors.Is(err, ErrDuplicate) {
+				return err
+			}
+			if err != nil {
+				return errors.Join(ErrInternal, err)
+			}
+			return nil
+		},
+		rollback: func(ctx context.Context) error {
+			return b.uc.MetaRepository.Update(ctx, prev)
+		},
+	}
+	b.ops = append(b.ops, op)
+	return b
+}
+
+// Poster is overwritten under the same key, so previous one is kept in saga for rollback
+func (b *MovieBuilder) WithPosterReplacement(ctx context.Context, prev model.MovieMeta) *MovieBuilder {
+	if b.movie.Poster == nil {
+		return b
+	}
+
+	key := prev.PosterLink
+	if key == "" {
+		key = b.movie.MM.ID.String()
+	}
+	b.movie.MM.PosterLink = key
+
+	op := Op{
+		name: stepPosterReplacement,
+		exec: func(ctx context.Context) error {
+			if prev.PosterLink != "" {
+				p, err := b.uc.PosterRepository.Load(ctx, key)
+				if err != nil {
+					return errors.Join(ErrInternal, err)
+				}
+				if err := b.uc.SagaLog.SetSagaBackup(ctx, b.sagaID, p.Content); err != nil {
+					return errors.Join(ErrInternal, err)
+				}
+				b.backup = p
+			}
+
+			_, err := b.uc.PosterRepository.Save(ctx, &model.Poster{
+				Filename: key,
+				Content:  b.movie.Poster.Content,
+				MovieID:  b.movie.Poster.MovieID,
+			}, &key)
+			if err != nil {
+				return errors.Join(ErrInternal, err)
+			}
+			return nil
+		},
+		rollback: func(ctx context.Context) error {
+			switch {
+			case prev.PosterLink == "":
+				return b.uc.PosterRepository.Delete(ctx, key)
+			// Backup is stored before overwrite, nothing's been overwritten without it
+			case b.backup == nil:
+				return nil
+			default:
+				_, err := b.uc.PosterRepository.Save(ctx, b.backup, &key)
+				return err
+			}
+		},
+	}
+	b.ops = append(b.ops, op)
+	return b
+}
+
+// Update changes movie in place, so its reactions are kept.
+// Embedding is rebuilt only if title, overview, genres or credits change.
+func (u *Usecase) Update(ctx context.Context, id uuid.UUID, patch MoviePatch) error {
+	mms, err := u.MetaRepository.LoadSome(ctx, []uuid.UUID{id})
+	if err != nil {
+		return errors.Join(ErrInternal, err)
+	}
+	if len(mms) == 0 {
+		return ErrResourceNotFound
+	}
+
+	prev := *mms[0]
+	next := patch.apply(prev)
+
+	switch {
+	case strings.TrimSpace(next.Title) == model.EmptyTitle:
+		return errors.Join(ErrInvalidMovie, errors.New("title is required"))
+	case strings.TrimSpace(next.Overview) == "":
+		return errors.Join(ErrInvalidMovie, errors.New("overview is required"))
+	case len(next.Genres) == 0:
+		return errors.Join(ErrInvalidMovie, errors.New("genres are required"))
+	case next.Runtime < 0:
+		return errors.Join(ErrInvalidMovie, errors.New("runtime can't be negative"))
+	}
+
+	b := NewMovieBuilder(u, model.Movie{MM: &next, Poster: patch.Poster}).
+		WithMetaUpdate(ctx, prev).
+		WithPosterReplacement(ctx, prev)
+	if textChanged(prev, next) {
+		b = b.WithEmbedding(ctx)
+	}
+
+	return b.Execute(ctx)
+}
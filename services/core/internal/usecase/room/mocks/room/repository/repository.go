@@ -18,17 +18,17 @@ type RoomRepository struct {
 	mock.Mock
 }
 
-// AddPreferenceEmbedding provides a mock function with given fields: ctx, code, userID, prefEmbedding
-func (_m *RoomRepository) AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, prefEmbedding model.Embedding) error {
-	ret := _m.Called(ctx, code, userID, prefEmbedding)
+// AddPreferenceEmbedding provides a mock function with given fields: ctx, code, userID, pref, prefEmbedding
+func (_m *RoomRepository) AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, pref model.Preference, prefEmbedding model.Embedding) error {
+	ret := _m.Called(ctx, code, userID, pref, prefEmbedding)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddPreferenceEmbedding")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID, model.Embedding) error); ok {
-		r0 = rf(ctx, code, userID, prefEmbedding)
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID, model.Preference, model.Embedding) error); ok {
+		r0 = rf(ctx, code, userID, pref, prefEmbedding)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -54,6 +54,36 @@ func (_m *RoomRepository) CleanupOrphantRooms(ctx context.Context, lobbiesDeadli
 	return r0
 }
 
+// Collections provides a mock function with given fields: ctx, code
+func (_m *RoomRepository) Collections(ctx context.Context, code string) ([]model.Collection, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Collections")
+	}
+
+	var r0 []model.Collection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.Collection, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Collection); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Collection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateAndBook provides a mock function with given fields: ctx, room, ownerID
 func (_m *RoomRepository) CreateAndBook(ctx context.Context, room model.Room, ownerID uuid.UUID) error {
 	ret := _m.Called(ctx, room, ownerID)
@@ -146,6 +176,64 @@ func (_m *RoomRepository) IsParticipant(ctx context.Context, code string, userID
 	return r0, r1
 }
 
+// MatchTitle provides a mock function with given fields: ctx, title, year
+func (_m *RoomRepository) MatchTitle(ctx context.Context, title string, year int) (uuid.UUID, error) {
+	ret := _m.Called(ctx, title, year)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MatchTitle")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (uuid.UUID, error)); ok {
+		return rf(ctx, title, year)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) uuid.UUID); ok {
+		r0 = rf(ctx, title, year)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, title, year)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ModeByCode provides a mock function with given fields: ctx, code
+func (_m *RoomRepository) ModeByCode(ctx context.Context, code string) (string, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ModeByCode")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, code)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ParticipantsCount provides a mock function with given fields: ctx, code
 func (_m *RoomRepository) ParticipantsCount(ctx context.Context, code string) (int, error) {
 	ret := _m.Called(ctx, code)
@@ -174,6 +262,54 @@ func (_m *RoomRepository) ParticipantsCount(ctx context.Context, code string) (i
 	return r0, r1
 }
 
+// ReadyMovies provides a mock function with given fields: ctx, ids
+func (_m *RoomRepository) ReadyMovies(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadyMovies")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uuid.UUID) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCollections provides a mock function with given fields: ctx, code, collectionIDs
+func (_m *RoomRepository) SetCollections(ctx context.Context, code string, collectionIDs []uuid.UUID) error {
+	ret := _m.Called(ctx, code, collectionIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCollections")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []uuid.UUID) error); ok {
+		r0 = rf(ctx, code, collectionIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetStatusByCode provides a mock function with given fields: ctx, code, status
 func (_m *RoomRepository) SetStatusByCode(ctx context.Context, code string, status string) error {
 	ret := _m.Called(ctx, code, status)
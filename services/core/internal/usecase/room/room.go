@@ -3,7 +3,10 @@ package usecase_room
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,8 +19,38 @@ var (
 	ErrRoomsUnavailable = errors.New("no available rooms")
 	ErrInternal         = errors.New("internal error")
 	ErrResourceNotFound = errors.New("no such resource")
+	// Candidate pool is fixed once voting starts
+	ErrVotingStarted = errors.New("voting has started")
+	// Only shortlist rooms may go without preferences
+	ErrPreferenceRequired = errors.New("preference is required")
+	ErrInvalidShortlist   = errors.New("invalid shortlist")
+	// Embedder is down, participant may try again later
+	ErrRecommendationsUnavailable = errors.New("recommendations temporarily unavailable")
 )
 
+// Shortlist is meant to be narrowed down already, voting on more is what recommendations are for
+const MaxShortlistSize = 50
+
+// ShortlistRequest lists movies of shortlist room. Titles are fuzzy matched against catalog,
+// year in parentheses narrows the match down, ex. "Heat (1995)".
+type ShortlistRequest struct {
+	MovieIDs []uuid.UUID
+	Titles   []string
+}
+
+// ShortlistMismatchError tells which shortlist ids or titles aren't found in catalog
+type ShortlistMismatchError struct {
+	Unmatched []string
+}
+
+func (e *ShortlistMismatchError) Error() string {
+	return fmt.Sprintf("no ready movies match %q", e.Unmatched)
+}
+
+func (e *ShortlistMismatchError) Unwrap() error {
+	return ErrInvalidShortlist
+}
+
 //go:generate mockery --name=RoomRepository --output=./mocks/room/repository --filename=repository.go
 type RoomRepository interface {
 	CreateAndBook(ctx context.Context, room model.Room, ownerID uuid.UUID) error
@@ -25,10 +58,17 @@ type RoomRepository interface {
 	DeleteByCode(ctx context.Context, code string) error
 	StatusByCode(ctx context.Context, code string) (string, error)
 	SetStatusByCode(ctx context.Context, code string, status string) error
-	AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, prefEmbedding model.Embedding) error
+	AddPreferenceEmbedding(ctx context.Context, code string, userID uuid.UUID, pref model.Preference, prefEmbedding model.Embedding) error
 	ParticipantsCount(ctx context.Context, code string) (int, error)
 	IsParticipant(ctx context.Context, code string, userID uuid.UUID) (bool, error)
 	UUIDByCode(ctx context.Context, code string) (uuid.UUID, error)
+	ModeByCode(ctx context.Context, code string) (model.RoomMode, error)
+	// ReadyMovies returns those of ids which are ready to be voted on
+	ReadyMovies(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
+	// MatchTitle finds ready movie whose title is the most similar, year is ignored if zero
+	MatchTitle(ctx context.Context, title string, year int) (uuid.UUID, error)
+	SetCollections(ctx context.Context, code string, collectionIDs []uuid.UUID) error
+	Collections(ctx context.Context, code string) ([]model.Collection, error)
 
 	CleanupOrphantRooms(ctx context.Context, lobbiesDeadline, votingDeadline time.Duration) error
 }
@@ -66,33 +106,115 @@ func New(
 // Owner token must be set on a client in order to be able to do 'owner ops'
 func (u *Usecase) Book(ctx context.Context) (roomCode string, ownerToken string, err error) {
 	ownerID := u.resolveOwnerToken()
+	roomCode, err = u.createRoomLobby(ctx, ownerID, model.Room{Mode: model.ModeRecommend})
+	if err != nil {
+		return "", "", err
+	}
+	return roomCode, ownerID.String(), nil
+}
 
-	// Cleanup orphant rooms
-	u.booksCount++
-	if u.booksCount%u.cleanupPeriod == 0 {
-		if err := u.RoomRepository.CleanupOrphantRooms(ctx, time.Minute*5 /* Lobbies */, time.Minute*10 /* Votings */); err != nil {
-			return "", "", errors.Join(ErrInternal, err)
-		}
+// BookShortlist books room whose participants vote only on the given movies
+func (u *Usecase) BookShortlist(ctx context.Context, req ShortlistRequest) (roomCode string, ownerToken string, err error) {
+	shortlist, err := u.resolveShortlist(ctx, req)
+	if err != nil {
+		return "", "", err
 	}
 
-	roomCode, err = u.createRoomLobby(ctx, ownerID)
+	ownerID := u.resolveOwnerToken()
+	roomCode, err = u.createRoomLobby(ctx, ownerID, model.Room{Mode: model.ModeShortlist, Shortlist: shortlist})
 	if err != nil {
 		return "", "", err
 	}
 	return roomCode, ownerID.String(), nil
 }
 
+var titleYear = regexp.MustCompile(`^(.+?)\s*\((\d{4})\)$`)
+
+// resolveShortlist keeps order of request, ids go first. Duplicates are dropped.
+func (u *Usecase) resolveShortlist(ctx context.Context, req ShortlistRequest) ([]uuid.UUID, error) {
+	if len(req.MovieIDs)+len(req.Titles) == 0 {
+		return nil, errors.Join(ErrInvalidShortlist, errors.New("shortlist is empty"))
+	}
+	if len(req.MovieIDs)+len(req.Titles) > MaxShortlistSize {
+		return nil, errors.Join(ErrInvalidShortlist, fmt.Errorf("shortlist is longer than %d", MaxShortlistSize))
+	}
+
+	var shortlist []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	add := func(id uuid.UUID) {
+		if !seen[id] {
+			seen[id] = true
+			shortlist = append(shortlist, id)
+		}
+	}
+
+	if len(req.MovieIDs) > 0 {
+		ready, err := u.RoomRepository.ReadyMovies(ctx, req.MovieIDs)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+		found := make(map[uuid.UUID]bool, len(ready))
+		for _, id := range ready {
+			found[id] = true
+		}
+
+		var unknown []string
+		for _, id := range req.MovieIDs {
+			if !found[id] {
+				unknown = append(unknown, id.String())
+				continue
+			}
+			add(id)
+		}
+		if len(unknown) > 0 {
+			return nil, &ShortlistMismatchError{Unmatched: unknown}
+		}
+	}
+
+	var unmatched []string
+	for _, raw := range req.Titles {
+		title, year := strings.TrimSpace(raw), 0
+		if m := titleYear.FindStringSubmatch(title); m != nil {
+			title = m[1]
+			year, _ = strconv.Atoi(m[2])
+		}
+		if title == "" {
+			return nil, errors.Join(ErrInvalidShortlist, errors.New("title is empty"))
+		}
+
+		id, err := u.RoomRepository.MatchTitle(ctx, title, year)
+		if errors.Is(err, ErrResourceNotFound) {
+			unmatched = append(unmatched, raw)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+		add(id)
+	}
+	if len(unmatched) > 0 {
+		return nil, &ShortlistMismatchError{Unmatched: unmatched}
+	}
+
+	return shortlist, nil
+}
+
 // Assuming that codes can conflict.
 // Retrying...
-func (u *Usecase) createRoomLobby(ctx context.Context, ownerID uuid.UUID) (string, error) {
+func (u *Usecase) createRoomLobby(ctx context.Context, ownerID uuid.UUID, room model.Room) (string, error) {
+	// Cleanup orphant rooms, every booking counts whatever the mode
+	u.booksCount++
+	if u.booksCount%u.cleanupPeriod == 0 {
+		if err := u.RoomRepository.CleanupOrphantRooms(ctx, time.Minute*5 /* Lobbies */, time.Minute*10 /* Votings */); err != nil {
+			return "", errors.Join(ErrInternal, err)
+		}
+	}
+
 	var retries = 3
 	for retries > 0 {
 		code := u.buildRoomCode()
-		if err := u.RoomRepository.CreateAndBook(ctx, model.Room{
-			ID:         uuid.New(),
-			PublicCode: code,
-			Status:     model.StatusLobby,
-		}, ownerID); err != nil {
+		room.ID, room.PublicCode, room.Status = uuid.New(), code, model.StatusLobby
+		if err := u.RoomRepository.CreateAndBook(ctx, room, ownerID); err != nil {
 			if errors.Is(err, ErrCodeConflict) {
 				retries--
 			} else {
@@ -187,7 +309,8 @@ func (u *Usecase) SetStatus(ctx context.Context, code string, status string) err
 	return nil
 }
 
-// Incomping userID == nil ~ it's not owner
+// Incomping userID == nil ~ it's not owner.
+// Participant of shortlist room may have no preference, nil embedding is stored then.
 func (u *Usecase) Participate(ctx context.Context, code string, pref model.Preference, userID *string) (string, error) {
 	var userUUID uuid.UUID
 	if userID == nil {
@@ -200,12 +323,30 @@ func (u *Usecase) Participate(ctx context.Context, code string, pref model.Prefe
 		userUUID, _ = uuid.Parse(*userID)
 	}
 
-	prefEmbedding, err := u.Embedder.BuildPreferenceEmbedding(ctx, pref)
-	if err != nil {
-		return *userID, errors.Join(ErrInternal, err)
+	var prefEmbedding model.Embedding
+	if strings.TrimSpace(pref.Text) == "" {
+		mode, err := u.RoomRepository.ModeByCode(ctx, code)
+		if err != nil {
+			if errors.Is(err, ErrResourceNotFound) {
+				return *userID, ErrResourceNotFound
+			}
+			return *userID, errors.Join(ErrInternal, err)
+		}
+		if mode != model.ModeShortlist {
+			return *userID, ErrPreferenceRequired
+		}
+	} else {
+		var err error
+		prefEmbedding, err = u.Embedder.BuildPreferenceEmbedding(ctx, pref)
+		if err != nil {
+			if errors.Is(err, model.ErrEmbedderUnavailable) {
+				return *userID, errors.Join(ErrRecommendationsUnavailable, err)
+			}
+			return *userID, errors.Join(ErrInternal, err)
+		}
 	}
 
-	if err := u.RoomRepository.AddPreferenceEmbedding(ctx, code, userUUID, prefEmbedding); err != nil {
+	if err := u.RoomRepository.AddPreferenceEmbedding(ctx, code, userUUID, pref, prefEmbedding); err != nil {
 		if errors.Is(err, ErrResourceNotFound) {
 			return *userID, ErrResourceNotFound
 		}
@@ -237,3 +378,28 @@ func (u *Usecase) UUIDByCode(ctx context.Context, code string) (uuid.UUID, error
 
 	return _uuid, err
 }
+
+// SetCollections restricts room candidates to movies of the given collections, none lifts the restriction.
+// It's allowed only in lobby.
+func (u *Usecase) SetCollections(ctx context.Context, code string, collectionIDs []uuid.UUID) error {
+	err := u.RoomRepository.SetCollections(ctx, code, collectionIDs)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) || errors.Is(err, ErrVotingStarted) {
+			return err
+		}
+		return errors.Join(ErrInternal, err)
+	}
+	return nil
+}
+
+// Collections returns collections room draws candidates from, empty if it draws from whole catalog
+func (u *Usecase) Collections(ctx context.Context, code string) ([]model.Collection, error) {
+	cs, err := u.RoomRepository.Collections(ctx, code)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return cs, nil
+}
@@ -5,7 +5,10 @@ package usecase_room
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
@@ -306,7 +309,7 @@ func (suite *UsecaseRoomUnitSuite) TestParticipate(t provider.T) {
 			name: "Should participate successfully with new userID",
 			setupMocks: func(r *resources, code string, pref model.Preference, userID *string) {
 				r.embedder.On("BuildPreferenceEmbedding", r.ctx, pref).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once()
-				r.roomRepo.On("AddPreferenceEmbedding", r.ctx, code, mock.AnythingOfType("uuid.UUID"), model.Embedding(make([]float32, model.EmbeddingDimension))).Return(nil).Once()
+				r.roomRepo.On("AddPreferenceEmbedding", r.ctx, code, mock.AnythingOfType("uuid.UUID"), pref, model.Embedding(make([]float32, model.EmbeddingDimension))).Return(nil).Once()
 			},
 			expectError: false,
 		},
@@ -314,11 +317,27 @@ func (suite *UsecaseRoomUnitSuite) TestParticipate(t provider.T) {
 			name: "Should return error when repository fails",
 			setupMocks: func(r *resources, code string, pref model.Preference, userID *string) {
 				r.embedder.On("BuildPreferenceEmbedding", r.ctx, pref).Return(model.Embedding(make([]float32, model.EmbeddingDimension)), nil).Once()
-				r.roomRepo.On("AddPreferenceEmbedding", r.ctx, code, mock.AnythingOfType("uuid.UUID"), model.Embedding(make([]float32, model.EmbeddingDimension))).Return(ErrResourceNotFound).Once()
+				r.roomRepo.On("AddPreferenceEmbedding", r.ctx, code, mock.AnythingOfType("uuid.UUID"), pref, model.Embedding(make([]float32, model.EmbeddingDimension))).Return(ErrResourceNotFound).Once()
 			},
 			expectError:   true,
 			expectedError: ErrResourceNotFound,
 		},
+		{
+			name: "Should report unavailable recommendations when embedder is down",
+			setupMocks: func(r *resources, code string, pref model.Preference, userID *string) {
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, pref).Return(nil, fmt.Errorf("%w: circuit breaker is open", model.ErrEmbedderUnavailable)).Once()
+			},
+			expectError:   true,
+			expectedError: ErrRecommendationsUnavailable,
+		},
+		{
+			name: "Should return internal error when embedder refuses preference",
+			setupMocks: func(r *resources, code string, pref model.Preference, userID *string) {
+				r.embedder.On("BuildPreferenceEmbedding", r.ctx, pref).Return(nil, errors.New("invalid argument")).Once()
+			},
+			expectError:   true,
+			expectedError: ErrInternal,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -393,6 +412,246 @@ func (suite *UsecaseRoomUnitSuite) TestParticipantsCount(t provider.T) {
 	}
 }
 
+func (suite *UsecaseRoomUnitSuite) TestSetCollections(t provider.T) {
+	t.Parallel()
+
+	collectionIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	testCases := []struct {
+		name          string
+		repoErr       error
+		expectedError error
+	}{
+		{
+			name: "Should restrict room to collections",
+		},
+		{
+			name:          "Should refuse room which started voting",
+			repoErr:       ErrVotingStarted,
+			expectedError: ErrVotingStarted,
+		},
+		{
+			name:          "Should return not found for unknown collection",
+			repoErr:       ErrResourceNotFound,
+			expectedError: ErrResourceNotFound,
+		},
+		{
+			name:          "Should wrap repository failure",
+			repoErr:       errors.New("connection refused"),
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			code := validRoomCode()
+			r.roomRepo.On("SetCollections", r.ctx, code, collectionIDs).Return(tc.repoErr).Once()
+
+			err := r.usecase.SetCollections(r.ctx, code, collectionIDs)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func (suite *UsecaseRoomUnitSuite) TestBookShortlist(t provider.T) {
+	t.Parallel()
+
+	heat, up := uuid.New(), uuid.New()
+	unknown := uuid.New()
+
+	testCases := []struct {
+		name              string
+		req               ShortlistRequest
+		setupMocks        func(r *resources)
+		expectedShortlist []uuid.UUID
+		expectedError     error
+		expectedUnmatched []string
+	}{
+		{
+			name: "Should keep order of ids and titles dropping duplicates",
+			req: ShortlistRequest{
+				MovieIDs: []uuid.UUID{up, up},
+				Titles:   []string{"Heat (1995)", " up "},
+			},
+			setupMocks: func(r *resources) {
+				r.roomRepo.On("ReadyMovies", r.ctx, []uuid.UUID{up, up}).Return([]uuid.UUID{up}, nil).Once()
+				r.roomRepo.On("MatchTitle", r.ctx, "Heat", 1995).Return(heat, nil).Once()
+				r.roomRepo.On("MatchTitle", r.ctx, "up", 0).Return(up, nil).Once()
+			},
+			expectedShortlist: []uuid.UUID{up, heat},
+		},
+		{
+			name: "Should report unknown ids",
+			req:  ShortlistRequest{MovieIDs: []uuid.UUID{heat, unknown}},
+			setupMocks: func(r *resources) {
+				r.roomRepo.On("ReadyMovies", r.ctx, []uuid.UUID{heat, unknown}).Return([]uuid.UUID{heat}, nil).Once()
+			},
+			expectedError:     ErrInvalidShortlist,
+			expectedUnmatched: []string{unknown.String()},
+		},
+		{
+			name: "Should report every unmatched title",
+			req:  ShortlistRequest{Titles: []string{"Heaat", "Nothing like it (1901)", "Up"}},
+			setupMocks: func(r *resources) {
+				r.roomRepo.On("MatchTitle", r.ctx, "Heaat", 0).Return(heat, nil).Once()
+				r.roomRepo.On("MatchTitle", r.ctx, "Nothing like it", 1901).Return(uuid.Nil, ErrResourceNotFound).Once()
+				r.roomRepo.On("MatchTitle", r.ctx, "Up", 0).Return(uuid.Nil, ErrResourceNotFound).Once()
+			},
+			expectedError:     ErrInvalidShortlist,
+			expectedUnmatched: []string{"Nothing like it (1901)", "Up"},
+		},
+		{
+			name:          "Should refuse empty shortlist",
+			req:           ShortlistRequest{},
+			setupMocks:    func(r *resources) {},
+			expectedError: ErrInvalidShortlist,
+		},
+		{
+			name:          "Should refuse too long shortlist",
+			req:           ShortlistRequest{Titles: make([]string, MaxShortlistSize+1)},
+			setupMocks:    func(r *resources) {},
+			expectedError: ErrInvalidShortlist,
+		},
+		{
+			name: "Should wrap repository failure",
+			req:  ShortlistRequest{Titles: []string{"Heat"}},
+			setupMocks: func(r *resources) {
+				r.roomRepo.On("MatchTitle", r.ctx, "Heat", 0).Return(uuid.Nil, errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			tc.setupMocks(r)
+			if tc.expectedError == nil {
+				r.roomRepo.On("CreateAndBook", r.ctx, mock.MatchedBy(func(room model.Room) bool {
+					return room.Mode == model.ModeShortlist && assert.ObjectsAreEqual(tc.expectedShortlist, room.Shortlist)
+				}), mock.AnythingOfType("uuid.UUID")).Return(nil).Once()
+			}
+
+			roomCode, ownerToken, err := r.usecase.BookShortlist(r.ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Empty(t, roomCode)
+				assert.Empty(t, ownerToken)
+				var mismatch *ShortlistMismatchError
+				if tc.expectedUnmatched != nil && assert.ErrorAs(t, err, &mismatch) {
+					assert.Equal(t, tc.expectedUnmatched, mismatch.Unmatched)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, roomCode)
+				assert.NotEmpty(t, ownerToken)
+			}
+			r.roomRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func (suite *UsecaseRoomUnitSuite) TestBookCleanup(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		cleanupErr error
+		expectErr  error
+	}{
+		{
+			name: "Should clean up orphant rooms on every Nth booking of any mode",
+		},
+		{
+			name:       "Should fail booking when cleanup fails",
+			cleanupErr: errors.New("connection refused"),
+			expectErr:  ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			r.usecase = New(r.roomRepo, r.embedder, 2)
+			movieID := uuid.New()
+
+			r.roomRepo.On("CreateAndBook", r.ctx, mock.AnythingOfType("model.Room"), mock.AnythingOfType("uuid.UUID")).
+				Return(nil).Once()
+			_, _, err := r.usecase.Book(r.ctx)
+			assert.NoError(t, err)
+
+			r.roomRepo.On("ReadyMovies", r.ctx, []uuid.UUID{movieID}).Return([]uuid.UUID{movieID}, nil).Once()
+			r.roomRepo.On("CleanupOrphantRooms", r.ctx, 5*time.Minute, 10*time.Minute).Return(tc.cleanupErr).Once()
+			if tc.expectErr == nil {
+				r.roomRepo.On("CreateAndBook", r.ctx, mock.AnythingOfType("model.Room"), mock.AnythingOfType("uuid.UUID")).
+					Return(nil).Once()
+			}
+			_, _, err = r.usecase.BookShortlist(r.ctx, ShortlistRequest{MovieIDs: []uuid.UUID{movieID}})
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			r.roomRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func (suite *UsecaseRoomUnitSuite) TestParticipateWithoutPreference(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		mode          model.RoomMode
+		expectedError error
+	}{
+		{
+			name: "Should let participant of shortlist room skip preference",
+			mode: model.ModeShortlist,
+		},
+		{
+			name:          "Should require preference in recommend room",
+			mode:          model.ModeRecommend,
+			expectedError: ErrPreferenceRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			code := validRoomCode()
+			pref := model.Preference{Text: "  "}
+			r.roomRepo.On("ModeByCode", r.ctx, code).Return(tc.mode, nil).Once()
+			if tc.expectedError == nil {
+				r.roomRepo.On("AddPreferenceEmbedding", r.ctx, code, mock.AnythingOfType("uuid.UUID"), pref, model.Embedding(nil)).Return(nil).Once()
+			}
+
+			userID, err := r.usecase.Participate(r.ctx, code, pref, nil)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, userID)
+			}
+			r.embedder.AssertNotCalled(t, "BuildPreferenceEmbedding", mock.Anything, mock.Anything)
+			r.roomRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestUnitSuite(t *testing.T) {
 	suite.RunSuite(t, new(UsecaseRoomUnitSuite))
 }
@@ -0,0 +1,57 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PosterPresigner is an autogenerated mock type for the PosterPresigner type
+type PosterPresigner struct {
+	mock.Mock
+}
+
+// GeneratePresignedURL provides a mock function with given fields: ctx, rawURL, ttl
+func (_m *PosterPresigner) GeneratePresignedURL(ctx context.Context, rawURL string, ttl time.Duration) (string, error) {
+	ret := _m.Called(ctx, rawURL, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GeneratePresignedURL")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (string, error)); ok {
+		return rf(ctx, rawURL, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) string); ok {
+		r0 = rf(ctx, rawURL, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, rawURL, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewPosterPresigner creates a new instance of PosterPresigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPosterPresigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PosterPresigner {
+	mock := &PosterPresigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -16,6 +16,24 @@ type VoteRepository struct {
 	mock.Mock
 }
 
+// AddReaction provides a mock function with given fields: ctx, roomID, userID, movieID, reaction
+func (_m *VoteRepository) AddReaction(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, movieID uuid.UUID, reaction int) error {
+	ret := _m.Called(ctx, roomID, userID, movieID, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddReaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, int) error); ok {
+		r0 = rf(ctx, roomID, userID, movieID, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddReactions provides a mock function with given fields: ctx, roomID, userID, reactions
 func (_m *VoteRepository) AddReactions(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, reactions map[uuid.UUID]int) error {
 	ret := _m.Called(ctx, roomID, userID, reactions)
@@ -62,6 +80,144 @@ func (_m *VoteRepository) IsAllReady(ctx context.Context, roomID uuid.UUID) (boo
 	return r0, r1
 }
 
+// LexicalMovies provides a mock function with given fields: ctx, roomID, text, limit
+func (_m *VoteRepository) LexicalMovies(ctx context.Context, roomID uuid.UUID, text string, limit int) ([]*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, text, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LexicalMovies")
+	}
+
+	var r0 []*model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, int) ([]*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, text, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, int) []*model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, text, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, int) error); ok {
+		r1 = rf(ctx, roomID, text, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkVoted provides a mock function with given fields: ctx, roomID, userID
+func (_m *VoteRepository) MarkVoted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	ret := _m.Called(ctx, roomID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkVoted")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, roomID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NextMovie provides a mock function with given fields: ctx, roomID, userID, queryEmbedding
+func (_m *VoteRepository) NextMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, queryEmbedding []float32) (*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, userID, queryEmbedding)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextMovie")
+	}
+
+	var r0 *model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, []float32) (*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, userID, queryEmbedding)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, []float32) *model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, userID, queryEmbedding)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, []float32) error); ok {
+		r1 = rf(ctx, roomID, userID, queryEmbedding)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NextShortlistMovie provides a mock function with given fields: ctx, roomID, userID
+func (_m *VoteRepository) NextShortlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextShortlistMovie")
+	}
+
+	var r0 *model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) (*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) *model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NextWatchlistMovie provides a mock function with given fields: ctx, roomID, userID
+func (_m *VoteRepository) NextWatchlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextWatchlistMovie")
+	}
+
+	var r0 *model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) (*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) *model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ParticipantsEmbeddings provides a mock function with given fields: ctx, roomID
 func (_m *VoteRepository) ParticipantsEmbeddings(ctx context.Context, roomID uuid.UUID) ([]model.Embedding, error) {
 	ret := _m.Called(ctx, roomID)
@@ -92,6 +248,94 @@ func (_m *VoteRepository) ParticipantsEmbeddings(ctx context.Context, roomID uui
 	return r0, r1
 }
 
+// ParticipantsPreferences provides a mock function with given fields: ctx, roomID
+func (_m *VoteRepository) ParticipantsPreferences(ctx context.Context, roomID uuid.UUID) ([]string, error) {
+	ret := _m.Called(ctx, roomID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParticipantsPreferences")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]string, error)); ok {
+		return rf(ctx, roomID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []string); ok {
+		r0 = rf(ctx, roomID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reacted provides a mock function with given fields: ctx, roomID, userID
+func (_m *VoteRepository) Reacted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx, roomID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reacted")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(ctx, roomID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(ctx, roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReactionsCount provides a mock function with given fields: ctx, roomID, userID
+func (_m *VoteRepository) ReactionsCount(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (int, error) {
+	ret := _m.Called(ctx, roomID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReactionsCount")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) (int, error)); ok {
+		return rf(ctx, roomID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) int); ok {
+		r0 = rf(ctx, roomID, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Results provides a mock function with given fields: ctx, roomID
 func (_m *VoteRepository) Results(ctx context.Context, roomID uuid.UUID) ([]*model.Result, error) {
 	ret := _m.Called(ctx, roomID)
@@ -152,9 +396,67 @@ func (_m *VoteRepository) RoomIDByCode(ctx context.Context, code string) (uuid.U
 	return r0, r1
 }
 
-// SimilarMovies provides a mock function with given fields: ctx, queryEmbedding, limit
-func (_m *VoteRepository) SimilarMovies(ctx context.Context, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error) {
-	ret := _m.Called(ctx, queryEmbedding, limit)
+// RoomMode provides a mock function with given fields: ctx, roomID
+func (_m *VoteRepository) RoomMode(ctx context.Context, roomID uuid.UUID) (string, error) {
+	ret := _m.Called(ctx, roomID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RoomMode")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (string, error)); ok {
+		return rf(ctx, roomID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) string); ok {
+		r0 = rf(ctx, roomID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Shortlist provides a mock function with given fields: ctx, roomID
+func (_m *VoteRepository) Shortlist(ctx context.Context, roomID uuid.UUID) ([]*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shortlist")
+	}
+
+	var r0 []*model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.MovieMeta); ok {
+		r0 = rf(ctx, roomID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, roomID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SimilarMovies provides a mock function with given fields: ctx, roomID, queryEmbedding, limit
+func (_m *VoteRepository) SimilarMovies(ctx context.Context, roomID uuid.UUID, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, queryEmbedding, limit)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SimilarMovies")
@@ -162,19 +464,49 @@ func (_m *VoteRepository) SimilarMovies(ctx context.Context, queryEmbedding []fl
 
 	var r0 []*model.MovieMeta
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, []float32, int) ([]*model.MovieMeta, error)); ok {
-		return rf(ctx, queryEmbedding, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []float32, int) ([]*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, queryEmbedding, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []float32, int) []*model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, queryEmbedding, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []float32, int) error); ok {
+		r1 = rf(ctx, roomID, queryEmbedding, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchlistMovies provides a mock function with given fields: ctx, roomID, limit
+func (_m *VoteRepository) WatchlistMovies(ctx context.Context, roomID uuid.UUID, limit int) ([]*model.MovieMeta, error) {
+	ret := _m.Called(ctx, roomID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WatchlistMovies")
+	}
+
+	var r0 []*model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int) ([]*model.MovieMeta, error)); ok {
+		return rf(ctx, roomID, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, []float32, int) []*model.MovieMeta); ok {
-		r0 = rf(ctx, queryEmbedding, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int) []*model.MovieMeta); ok {
+		r0 = rf(ctx, roomID, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*model.MovieMeta)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, []float32, int) error); ok {
-		r1 = rf(ctx, queryEmbedding, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int) error); ok {
+		r1 = rf(ctx, roomID, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
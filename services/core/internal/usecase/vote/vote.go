@@ -3,24 +3,61 @@ package usecase_vote
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/rank_fusion"
 )
 
 var (
 	ErrInternal         = errors.New("internal error")
 	ErrResourceNotFound = errors.New("no such resource")
+	ErrInvalidReaction  = errors.New("invalid reaction")
+	ErrDeckExhausted    = errors.New("deck exhausted")
 )
 
+// Amount of cards served to every participant during swipe-by-swipe voting
+const defaultDeckSize = 20
+
+// Every second card of the deck comes from participants' watchlists while they have unseen movies
+const watchlistTurn = 2
+
 //go:generate mockery --name=VoteRepository --output=./mocks/vote/repository --filename=repository.go
 type VoteRepository interface {
 	RoomIDByCode(ctx context.Context, code string) (uuid.UUID, error)
 	ParticipantsEmbeddings(ctx context.Context, roomID uuid.UUID) ([]model.Embedding, error)
-	SimilarMovies(ctx context.Context, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error)
+	// Candidates are limited to room collections, if it has any
+	SimilarMovies(ctx context.Context, roomID uuid.UUID, queryEmbedding []float32, limit int) ([]*model.MovieMeta, error)
+	ParticipantsPreferences(ctx context.Context, roomID uuid.UUID) ([]string, error)
+	LexicalMovies(ctx context.Context, roomID uuid.UUID, text string, limit int) ([]*model.MovieMeta, error)
 	Results(ctx context.Context, roomID uuid.UUID) ([]*model.Result, error)
 	AddReactions(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, reactions map[uuid.UUID]int) error
 	IsAllReady(ctx context.Context, roomID uuid.UUID) (bool, error)
+
+	AddReaction(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, movieID uuid.UUID, reaction int) error
+	ReactionsCount(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (int, error)
+	NextMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, queryEmbedding []float32) (*model.MovieMeta, error)
+	// Movies participant has reacted to in the room
+	Reacted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]uuid.UUID, error)
+	MarkVoted(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
+
+	// Mode of room decides between shortlist and recommendations, shortlist movies may be not ready meanwhile
+	RoomMode(ctx context.Context, roomID uuid.UUID) (model.RoomMode, error)
+	// Shortlist is empty unless owner picked movies of the room
+	Shortlist(ctx context.Context, roomID uuid.UUID) ([]*model.MovieMeta, error)
+	NextShortlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error)
+
+	// Movies on participants' watchlists, wanted by the most of them first.
+	// Watched movies are never candidates.
+	WatchlistMovies(ctx context.Context, roomID uuid.UUID, limit int) ([]*model.MovieMeta, error)
+	NextWatchlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error)
+}
+
+//go:generate mockery --name=PosterPresigner --output=./mocks/vote/poster --filename=poster.go
+type PosterPresigner interface {
+	GeneratePresignedURL(ctx context.Context, rawURL string, ttl time.Duration) (string, error)
 }
 
 //go:generate mockery --name=RoomUUIDer --output=./mocks/vote/roomuc --filename=roomuc.go
@@ -31,24 +68,90 @@ type RoomUUIDer interface {
 type Usecase struct {
 	VoteRepository VoteRepository
 	RoomUUIDer     RoomUUIDer
+
+	deckSize      int
+	candidateMode model.SearchMode
+	posters       PosterPresigner
+}
+
+type Option func(*Usecase)
+
+// Limits amount of cards each participant swipes before he's marked as voted
+func WithDeckSize(n int) Option {
+	return func(u *Usecase) {
+		if n > 0 {
+			u.deckSize = n
+		}
+	}
+}
+
+// Picks how VotingBatch and NextMovie find candidates, vector search over averaged preferences by default
+func WithCandidateMode(mode model.SearchMode) Option {
+	return func(u *Usecase) {
+		if mode != "" {
+			u.candidateMode = mode
+		}
+	}
+}
+
+// Posters are private, so every served movie carries presigned link. Raw links are served without presigner
+func WithPosterPresigner(p PosterPresigner) Option {
+	return func(u *Usecase) {
+		u.posters = p
+	}
 }
 
 func New(
 	VoteRepository VoteRepository,
 	RoomUUIDer RoomUUIDer,
+	opts ...Option,
 ) *Usecase {
-	return &Usecase{
+	u := &Usecase{
 		VoteRepository: VoteRepository,
 		RoomUUIDer:     RoomUUIDer,
+		deckSize:       defaultDeckSize,
+		candidateMode:  model.SearchModeVector,
 	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
+// VotingBatch finds n movies closest to the room preferences. Shortlist room gets its whole shortlist,
+// ErrDeckExhausted if none of its movies is ready.
 func (u *Usecase) VotingBatch(ctx context.Context, n int, code string) ([]*model.MovieMeta, error) {
+	movies, err := u.votingBatch(ctx, n, code)
+	if err != nil {
+		return movies, err
+	}
+	if err := u.presign(ctx, movies...); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+func (u *Usecase) votingBatch(ctx context.Context, n int, code string) ([]*model.MovieMeta, error) {
 	roomID, err := u.RoomUUIDer.UUIDByCode(ctx, code)
 	if err != nil {
 		return nil, err
 	}
 
+	shortlisted, err := u.isShortlist(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if shortlisted {
+		shortlist, err := u.VoteRepository.Shortlist(ctx, roomID)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+		if len(shortlist) == 0 {
+			return []*model.MovieMeta{}, ErrDeckExhausted
+		}
+		return shortlist, nil
+	}
+
 	embeddings, err := u.VoteRepository.ParticipantsEmbeddings(ctx, roomID)
 	if err != nil {
 		return nil, errors.Join(ErrInternal, err)
@@ -60,14 +163,97 @@ func (u *Usecase) VotingBatch(ctx context.Context, n int, code string) ([]*model
 
 	avgEmbedding := u.averageEmbeddings(embeddings)
 
-	movies, err := u.VoteRepository.SimilarMovies(ctx, avgEmbedding, n)
+	var movies []*model.MovieMeta
+	if u.candidateMode == model.SearchModeVector {
+		movies, err = u.VoteRepository.SimilarMovies(ctx, roomID, avgEmbedding, n)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+	} else {
+		movies, err = u.textCandidates(ctx, n, roomID, avgEmbedding)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.boostWatchlisted(ctx, roomID, movies, n)
+}
+
+// boostWatchlisted fuses movies participants want to see into candidates,
+// so ones found both ways go first and the rest of them take turns with candidates
+func (u *Usecase) boostWatchlisted(ctx context.Context, roomID uuid.UUID, movies []*model.MovieMeta, n int) ([]*model.MovieMeta, error) {
+	wanted, err := u.VoteRepository.WatchlistMovies(ctx, roomID, n)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+	if len(wanted) == 0 {
+		return movies, nil
+	}
+
+	boosted := rank_fusion.Movies(rank_fusion.Fuse(rank_fusion.DefaultK, movies, wanted))
+	if len(boosted) > n {
+		boosted = boosted[:n]
+	}
+	return boosted, nil
+}
+
+// Each ranking is deeper than the batch, so movies ranked moderately by both can make it
+const hybridOverfetch = 3
+
+// Lexical candidates match preferences word by word. Participants joined before
+// preference texts were stored have none, so vector ranking fills in the gaps.
+func (u *Usecase) textCandidates(ctx context.Context, n int, roomID uuid.UUID, avgEmbedding model.Embedding) ([]*model.MovieMeta, error) {
+	texts, err := u.VoteRepository.ParticipantsPreferences(ctx, roomID)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+
+	var lexical []*model.MovieMeta
+	if len(texts) > 0 {
+		lexical, err = u.VoteRepository.LexicalMovies(ctx, roomID, strings.Join(texts, " "), n*hybridOverfetch)
+		if err != nil {
+			return nil, errors.Join(ErrInternal, err)
+		}
+	}
+
+	if u.candidateMode == model.SearchModeLexical && len(lexical) >= n {
+		return lexical[:n], nil
+	}
+
+	vector, err := u.VoteRepository.SimilarMovies(ctx, roomID, avgEmbedding, n*hybridOverfetch)
 	if err != nil {
 		return nil, errors.Join(ErrInternal, err)
 	}
 
+	if u.candidateMode == model.SearchModeLexical {
+		return topUp(lexical, vector, n), nil
+	}
+
+	movies := rank_fusion.Movies(rank_fusion.Fuse(rank_fusion.DefaultK, lexical, vector))
+	if len(movies) > n {
+		movies = movies[:n]
+	}
 	return movies, nil
 }
 
+// topUp appends movies of extra not yet in movies until there're n of them
+func topUp(movies, extra []*model.MovieMeta, n int) []*model.MovieMeta {
+	seen := make(map[uuid.UUID]bool, len(movies))
+	for _, m := range movies {
+		seen[m.ID] = true
+	}
+	for _, m := range extra {
+		if len(movies) >= n {
+			break
+		}
+		if !seen[m.ID] {
+			seen[m.ID] = true
+			movies = append(movies, m)
+		}
+	}
+	return movies
+}
+
 func (u *Usecase) averageEmbeddings(embeddings []model.Embedding) model.Embedding {
 	if len(embeddings) == 0 {
 		return nil
@@ -99,6 +285,11 @@ func (u *Usecase) Results(ctx context.Context, code string) ([]*model.Result, er
 	if err != nil {
 		return nil, errors.Join(ErrInternal, err)
 	}
+	for _, result := range results {
+		if err := u.presign(ctx, &result.MM); err != nil {
+			return nil, err
+		}
+	}
 
 	return results, nil
 }
@@ -134,3 +325,175 @@ func (u *Usecase) IsAllReady(ctx context.Context, code string) (bool, error) {
 	}
 	return ready, err
 }
+
+// React persists a single swipe.
+// Repeated reactions on the same movie are ignored, so clients may safely resend unacknowledged swipes
+func (u *Usecase) React(ctx context.Context, code string, userID uuid.UUID, movieID uuid.UUID, reaction model.Reaction) error {
+	if reaction != model.PassReaction && reaction != model.SmashReaction {
+		return ErrInvalidReaction
+	}
+
+	roomID, err := u.RoomUUIDer.UUIDByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	err = u.VoteRepository.AddReaction(ctx, roomID, userID, movieID, reaction)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return ErrResourceNotFound
+		}
+		return errors.Join(ErrInternal, err)
+	}
+
+	return nil
+}
+
+// NextMovie picks the closest to the room preferences movie participant hasn't reacted to yet.
+// Once the deck is over participant is marked as voted and ErrDeckExhausted is returned
+func (u *Usecase) NextMovie(ctx context.Context, code string, userID uuid.UUID) (*model.MovieMeta, error) {
+	movie, err := u.nextMovie(ctx, code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.presign(ctx, movie); err != nil {
+		return nil, err
+	}
+	return movie, nil
+}
+
+func (u *Usecase) nextMovie(ctx context.Context, code string, userID uuid.UUID) (*model.MovieMeta, error) {
+	roomID, err := u.RoomUUIDer.UUIDByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	shortlisted, err := u.isShortlist(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if shortlisted {
+		return u.nextShortlistMovie(ctx, roomID, userID)
+	}
+
+	count, err := u.VoteRepository.ReactionsCount(ctx, roomID, userID)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+
+	if count >= u.deckSize {
+		return nil, u.finishDeck(ctx, roomID, userID)
+	}
+
+	embeddings, err := u.VoteRepository.ParticipantsEmbeddings(ctx, roomID)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+
+	if len(embeddings) == 0 {
+		return nil, ErrResourceNotFound
+	}
+
+	if count%watchlistTurn == 0 {
+		movie, err := u.VoteRepository.NextWatchlistMovie(ctx, roomID, userID)
+		if err == nil {
+			return movie, nil
+		}
+		if !errors.Is(err, ErrResourceNotFound) {
+			return nil, errors.Join(ErrInternal, err)
+		}
+	}
+
+	if u.candidateMode != model.SearchModeVector {
+		return u.nextTextMovie(ctx, roomID, userID, u.averageEmbeddings(embeddings))
+	}
+
+	movie, err := u.VoteRepository.NextMovie(ctx, roomID, userID, u.averageEmbeddings(embeddings))
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, u.finishDeck(ctx, roomID, userID)
+		}
+		return nil, errors.Join(ErrInternal, err)
+	}
+
+	return movie, nil
+}
+
+// Text modes rank candidates as VotingBatch does, the best one participant hasn't reacted to is dealt
+func (u *Usecase) nextTextMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, avgEmbedding model.Embedding) (*model.MovieMeta, error) {
+	reacted, err := u.VoteRepository.Reacted(ctx, roomID, userID)
+	if err != nil {
+		return nil, errors.Join(ErrInternal, err)
+	}
+
+	// One candidate more than reacted movies, so some is left unless the catalog is over
+	movies, err := u.textCandidates(ctx, len(reacted)+1, roomID, avgEmbedding)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(reacted))
+	for _, id := range reacted {
+		seen[id] = true
+	}
+	for _, m := range movies {
+		if !seen[m.ID] {
+			return m, nil
+		}
+	}
+	return nil, u.finishDeck(ctx, roomID, userID)
+}
+
+// The same lifetime as of posters served by movie usecase
+const posterLinkTTL = 10 * time.Minute
+
+func (u *Usecase) presign(ctx context.Context, movies ...*model.MovieMeta) error {
+	if u.posters == nil {
+		return nil
+	}
+	for _, m := range movies {
+		if m == nil || m.PosterLink == "" {
+			continue
+		}
+		link, err := u.posters.GeneratePresignedURL(ctx, m.PosterLink, posterLinkTTL)
+		if err != nil {
+			return errors.Join(ErrInternal, err)
+		}
+		m.PosterLink = link
+	}
+	return nil
+}
+
+// isShortlist reads mode of room, shortlist room never falls back to recommendations
+func (u *Usecase) isShortlist(ctx context.Context, roomID uuid.UUID) (bool, error) {
+	mode, err := u.VoteRepository.RoomMode(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return false, ErrResourceNotFound
+		}
+		return false, errors.Join(ErrInternal, err)
+	}
+	return mode == model.ModeShortlist, nil
+}
+
+// Deck of shortlist room is the whole shortlist, regardless of deck size
+func (u *Usecase) nextShortlistMovie(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*model.MovieMeta, error) {
+	movie, err := u.VoteRepository.NextShortlistMovie(ctx, roomID, userID)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, u.finishDeck(ctx, roomID, userID)
+		}
+		return nil, errors.Join(ErrInternal, err)
+	}
+	return movie, nil
+}
+
+func (u *Usecase) finishDeck(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	if err := u.VoteRepository.MarkVoted(ctx, roomID, userID); err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return ErrResourceNotFound
+		}
+		return errors.Join(ErrInternal, err)
+	}
+	return ErrDeckExhausted
+}
@@ -9,11 +9,13 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/humanbelnik/kinoswap/core/internal/model"
+	mocks_poster "github.com/humanbelnik/kinoswap/core/internal/usecase/vote/mocks/vote/poster"
 	mocks_repo "github.com/humanbelnik/kinoswap/core/internal/usecase/vote/mocks/vote/repository"
 	mocks_room "github.com/humanbelnik/kinoswap/core/internal/usecase/vote/mocks/vote/roomuc"
 	"github.com/ozontech/allure-go/pkg/framework/provider"
 	"github.com/ozontech/allure-go/pkg/framework/suite"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type UsecaseVoteUnitSuite struct {
@@ -33,11 +35,13 @@ func initResources(t provider.T) *resources {
 	return &resources{
 		mockRepo:   repo,
 		mockRoomUC: roomUC,
-		usecase:    New(repo, roomUC),
+		usecase:    New(repo, roomUC, WithDeckSize(testDeckSize)),
 		ctx:        context.Background(),
 	}
 }
 
+const testDeckSize = 5
+
 func validRoomID() uuid.UUID {
 	return uuid.New()
 }
@@ -103,6 +107,8 @@ func validResults() []*model.Result {
 func (suite *UsecaseVoteUnitSuite) TestVotingBatch(t provider.T) {
 	t.Parallel()
 
+	shortlist := validMovieMetas(3)
+
 	testCases := []struct {
 		name           string
 		setupMocks     func(r *resources, code string, roomID uuid.UUID)
@@ -113,11 +119,32 @@ func (suite *UsecaseVoteUnitSuite) TestVotingBatch(t provider.T) {
 			name: "Should return error when repository fails",
 			setupMocks: func(r *resources, code string, roomID uuid.UUID) {
 				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
 				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(nil, ErrInternal).Once()
 			},
 			expectError:    true,
 			expectedMovies: nil,
 		},
+		{
+			name: "Should return whole shortlist without preferences",
+			setupMocks: func(r *resources, code string, roomID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeShortlist, nil).Once()
+				r.mockRepo.On("Shortlist", r.ctx, roomID).Return(shortlist, nil).Once()
+			},
+			expectError:    false,
+			expectedMovies: shortlist,
+		},
+		{
+			name: "Should not recommend movies when none of shortlist is ready",
+			setupMocks: func(r *resources, code string, roomID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeShortlist, nil).Once()
+				r.mockRepo.On("Shortlist", r.ctx, roomID).Return([]*model.MovieMeta{}, nil).Once()
+			},
+			expectError:    true,
+			expectedMovies: nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -142,6 +169,65 @@ func (suite *UsecaseVoteUnitSuite) TestVotingBatch(t provider.T) {
 	}
 }
 
+func (suite *UsecaseVoteUnitSuite) TestVotingBatchCandidateModes(t provider.T) {
+	t.Parallel()
+
+	movies := validMovieMetas(4)
+	lexical := []*model.MovieMeta{movies[0], movies[1]}
+	vector := []*model.MovieMeta{movies[2], movies[1], movies[3]}
+
+	testCases := []struct {
+		name           string
+		mode           model.SearchMode
+		texts          []string
+		expectedMovies []*model.MovieMeta
+	}{
+		{
+			name:           "Should rank movies found both ways first in hybrid mode",
+			mode:           model.SearchModeHybrid,
+			texts:          []string{"heist", "space"},
+			expectedMovies: []*model.MovieMeta{movies[1], movies[0], movies[2]},
+		},
+		{
+			name:           "Should top up lexical matches with vector ones in lexical mode",
+			mode:           model.SearchModeLexical,
+			texts:          []string{"heist", "space"},
+			expectedMovies: []*model.MovieMeta{movies[0], movies[1], movies[2]},
+		},
+		{
+			name:           "Should fall back to vector ranking without preference texts",
+			mode:           model.SearchModeHybrid,
+			expectedMovies: []*model.MovieMeta{movies[2], movies[1], movies[3]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			repo := mocks_repo.NewVoteRepository(t)
+			roomUC := mocks_room.NewRoomUUIDer(t)
+			usecase := New(repo, roomUC, WithCandidateMode(tc.mode))
+			ctx := context.Background()
+			roomID := validRoomID()
+
+			roomUC.On("UUIDByCode", ctx, validCode()).Return(roomID, nil).Once()
+			repo.On("RoomMode", ctx, roomID).Return(model.ModeRecommend, nil).Once()
+			repo.On("ParticipantsEmbeddings", ctx, roomID).Return(validEmbeddings(2), nil).Once()
+			repo.On("ParticipantsPreferences", ctx, roomID).Return(tc.texts, nil).Once()
+			if len(tc.texts) > 0 {
+				repo.On("LexicalMovies", ctx, roomID, "heist space", 3*hybridOverfetch).Return(lexical, nil).Once()
+			}
+			repo.On("SimilarMovies", ctx, roomID, mock.Anything, 3*hybridOverfetch).Return(vector, nil).Once()
+			repo.On("WatchlistMovies", ctx, roomID, 3).Return([]*model.MovieMeta{}, nil).Once()
+
+			batch, err := usecase.VotingBatch(ctx, 3, validCode())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMovies, batch)
+		})
+	}
+}
+
 func (suite *UsecaseVoteUnitSuite) TestResults(t provider.T) {
 	t.Parallel()
 
@@ -285,6 +371,335 @@ func (suite *UsecaseVoteUnitSuite) TestIsAllReady(t provider.T) {
 	}
 }
 
+func (suite *UsecaseVoteUnitSuite) TestReact(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		reaction    model.Reaction
+		setupMocks  func(r *resources, code string, roomID, userID, movieID uuid.UUID)
+		expectedErr error
+	}{
+		{
+			name:     "Should persist reaction successfully",
+			reaction: model.PassReaction,
+			setupMocks: func(r *resources, code string, roomID, userID, movieID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("AddReaction", r.ctx, roomID, userID, movieID, model.PassReaction).Return(nil).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "Should reject unknown reaction value",
+			reaction:    2,
+			setupMocks:  func(r *resources, code string, roomID, userID, movieID uuid.UUID) {},
+			expectedErr: ErrInvalidReaction,
+		},
+		{
+			name:     "Should return not found when participant is missing",
+			reaction: model.SmashReaction,
+			setupMocks: func(r *resources, code string, roomID, userID, movieID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("AddReaction", r.ctx, roomID, userID, movieID, model.SmashReaction).Return(ErrResourceNotFound).Once()
+			},
+			expectedErr: ErrResourceNotFound,
+		},
+		{
+			name:     "Should return internal error when repository fails",
+			reaction: model.PassReaction,
+			setupMocks: func(r *resources, code string, roomID, userID, movieID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("AddReaction", r.ctx, roomID, userID, movieID, model.PassReaction).Return(assert.AnError).Once()
+			},
+			expectedErr: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			code := validCode()
+			roomID := validRoomID()
+			userID := validUserID()
+			movieID := uuid.New()
+			tc.setupMocks(r, code, roomID, userID, movieID)
+
+			err := r.usecase.React(r.ctx, code, userID, movieID, tc.reaction)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			r.mockRepo.AssertExpectations(t)
+			r.mockRoomUC.AssertExpectations(t)
+		})
+	}
+}
+
+func (suite *UsecaseVoteUnitSuite) TestVotingBatchWatchlist(t provider.T) {
+	t.Parallel()
+
+	movies := validMovieMetas(5)
+	similar := []*model.MovieMeta{movies[0], movies[1], movies[2]}
+
+	testCases := []struct {
+		name           string
+		wanted         []*model.MovieMeta
+		expectedMovies []*model.MovieMeta
+	}{
+		{
+			name:           "Should keep candidates when nobody uploaded watchlist",
+			wanted:         []*model.MovieMeta{},
+			expectedMovies: similar,
+		},
+		{
+			name:           "Should rank wanted candidates first",
+			wanted:         []*model.MovieMeta{movies[2]},
+			expectedMovies: []*model.MovieMeta{movies[2], movies[0], movies[1]},
+		},
+		{
+			name:           "Should let wanted movies take turns with candidates",
+			wanted:         []*model.MovieMeta{movies[3], movies[4]},
+			expectedMovies: []*model.MovieMeta{movies[0], movies[3], movies[1]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			roomID := validRoomID()
+
+			r.mockRoomUC.On("UUIDByCode", r.ctx, validCode()).Return(roomID, nil).Once()
+			r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+			r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+			r.mockRepo.On("SimilarMovies", r.ctx, roomID, mock.Anything, 3).Return(similar, nil).Once()
+			r.mockRepo.On("WatchlistMovies", r.ctx, roomID, 3).Return(tc.wanted, nil).Once()
+
+			batch, err := r.usecase.VotingBatch(r.ctx, 3, validCode())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMovies, batch)
+		})
+	}
+}
+
+func (suite *UsecaseVoteUnitSuite) TestNextMovie(t provider.T) {
+	t.Parallel()
+
+	movie := validMovieMetas(1)[0]
+	shortlist := validMovieMetas(2)
+	// Fused in hybrid mode as movies[1], movies[0], movies[2], movies[3]
+	movies := validMovieMetas(4)
+	lexical := []*model.MovieMeta{movies[0], movies[1]}
+	vector := []*model.MovieMeta{movies[2], movies[1], movies[3]}
+
+	testCases := []struct {
+		name          string
+		mode          model.SearchMode
+		setupMocks    func(r *resources, code string, roomID, userID uuid.UUID)
+		expectedErr   error
+		expectedMovie *model.MovieMeta
+	}{
+		{
+			name: "Should return closest movie participant hasn't seen",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(3, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+				r.mockRepo.On("NextMovie", r.ctx, roomID, userID, mock.Anything).Return(movie, nil).Once()
+			},
+			expectedErr:   nil,
+			expectedMovie: movie,
+		},
+		{
+			name: "Should finish deck when participant reached deck size",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(testDeckSize, nil).Once()
+				r.mockRepo.On("MarkVoted", r.ctx, roomID, userID).Return(nil).Once()
+			},
+			expectedErr: ErrDeckExhausted,
+		},
+		{
+			name: "Should finish deck when catalog is over",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(1, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(1), nil).Once()
+				r.mockRepo.On("NextMovie", r.ctx, roomID, userID, mock.Anything).Return(nil, ErrResourceNotFound).Once()
+				r.mockRepo.On("MarkVoted", r.ctx, roomID, userID).Return(nil).Once()
+			},
+			expectedErr: ErrDeckExhausted,
+		},
+		{
+			name: "Should return not found when room has no preferences",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(0, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return([]model.Embedding{}, nil).Once()
+			},
+			expectedErr: ErrResourceNotFound,
+		},
+		{
+			name: "Should return internal error when marking as voted fails",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(testDeckSize, nil).Once()
+				r.mockRepo.On("MarkVoted", r.ctx, roomID, userID).Return(assert.AnError).Once()
+			},
+			expectedErr: ErrInternal,
+		},
+		{
+			name: "Should deal watchlisted movie every second card",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(2, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+				r.mockRepo.On("NextWatchlistMovie", r.ctx, roomID, userID).Return(shortlist[0], nil).Once()
+			},
+			expectedErr:   nil,
+			expectedMovie: shortlist[0],
+		},
+		{
+			name: "Should deal closest movie once watchlists are seen",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(2, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+				r.mockRepo.On("NextWatchlistMovie", r.ctx, roomID, userID).Return(nil, ErrResourceNotFound).Once()
+				r.mockRepo.On("NextMovie", r.ctx, roomID, userID, mock.Anything).Return(movie, nil).Once()
+			},
+			expectedErr:   nil,
+			expectedMovie: movie,
+		},
+		{
+			name: "Should return next shortlisted movie regardless of deck size",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeShortlist, nil).Once()
+				r.mockRepo.On("NextShortlistMovie", r.ctx, roomID, userID).Return(shortlist[1], nil).Once()
+			},
+			expectedErr:   nil,
+			expectedMovie: shortlist[1],
+		},
+		{
+			name: "Should finish deck when shortlist is over",
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeShortlist, nil).Once()
+				r.mockRepo.On("NextShortlistMovie", r.ctx, roomID, userID).Return(nil, ErrResourceNotFound).Once()
+				r.mockRepo.On("MarkVoted", r.ctx, roomID, userID).Return(nil).Once()
+			},
+			expectedErr: ErrDeckExhausted,
+		},
+		{
+			name: "Should deal the best fused movie participant hasn't reacted to in hybrid mode",
+			mode: model.SearchModeHybrid,
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(1, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+				r.mockRepo.On("Reacted", r.ctx, roomID, userID).Return([]uuid.UUID{movies[1].ID}, nil).Once()
+				r.mockRepo.On("ParticipantsPreferences", r.ctx, roomID).Return([]string{"heist"}, nil).Once()
+				r.mockRepo.On("LexicalMovies", r.ctx, roomID, "heist", 2*hybridOverfetch).Return(lexical, nil).Once()
+				r.mockRepo.On("SimilarMovies", r.ctx, roomID, mock.Anything, 2*hybridOverfetch).Return(vector, nil).Once()
+			},
+			expectedErr:   nil,
+			expectedMovie: movies[0],
+		},
+		{
+			name: "Should finish deck when hybrid candidates are over",
+			mode: model.SearchModeHybrid,
+			setupMocks: func(r *resources, code string, roomID, userID uuid.UUID) {
+				reacted := []uuid.UUID{movies[0].ID, movies[1].ID, movies[2].ID, movies[3].ID}
+				r.mockRoomUC.On("UUIDByCode", r.ctx, code).Return(roomID, nil).Once()
+				r.mockRepo.On("RoomMode", r.ctx, roomID).Return(model.ModeRecommend, nil).Once()
+				r.mockRepo.On("ReactionsCount", r.ctx, roomID, userID).Return(3, nil).Once()
+				r.mockRepo.On("ParticipantsEmbeddings", r.ctx, roomID).Return(validEmbeddings(2), nil).Once()
+				r.mockRepo.On("Reacted", r.ctx, roomID, userID).Return(reacted, nil).Once()
+				r.mockRepo.On("ParticipantsPreferences", r.ctx, roomID).Return([]string{"heist"}, nil).Once()
+				r.mockRepo.On("LexicalMovies", r.ctx, roomID, "heist", 5*hybridOverfetch).Return(lexical, nil).Once()
+				r.mockRepo.On("SimilarMovies", r.ctx, roomID, mock.Anything, 5*hybridOverfetch).Return(vector, nil).Once()
+				r.mockRepo.On("MarkVoted", r.ctx, roomID, userID).Return(nil).Once()
+			},
+			expectedErr: ErrDeckExhausted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			if tc.mode != "" {
+				r.usecase = New(r.mockRepo, r.mockRoomUC, WithDeckSize(testDeckSize), WithCandidateMode(tc.mode))
+			}
+			code := validCode()
+			roomID := validRoomID()
+			userID := validUserID()
+			tc.setupMocks(r, code, roomID, userID)
+
+			got, err := r.usecase.NextMovie(r.ctx, code, userID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedMovie, got)
+			}
+			r.mockRepo.AssertExpectations(t)
+			r.mockRoomUC.AssertExpectations(t)
+		})
+	}
+}
+
+func (suite *UsecaseVoteUnitSuite) TestPresignedPosters(t provider.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	roomID := validRoomID()
+	userID := validUserID()
+	shortlist := validMovieMetas(2)
+	shortlist[1].PosterLink = ""
+
+	repo := mocks_repo.NewVoteRepository(t)
+	roomUC := mocks_room.NewRoomUUIDer(t)
+	posters := mocks_poster.NewPosterPresigner(t)
+	usecase := New(repo, roomUC, WithPosterPresigner(posters))
+
+	roomUC.On("UUIDByCode", ctx, validCode()).Return(roomID, nil).Times(3)
+	repo.On("RoomMode", ctx, roomID).Return(model.ModeShortlist, nil).Times(2)
+	repo.On("Shortlist", ctx, roomID).Return(shortlist, nil).Once()
+	next := validMovieMeta()
+	repo.On("NextShortlistMovie", ctx, roomID, userID).Return(&next, nil).Once()
+	posters.On("GeneratePresignedURL", ctx, "link", posterLinkTTL).Return("https://signed", nil).Twice()
+
+	batch, err := usecase.VotingBatch(ctx, 2, validCode())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://signed", batch[0].PosterLink)
+	assert.Empty(t, batch[1].PosterLink, "movie without poster isn't presigned")
+
+	movie, err := usecase.NextMovie(ctx, validCode(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://signed", movie.PosterLink)
+
+	repo.On("Results", ctx, roomID).Return([]*model.Result{{MM: validMovieMeta()}}, nil).Once()
+	posters.On("GeneratePresignedURL", ctx, "link", posterLinkTTL).Return("", assert.AnError).Once()
+	_, err = usecase.Results(ctx, validCode())
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
 func TestUnitSuite(t *testing.T) {
 	suite.RunSuite(t, new(UsecaseVoteUnitSuite))
 }
@@ -0,0 +1,79 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/humanbelnik/kinoswap/core/internal/model"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// Candidates provides a mock function with given fields: ctx, entries, perEntry
+func (_m *Repository) Candidates(ctx context.Context, entries []model.WatchlistEntry, perEntry int) ([][]model.MovieMeta, error) {
+	ret := _m.Called(ctx, entries, perEntry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Candidates")
+	}
+
+	var r0 [][]model.MovieMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []model.WatchlistEntry, int) ([][]model.MovieMeta, error)); ok {
+		return rf(ctx, entries, perEntry)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []model.WatchlistEntry, int) [][]model.MovieMeta); ok {
+		r0 = rf(ctx, entries, perEntry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]model.MovieMeta)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []model.WatchlistEntry, int) error); ok {
+		r1 = rf(ctx, entries, perEntry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: ctx, code, userID, kind, movieIDs
+func (_m *Repository) Replace(ctx context.Context, code string, userID uuid.UUID, kind model.WatchlistKind, movieIDs []uuid.UUID) error {
+	ret := _m.Called(ctx, code, userID, kind, movieIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replace")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID, model.WatchlistKind, []uuid.UUID) error); ok {
+		r0 = rf(ctx, code, userID, kind, movieIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
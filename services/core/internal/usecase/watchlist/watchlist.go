@@ -0,0 +1,135 @@
+package usecase_watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/watchlist_import"
+)
+
+var (
+	ErrInternal         = errors.New("internal error")
+	ErrResourceNotFound = errors.New("no such resource")
+	ErrInvalidWatchlist = errors.New("invalid watchlist")
+)
+
+// Exports of the most devoted users are a few thousand rows
+const MaxEntries = 10000
+
+// Amount of similar titles fetched for every entry, matcher picks one of them
+const candidatesPerEntry = 5
+
+//go:generate mockery --name=Repository --output=./mocks/watchlist/repository --filename=repository.go
+type Repository interface {
+	// Candidates returns ready movies every entry may refer to, aligned with entries.
+	// Those are movies with the same IMDb id and the most similar titles.
+	Candidates(ctx context.Context, entries []model.WatchlistEntry, perEntry int) ([][]model.MovieMeta, error)
+	// Replace stores movies instead of participant's previous list of that kind
+	Replace(ctx context.Context, code string, userID uuid.UUID, kind model.WatchlistKind, movieIDs []uuid.UUID) error
+}
+
+type Usecase struct {
+	Repository Repository
+}
+
+func New(repository Repository) *Usecase {
+	return &Usecase{Repository: repository}
+}
+
+type Unmatched struct {
+	Line   int
+	Title  string
+	Year   int
+	Reason string
+}
+
+type Report struct {
+	Total     int
+	Matched   int
+	Unmatched []Unmatched
+}
+
+func ParseKind(s string) (model.WatchlistKind, error) {
+	switch kind := model.WatchlistKind(s); kind {
+	case model.WatchlistWant, model.WatchlistWatched:
+		return kind, nil
+	}
+	return "", fmt.Errorf("%w: unknown list %q", ErrInvalidWatchlist, s)
+}
+
+// Import matches export against catalog and stores it as participant's list of the kind.
+// Previous list of the kind is replaced, so re-uploading updated export is fine.
+func (u *Usecase) Import(ctx context.Context, code string, userID uuid.UUID, kind model.WatchlistKind, export watchlist_import.Export) (Report, error) {
+	if _, err := ParseKind(string(kind)); err != nil {
+		return Report{}, err
+	}
+	if len(export.Entries) > MaxEntries {
+		return Report{}, fmt.Errorf("%w: more than %d entries", ErrInvalidWatchlist, MaxEntries)
+	}
+
+	report := Report{Total: len(export.Entries)}
+	unmatched := func(entry model.WatchlistEntry, reason string) {
+		report.Unmatched = append(report.Unmatched, Unmatched{
+			Line:   entry.Line,
+			Title:  entry.Title,
+			Year:   entry.Year,
+			Reason: reason,
+		})
+	}
+
+	var valid []model.WatchlistEntry
+	for _, entry := range export.Entries {
+		if entry.Err != nil {
+			unmatched(entry, entry.Err.Error())
+			continue
+		}
+		valid = append(valid, entry)
+	}
+
+	var candidates [][]model.MovieMeta
+	if len(valid) > 0 {
+		var err error
+		candidates, err = u.Repository.Candidates(ctx, valid, candidatesPerEntry)
+		if err != nil {
+			return Report{}, errors.Join(ErrInternal, err)
+		}
+	}
+
+	var (
+		movieIDs []uuid.UUID
+		seen     = make(map[uuid.UUID]bool)
+	)
+	for i, entry := range valid {
+		var movie model.MovieMeta
+		ok := i < len(candidates)
+		if ok {
+			movie, ok = watchlist_import.Match(entry, candidates[i])
+		}
+		if !ok {
+			unmatched(entry, "not found in catalog")
+			continue
+		}
+
+		report.Matched++
+		if !seen[movie.ID] {
+			seen[movie.ID] = true
+			movieIDs = append(movieIDs, movie.ID)
+		}
+	}
+
+	if err := u.Repository.Replace(ctx, code, userID, kind, movieIDs); err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return Report{}, ErrResourceNotFound
+		}
+		return Report{}, errors.Join(ErrInternal, err)
+	}
+
+	slices.SortFunc(report.Unmatched, func(a, b Unmatched) int {
+		return a.Line - b.Line
+	})
+	return report, nil
+}
@@ -0,0 +1,177 @@
+//go:build !integration
+// +build !integration
+
+package usecase_watchlist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/humanbelnik/kinoswap/core/internal/model"
+	"github.com/humanbelnik/kinoswap/core/internal/service/watchlist_import"
+	repo_mocks "github.com/humanbelnik/kinoswap/core/internal/usecase/watchlist/mocks/watchlist/repository"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type UsecaseWatchlistUnitSuite struct {
+	suite.Suite
+}
+
+type resources struct {
+	usecase *Usecase
+	repo    *repo_mocks.Repository
+	ctx     context.Context
+}
+
+func initResources(t provider.T) *resources {
+	repo := repo_mocks.NewRepository(t)
+	return &resources{
+		usecase: New(repo),
+		repo:    repo,
+		ctx:     context.Background(),
+	}
+}
+
+func validCode() string {
+	return "123456"
+}
+
+func (suite *UsecaseWatchlistUnitSuite) TestImport(t provider.T) {
+	t.Parallel()
+
+	heat := model.MovieMeta{ID: uuid.New(), Title: "Heat", Year: 1995}
+	alien := model.MovieMeta{ID: uuid.New(), Title: "Alien", Year: 1979, ImdbID: "tt0078748"}
+	aliens := model.MovieMeta{ID: uuid.New(), Title: "Aliens", Year: 1986}
+
+	export := watchlist_import.Export{
+		Source: watchlist_import.SourceIMDb,
+		Entries: []model.WatchlistEntry{
+			{Line: 2, Title: "Heat", Year: 1995},
+			{Line: 3, Title: "Twin Peaks", Err: watchlist_import.ErrNotMovie},
+			{Line: 4, Title: "Le Huitième Passager", Year: 1979, ImdbID: "tt0078748"},
+			{Line: 5, Title: "Unknown Indie", Year: 2023},
+			{Line: 6, Title: "Heat", Year: 1995},
+		},
+	}
+	valid := []model.WatchlistEntry{export.Entries[0], export.Entries[2], export.Entries[3], export.Entries[4]}
+
+	testCases := []struct {
+		name           string
+		setupMocks     func(r *resources, userID uuid.UUID)
+		expectedReport Report
+		expectedError  error
+	}{
+		{
+			name: "Should store matched movies once and report the rest in file order",
+			setupMocks: func(r *resources, userID uuid.UUID) {
+				r.repo.On("Candidates", r.ctx, valid, candidatesPerEntry).Return([][]model.MovieMeta{
+					{heat},
+					{aliens, alien},
+					{heat},
+					{heat},
+				}, nil).Once()
+				r.repo.On("Replace", r.ctx, validCode(), userID, model.WatchlistWatched, []uuid.UUID{heat.ID, alien.ID}).Return(nil).Once()
+			},
+			expectedReport: Report{
+				Total:   5,
+				Matched: 3,
+				Unmatched: []Unmatched{
+					{Line: 3, Title: "Twin Peaks", Reason: watchlist_import.ErrNotMovie.Error()},
+					{Line: 5, Title: "Unknown Indie", Year: 2023, Reason: "not found in catalog"},
+				},
+			},
+		},
+		{
+			name: "Should return not found for stranger of the room",
+			setupMocks: func(r *resources, userID uuid.UUID) {
+				r.repo.On("Candidates", r.ctx, valid, candidatesPerEntry).Return([][]model.MovieMeta{{heat}, {alien}, {}, {heat}}, nil).Once()
+				r.repo.On("Replace", r.ctx, validCode(), userID, model.WatchlistWatched, mock.Anything).Return(ErrResourceNotFound).Once()
+			},
+			expectedError: ErrResourceNotFound,
+		},
+		{
+			name: "Should wrap repository failure",
+			setupMocks: func(r *resources, userID uuid.UUID) {
+				r.repo.On("Candidates", r.ctx, valid, candidatesPerEntry).Return(nil, errors.New("connection refused")).Once()
+			},
+			expectedError: ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+			userID := uuid.New()
+			tc.setupMocks(r, userID)
+
+			report, err := r.usecase.Import(r.ctx, validCode(), userID, model.WatchlistWatched, export)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedReport, report)
+			}
+			r.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func (suite *UsecaseWatchlistUnitSuite) TestImportWithoutMatches(t provider.T) {
+	t.Parallel()
+
+	r := initResources(t)
+	userID := uuid.New()
+	export := watchlist_import.Export{Entries: []model.WatchlistEntry{
+		{Line: 2, Err: watchlist_import.ErrInvalidEntry},
+	}}
+	r.repo.On("Replace", r.ctx, validCode(), userID, model.WatchlistWant, []uuid.UUID(nil)).Return(nil).Once()
+
+	report, err := r.usecase.Import(r.ctx, validCode(), userID, model.WatchlistWant, export)
+
+	assert.NoError(t, err, "previous list is cleared")
+	assert.Equal(t, 1, report.Total)
+	assert.Len(t, report.Unmatched, 1)
+	r.repo.AssertNotCalled(t, "Candidates", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UsecaseWatchlistUnitSuite) TestImportInvalid(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		kind   model.WatchlistKind
+		export watchlist_import.Export
+	}{
+		{
+			name: "Should refuse unknown list",
+			kind: model.WatchlistKind("FAVOURITES"),
+		},
+		{
+			name:   "Should refuse too long export",
+			kind:   model.WatchlistWant,
+			export: watchlist_import.Export{Entries: make([]model.WatchlistEntry, MaxEntries+1)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+			r := initResources(t)
+
+			_, err := r.usecase.Import(r.ctx, validCode(), uuid.New(), tc.kind, tc.export)
+
+			assert.ErrorIs(t, err, ErrInvalidWatchlist)
+		})
+	}
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(UsecaseWatchlistUnitSuite))
+}
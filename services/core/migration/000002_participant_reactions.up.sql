@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS participant_reactions (
+    participant_id UUID NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    reaction INT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (participant_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS participant_reactions_room_idx ON participant_reactions (room_id);
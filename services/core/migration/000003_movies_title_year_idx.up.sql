@@ -0,0 +1,2 @@
+-- Catalog import treats movies with the same title and year as duplicates
+CREATE INDEX IF NOT EXISTS movies_title_year_idx ON movies (lower(title), year);
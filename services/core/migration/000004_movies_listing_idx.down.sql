@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movies_rating_idx;
+DROP INDEX IF EXISTS movies_year_idx;
+DROP INDEX IF EXISTS movies_genres_idx;
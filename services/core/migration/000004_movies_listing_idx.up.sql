@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS movies_genres_idx ON movies USING GIN (genres);
+CREATE INDEX IF NOT EXISTS movies_year_idx ON movies (year);
+CREATE INDEX IF NOT EXISTS movies_rating_idx ON movies (rating);
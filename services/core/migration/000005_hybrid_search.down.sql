@@ -0,0 +1,3 @@
+ALTER TABLE participants DROP COLUMN IF EXISTS preference_text;
+DROP INDEX IF EXISTS movies_search_tsv_idx;
+ALTER TABLE movies DROP COLUMN IF EXISTS search_tsv;
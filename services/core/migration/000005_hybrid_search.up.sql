@@ -0,0 +1,11 @@
+-- Title matches outweigh overview ones
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_tsv tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(overview, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS movies_search_tsv_idx ON movies USING GIN (search_tsv);
+
+-- Raw preference is the lexical query of voting candidates
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS preference_text TEXT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS movie_neighbours;
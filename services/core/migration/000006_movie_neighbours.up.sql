@@ -0,0 +1,10 @@
+-- Precomputed "more like this" lists, rebuilt by the neighbours refresher
+CREATE TABLE IF NOT EXISTS movie_neighbours (
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    neighbour_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    rank INT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (movie_id, neighbour_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_neighbours_rank_idx ON movie_neighbours (movie_id, rank);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reembed_jobs;
+ALTER TABLE movies DROP COLUMN IF EXISTS movie_vector_next_model;
+ALTER TABLE movies DROP COLUMN IF EXISTS movie_vector_next;
+ALTER TABLE movies DROP COLUMN IF EXISTS movie_vector_model;
@@ -0,0 +1,26 @@
+-- Model that produced the vector, see EmbeddingModel.ID
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS movie_vector_model TEXT;
+
+-- Existing vectors were built by the model shipped with embedder so far
+UPDATE movies SET movie_vector_model = 'sentence-transformers/all-MiniLM-L6-v2'
+WHERE movie_vector IS NOT NULL AND movie_vector_model IS NULL;
+
+-- Re-embed job builds vectors here and moves them to movie_vector once all are ready
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS movie_vector_next VECTOR(384);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS movie_vector_next_model TEXT;
+
+CREATE TABLE IF NOT EXISTS reembed_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    model TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    last_id UUID,
+    done INT NOT NULL DEFAULT 0,
+    total INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+-- At most one job is running
+CREATE UNIQUE INDEX IF NOT EXISTS reembed_jobs_running_idx ON reembed_jobs (status) WHERE status = 'running';
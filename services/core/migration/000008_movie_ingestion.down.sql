@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ingestion_jobs;
+ALTER TABLE movies DROP COLUMN IF EXISTS status;
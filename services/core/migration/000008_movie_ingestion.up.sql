@@ -0,0 +1,21 @@
+-- PENDING movies wait for ingestion worker, only READY ones are voted for
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'READY';
+
+CREATE TABLE IF NOT EXISTS ingestion_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    -- Kept until poster is stored
+    poster BYTEA,
+    -- queued, running, done or dead
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- Running job is picked up again once lease expires, worker must have crashed
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ingestion_jobs_due_idx ON ingestion_jobs (next_run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS ingestion_jobs_movie_idx ON ingestion_jobs (movie_id);
@@ -0,0 +1,38 @@
+-- Movie writes spanning database and poster storage, see model.Saga
+CREATE TABLE IF NOT EXISTS movie_sagas (
+    id UUID PRIMARY KEY,
+    -- No reference, delete sagas outlive their movies
+    movie_id UUID NOT NULL,
+    -- create, update or delete
+    kind TEXT NOT NULL,
+    -- running, compensating, done or compensated
+    status TEXT NOT NULL DEFAULT 'running',
+    steps TEXT[] NOT NULL,
+    step INT NOT NULL DEFAULT 0,
+    movie JSONB NOT NULL,
+    prev JSONB,
+    backup BYTEA,
+    attempts INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    -- Extended on every step, unfinished saga is recovered once it expires
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_sagas_unfinished_idx ON movie_sagas (locked_until) WHERE status IN ('running', 'compensating');
+
+CREATE TABLE IF NOT EXISTS movie_saga_steps (
+    id BIGSERIAL PRIMARY KEY,
+    saga_id UUID NOT NULL REFERENCES movie_sagas(id) ON DELETE CASCADE,
+    step INT NOT NULL,
+    name TEXT NOT NULL,
+    -- exec or compensate
+    action TEXT NOT NULL,
+    -- started, done or failed
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_saga_steps_saga_idx ON movie_saga_steps (saga_id, id);
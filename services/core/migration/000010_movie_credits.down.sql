@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS movie_people;
+DROP TABLE IF EXISTS people;
+DROP INDEX IF EXISTS movies_runtime_idx;
+DROP INDEX IF EXISTS movies_imdb_id_idx;
+ALTER TABLE movies DROP COLUMN IF EXISTS imdb_id;
+ALTER TABLE movies DROP COLUMN IF EXISTS gross;
+ALTER TABLE movies DROP COLUMN IF EXISTS votes;
+ALTER TABLE movies DROP COLUMN IF EXISTS meta_score;
+ALTER TABLE movies DROP COLUMN IF EXISTS certificate;
+ALTER TABLE movies DROP COLUMN IF EXISTS runtime;
@@ -0,0 +1,31 @@
+-- Zero and empty values stand for unknown
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS runtime INT NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS certificate TEXT NOT NULL DEFAULT '';
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS meta_score INT NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS votes INT NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS gross BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS imdb_id TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS movies_imdb_id_idx ON movies (imdb_id) WHERE imdb_id <> '';
+CREATE INDEX IF NOT EXISTS movies_runtime_idx ON movies (runtime) WHERE runtime > 0;
+
+CREATE TABLE IF NOT EXISTS people (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE
+);
+
+-- Name filters match part of a name case insensitive
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS people_name_trgm_idx ON people USING GIN (lower(name) gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS movie_people (
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    person_id UUID NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+    -- director or cast
+    role TEXT NOT NULL,
+    -- Billing order within role
+    position INT NOT NULL,
+    PRIMARY KEY (movie_id, role, position)
+);
+
+CREATE INDEX IF NOT EXISTS movie_people_person_idx ON movie_people (person_id, role);
@@ -0,0 +1,26 @@
+-- Admin-curated movie sets, rooms may restrict candidates to some of them
+CREATE TABLE IF NOT EXISTS collections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS collection_movies (
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_movies_movie_idx ON collection_movies (movie_id);
+
+-- Room without collections draws from the whole catalog
+CREATE TABLE IF NOT EXISTS room_collections (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE RESTRICT,
+    PRIMARY KEY (room_id, collection_id)
+);
+
+CREATE INDEX IF NOT EXISTS room_collections_collection_idx ON room_collections (collection_id);
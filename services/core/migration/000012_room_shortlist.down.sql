@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movies_title_trgm_idx;
+DROP TABLE IF EXISTS room_shortlist;
+ALTER TABLE rooms DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,14 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'RECOMMEND';
+
+-- Movies owner picked for SHORTLIST room
+CREATE TABLE IF NOT EXISTS room_shortlist (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    PRIMARY KEY (room_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS room_shortlist_position_idx ON room_shortlist (room_id, position);
+
+-- Shortlist titles are fuzzy matched against catalog
+CREATE INDEX IF NOT EXISTS movies_title_trgm_idx ON movies USING gin (lower(title) gin_trgm_ops);
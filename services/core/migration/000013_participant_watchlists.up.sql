@@ -0,0 +1,11 @@
+-- Catalog movies matched from participant's Letterboxd or IMDb export
+CREATE TABLE IF NOT EXISTS participant_watchlists (
+    participant_id UUID NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    -- WATCHLIST boosts the movie, WATCHED excludes it from the room
+    kind TEXT NOT NULL,
+    PRIMARY KEY (participant_id, kind, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS participant_watchlists_room_idx ON participant_watchlists (room_id, kind, movie_id);
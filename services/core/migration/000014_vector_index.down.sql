@@ -0,0 +1 @@
+DROP INDEX IF EXISTS movies_vector_idx;
@@ -0,0 +1,30 @@
+-- Index serving movie ordering by vector distance, it has to match VECTOR_INDEX and VECTOR_METRIC of core.
+-- Kind and metric are read from database settings, hnsw and cosine unless set before migrating, ex.
+--   ALTER DATABASE kinoswap SET kinoswap.vector_index = 'ivfflat';
+--   ALTER DATABASE kinoswap SET kinoswap.vector_metric = 'l2';
+-- To switch later, migrate down to 13 and up again.
+DO $$
+DECLARE
+    kind TEXT := coalesce(nullif(current_setting('kinoswap.vector_index', true), ''), 'hnsw');
+    metric TEXT := coalesce(nullif(current_setting('kinoswap.vector_metric', true), ''), 'cosine');
+    lists INT;
+BEGIN
+    IF metric NOT IN ('cosine', 'l2', 'ip') THEN
+        RAISE EXCEPTION 'unknown vector metric %', metric;
+    END IF;
+
+    CASE kind
+    WHEN 'hnsw' THEN
+        EXECUTE format(
+            'CREATE INDEX IF NOT EXISTS movies_vector_idx ON movies USING hnsw (movie_vector vector_%s_ops) WITH (m = 16, ef_construction = 64)',
+            metric);
+    WHEN 'ivfflat' THEN
+        -- Lists are clustered over existing rows, rows / 1000 is the usual choice up to a million
+        SELECT greatest(count(*) / 1000, 1) INTO lists FROM movies WHERE movie_vector IS NOT NULL;
+        EXECUTE format(
+            'CREATE INDEX IF NOT EXISTS movies_vector_idx ON movies USING ivfflat (movie_vector vector_%s_ops) WITH (lists = %s)',
+            metric, lists);
+    ELSE
+        RAISE EXCEPTION 'unknown vector index %', kind;
+    END CASE;
+END $$;
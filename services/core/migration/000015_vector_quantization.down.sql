@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS movies_vector_quantized_idx;
+
+-- Full precision index as 000014_vector_index creates it
+DO $$
+DECLARE
+    kind TEXT := coalesce(nullif(current_setting('kinoswap.vector_index', true), ''), 'hnsw');
+    metric TEXT := coalesce(nullif(current_setting('kinoswap.vector_metric', true), ''), 'cosine');
+    params TEXT := 'm = 16, ef_construction = 64';
+BEGIN
+    IF kind = 'ivfflat' THEN
+        SELECT format('lists = %s', greatest(count(*) / 1000, 1)) INTO params FROM movies WHERE movie_vector IS NOT NULL;
+    END IF;
+
+    EXECUTE format('CREATE INDEX IF NOT EXISTS movies_vector_idx ON movies USING %s (movie_vector vector_%s_ops) WITH (%s)',
+        kind, metric, params);
+END $$;
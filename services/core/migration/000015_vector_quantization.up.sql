@@ -0,0 +1,36 @@
+-- Optional index over quantized vectors replacing the full precision one, it has to match VECTOR_QUANTIZATION of core.
+-- Table keeps full vectors, core re-ranks candidates of the quantized index by them.
+-- Quantization is read from database settings along with index kind and metric of 000014_vector_index, none by default, ex.
+--   ALTER DATABASE kinoswap SET kinoswap.vector_quantization = 'halfvec';
+-- To switch later, migrate down to 14 and up again.
+DO $$
+DECLARE
+    quantization TEXT := coalesce(nullif(current_setting('kinoswap.vector_quantization', true), ''), 'none');
+    kind TEXT := coalesce(nullif(current_setting('kinoswap.vector_index', true), ''), 'hnsw');
+    metric TEXT := coalesce(nullif(current_setting('kinoswap.vector_metric', true), ''), 'cosine');
+    expr TEXT;
+    opclass TEXT;
+    params TEXT := 'm = 16, ef_construction = 64';
+BEGIN
+    CASE quantization
+    WHEN 'none' THEN
+        RETURN;
+    WHEN 'halfvec' THEN
+        expr := '(movie_vector::halfvec(384))';
+        opclass := format('halfvec_%s_ops', metric);
+    WHEN 'binary' THEN
+        -- Hamming distance whatever the metric, it's used for re-ranking only
+        expr := '(binary_quantize(movie_vector)::bit(384))';
+        opclass := 'bit_hamming_ops';
+    ELSE
+        RAISE EXCEPTION 'unknown vector quantization %', quantization;
+    END CASE;
+
+    IF kind = 'ivfflat' THEN
+        SELECT format('lists = %s', greatest(count(*) / 1000, 1)) INTO params FROM movies WHERE movie_vector IS NOT NULL;
+    END IF;
+
+    EXECUTE format('CREATE INDEX IF NOT EXISTS movies_vector_quantized_idx ON movies USING %s (%s %s) WITH (%s)',
+        kind, expr, opclass, params);
+    DROP INDEX IF EXISTS movies_vector_idx;
+END $$;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS movies_update_changes ON movies;
+DROP TRIGGER IF EXISTS movies_changes ON movies;
+DROP FUNCTION IF EXISTS notify_movie_changes();
@@ -0,0 +1,31 @@
+-- Instances keeping vector index in memory apply changes of movies as soon as they are notified.
+-- Updates notify once vector, status or any field served with the card changes.
+CREATE OR REPLACE FUNCTION notify_movie_changes() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('movie_changes', OLD.id::text);
+    ELSE
+        PERFORM pg_notify('movie_changes', NEW.id::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER movies_changes
+AFTER INSERT OR DELETE ON movies
+FOR EACH ROW EXECUTE FUNCTION notify_movie_changes();
+
+CREATE TRIGGER movies_update_changes
+AFTER UPDATE OF movie_vector, status, title, year, rating, genres, overview, poster_link,
+    runtime, certificate, meta_score, votes, gross, imdb_id ON movies
+FOR EACH ROW
+WHEN (
+    OLD.movie_vector IS DISTINCT FROM NEW.movie_vector OR OLD.status IS DISTINCT FROM NEW.status OR
+    OLD.title IS DISTINCT FROM NEW.title OR OLD.year IS DISTINCT FROM NEW.year OR
+    OLD.rating IS DISTINCT FROM NEW.rating OR OLD.genres IS DISTINCT FROM NEW.genres OR
+    OLD.overview IS DISTINCT FROM NEW.overview OR OLD.poster_link IS DISTINCT FROM NEW.poster_link OR
+    OLD.runtime IS DISTINCT FROM NEW.runtime OR OLD.certificate IS DISTINCT FROM NEW.certificate OR
+    OLD.meta_score IS DISTINCT FROM NEW.meta_score OR OLD.votes IS DISTINCT FROM NEW.votes OR
+    OLD.gross IS DISTINCT FROM NEW.gross OR OLD.imdb_id IS DISTINCT FROM NEW.imdb_id
+)
+EXECUTE FUNCTION notify_movie_changes();
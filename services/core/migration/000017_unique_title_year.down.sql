@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS movies_title_year_key;
+CREATE INDEX IF NOT EXISTS movies_title_year_idx ON movies (lower(title), year);
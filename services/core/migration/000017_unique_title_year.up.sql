@@ -0,0 +1,4 @@
+-- Movies with the same title and year are duplicates, database rejects them so concurrent imports and uploads can't race.
+-- Fails if catalog has duplicates already, they have to be removed by hand first.
+DROP INDEX IF EXISTS movies_title_year_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS movies_title_year_key ON movies (lower(title), year);
@@ -0,0 +1,322 @@
+// Package wsclient is a Go client for the room events stream.
+// Events are decoded into wsproto structs, so consumers switch on types instead of parsing JSON.
+// WebSocket is used by default, SSE and long-polling are there for networks stripping upgrades.
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+)
+
+var (
+	ErrNoHello          = errors.New("server didn't greet with HELLO")
+	ErrUnknownTransport = errors.New("unknown transport")
+)
+
+type Transport string
+
+const (
+	TransportWebSocket Transport = "ws"
+	TransportSSE       Transport = "sse"
+	TransportLongPoll  Transport = "poll"
+)
+
+// ParseTransport is handy for command line flags
+func ParseTransport(s string) (Transport, error) {
+	switch t := Transport(s); t {
+	case TransportWebSocket, TransportSSE, TransportLongPoll:
+		return t, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownTransport, s)
+}
+
+// transport moves envelopes, Client doesn't care how
+type transport interface {
+	// read blocks until next envelope arrives
+	read() (wsproto.Envelope, error)
+	write(wsproto.Envelope) error
+	close() error
+}
+
+type Client struct {
+	transport transport
+	hello     wsproto.Hello
+	events    chan wsproto.Payload
+	closed    atomic.Bool
+
+	errMu sync.Mutex
+	err   error
+}
+
+type options struct {
+	transport  Transport
+	dialer     *websocket.Dialer
+	httpClient *http.Client
+	versions   []int
+	buffer     int
+}
+
+type Option func(*options)
+
+func WithTransport(t Transport) Option {
+	return func(o *options) {
+		o.transport = t
+	}
+}
+
+func WithDialer(d *websocket.Dialer) Option {
+	return func(o *options) {
+		o.dialer = d
+	}
+}
+
+// HTTP client used by SSE and long-poll transports, must not have timeout shorter than a poll
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// Restricts versions offered to server, newest first
+func WithVersions(versions ...int) Option {
+	return func(o *options) {
+		o.versions = versions
+	}
+}
+
+func WithBuffer(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.buffer = n
+		}
+	}
+}
+
+// RoomURL builds WebSocket URL of the room from REST API base URL (ex. http://localhost/api/v1)
+func RoomURL(baseURL, roomCode, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws/rooms/" + url.PathEscape(roomCode)
+	u.RawQuery = url.Values{"token": {token}}.Encode()
+
+	return u.String(), nil
+}
+
+// Dial connects to the room and waits for server greeting
+func Dial(ctx context.Context, baseURL, roomCode, token string, opts ...Option) (*Client, error) {
+	o := options{
+		transport:  TransportWebSocket,
+		dialer:     websocket.DefaultDialer,
+		httpClient: http.DefaultClient,
+		versions:   []int{wsproto.Version},
+		buffer:     64,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	subprotocols := make([]string, 0, len(o.versions))
+	for _, v := range o.versions {
+		subprotocols = append(subprotocols, wsproto.Subprotocol(v))
+	}
+
+	var (
+		t   transport
+		err error
+	)
+	switch o.transport {
+	case TransportWebSocket:
+		t, err = dialWebSocket(ctx, o.dialer, baseURL, roomCode, token, subprotocols)
+	case TransportSSE:
+		t, err = dialSSE(ctx, o.httpClient, baseURL, roomCode, token, subprotocols)
+	case TransportLongPoll:
+		t, err = dialLongPoll(ctx, o.httpClient, baseURL, roomCode, token, subprotocols)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownTransport, o.transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		transport: t,
+		events:    make(chan wsproto.Payload, o.buffer),
+	}
+
+	first, err := c.read()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+
+	hello, ok := first.(*wsproto.Hello)
+	if !ok {
+		t.close()
+		return nil, fmt.Errorf("%w: got %s", ErrNoHello, first.EventType())
+	}
+	c.hello = *hello
+
+	if s, ok := t.(*httpTransport); ok {
+		s.setSession(hello.Session)
+	}
+
+	go c.listen()
+
+	return c, nil
+}
+
+// Hello returns server greeting with negotiated version and client role
+func (c *Client) Hello() wsproto.Hello {
+	return c.hello
+}
+
+// Events delivers decoded server events as pointers to wsproto structs.
+// Channel is closed once connection is gone, see Err for the reason.
+func (c *Client) Events() <-chan wsproto.Payload {
+	return c.events
+}
+
+func (c *Client) Err() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return c.err
+}
+
+func (c *Client) Send(p wsproto.Payload) error {
+	envelope, err := wsproto.Encode(p)
+	if err != nil {
+		return err
+	}
+	return c.transport.write(envelope)
+}
+
+func (c *Client) StartVoting() error {
+	return c.Send(wsproto.StartVoting{})
+}
+
+func (c *Client) React(movieID uuid.UUID, reaction int) error {
+	return c.Send(wsproto.React{
+		MovieID:  movieID,
+		Reaction: reaction,
+	})
+}
+
+func (c *Client) SendMessage(text string) error {
+	return c.Send(wsproto.ChatMessage{
+		Text: text,
+	})
+}
+
+func (c *Client) SendEmoji(emoji string) error {
+	return c.Send(wsproto.Emoji{
+		Emoji: emoji,
+	})
+}
+
+// Mute silences participant in lobby chat, owner only
+func (c *Client) Mute(userID string, muted bool) error {
+	return c.Send(wsproto.Mute{
+		UserID: userID,
+		Muted:  muted,
+	})
+}
+
+func (c *Client) Close() error {
+	c.closed.Store(true)
+	return c.transport.close()
+}
+
+func (c *Client) read() (wsproto.Payload, error) {
+	envelope, err := c.transport.read()
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Decode()
+}
+
+func (c *Client) listen() {
+	defer close(c.events)
+
+	for {
+		envelope, err := c.transport.read()
+		if err != nil {
+			if !c.closed.Load() && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				c.errMu.Lock()
+				c.err = err
+				c.errMu.Unlock()
+			}
+			return
+		}
+
+		// Newer servers may send events this client doesn't know yet
+		event, err := envelope.Decode()
+		if err != nil {
+			continue
+		}
+		c.events <- event
+	}
+}
+
+type wsTransport struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func dialWebSocket(ctx context.Context, dialer *websocket.Dialer, baseURL, roomCode, token string, subprotocols []string) (*wsTransport, error) {
+	roomURL, err := RoomURL(baseURL, roomCode, token)
+	if err != nil {
+		return nil, err
+	}
+
+	d := *dialer
+	d.Subprotocols = subprotocols
+
+	conn, resp, err := d.DialContext(ctx, roomURL, nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("websocket connection failed: %s: %w", resp.Status, err)
+		}
+		return nil, fmt.Errorf("websocket connection failed: %w", err)
+	}
+
+	return &wsTransport{conn: conn}, nil
+}
+
+func (t *wsTransport) read() (wsproto.Envelope, error) {
+	var envelope wsproto.Envelope
+	err := t.conn.ReadJSON(&envelope)
+	return envelope, err
+}
+
+func (t *wsTransport) write(envelope wsproto.Envelope) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteJSON(envelope)
+}
+
+func (t *wsTransport) close() error {
+	t.writeMu.Lock()
+	_ = t.conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	t.writeMu.Unlock()
+	return t.conn.Close()
+}
@@ -0,0 +1,261 @@
+package wsclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
+)
+
+// httpTransport receives events over SSE or long-polling and posts client events
+type httpTransport struct {
+	client    *http.Client
+	eventsURL string
+	token     string
+
+	// Subscription outlives Dial context, which only bounds connecting
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopWatch func() bool
+
+	mu      sync.Mutex
+	session string
+
+	// SSE
+	body   io.ReadCloser
+	reader *bufio.Reader
+
+	// Long-poll
+	polling bool
+	after   int64
+	pending []wsproto.SequencedEnvelope
+}
+
+// EventsURL builds URL of room events endpoint from REST API base URL (ex. http://localhost/api/v1)
+func EventsURL(baseURL, roomCode string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/rooms/" + url.PathEscape(roomCode) + "/events"
+	u.RawQuery = ""
+
+	return u.String(), nil
+}
+
+func newHTTPTransport(ctx context.Context, client *http.Client, baseURL, roomCode, token string) (*httpTransport, error) {
+	eventsURL, err := EventsURL(baseURL, roomCode)
+	if err != nil {
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	return &httpTransport{
+		client:    client,
+		eventsURL: eventsURL,
+		token:     token,
+		ctx:       streamCtx,
+		cancel:    cancel,
+		stopWatch: context.AfterFunc(ctx, cancel),
+	}, nil
+}
+
+func dialSSE(ctx context.Context, client *http.Client, baseURL, roomCode, token string, subprotocols []string) (*httpTransport, error) {
+	t, err := newHTTPTransport(ctx, client, baseURL, roomCode, token)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"token": {token}, "protocol": subprotocols}
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.eventsURL+"?"+query.Encode(), nil)
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.close()
+		return nil, fmt.Errorf("sse connection failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.close()
+		return nil, fmt.Errorf("sse connection failed: %s - %s", resp.Status, string(body))
+	}
+
+	t.body = resp.Body
+	t.reader = bufio.NewReader(resp.Body)
+
+	return t, nil
+}
+
+func dialLongPoll(ctx context.Context, client *http.Client, baseURL, roomCode, token string, subprotocols []string) (*httpTransport, error) {
+	t, err := newHTTPTransport(ctx, client, baseURL, roomCode, token)
+	if err != nil {
+		return nil, err
+	}
+	t.polling = true
+
+	// First poll opens subscription and returns greeting right away
+	if err := t.poll(url.Values{"token": {token}, "protocol": subprotocols}); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Called once greeting is received, from now on subscription lives until close
+func (t *httpTransport) setSession(session string) {
+	t.stopWatch()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session = session
+}
+
+func (t *httpTransport) read() (wsproto.Envelope, error) {
+	if t.polling {
+		return t.readPoll()
+	}
+	return t.readSSE()
+}
+
+func (t *httpTransport) readSSE() (wsproto.Envelope, error) {
+	var data strings.Builder
+
+	for {
+		line, err := t.reader.ReadString('\n')
+		if err != nil {
+			return wsproto.Envelope{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// Blank line completes the event
+			if data.Len() == 0 {
+				continue
+			}
+			var envelope wsproto.Envelope
+			if err := json.Unmarshal([]byte(data.String()), &envelope); err != nil {
+				return wsproto.Envelope{}, fmt.Errorf("invalid sse event: %w", err)
+			}
+			return envelope, nil
+
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// Comments (heartbeats), id and event fields aren't needed: type is inside the envelope
+	}
+}
+
+func (t *httpTransport) readPoll() (wsproto.Envelope, error) {
+	for len(t.pending) == 0 {
+		t.mu.Lock()
+		query := url.Values{
+			"token":   {t.token},
+			"session": {t.session},
+			"after":   {strconv.FormatInt(t.after, 10)},
+		}
+		t.mu.Unlock()
+
+		if err := t.poll(query); err != nil {
+			return wsproto.Envelope{}, err
+		}
+	}
+
+	next := t.pending[0]
+	t.pending = t.pending[1:]
+	t.after = next.ID
+
+	return next.Envelope, nil
+}
+
+func (t *httpTransport) poll(query url.Values) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.eventsURL+"/poll?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("poll failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("poll failed: %s - %s", resp.Status, string(body))
+	}
+
+	var response wsproto.PollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("invalid poll response: %w", err)
+	}
+
+	t.mu.Lock()
+	t.session = response.Session
+	t.mu.Unlock()
+
+	// Events already seen are resent if previous response got lost
+	for _, e := range response.Events {
+		if e.ID > t.after {
+			t.pending = append(t.pending, e)
+		}
+	}
+	return nil
+}
+
+func (t *httpTransport) write(envelope wsproto.Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	query := url.Values{"token": {t.token}, "session": {t.session}}
+	t.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.eventsURL+"?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-user-token", t.token)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", envelope.Type, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send %s: %s - %s", envelope.Type, resp.Status, string(body))
+	}
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	t.cancel()
+	if t.body != nil {
+		return t.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package wsproto
+
+//go:generate go run ../../cmd/asyncapi -out ../../../../api/ws.yaml
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	asyncAPIVersion = "2.6.0"
+	channelPath     = "/api/v1/ws/rooms/{room_id}"
+)
+
+// AsyncAPI renders AsyncAPI document describing the protocol.
+// Schemas are built from payload structs so the document can't drift from the code.
+func AsyncAPI() ([]byte, error) {
+	schemas := ordered{}
+	messages := ordered{}
+
+	refs := func(events []Payload) []any {
+		oneOf := make([]any, 0, len(events))
+		for _, e := range events {
+			name := reflect.TypeOf(e).Name()
+			if _, seen := messages.get(name); !seen {
+				schemas = append(schemas, kv{name, schemaOf(reflect.TypeOf(e))})
+				messages = append(messages, kv{name, messageOf(e, name)})
+			}
+			oneOf = append(oneOf, ordered{{"$ref", "#/components/messages/" + name}})
+		}
+		return oneOf
+	}
+
+	publish := refs(ClientEvents())
+	subscribe := refs(ServerEvents())
+
+	doc := ordered{
+		{"asyncapi", asyncAPIVersion},
+		{"info", ordered{
+			{"title", "Kinoswap room events"},
+			{"version", strconv.Itoa(Version)},
+			{"description", "Generated from pkg/wsproto. Do not edit by hand, run `go generate ./pkg/wsproto`."},
+		}},
+		{"defaultContentType", "application/json"},
+		{"channels", ordered{
+			{channelPath, ordered{
+				{"description", "Добавить клиента в пул участников комнаты"},
+				{"parameters", ordered{
+					{"room_id", ordered{
+						{"description", "Код комнаты"},
+						{"schema", ordered{{"type", "string"}}},
+					}},
+				}},
+				{"bindings", ordered{
+					{"ws", ordered{
+						{"method", "GET"},
+						{"query", ordered{
+							{"type", "object"},
+							{"properties", ordered{
+								{"token", ordered{
+									{"type", "string"},
+									{"description", "X-user-token участника"},
+								}},
+							}},
+							{"required", []string{"token"}},
+						}},
+						{"headers", ordered{
+							{"type", "object"},
+							{"properties", ordered{
+								{"Sec-WebSocket-Protocol", ordered{
+									{"type", "string"},
+									{"description", "Comma separated protocol versions client speaks. Legacy clients may omit it"},
+									{"enum", Subprotocols()},
+								}},
+							}},
+						}},
+						{"bindingVersion", "0.1.0"},
+					}},
+				}},
+				{"publish", ordered{
+					{"summary", "Events sent by clients"},
+					{"message", ordered{{"oneOf", publish}}},
+				}},
+				{"subscribe", ordered{
+					{"summary", "Events sent by server"},
+					{"message", ordered{{"oneOf", subscribe}}},
+				}},
+			}},
+		}},
+		{"components", ordered{
+			{"messages", messages},
+			{"schemas", schemas},
+		}},
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func messageOf(p Payload, name string) ordered {
+	return ordered{
+		{"name", string(p.EventType())},
+		{"payload", ordered{
+			{"type", "object"},
+			{"properties", ordered{
+				{"type", ordered{
+					{"type", "string"},
+					{"const", string(p.EventType())},
+				}},
+				{"payload", ordered{{"$ref", "#/components/schemas/" + name}}},
+			}},
+			{"required", []string{"type"}},
+		}},
+	}
+}
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+func schemaOf(t reflect.Type) ordered {
+	if t == uuidType {
+		return ordered{{"type", "string"}, {"format", "uuid"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return ordered{{"type", "string"}}
+	case reflect.Bool:
+		return ordered{{"type", "boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ordered{{"type", "integer"}}
+	case reflect.Float32, reflect.Float64:
+		return ordered{{"type", "number"}}
+	case reflect.Slice, reflect.Array:
+		return ordered{{"type", "array"}, {"items", schemaOf(t.Elem())}}
+	case reflect.Map:
+		return ordered{{"type", "object"}, {"additionalProperties", schemaOf(t.Elem())}}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return ordered{}
+}
+
+func structSchema(t reflect.Type) ordered {
+	properties := ordered{}
+	var required []string
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := schemaOf(f.Type)
+		if description := f.Tag.Get("description"); description != "" {
+			schema = append(schema, kv{"description", description})
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			schema = append(schema, kv{"enum", enumValues(f.Type, enum)})
+		}
+
+		properties = append(properties, kv{name, schema})
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := ordered{{"type", "object"}}
+	if len(properties) > 0 {
+		schema = append(schema, kv{"properties", properties})
+	}
+	if len(required) > 0 {
+		schema = append(schema, kv{"required", required})
+	}
+	return schema
+}
+
+func enumValues(t reflect.Type, raw string) []any {
+	parts := strings.Split(raw, ",")
+	values := make([]any, 0, len(parts))
+	for _, p := range parts {
+		if t.Kind() == reflect.Int {
+			if n, err := strconv.Atoi(p); err == nil {
+				values = append(values, n)
+				continue
+			}
+		}
+		values = append(values, p)
+	}
+	return values
+}
+
+// YAML mapping which keeps insertion order
+type kv struct {
+	key   string
+	value any
+}
+
+type ordered []kv
+
+func (o ordered) get(key string) (any, bool) {
+	for _, e := range o {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return nil, false
+}
+
+func (o ordered) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, e := range o {
+		var value yaml.Node
+		if err := value.Encode(e.value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: e.key},
+			&value,
+		)
+	}
+	return node, nil
+}
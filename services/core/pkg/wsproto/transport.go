@@ -0,0 +1,15 @@
+package wsproto
+
+// Networks stripping WebSocket upgrades fall back to SSE or long-polling.
+// Both carry exactly the same envelopes as WebSocket frames, client events are POSTed.
+
+// SequencedEnvelope is an envelope numbered within SSE or long-poll subscription
+type SequencedEnvelope struct {
+	ID int64 `json:"id"`
+	Envelope
+}
+
+type PollResponse struct {
+	Session string              `json:"session"`
+	Events  []SequencedEnvelope `json:"events"`
+}
@@ -0,0 +1,279 @@
+// Package wsproto describes room events exchanged over /ws/rooms/:room_id.
+// Every event is a JSON envelope {"type": ..., "payload": ...} where payload is one of the structs below.
+package wsproto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Version is bumped on every incompatible change of the payloads
+const Version = 1
+
+// Oldest version server still talks
+const MinVersion = 1
+
+// Versions are negotiated through Sec-WebSocket-Protocol header.
+// Client lists subprotocols it speaks, server picks the first one it supports.
+const subprotocolPrefix = "kinoswap.v"
+
+var (
+	ErrUnknownEvent       = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+)
+
+type EventType string
+
+const (
+	EventHello            EventType = "HELLO"
+	EventLobbyUpdate      EventType = "LOBBY_UPDATE"
+	EventStartVoting      EventType = "START_VOTING"
+	EventRedirectToVoting EventType = "REDIRECT_TO_VOTING"
+	EventVotingFinished   EventType = "VOTING_FINISHED"
+	EventReact            EventType = "REACT"
+	EventReactAck         EventType = "REACT_ACK"
+	EventNextMovie        EventType = "NEXT_MOVIE"
+	EventDeckFinished     EventType = "DECK_FINISHED"
+	EventChatMessage      EventType = "CHAT_MESSAGE"
+	EventChatHistory      EventType = "CHAT_HISTORY"
+	EventEmoji            EventType = "EMOJI"
+	EventMute             EventType = "MUTE"
+	EventError            EventType = "ERROR"
+)
+
+// Payload is implemented by every event struct
+type Payload interface {
+	EventType() EventType
+}
+
+type Envelope struct {
+	Type    EventType       `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Hello is sent by server right after connection is established
+type Hello struct {
+	Version  int    `json:"version" description:"Negotiated protocol version"`
+	RoomCode string `json:"room_code"`
+	UserID   string `json:"user_id"`
+	Role     string `json:"role" enum:"owner,participant"`
+	Session  string `json:"session,omitempty" description:"Set for SSE and long-poll subscriptions, pass it when posting events"`
+}
+
+type LobbyUpdate struct {
+	ParticipantsCount int `json:"participants_count"`
+}
+
+// StartVoting is sent by room owner
+type StartVoting struct{}
+
+type RedirectToVoting struct {
+	InitiatedBy string `json:"initiated_by"`
+	RoomCode    string `json:"room_code"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+type VotingFinished struct {
+	RoomCode    string `json:"room_code"`
+	Message     string `json:"message"`
+	Code        string `json:"code"`
+	RedirectURL string `json:"redirect_url"`
+	Timestamp   int64  `json:"timestamp" description:"Unix time"`
+}
+
+// React is a single swipe sent by participant
+type React struct {
+	MovieID  uuid.UUID `json:"movie_id"`
+	Reaction int       `json:"reaction" enum:"0,1" description:"1 - like, 0 - pass"`
+}
+
+type ReactAck struct {
+	MovieID  uuid.UUID `json:"movie_id"`
+	Reaction int       `json:"reaction" enum:"0,1"`
+}
+
+type Movie struct {
+	ID         uuid.UUID `json:"id"`
+	Title      string    `json:"title"`
+	Year       int       `json:"year"`
+	Rating     float64   `json:"rating"`
+	Genres     []string  `json:"genres"`
+	Overview   string    `json:"overview"`
+	PosterLink string    `json:"poster_link"`
+}
+
+type NextMovie struct {
+	Movie Movie `json:"movie"`
+}
+
+type DeckFinished struct {
+	RoomCode string `json:"room_code"`
+}
+
+// ChatMessage is sent by participant with text only, server fills author and time before broadcasting
+type ChatMessage struct {
+	UserID string `json:"user_id,omitempty" description:"Author, filled by server"`
+	Text   string `json:"text"`
+	SentAt int64  `json:"sent_at,omitempty" description:"Unix time, filled by server"`
+}
+
+// ChatHistory is sent to client right after HELLO, oldest message first
+type ChatHistory struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+// Emojis accepted in EMOJI events, keep in sync with enum tag below
+var Emojis = []string{"👍", "👎", "❤️", "😂", "😮", "🍿", "🔥"}
+
+type Emoji struct {
+	UserID string `json:"user_id,omitempty" description:"Author, filled by server"`
+	Emoji  string `json:"emoji" enum:"👍,👎,❤️,😂,😮,🍿,🔥"`
+}
+
+// Mute is sent by room owner and broadcasted back to the room once applied
+type Mute struct {
+	UserID string `json:"user_id"`
+	Muted  bool   `json:"muted"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+}
+
+func (Hello) EventType() EventType            { return EventHello }
+func (LobbyUpdate) EventType() EventType      { return EventLobbyUpdate }
+func (StartVoting) EventType() EventType      { return EventStartVoting }
+func (RedirectToVoting) EventType() EventType { return EventRedirectToVoting }
+func (VotingFinished) EventType() EventType   { return EventVotingFinished }
+func (React) EventType() EventType            { return EventReact }
+func (ReactAck) EventType() EventType         { return EventReactAck }
+func (NextMovie) EventType() EventType        { return EventNextMovie }
+func (DeckFinished) EventType() EventType     { return EventDeckFinished }
+func (ChatMessage) EventType() EventType      { return EventChatMessage }
+func (ChatHistory) EventType() EventType      { return EventChatHistory }
+func (Emoji) EventType() EventType            { return EventEmoji }
+func (Mute) EventType() EventType             { return EventMute }
+func (Error) EventType() EventType            { return EventError }
+
+// Events sent from server to clients
+func ServerEvents() []Payload {
+	return []Payload{
+		Hello{},
+		LobbyUpdate{},
+		RedirectToVoting{},
+		NextMovie{},
+		ReactAck{},
+		DeckFinished{},
+		VotingFinished{},
+		ChatHistory{},
+		ChatMessage{},
+		Emoji{},
+		Mute{},
+		Error{},
+	}
+}
+
+// Events sent from clients to server
+func ClientEvents() []Payload {
+	return []Payload{
+		StartVoting{},
+		React{},
+		ChatMessage{},
+		Emoji{},
+		Mute{},
+	}
+}
+
+var registry = func() map[EventType]func() Payload {
+	r := make(map[EventType]func() Payload)
+	r[EventHello] = func() Payload { return &Hello{} }
+	r[EventLobbyUpdate] = func() Payload { return &LobbyUpdate{} }
+	r[EventStartVoting] = func() Payload { return &StartVoting{} }
+	r[EventRedirectToVoting] = func() Payload { return &RedirectToVoting{} }
+	r[EventVotingFinished] = func() Payload { return &VotingFinished{} }
+	r[EventReact] = func() Payload { return &React{} }
+	r[EventReactAck] = func() Payload { return &ReactAck{} }
+	r[EventNextMovie] = func() Payload { return &NextMovie{} }
+	r[EventDeckFinished] = func() Payload { return &DeckFinished{} }
+	r[EventChatMessage] = func() Payload { return &ChatMessage{} }
+	r[EventChatHistory] = func() Payload { return &ChatHistory{} }
+	r[EventEmoji] = func() Payload { return &Emoji{} }
+	r[EventMute] = func() Payload { return &Mute{} }
+	r[EventError] = func() Payload { return &Error{} }
+	return r
+}()
+
+func Encode(p Payload) (Envelope, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		Type:    p.EventType(),
+		Payload: raw,
+	}, nil
+}
+
+// Decode returns pointer to the payload struct matching envelope type
+func (e Envelope) Decode() (Payload, error) {
+	newPayload, ok := registry[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, e.Type)
+	}
+
+	p := newPayload()
+	if len(e.Payload) == 0 || string(e.Payload) == "null" {
+		return p, nil
+	}
+	if err := json.Unmarshal(e.Payload, p); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", e.Type, err)
+	}
+	return p, nil
+}
+
+func Subprotocol(version int) string {
+	return subprotocolPrefix + strconv.Itoa(version)
+}
+
+// Subprotocols lists supported subprotocols, newest first
+func Subprotocols() []string {
+	protocols := make([]string, 0, Version-MinVersion+1)
+	for v := Version; v >= MinVersion; v-- {
+		protocols = append(protocols, Subprotocol(v))
+	}
+	return protocols
+}
+
+// ParseSubprotocol extracts version from subprotocol name
+func ParseSubprotocol(protocol string) (int, error) {
+	raw, ok := strings.CutPrefix(protocol, subprotocolPrefix)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedVersion, protocol)
+	}
+
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < MinVersion || version > Version {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedVersion, protocol)
+	}
+	return version, nil
+}
+
+// Negotiate picks the first subprotocol offered by client that server supports.
+// Clients offering nothing are legacy ones and get the current version.
+func Negotiate(offered []string) (int, string, error) {
+	if len(offered) == 0 {
+		return Version, "", nil
+	}
+
+	for _, protocol := range offered {
+		if version, err := ParseSubprotocol(protocol); err == nil {
+			return version, protocol, nil
+		}
+	}
+	return 0, "", ErrUnsupportedVersion
+}
@@ -0,0 +1,183 @@
+//go:build !integration
+// +build !integration
+
+package wsproto
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"github.com/stretchr/testify/assert"
+)
+
+type WSProtoUnitSuite struct {
+	suite.Suite
+}
+
+func (suite *WSProtoUnitSuite) TestRoundTrip(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		payload Payload
+		decoded Payload
+	}{
+		{
+			name:    "Should round trip REACT",
+			payload: React{MovieID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), Reaction: 1},
+			decoded: &React{MovieID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), Reaction: 1},
+		},
+		{
+			name:    "Should round trip LOBBY_UPDATE",
+			payload: LobbyUpdate{ParticipantsCount: 3},
+			decoded: &LobbyUpdate{ParticipantsCount: 3},
+		},
+		{
+			name:    "Should round trip empty START_VOTING",
+			payload: StartVoting{},
+			decoded: &StartVoting{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+
+			envelope, err := Encode(tc.payload)
+			assert.NoError(t, err)
+
+			raw, err := json.Marshal(envelope)
+			assert.NoError(t, err)
+
+			var got Envelope
+			assert.NoError(t, json.Unmarshal(raw, &got))
+			assert.Equal(t, tc.payload.EventType(), got.Type)
+
+			decoded, err := got.Decode()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.decoded, decoded)
+		})
+	}
+}
+
+func (suite *WSProtoUnitSuite) TestDecode(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		raw         string
+		expectError bool
+		expected    Payload
+	}{
+		{
+			name:     "Should keep legacy wire format of LOBBY_UPDATE",
+			raw:      `{"type":"LOBBY_UPDATE","payload":{"participants_count":2}}`,
+			expected: &LobbyUpdate{ParticipantsCount: 2},
+		},
+		{
+			name:     "Should accept START_VOTING without payload",
+			raw:      `{"type":"START_VOTING"}`,
+			expected: &StartVoting{},
+		},
+		{
+			name:        "Should reject unknown event",
+			raw:         `{"type":"DANCE","payload":{}}`,
+			expectError: true,
+		},
+		{
+			name:        "Should reject malformed payload",
+			raw:         `{"type":"REACT","payload":{"movie_id":"not-a-uuid"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+
+			var envelope Envelope
+			assert.NoError(t, json.Unmarshal([]byte(tc.raw), &envelope))
+
+			decoded, err := envelope.Decode()
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, decoded)
+			}
+		})
+	}
+}
+
+func (suite *WSProtoUnitSuite) TestNegotiate(t provider.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		offered         []string
+		expectError     bool
+		expectedVersion int
+		expectedProto   string
+	}{
+		{
+			name:            "Should serve legacy clients with current version",
+			offered:         nil,
+			expectedVersion: Version,
+			expectedProto:   "",
+		},
+		{
+			name:            "Should pick first supported subprotocol",
+			offered:         []string{"kinoswap.v99", Subprotocol(Version)},
+			expectedVersion: Version,
+			expectedProto:   Subprotocol(Version),
+		},
+		{
+			name:        "Should reject when nothing is supported",
+			offered:     []string{"kinoswap.v99", "graphql-ws"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t provider.T) {
+			t.Parallel()
+
+			version, protocol, err := Negotiate(tc.offered)
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrUnsupportedVersion)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedVersion, version)
+				assert.Equal(t, tc.expectedProto, protocol)
+			}
+		})
+	}
+}
+
+func (suite *WSProtoUnitSuite) TestAsyncAPIIsUpToDate(t provider.T) {
+	doc, err := AsyncAPI()
+	assert.NoError(t, err)
+
+	// Document lives outside of the module, so it's missing in containerized runs
+	committed, err := os.ReadFile("../../../../api/ws.yaml")
+	if os.IsNotExist(err) {
+		t.Skip("api/ws.yaml is not available")
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, string(committed), string(doc), "api/ws.yaml is stale, run go generate ./pkg/wsproto")
+}
+
+func (suite *WSProtoUnitSuite) TestEmojisMatchSchema(t provider.T) {
+	field, _ := reflect.TypeOf(Emoji{}).FieldByName("Emoji")
+	assert.Equal(t, strings.Join(Emojis, ","), field.Tag.Get("enum"))
+}
+
+func TestUnitSuite(t *testing.T) {
+	suite.RunSuite(t, new(WSProtoUnitSuite))
+}
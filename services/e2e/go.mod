@@ -2,14 +2,21 @@ module github.com/humanbelnik/kinoswap/services/e2e
 
 go 1.25.3
 
+require (
+	github.com/humanbelnik/kinoswap/core v0.0.0
+	github.com/ozontech/allure-go/pkg/framework v0.7.4
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/goccy/go-json v0.10.5 // indirect
-	github.com/google/uuid v1.3.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/ozontech/allure-go/pkg/allure v0.6.14 // indirect
-	github.com/ozontech/allure-go/pkg/framework v0.7.4 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/humanbelnik/kinoswap/core => ../core
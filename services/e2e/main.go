@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,9 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/humanbelnik/kinoswap/core/pkg/wsclient"
+	"github.com/humanbelnik/kinoswap/core/pkg/wsproto"
 )
 
 func baseURL() string {
@@ -69,6 +73,19 @@ func main() {
 	}
 	fmt.Printf("Retrieved %d movies successfully\n", moviesCount)
 
+	roomCode, ownerToken, err := bookRoom(client)
+	if err != nil {
+		fmt.Printf("Book room failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Room booked successfully. Code: %s\n", roomCode)
+
+	if err := joinLobby(roomCode, ownerToken); err != nil {
+		fmt.Printf("Join lobby failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Joined lobby over WebSocket successfully")
+
 	fmt.Println("\n All E2E tests passed!")
 }
 
@@ -216,12 +233,21 @@ func createMovie(client *http.Client, adminToken string) (string, error) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
+	// Poster and embedding are stored in background
+	if resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("create movie returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return "created", nil
+	var response struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to parse create movie response: %v", err)
+	}
+
+	return response.ID, nil
 }
 
 func getMovies(client *http.Client, adminToken string) (int, error) {
@@ -262,3 +288,67 @@ func getMovies(client *http.Client, adminToken string) (int, error) {
 
 	return response.Total, nil
 }
+
+func bookRoom(client *http.Client) (string, string, error) {
+	fmt.Println("\n Step 4: Booking room...")
+
+	resp, err := client.Post(baseURL()+"/rooms", "application/json", nil)
+	if err != nil {
+		return "", "", fmt.Errorf("book room request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", "", fmt.Errorf("book room returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		RoomCode string `json:"room_code"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", "", fmt.Errorf("failed to parse book room response: %v", err)
+	}
+
+	token := resp.Header.Get("X-user-token")
+	if token == "" {
+		return "", "", fmt.Errorf("user token not found in response headers")
+	}
+
+	return response.RoomCode, token, nil
+}
+
+func joinLobby(roomCode, ownerToken string) error {
+	fmt.Println("\n Step 5: Joining lobby over WebSocket...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ws, err := wsclient.Dial(ctx, baseURL(), roomCode, ownerToken)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	hello := ws.Hello()
+	if hello.Version != wsproto.Version {
+		return fmt.Errorf("negotiated version %d, expected %d", hello.Version, wsproto.Version)
+	}
+	if hello.Role != "owner" {
+		return fmt.Errorf("got role %q, expected owner", hello.Role)
+	}
+
+	for {
+		select {
+		case event, ok := <-ws.Events():
+			if !ok {
+				return fmt.Errorf("connection closed: %v", ws.Err())
+			}
+			if _, ok := event.(*wsproto.LobbyUpdate); ok {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("no LOBBY_UPDATE received: %v", ctx.Err())
+		}
+	}
+}
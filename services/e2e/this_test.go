@@ -50,6 +50,20 @@ func (s *E2EMovieFlowSuite) TestIntegrationUpload(t provider.T) {
 	}
 	fmt.Printf("Retrieved %d movies successfully\n", moviesCount)
 
+	roomCode, ownerToken, err := bookRoom(client)
+	if err != nil {
+		fmt.Printf("Book room failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Room booked successfully. Code: %s\n", roomCode)
+
+	err = joinLobby(roomCode, ownerToken)
+	if err != nil {
+		fmt.Printf("Join lobby failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Joined lobby over WebSocket successfully")
+
 	fmt.Println("\n All E2E tests passed!")
 	t.Assert().NoError(err)
 }
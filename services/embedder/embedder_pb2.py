@@ -24,7 +24,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0e\x65mbedder.proto\x12\tembedding\"f\n\x15MovieEmbeddingRequest\x12\r\n\x05title\x18\x01 \x01(\t\x12\x0e\n\x06genres\x18\x02 \x03(\t\x12\x10\n\x08overview\x18\x03 \x01(\t\x12\x0c\n\x04year\x18\x04 \x01(\x05\x12\x0e\n\x06rating\x18\x05 \x01(\x02\"*\n\x1aPreferenceEmbeddingRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"&\n\x11\x45mbeddingResponse\x12\x11\n\tembedding\x18\x01 \x03(\x02\x32\xd0\x01\n\x10\x45mbeddingService\x12X\n\x14\x43reateMovieEmbedding\x12 .embedding.MovieEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x12\x62\n\x19\x43reatePreferenceEmbedding\x12%.embedding.PreferenceEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x42\x0bZ\tgen/protob\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0e\x65mbedder.proto\x12\tembedding\"\xac\x01\n\x15MovieEmbeddingRequest\x12\r\n\x05title\x18\x01 \x01(\t\x12\x0e\n\x06genres\x18\x02 \x03(\t\x12\x10\n\x08overview\x18\x03 \x01(\t\x12\x0c\n\x04year\x18\x04 \x01(\x05\x12\x0e\n\x06rating\x18\x05 \x01(\x02\x12\x10\n\x08\x64irector\x18\x06 \x01(\t\x12\x0c\n\x04\x63\x61st\x18\x07 \x03(\t\x12\x0f\n\x07runtime\x18\x08 \x01(\x05\x12\x13\n\x0b\x63\x65rtificate\x18\t \x01(\t\"*\n\x1aPreferenceEmbeddingRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"&\n\x11\x45mbeddingResponse\x12\x11\n\tembedding\x18\x01 \x03(\x02\"N\n\x1a\x42\x61tchMovieEmbeddingRequest\x12\x30\n\x06movies\x18\x01 \x03(\x0b\x32 .embedding.MovieEmbeddingRequest\"J\n\x16\x42\x61tchEmbeddingResponse\x12\x30\n\nembeddings\x18\x01 \x03(\x0b\x32\x1c.embedding.EmbeddingResponse\"Z\n\x1bStreamMovieEmbeddingRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12/\n\x05movie\x18\x02 \x01(\x0b\x32 .embedding.MovieEmbeddingRequest\"G\n\x17StreamEmbeddingResponse\x12\n\n\x02id\x18\x01 \x01(\t\x12\x11\n\tembedding\x18\x02 \x03(\x02\x12\r\n\x05\x65rror\x18\x03 \x01(\t\"\x12\n\x10ModelInfoRequest\"T\n\tModelInfo\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0f\n\x07version\x18\x02 \x01(\t\x12\x11\n\tdimension\x18\x03 \x01(\x05\x12\x15\n\rnormalization\x18\x04 \x01(\t2\xea\x03\n\x10\x45mbeddingService\x12X\n\x14\x43reateMovieEmbedding\x12 .embedding.MovieEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x12\x62\n\x19\x43reatePreferenceEmbedding\x12%.embedding.PreferenceEmbeddingRequest\x1a\x1c.embedding.EmbeddingResponse\"\x00\x12\x43\n\x0cGetModelInfo\x12\x1b.embedding.ModelInfoRequest\x1a\x14.embedding.ModelInfo\"\x00\x12h\n\x1a\x42\x61tchCreateMovieEmbeddings\x12%.embedding.BatchMovieEmbeddingRequest\x1a!.embedding.BatchEmbeddingResponse\"\x00\x12i\n\x15StreamMovieEmbeddings\x12&.embedding.StreamMovieEmbeddingRequest\x1a\".embedding.StreamEmbeddingResponse\"\x00(\x01\x30\x01\x42\x0bZ\tgen/protob\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -32,12 +32,24 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'embedder_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z\tgen/proto'
-  _globals['_MOVIEEMBEDDINGREQUEST']._serialized_start=29
-  _globals['_MOVIEEMBEDDINGREQUEST']._serialized_end=131
-  _globals['_PREFERENCEEMBEDDINGREQUEST']._serialized_start=133
-  _globals['_PREFERENCEEMBEDDINGREQUEST']._serialized_end=175
-  _globals['_EMBEDDINGRESPONSE']._serialized_start=177
-  _globals['_EMBEDDINGRESPONSE']._serialized_end=215
-  _globals['_EMBEDDINGSERVICE']._serialized_start=218
-  _globals['_EMBEDDINGSERVICE']._serialized_end=426
+  _globals['_MOVIEEMBEDDINGREQUEST']._serialized_start=30
+  _globals['_MOVIEEMBEDDINGREQUEST']._serialized_end=202
+  _globals['_PREFERENCEEMBEDDINGREQUEST']._serialized_start=204
+  _globals['_PREFERENCEEMBEDDINGREQUEST']._serialized_end=246
+  _globals['_EMBEDDINGRESPONSE']._serialized_start=248
+  _globals['_EMBEDDINGRESPONSE']._serialized_end=286
+  _globals['_BATCHMOVIEEMBEDDINGREQUEST']._serialized_start=288
+  _globals['_BATCHMOVIEEMBEDDINGREQUEST']._serialized_end=366
+  _globals['_BATCHEMBEDDINGRESPONSE']._serialized_start=368
+  _globals['_BATCHEMBEDDINGRESPONSE']._serialized_end=442
+  _globals['_STREAMMOVIEEMBEDDINGREQUEST']._serialized_start=444
+  _globals['_STREAMMOVIEEMBEDDINGREQUEST']._serialized_end=534
+  _globals['_STREAMEMBEDDINGRESPONSE']._serialized_start=536
+  _globals['_STREAMEMBEDDINGRESPONSE']._serialized_end=607
+  _globals['_MODELINFOREQUEST']._serialized_start=609
+  _globals['_MODELINFOREQUEST']._serialized_end=627
+  _globals['_MODELINFO']._serialized_start=629
+  _globals['_MODELINFO']._serialized_end=713
+  _globals['_EMBEDDINGSERVICE']._serialized_start=716
+  _globals['_EMBEDDINGSERVICE']._serialized_end=1206
 # @@protoc_insertion_point(module_scope)
@@ -1,23 +1,31 @@
 from google.protobuf.internal import containers as _containers
 from google.protobuf import descriptor as _descriptor
 from google.protobuf import message as _message
-from typing import ClassVar as _ClassVar, Iterable as _Iterable, Optional as _Optional
+from typing import ClassVar as _ClassVar, Iterable as _Iterable, Mapping as _Mapping, Optional as _Optional, Union as _Union
 
 DESCRIPTOR: _descriptor.FileDescriptor
 
 class MovieEmbeddingRequest(_message.Message):
-    __slots__ = ("title", "genres", "overview", "year", "rating")
+    __slots__ = ("title", "genres", "overview", "year", "rating", "director", "cast", "runtime", "certificate")
     TITLE_FIELD_NUMBER: _ClassVar[int]
     GENRES_FIELD_NUMBER: _ClassVar[int]
     OVERVIEW_FIELD_NUMBER: _ClassVar[int]
     YEAR_FIELD_NUMBER: _ClassVar[int]
     RATING_FIELD_NUMBER: _ClassVar[int]
+    DIRECTOR_FIELD_NUMBER: _ClassVar[int]
+    CAST_FIELD_NUMBER: _ClassVar[int]
+    RUNTIME_FIELD_NUMBER: _ClassVar[int]
+    CERTIFICATE_FIELD_NUMBER: _ClassVar[int]
     title: str
     genres: _containers.RepeatedScalarFieldContainer[str]
     overview: str
     year: int
     rating: float
-    def __init__(self, title: _Optional[str] = ..., genres: _Optional[_Iterable[str]] = ..., overview: _Optional[str] = ..., year: _Optional[int] = ..., rating: _Optional[float] = ...) -> None: ...
+    director: str
+    cast: _containers.RepeatedScalarFieldContainer[str]
+    runtime: int
+    certificate: str
+    def __init__(self, title: _Optional[str] = ..., genres: _Optional[_Iterable[str]] = ..., overview: _Optional[str] = ..., year: _Optional[int] = ..., rating: _Optional[float] = ..., director: _Optional[str] = ..., cast: _Optional[_Iterable[str]] = ..., runtime: _Optional[int] = ..., certificate: _Optional[str] = ...) -> None: ...
 
 class PreferenceEmbeddingRequest(_message.Message):
     __slots__ = ("text",)
@@ -30,3 +38,49 @@ class EmbeddingResponse(_message.Message):
     EMBEDDING_FIELD_NUMBER: _ClassVar[int]
     embedding: _containers.RepeatedScalarFieldContainer[float]
     def __init__(self, embedding: _Optional[_Iterable[float]] = ...) -> None: ...
+
+class BatchMovieEmbeddingRequest(_message.Message):
+    __slots__ = ("movies",)
+    MOVIES_FIELD_NUMBER: _ClassVar[int]
+    movies: _containers.RepeatedCompositeFieldContainer[MovieEmbeddingRequest]
+    def __init__(self, movies: _Optional[_Iterable[_Union[MovieEmbeddingRequest, _Mapping]]] = ...) -> None: ...
+
+class BatchEmbeddingResponse(_message.Message):
+    __slots__ = ("embeddings",)
+    EMBEDDINGS_FIELD_NUMBER: _ClassVar[int]
+    embeddings: _containers.RepeatedCompositeFieldContainer[EmbeddingResponse]
+    def __init__(self, embeddings: _Optional[_Iterable[_Union[EmbeddingResponse, _Mapping]]] = ...) -> None: ...
+
+class StreamMovieEmbeddingRequest(_message.Message):
+    __slots__ = ("id", "movie")
+    ID_FIELD_NUMBER: _ClassVar[int]
+    MOVIE_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    movie: MovieEmbeddingRequest
+    def __init__(self, id: _Optional[str] = ..., movie: _Optional[_Union[MovieEmbeddingRequest, _Mapping]] = ...) -> None: ...
+
+class StreamEmbeddingResponse(_message.Message):
+    __slots__ = ("id", "embedding", "error")
+    ID_FIELD_NUMBER: _ClassVar[int]
+    EMBEDDING_FIELD_NUMBER: _ClassVar[int]
+    ERROR_FIELD_NUMBER: _ClassVar[int]
+    id: str
+    embedding: _containers.RepeatedScalarFieldContainer[float]
+    error: str
+    def __init__(self, id: _Optional[str] = ..., embedding: _Optional[_Iterable[float]] = ..., error: _Optional[str] = ...) -> None: ...
+
+class ModelInfoRequest(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class ModelInfo(_message.Message):
+    __slots__ = ("name", "version", "dimension", "normalization")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    VERSION_FIELD_NUMBER: _ClassVar[int]
+    DIMENSION_FIELD_NUMBER: _ClassVar[int]
+    NORMALIZATION_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    version: str
+    dimension: int
+    normalization: str
+    def __init__(self, name: _Optional[str] = ..., version: _Optional[str] = ..., dimension: _Optional[int] = ..., normalization: _Optional[str] = ...) -> None: ...
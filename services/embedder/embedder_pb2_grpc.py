@@ -44,6 +44,21 @@ class EmbeddingServiceStub(object):
                 request_serializer=embedder__pb2.PreferenceEmbeddingRequest.SerializeToString,
                 response_deserializer=embedder__pb2.EmbeddingResponse.FromString,
                 _registered_method=True)
+        self.GetModelInfo = channel.unary_unary(
+                '/embedding.EmbeddingService/GetModelInfo',
+                request_serializer=embedder__pb2.ModelInfoRequest.SerializeToString,
+                response_deserializer=embedder__pb2.ModelInfo.FromString,
+                _registered_method=True)
+        self.BatchCreateMovieEmbeddings = channel.unary_unary(
+                '/embedding.EmbeddingService/BatchCreateMovieEmbeddings',
+                request_serializer=embedder__pb2.BatchMovieEmbeddingRequest.SerializeToString,
+                response_deserializer=embedder__pb2.BatchEmbeddingResponse.FromString,
+                _registered_method=True)
+        self.StreamMovieEmbeddings = channel.stream_stream(
+                '/embedding.EmbeddingService/StreamMovieEmbeddings',
+                request_serializer=embedder__pb2.StreamMovieEmbeddingRequest.SerializeToString,
+                response_deserializer=embedder__pb2.StreamEmbeddingResponse.FromString,
+                _registered_method=True)
 
 
 class EmbeddingServiceServicer(object):
@@ -61,6 +76,26 @@ class EmbeddingServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def GetModelInfo(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def BatchCreateMovieEmbeddings(self, request, context):
+        """Embeddings are in order of movies
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def StreamMovieEmbeddings(self, request_iterator, context):
+        """Every request is answered with response of the same id, not necessarily in order
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_EmbeddingServiceServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -74,6 +109,21 @@ def add_EmbeddingServiceServicer_to_server(servicer, server):
                     request_deserializer=embedder__pb2.PreferenceEmbeddingRequest.FromString,
                     response_serializer=embedder__pb2.EmbeddingResponse.SerializeToString,
             ),
+            'GetModelInfo': grpc.unary_unary_rpc_method_handler(
+                    servicer.GetModelInfo,
+                    request_deserializer=embedder__pb2.ModelInfoRequest.FromString,
+                    response_serializer=embedder__pb2.ModelInfo.SerializeToString,
+            ),
+            'BatchCreateMovieEmbeddings': grpc.unary_unary_rpc_method_handler(
+                    servicer.BatchCreateMovieEmbeddings,
+                    request_deserializer=embedder__pb2.BatchMovieEmbeddingRequest.FromString,
+                    response_serializer=embedder__pb2.BatchEmbeddingResponse.SerializeToString,
+            ),
+            'StreamMovieEmbeddings': grpc.stream_stream_rpc_method_handler(
+                    servicer.StreamMovieEmbeddings,
+                    request_deserializer=embedder__pb2.StreamMovieEmbeddingRequest.FromString,
+                    response_serializer=embedder__pb2.StreamEmbeddingResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'embedding.EmbeddingService', rpc_method_handlers)
@@ -138,3 +188,84 @@ class EmbeddingService(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def GetModelInfo(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/embedding.EmbeddingService/GetModelInfo',
+            embedder__pb2.ModelInfoRequest.SerializeToString,
+            embedder__pb2.ModelInfo.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def BatchCreateMovieEmbeddings(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/embedding.EmbeddingService/BatchCreateMovieEmbeddings',
+            embedder__pb2.BatchMovieEmbeddingRequest.SerializeToString,
+            embedder__pb2.BatchEmbeddingResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def StreamMovieEmbeddings(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_stream(
+            request_iterator,
+            target,
+            '/embedding.EmbeddingService/StreamMovieEmbeddings',
+            embedder__pb2.StreamMovieEmbeddingRequest.SerializeToString,
+            embedder__pb2.StreamEmbeddingResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
@@ -1,4 +1,5 @@
 import grpc
+from grpc_health.v1 import health, health_pb2, health_pb2_grpc
 from concurrent import futures
 import logging
 import embedder_pb2 as embedding_pb2
@@ -7,6 +8,23 @@ from service import EmbeddingService
 import os
 from flask import Flask, request, jsonify
 
+# Bumped whenever movie text changes, so core re-embeds catalog built from the old one
+MOVIE_TEXT_VERSION = "2"
+
+def movie_text(request):
+    genres_text = ", ".join(request.genres) if request.genres else "unknown"
+    parts = [f"Title: {request.title}", f"Genres: {genres_text}", f"Overview: {request.overview}",
+             f"Year: {request.year}", f"Rating: {request.rating}"]
+    if request.director:
+        parts.append(f"Director: {request.director}")
+    if request.cast:
+        parts.append(f"Cast: {', '.join(request.cast)}")
+    if request.runtime:
+        parts.append(f"Runtime: {request.runtime} min")
+    if request.certificate:
+        parts.append(f"Certificate: {request.certificate}")
+    return ". ".join(parts)
+
 class EmbeddingServicer(embedding_pb2_grpc.EmbeddingServiceServicer):
     def __init__(self):
         self.embedding_service = EmbeddingService()
@@ -15,8 +33,7 @@ class EmbeddingServicer(embedding_pb2_grpc.EmbeddingServiceServicer):
 
     def CreateMovieEmbedding(self, request, context):
         try:
-            genres_text = ", ".join(request.genres) if request.genres else "unknown"
-            text_data = f"Title: {request.title}. Genres: {genres_text}. Overview: {request.overview}. Year: {request.year}. Rating: {request.rating}"
+            text_data = movie_text(request)
             
             self.logger.info(f"Creating movie embedding for: {request.title}")
         
@@ -51,6 +68,39 @@ class EmbeddingServicer(embedding_pb2_grpc.EmbeddingServiceServicer):
             context.set_code(grpc.StatusCode.INTERNAL)
             return embedding_pb2.EmbeddingResponse()
 
+    def BatchCreateMovieEmbeddings(self, request, context):
+        try:
+            self.logger.info(f"Creating {len(request.movies)} movie embeddings")
+
+            embeddings = self.embedding_service.build_embeddings([movie_text(movie) for movie in request.movies])
+
+            return embedding_pb2.BatchEmbeddingResponse(embeddings=[
+                embedding_pb2.EmbeddingResponse(embedding=embedding.tolist()) for embedding in embeddings
+            ])
+
+        except Exception as e:
+            self.logger.error(f"Error in BatchCreateMovieEmbeddings: {str(e)}")
+            context.set_details(f"Internal server error: {str(e)}")
+            context.set_code(grpc.StatusCode.INTERNAL)
+            return embedding_pb2.BatchEmbeddingResponse()
+
+    def StreamMovieEmbeddings(self, request_iterator, context):
+        for request in request_iterator:
+            try:
+                embedding = self.embedding_service.build_embedding(movie_text(request.movie))
+                yield embedding_pb2.StreamEmbeddingResponse(id=request.id, embedding=embedding.tolist())
+            except Exception as e:
+                self.logger.error(f"Error in StreamMovieEmbeddings for {request.id}: {str(e)}")
+                yield embedding_pb2.StreamEmbeddingResponse(id=request.id, error=str(e))
+
+    def GetModelInfo(self, request, context):
+        return embedding_pb2.ModelInfo(
+            name=self.embedding_service.model_name,
+            version=f"{self.embedding_service.model_version}+text{MOVIE_TEXT_VERSION}",
+            dimension=self.embedding_service.dimension,
+            normalization=self.embedding_service.normalization,
+        )
+
 def create_flask_app():
     app = Flask(__name__)
     embedding_service = EmbeddingService()
@@ -88,12 +138,21 @@ def serve_grpc():
         options=[
             ('grpc.max_send_message_length', 50 * 1024 * 1024),
             ('grpc.max_receive_message_length', 50 * 1024 * 1024),
+            # Core pings idle connections every 30s
+            ('grpc.keepalive_permit_without_calls', 1),
+            ('grpc.http2.min_ping_interval_without_data_ms', 20000),
         ]
     )
     
+    health_servicer = health.HealthServicer()
+    health_pb2_grpc.add_HealthServicer_to_server(health_servicer, server)
+
+    # Model is loaded by the servicer, clients see the service once it is ready
     embedding_pb2_grpc.add_EmbeddingServiceServicer_to_server(
         EmbeddingServicer(), server
     )
+    for service in ('', 'embedding.EmbeddingService'):
+        health_servicer.set(service, health_pb2.HealthCheckResponse.SERVING)
     
     port = '50051'
     server.add_insecure_port(f'[::]:{port}')
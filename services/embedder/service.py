@@ -1,7 +1,12 @@
 import logging
+import os
 from sentence_transformers import SentenceTransformer
+from sentence_transformers.models import Normalize
 import numpy as np
 
+# Texts encoded by model at once, larger batches only take more memory
+BATCH_SIZE = int(os.getenv("EMBEDDING_BATCH_SIZE", "32"))
+
 class EmbeddingService:
     def __init__(self, model_name: str = "./model/"):
 
@@ -12,10 +17,26 @@ class EmbeddingService:
         self.model = SentenceTransformer(model_name)
         self.logger.info("Model loaded successfully")
 
+        # Saved model keeps its hub name, MODEL_NAME overrides it for custom models.
+        # Bump MODEL_VERSION when weights change under the same name, core re-embeds catalog on mismatch
+        self.model_name = os.getenv("MODEL_NAME") or self.model[0].auto_model.config._name_or_path
+        self.model_version = os.getenv("MODEL_VERSION", "")
+        self.dimension = self.model.get_sentence_embedding_dimension()
+        # Core refuses to mix replicas that differ here, distances of their vectors are not comparable
+        self.normalization = "l2" if any(isinstance(m, Normalize) for m in self.model) else "none"
+        self.logger.info(f"Serving model {self.model_name} version '{self.model_version}', dimension {self.dimension}, normalization {self.normalization}")
+
     def build_embedding(self, text: str) -> np.ndarray:
         try:
             embedding = self.model.encode(text)
             return embedding # pyright: ignore[reportReturnType]
         except Exception as e:
             self.logger.error(f"Error generating embedding: {str(e)}")
-            raise
\ No newline at end of file
+            raise
+
+    def build_embeddings(self, texts: list[str]) -> np.ndarray:
+        try:
+            return self.model.encode(texts, batch_size=BATCH_SIZE) # pyright: ignore[reportReturnType]
+        except Exception as e:
+            self.logger.error(f"Error generating {len(texts)} embeddings: {str(e)}")
+            raise